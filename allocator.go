@@ -0,0 +1,147 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Allocator provides the memory of the bytes, fixed, strings and arrays decoded by a binary decoder
+// and the datum readers reading from it, e.g. to decode batches into an arena or pooled buffers
+// that get freed all at once. Maps, records and other values are allocated as usual.
+//
+// Strings share the memory returned by Alloc, which must not be reused while they are in use.
+type Allocator interface {
+	// Alloc returns a slice of at least n bytes to decode a bytes, fixed or string value into.
+	Alloc(n int) []byte
+
+	// AllocSlice returns a slice of the slice type t and length n, with zero items, to decode an
+	// array into.
+	AllocSlice(t reflect.Type, n int) reflect.Value
+}
+
+// SetDecoderAllocator makes dec, a decoder created by NewBinaryDecoder, NewBinaryDecoderReader or
+// their variants, and the datum readers reading from it take the memory of the values they decode
+// from a, or allocate it as usual again if a is nil.
+// May return an error if dec is another kind of Decoder.
+func SetDecoderAllocator(dec Decoder, a Allocator) error {
+	d, ok := dec.(interface {
+		setAllocator(a Allocator)
+	})
+	if !ok {
+		return fmt.Errorf("Decoder %T doesn't support allocators", dec)
+	}
+	d.setAllocator(a)
+	return nil
+}
+
+var interfaceSliceType = reflect.TypeOf([]interface{}{})
+
+// allocatorHook holds the Allocator of a decoder, if it has one.
+type allocatorHook struct {
+	allocator Allocator
+}
+
+func (h *allocatorHook) setAllocator(a Allocator) {
+	h.allocator = a
+}
+
+func (h *allocatorHook) decodeAllocator() Allocator {
+	return h.allocator
+}
+
+// makeBytes returns a slice of n bytes from the allocator, or a new one if there is none.
+func (h *allocatorHook) makeBytes(n int64) []byte {
+	if h.allocator != nil {
+		return h.allocator.Alloc(int(n))[:n]
+	}
+	return make([]byte, n)
+}
+
+// makeString returns a copy of b as a string, in memory of the allocator if there is one.
+func (h *allocatorHook) makeString(b []byte) string {
+	if h.allocator == nil || len(b) == 0 {
+		return string(b)
+	}
+	s := h.allocator.Alloc(len(b))[:len(b)]
+	copy(s, b)
+	return allocatedString(s)
+}
+
+// allocatedString returns b as a string sharing its memory, which came from an Allocator.
+func allocatedString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// decoderAllocator returns the Allocator of dec, nil if it has none.
+func decoderAllocator(dec Decoder) Allocator {
+	if d, ok := dec.(interface {
+		decodeAllocator() Allocator
+	}); ok {
+		return d.decodeAllocator()
+	}
+	return nil
+}
+
+// makeBytes returns a slice of n bytes from the allocator of dec, or a new one if it has none.
+func makeBytes(dec Decoder, n int) []byte {
+	if a := decoderAllocator(dec); a != nil {
+		return a.Alloc(n)[:n]
+	}
+	return make([]byte, n)
+}
+
+// makeSlice returns a slice of type t and length n from the allocator of dec, or a new one if it
+// has none.
+func makeSlice(dec Decoder, t reflect.Type, n int) reflect.Value {
+	if a := decoderAllocator(dec); a != nil {
+		return a.AllocSlice(t, n)
+	}
+	return reflect.MakeSlice(t, n, n)
+}
+
+// SlabAllocator is an Allocator carving bytes out of large slabs, so that decoding many small
+// strings and bytes values takes a few large allocations, and freeing them takes dropping the
+// allocator or resetting it. Slices are allocated as usual. A SlabAllocator is not safe for
+// concurrent use.
+type SlabAllocator struct {
+	slabSize int
+	slabs    [][]byte
+	current  int // index of the slab being filled
+}
+
+// NewSlabAllocator creates a SlabAllocator allocating slabs of the given size. Values larger than a
+// slab are allocated apart.
+func NewSlabAllocator(slabSize int) *SlabAllocator {
+	return &SlabAllocator{slabSize: slabSize}
+}
+
+// Alloc returns n bytes of the current slab, moving on to the next one if they don't fit.
+func (a *SlabAllocator) Alloc(n int) []byte {
+	if n > a.slabSize {
+		return make([]byte, n)
+	}
+	for a.current < len(a.slabs) && n > cap(a.slabs[a.current])-len(a.slabs[a.current]) {
+		a.current++
+	}
+	if a.current == len(a.slabs) {
+		a.slabs = append(a.slabs, make([]byte, 0, a.slabSize))
+	}
+	slab := a.slabs[a.current]
+	a.slabs[a.current] = slab[:len(slab)+n]
+	return slab[len(slab) : len(slab)+n : len(slab)+n]
+}
+
+// AllocSlice returns a new slice.
+func (a *SlabAllocator) AllocSlice(t reflect.Type, n int) reflect.Value {
+	return reflect.MakeSlice(t, n, n)
+}
+
+// Reset makes the slabs allocated so far reusable, so that values decoded next overwrite those
+// decoded before, which must not be in use anymore.
+func (a *SlabAllocator) Reset() {
+	for i := range a.slabs {
+		a.slabs[i] = a.slabs[i][:0]
+	}
+	a.current = 0
+}
@@ -0,0 +1,92 @@
+package avro
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// countingAllocator counts the bytes and slices allocated through it.
+type countingAllocator struct {
+	bytes  int
+	slices int
+}
+
+func (a *countingAllocator) Alloc(n int) []byte {
+	a.bytes += n
+	return make([]byte, n, n+8)
+}
+
+func (a *countingAllocator) AllocSlice(t reflect.Type, n int) reflect.Value {
+	a.slices++
+	return reflect.MakeSlice(t, n, n)
+}
+
+var allocatorSchema = MustParseSchema(`{"type": "record", "name": "Doc", "fields": [
+	{"name": "title", "type": "string"},
+	{"name": "body", "type": "bytes"},
+	{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+	{"name": "tags", "type": {"type": "array", "items": "string"}}
+]}`)
+
+type allocatorDoc struct {
+	Title string   `avro:"title"`
+	Body  []byte   `avro:"body"`
+	Hash  []byte   `avro:"hash"`
+	Tags  []string `avro:"tags"`
+}
+
+func TestDecoderAllocator(t *testing.T) {
+	doc := &allocatorDoc{Title: "avro", Body: []byte{1, 2, 3}, Hash: []byte{4, 5, 6, 7}, Tags: []string{"a", "bc"}}
+	encoded := testEncodeBytes(allocatorSchema, doc)
+
+	for _, newDecoder := range []func() Decoder{
+		func() Decoder { return NewBinaryDecoder(encoded) },
+		func() Decoder { return NewBinaryDecoderReader(bytes.NewReader(encoded)) },
+	} {
+		allocator := &countingAllocator{}
+		dec := newDecoder()
+		assert(t, SetDecoderAllocator(dec, allocator), nil)
+		decoded := &allocatorDoc{}
+		assert(t, NewSpecificDatumReader().SetSchema(allocatorSchema).Read(decoded, dec), nil)
+		assert(t, decoded, doc)
+		assert(t, allocator.bytes, 4+3+4+3)
+		assert(t, allocator.slices, 1)
+
+		allocator = &countingAllocator{}
+		dec = newDecoder()
+		assert(t, SetDecoderAllocator(dec, allocator), nil)
+		record := NewGenericRecord(allocatorSchema)
+		assert(t, NewGenericDatumReader().SetSchema(allocatorSchema).Read(record, dec), nil)
+		assert(t, record.Get("title"), "avro")
+		assert(t, record.Get("tags"), []interface{}{"a", "bc"})
+		assert(t, allocator.bytes, 4+3+4+3)
+		assert(t, allocator.slices, 1)
+	}
+
+	assert(t, SetDecoderAllocator(struct{ Decoder }{}, &countingAllocator{}).Error(), "Decoder struct { avro.Decoder } doesn't support allocators")
+}
+
+func TestSlabAllocator(t *testing.T) {
+	a := NewSlabAllocator(8)
+	first := a.Alloc(5)
+	assert(t, len(first), 5)
+	assert(t, cap(first), 5)
+	assert(t, cap(a.Alloc(3)), 3)
+	third := a.Alloc(4) // doesn't fit in the first slab
+	assert(t, len(a.slabs), 2)
+	assert(t, len(a.Alloc(16)), 16) // larger than slabs
+	assert(t, len(a.slabs), 2)
+
+	a.Reset()
+	again := a.Alloc(5)
+	assert(t, &again[0] == &first[0], true)
+	assert(t, &a.Alloc(4)[0] == &third[0], true)
+	assert(t, len(a.slabs), 2)
+
+	dec := NewBinaryDecoder([]byte{0x06, 'f', 'o', 'o'})
+	assert(t, SetDecoderAllocator(dec, a), nil)
+	s, err := dec.ReadString()
+	assert(t, err, nil)
+	assert(t, s, "foo")
+}
@@ -0,0 +1,133 @@
+// Package avrotest provides assertions to enforce schema discipline in ordinary go test runs:
+// that schema changes stay compatible with data written before, and that encodings don't change
+// unnoticed.
+//
+//	func TestUserSchema(t *testing.T) {
+//		avrotest.RequireBackwardCompatible(t, userV1, userV2)
+//		avrotest.RequireGolden(t, avro.MustParseSchema(userV2), &User{Name: "alice"}, "testdata/user.avro")
+//	}
+package avrotest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/avro.v0"
+)
+
+// Update makes RequireGolden write golden files instead of checking them. It is set when the
+// AVROTEST_UPDATE environment variable is not empty, and may be set from a test flag too.
+var Update = os.Getenv("AVROTEST_UPDATE") != ""
+
+// helper is implemented by testing.TB from Go 1.9, which has Helper to leave assertions out of the
+// locations it reports failures at.
+type helper interface {
+	Helper()
+}
+
+// RequireBackwardCompatible fails the test unless data written with the old schema can be read
+// with the new one.
+func RequireBackwardCompatible(t testing.TB, oldJSON, newJSON string) {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	requireReadable(t, "backward", parse(t, oldJSON), parse(t, newJSON))
+}
+
+// RequireForwardCompatible fails the test unless data written with the new schema can be read
+// with the old one.
+func RequireForwardCompatible(t testing.TB, oldJSON, newJSON string) {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	requireReadable(t, "forward", parse(t, newJSON), parse(t, oldJSON))
+}
+
+// RequireFullCompatible fails the test unless the schemas are both backward and forward compatible.
+func RequireFullCompatible(t testing.TB, oldJSON, newJSON string) {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	oldSchema, newSchema := parse(t, oldJSON), parse(t, newJSON)
+	requireReadable(t, "backward", oldSchema, newSchema)
+	requireReadable(t, "forward", newSchema, oldSchema)
+}
+
+func parse(t testing.TB, schemaJSON string) avro.Schema {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	schema, err := avro.ParseSchema(schemaJSON)
+	if err != nil {
+		t.Fatalf("Invalid schema: %s", err)
+	}
+	return schema
+}
+
+func requireReadable(t testing.TB, compatibility string, writer, reader avro.Schema) {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	if _, err := avro.NewDatumProjector(writer, reader); err != nil {
+		t.Fatalf("Schemas are not %s compatible: %s", compatibility, err)
+	}
+}
+
+// RequireGolden fails the test unless v, a *GenericRecord or a pointer to a struct, encodes with
+// the given schema to data which decodes to the same value as the golden file at path. Values are
+// compared after decoding rather than byte by byte as the order of map entries isn't stable.
+//
+// When Update is set, the golden file is written instead.
+func RequireGolden(t testing.TB, schema avro.Schema, v interface{}, path string) {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	buf := &bytes.Buffer{}
+	if err := avro.NewDatumWriter(schema).Write(v, avro.NewBinaryEncoder(buf)); err != nil {
+		t.Fatalf("Encoding value: %s", err)
+	}
+
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Writing golden file: %s", err)
+		}
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("Writing golden file: %s", err)
+		}
+		return
+	}
+
+	golden, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("Golden file %s is missing, run with AVROTEST_UPDATE=1 to create it", path)
+	} else if err != nil {
+		t.Fatalf("Reading golden file: %s", err)
+	}
+	want, err := decode(schema, golden, v)
+	if err != nil {
+		t.Fatalf("Decoding golden file %s: %s", path, err)
+	}
+	got, err := decode(schema, buf.Bytes(), v)
+	if err != nil {
+		t.Fatalf("Decoding value: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Value doesn't match golden file %s, run with AVROTEST_UPDATE=1 to update it\n got: %+v\nwant: %+v", path, got, want)
+	}
+}
+
+// decode decodes data into a new value of the same type as like.
+func decode(schema avro.Schema, data []byte, like interface{}) (interface{}, error) {
+	var target interface{}
+	if _, ok := like.(*avro.GenericRecord); ok {
+		target = avro.NewGenericRecord(schema)
+	} else {
+		target = reflect.New(reflect.TypeOf(like).Elem()).Interface()
+	}
+	err := avro.NewDatumReader(schema).Read(target, avro.NewBinaryDecoder(data))
+	return target, err
+}
@@ -0,0 +1,105 @@
+package avrotest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/avro.v0"
+)
+
+// recorder records the failure of an assertion instead of failing the test.
+type recorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failure = fmt.Sprintf(format, args...)
+	panic(r)
+}
+
+func failure(assertion func(t testing.TB)) (failure string) {
+	r := &recorder{}
+	defer func() {
+		if p := recover(); p != nil && p != r {
+			panic(p)
+		}
+		failure = r.failure
+	}()
+	assertion(r)
+	return
+}
+
+const (
+	userV1 = `{"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}]}`
+	userV2 = `{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "tags", "type": {"type": "map", "values": "int"}, "default": {}}
+	]}`
+	userV3 = `{"type": "record", "name": "User", "fields": [{"name": "email", "type": "string"}]}`
+)
+
+func TestRequireCompatible(t *testing.T) {
+	RequireBackwardCompatible(t, userV1, userV2)
+	RequireForwardCompatible(t, userV1, userV2)
+	RequireFullCompatible(t, userV1, userV2)
+
+	message := failure(func(t testing.TB) { RequireBackwardCompatible(t, userV2, userV3) })
+	if message != "Schemas are not backward compatible: Field User.email is missing from writer schema and has no default" {
+		t.Fatal(message)
+	}
+	message = failure(func(t testing.TB) { RequireFullCompatible(t, userV1, userV3) })
+	if !strings.HasPrefix(message, "Schemas are not backward compatible") {
+		t.Fatal(message)
+	}
+	message = failure(func(t testing.TB) { RequireForwardCompatible(t, userV1, "{") })
+	if !strings.HasPrefix(message, "Invalid schema") {
+		t.Fatal(message)
+	}
+}
+
+type user struct {
+	Name string           `avro:"name"`
+	Tags map[string]int32 `avro:"tags"`
+}
+
+func TestRequireGolden(t *testing.T) {
+	schema := avro.MustParseSchema(userV2)
+	dir, err := ioutil.TempDir("", "avrotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "golden", "user.avro")
+	v := &user{Name: "alice", Tags: map[string]int32{"a": 1, "b": 2, "c": 3}}
+
+	message := failure(func(t testing.TB) { RequireGolden(t, schema, v, path) })
+	if !strings.Contains(message, "is missing") {
+		t.Fatal(message)
+	}
+
+	Update = true
+	RequireGolden(t, schema, v, path)
+	Update = false
+	RequireGolden(t, schema, v, path)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+
+	record := avro.NewGenericRecord(schema)
+	record.Set("name", "alice")
+	record.Set("tags", map[string]interface{}{"c": int32(3), "b": int32(2), "a": int32(1)})
+	RequireGolden(t, schema, record, path)
+
+	v.Tags["a"] = 0
+	message = failure(func(t testing.TB) { RequireGolden(t, schema, v, path) })
+	if !strings.HasPrefix(message, "Value doesn't match golden file") {
+		t.Fatal(message)
+	}
+}
@@ -0,0 +1,232 @@
+// Command avrotool provides utilities for working with Avro object container files.
+//
+// Usage:
+//
+//	avrotool validate file.avro [file.avro ...]
+//	avrotool repair broken.avro repaired.avro
+//	avrotool split [-progress] [-bytes n] [-records n] large.avro part-%03d.avro
+//	avrotool sort [-progress] [-memory n] src.avro sorted.avro field [field ...]
+//	avrotool dedup [-progress] [-last] [-exact] src.avro deduped.avro field [field ...]
+//	avrotool merge [-progress] schema.avsc merged.avro src.avro [src.avro ...]
+//
+// With -progress, the progress of reading the source files is shown on stderr.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/avro.v0"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "validate":
+		validate(os.Args[2:])
+	case "repair":
+		repair(os.Args[2:])
+	case "split":
+		split(os.Args[2:])
+	case "sort":
+		sortFile(os.Args[2:])
+	case "dedup":
+		dedup(os.Args[2:])
+	case "merge":
+		merge(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Println("Usage: avrotool <command> [arguments]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  validate file.avro...   check container files for corruption")
+	fmt.Println("  repair src.avro dst.avro  copy all valid blocks of a truncated or corrupt file")
+	fmt.Println("  split [-progress] [-bytes n] [-records n] src.avro dst-%03d.avro")
+	fmt.Println("                          split a file at block boundaries into numbered files")
+	fmt.Println("  sort [-progress] [-memory n] src.avro dst.avro field...")
+	fmt.Println("                          sort a file by fields, spilling to temporary files")
+	fmt.Println("  dedup [-progress] [-last] [-exact] src.avro dst.avro field...")
+	fmt.Println("                          keep one record per key made of fields, e.g. user.id")
+	fmt.Println("  merge [-progress] schema.avsc dst.avro src.avro...")
+	fmt.Println("                          merge files written with compatible schemas into one")
+	os.Exit(2)
+}
+
+// open opens a source file, exiting on errors, and returns it along with a reader of it showing
+// progress on stderr if enabled.
+func open(name string, progress bool) (*os.File, io.Reader) {
+	f, err := os.Open(name)
+	if err != nil {
+		fmt.Printf("%s: %s\n", name, err)
+		os.Exit(1)
+	}
+	if !progress {
+		return f, f
+	}
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	return f, avro.WithProgress(f, func(p avro.Progress) {
+		percent := 100.0
+		if size > 0 {
+			percent = float64(p.Bytes) * 100 / float64(size)
+		}
+		fmt.Fprintf(os.Stderr, "\r%s: %.1f%%, %d records in %d blocks", name, percent, p.Records, p.Blocks)
+		if p.Bytes >= size {
+			fmt.Fprintln(os.Stderr)
+		}
+	})
+}
+
+// create creates a destination file, exiting on errors.
+func create(name string) *os.File {
+	f, err := os.Create(name)
+	if err != nil {
+		fmt.Printf("%s: %s\n", name, err)
+		os.Exit(1)
+	}
+	return f
+}
+
+// closeOutput closes a destination file, returning err or the error closing it.
+func closeOutput(f *os.File, err error) error {
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func validate(files []string) {
+	if len(files) == 0 {
+		usage()
+	}
+
+	failed := false
+	for _, file := range files {
+		records, err := avro.ValidateDataFile(file)
+		if err != nil {
+			fmt.Printf("%s: %s (%d records valid before)\n", file, err, records)
+			failed = true
+		} else {
+			fmt.Printf("%s: OK, %d records\n", file, records)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func repair(args []string) {
+	if len(args) != 2 {
+		usage()
+	}
+
+	records, err := avro.RepairDataFileName(args[0], args[1])
+	if err != nil {
+		fmt.Printf("%s: %s\n", args[0], err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: salvaged %d records into %s\n", args[0], records, args[1])
+}
+
+func split(args []string) {
+	flags := flag.NewFlagSet("split", flag.ExitOnError)
+	maxBytes := flags.Int64("bytes", 0, "maximum size of every file, 0 for no limit")
+	maxRecords := flags.Int64("records", 0, "maximum number of records in every file, 0 for no limit")
+	progress := flags.Bool("progress", false, "show progress on stderr")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		usage()
+	}
+
+	f, src := open(flags.Arg(0), *progress)
+	defer f.Close()
+	files, err := avro.SplitDataFile(src, flags.Arg(1), *maxBytes, *maxRecords)
+	if err != nil {
+		fmt.Printf("%s: %s (%d files written)\n", flags.Arg(0), err, len(files))
+		os.Exit(1)
+	}
+	fmt.Printf("%s: split into %d files\n", flags.Arg(0), len(files))
+}
+
+func sortFile(args []string) {
+	flags := flag.NewFlagSet("sort", flag.ExitOnError)
+	maxMemory := flags.Int64("memory", 64<<20, "maximum size of the records sorted in memory at once, 0 for no limit")
+	progress := flags.Bool("progress", false, "show progress on stderr")
+	flags.Parse(args)
+	if flags.NArg() < 3 {
+		usage()
+	}
+
+	f, src := open(flags.Arg(0), *progress)
+	defer f.Close()
+	dst := create(flags.Arg(1))
+	if err := closeOutput(dst, avro.SortDataFile(src, dst, flags.Args()[2:], *maxMemory)); err != nil {
+		fmt.Printf("%s: %s\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: sorted into %s\n", flags.Arg(0), flags.Arg(1))
+}
+
+func dedup(args []string) {
+	flags := flag.NewFlagSet("dedup", flag.ExitOnError)
+	var options avro.DedupOptions
+	flags.BoolVar(&options.KeepLast, "last", false, "keep the last record of every key rather than the first")
+	flags.BoolVar(&options.Exact, "exact", false, "keep whole keys in memory rather than their hashes")
+	progress := flags.Bool("progress", false, "show progress on stderr")
+	flags.Parse(args)
+	if flags.NArg() < 3 {
+		usage()
+	}
+
+	f, src := open(flags.Arg(0), *progress)
+	defer f.Close()
+	dst := create(flags.Arg(1))
+	duplicates, err := avro.DedupDataFile(src, dst, flags.Args()[2:], options)
+	err = closeOutput(dst, err)
+	if err != nil {
+		fmt.Printf("%s: %s\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: dropped %d duplicates, wrote %s\n", flags.Arg(0), duplicates, flags.Arg(1))
+}
+
+func merge(args []string) {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	progress := flags.Bool("progress", false, "show progress on stderr")
+	flags.Parse(args)
+	args = flags.Args()
+	if len(args) < 3 {
+		usage()
+	}
+
+	schema, err := avro.ParseSchemaFile(args[0])
+	if err != nil {
+		fmt.Printf("%s: %s\n", args[0], err)
+		os.Exit(1)
+	}
+	srcs := make([]io.Reader, len(args)-2)
+	for i, name := range args[2:] {
+		var f *os.File
+		f, srcs[i] = open(name, *progress)
+		defer f.Close()
+	}
+	dst := create(args[1])
+	records, err := avro.MergeDataFiles(dst, schema, srcs...)
+	err = closeOutput(dst, err)
+	if err != nil {
+		fmt.Printf("%s: %s\n", args[1], err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: merged %d records\n", args[1], records)
+}
@@ -0,0 +1,87 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// BatchRecord is a record to encode in a batch with EncodeBatch, along with its schema.
+type BatchRecord struct {
+	Schema Schema
+	Value  interface{}
+}
+
+// EncodeBatch encodes records into a batch envelope, so that they can be shipped in a single
+// transport message. A batch is the number of records as an Avro long, followed by every record in
+// the single-object encoding, as Avro bytes. Every record thus carries the fingerprint of its own
+// schema, and records of different schemas can be mixed.
+//
+// Values are written the way a DatumWriter returned by NewDatumWriter would.
+func EncodeBatch(records []BatchRecord) ([]byte, error) {
+	headers := make(map[Schema][]byte)
+	buf := &bytes.Buffer{}
+	enc := newBinaryEncoder(buf)
+	enc.WriteLong(int64(len(records)))
+	message := &bytes.Buffer{}
+	for i, record := range records {
+		header, ok := headers[record.Schema]
+		if !ok {
+			var err error
+			if header, err = singleObjectPrefix(record.Schema); err != nil {
+				return nil, fmt.Errorf("Batch record %d: %s", i, err)
+			}
+			headers[record.Schema] = header
+		}
+		message.Reset()
+		message.Write(header)
+		if err := NewDatumWriter(record.Schema).Write(record.Value, newBinaryEncoder(message)); err != nil {
+			return nil, fmt.Errorf("Batch record %d: %s", i, err)
+		}
+		enc.WriteBytes(message.Bytes())
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBatch decodes the records of a batch envelope written by EncodeBatch with the given
+// MessageReader, which resolves the schemas of the records by their fingerprints and projects them
+// to its reader schema. Errors are prefixed with the index of the record they're about.
+func DecodeBatch(reader *MessageReader, batch []byte) ([]interface{}, error) {
+	messages, err := splitBatch(batch)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(messages))
+	for i, message := range messages {
+		if values[i], err = reader.Read(message); err != nil {
+			return nil, fmt.Errorf("Batch record %d: %s", i, err)
+		}
+	}
+	return values, nil
+}
+
+// splitBatch returns the single-object encoded messages of a batch envelope.
+func splitBatch(batch []byte) ([][]byte, error) {
+	bd := &binaryDecoder{buf: batch}
+	count, err := bd.ReadLong()
+	if err != nil {
+		return nil, err
+	} else if count < 0 || count > int64(len(batch)) {
+		// Every message takes a byte for its length at least.
+		return nil, fmt.Errorf("Invalid batch record count %d", count)
+	}
+	messages := make([][]byte, count)
+	for i := range messages {
+		length, err := bd.ReadLong()
+		if err != nil {
+			return nil, err
+		} else if length < 0 || length > int64(len(batch))-bd.pos {
+			return nil, fmt.Errorf("Invalid length %d of batch record %d", length, i)
+		}
+		messages[i] = batch[bd.pos : bd.pos+length]
+		bd.pos += length
+	}
+	if bd.pos != int64(len(batch)) {
+		return nil, fmt.Errorf("Batch is followed by %d unread bytes", int64(len(batch))-bd.pos)
+	}
+	return messages, nil
+}
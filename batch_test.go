@@ -0,0 +1,59 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "int"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "source", "type": "string", "default": "unknown"}
+	]}`)
+	type event struct {
+		ID     int64  `avro:"id"`
+		Source string `avro:"source"`
+	}
+	old := NewGenericRecord(v1)
+	old.Set("id", int32(1))
+
+	batch, err := EncodeBatch([]BatchRecord{
+		{Schema: v1, Value: old},
+		{Schema: v2, Value: &event{ID: 2, Source: "app"}},
+		{Schema: v1, Value: old},
+	})
+	assert(t, err, nil)
+	assert(t, batch[0], byte(6))
+	assert(t, batch[2:4], singleObjectHeader(t, v1)[:2])
+
+	reader := NewMessageReader(v2)
+	_, err = DecodeBatch(reader, batch)
+	fingerprint := binary.LittleEndian.Uint64(singleObjectHeader(t, v1)[2:])
+	assert(t, err.Error(), fmt.Sprintf("Batch record 0: Unknown writer schema fingerprint %016x", fingerprint))
+
+	assert(t, reader.AddSchema(v1), nil)
+	assert(t, reader.AddSchema(v2), nil)
+	values, err := DecodeBatch(reader, batch)
+	assert(t, err, nil)
+	assert(t, len(values), 3)
+	assert(t, values[0].(*GenericRecord).Get("source"), "unknown")
+	assert(t, values[1].(*GenericRecord).Get("id"), int64(2))
+	assert(t, values[1].(*GenericRecord).Get("source"), "app")
+
+	empty, err := EncodeBatch(nil)
+	assert(t, err, nil)
+	values, err = DecodeBatch(reader, empty)
+	assert(t, err, nil)
+	assert(t, len(values), 0)
+
+	_, err = DecodeBatch(reader, batch[:len(batch)-1])
+	assert(t, err.Error(), "Invalid length 11 of batch record 2")
+	_, err = DecodeBatch(reader, []byte{0xfe, 0xff, 0x7f})
+	assert(t, err.Error(), "Invalid batch record count 1048575")
+	_, err = EncodeBatch([]BatchRecord{{Schema: v2, Value: old}})
+	assert(t, err != nil, true)
+}
@@ -0,0 +1,209 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// CompareBinary compares two values of the given schema in their binary encoding without decoding
+// them, following the sort order of the Avro specification: numbers compare by value, booleans
+// false first, bytes, fixed and strings byte by byte, enums by the index of their symbol, unions by
+// the index of their branch first, arrays item by item and records field by field, skipping
+// fields with the "ignore" order and reversing those with the "descending" one.
+//
+// Returns a negative number if a sorts before b, a positive one if it sorts after b, and 0 if they
+// are equal. Maps can't be compared and fail with ErrMapNotComparable.
+func CompareBinary(schema Schema, a, b []byte) (int, error) {
+	return compareBinary(schema, &binaryDecoder{buf: a}, &binaryDecoder{buf: b})
+}
+
+func compareBinary(schema Schema, a, b *binaryDecoder) (int, error) {
+	switch schema.Type() {
+	case Null:
+		return 0, nil
+	case Boolean:
+		x, err := a.ReadBoolean()
+		if err != nil {
+			return 0, err
+		}
+		y, err := b.ReadBoolean()
+		if err != nil || x == y {
+			return 0, err
+		} else if y {
+			return -1, nil
+		}
+		return 1, nil
+	case Int, Long, Enum:
+		x, err := a.ReadLong()
+		if err != nil {
+			return 0, err
+		}
+		y, err := b.ReadLong()
+		if err != nil {
+			return 0, err
+		}
+		return compareInts(x, y), nil
+	case Float:
+		x, err := a.ReadFloat()
+		if err != nil {
+			return 0, err
+		}
+		y, err := b.ReadFloat()
+		if err != nil {
+			return 0, err
+		}
+		return compareFloats(float64(x), float64(y)), nil
+	case Double:
+		x, err := a.ReadDouble()
+		if err != nil {
+			return 0, err
+		}
+		y, err := b.ReadDouble()
+		if err != nil {
+			return 0, err
+		}
+		return compareFloats(x, y), nil
+	case Bytes, String:
+		x, err := a.readRawBytes(-1)
+		if err != nil {
+			return 0, err
+		}
+		y, err := b.readRawBytes(-1)
+		if err != nil {
+			return 0, err
+		}
+		return bytes.Compare(x, y), nil
+	case Fixed:
+		size := int64(schema.(*FixedSchema).Size)
+		x, err := a.readRawBytes(size)
+		if err != nil {
+			return 0, err
+		}
+		y, err := b.readRawBytes(size)
+		if err != nil {
+			return 0, err
+		}
+		return bytes.Compare(x, y), nil
+	case Union:
+		x, err := a.ReadInt()
+		if err != nil {
+			return 0, err
+		}
+		y, err := b.ReadInt()
+		if err != nil {
+			return 0, err
+		}
+		if x != y {
+			return compareInts(int64(x), int64(y)), nil
+		}
+		if x < 0 || x >= int32(len(schema.(*UnionSchema).Types)) {
+			return 0, ErrUnionTypeOverflow
+		}
+		return compareBinary(schema.(*UnionSchema).Types[x], a, b)
+	case Array:
+		return compareArrays(schema.(*ArraySchema).Items, a, b)
+	case Map:
+		return 0, ErrMapNotComparable
+	case Record:
+		for _, field := range assertRecordSchema(schema).Fields {
+			if field.Order == "ignore" {
+				if err := a.skip(field.Type, nil, DefaultMaxDepth); err != nil {
+					return 0, err
+				}
+				if err := b.skip(field.Type, nil, DefaultMaxDepth); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			c, err := compareBinary(field.Type, a, b)
+			if err != nil || c != 0 {
+				if field.Order == "descending" {
+					c = -c
+				}
+				return c, err
+			}
+		}
+		return 0, nil
+	case Recursive:
+		return compareBinary(schema.(*RecursiveSchema).Actual, a, b)
+	}
+
+	return 0, fmt.Errorf("Unknown field type: %d", schema.Type())
+}
+
+// compareArrays compares arrays item by item, a shorter array sorting before any longer one it
+// is a prefix of.
+func compareArrays(items Schema, a, b *binaryDecoder) (int, error) {
+	x, err := a.ReadArrayStart()
+	if err != nil {
+		return 0, err
+	}
+	y, err := b.ReadArrayStart()
+	if err != nil {
+		return 0, err
+	}
+	for x > 0 && y > 0 {
+		c, err := compareBinary(items, a, b)
+		if err != nil || c != 0 {
+			return c, err
+		}
+		if x--; x == 0 {
+			if x, err = a.ArrayNext(); err != nil {
+				return 0, err
+			}
+		}
+		if y--; y == 0 {
+			if y, err = b.ArrayNext(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return compareInts(x, y), nil
+}
+
+// readRawBytes returns the next size bytes without copying them, or bytes prefixed with their
+// length if size is negative.
+func (bd *binaryDecoder) readRawBytes(size int64) ([]byte, error) {
+	if size < 0 {
+		length, err := bd.ReadLong()
+		if err != nil {
+			return nil, err
+		} else if length < 0 {
+			return nil, ErrNegativeBytesLength
+		}
+		size = length
+	}
+	start := bd.pos
+	if err := bd.skipBytes(size); err != nil {
+		return nil, err
+	}
+	return bd.buf[start:bd.pos], nil
+}
+
+func compareInts(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// compareFloats orders NaN after every other number and equal to itself.
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	case x == y:
+		return 0
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x):
+		return 1
+	}
+	return -1
+}
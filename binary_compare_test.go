@@ -0,0 +1,84 @@
+package avro
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func encodeGeneric(t *testing.T, schema Schema, v interface{}) []byte {
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(v, NewBinaryEncoder(&buf)), nil)
+	return buf.Bytes()
+}
+
+func TestCompareBinary(t *testing.T) {
+	enum := MustParseSchema(`{"type": "enum", "name": "Suit", "symbols": ["SPADES", "HEARTS"]}`)
+	cases := []struct {
+		schema string
+		a, b   interface{}
+		want   int
+	}{
+		{`"null"`, nil, nil, 0},
+		{`"boolean"`, false, true, -1},
+		{`"int"`, int32(-3), int32(2), -1},
+		{`"long"`, int64(300), int64(2), 1},
+		{`"float"`, float32(1.5), float32(1.5), 0},
+		{`"double"`, math.NaN(), 1e300, 1},
+		{`"double"`, math.Inf(-1), -1.0, -1},
+		{`"string"`, "ab", "b", -1},
+		{`"string"`, "ab", "a", 1},
+		{`"bytes"`, []byte{0xff}, []byte{0x01, 0x02}, 1},
+		{`{"type": "fixed", "name": "F", "size": 2}`, []byte{1, 2}, []byte{1, 3}, -1},
+		{`["null", "string"]`, nil, "a", -1},
+		{`["string", "null"]`, nil, "a", 1},
+		{`{"type": "array", "items": "int"}`, []interface{}{int32(1), int32(2)}, []interface{}{int32(1), int32(2)}, 0},
+		{`{"type": "array", "items": "int"}`, []interface{}{int32(1)}, []interface{}{int32(1), int32(0)}, -1},
+		{`{"type": "array", "items": "int"}`, []interface{}{int32(2)}, []interface{}{int32(1), int32(5)}, 1},
+	}
+	for _, c := range cases {
+		schema := MustParseSchema(c.schema)
+		got, err := CompareBinary(schema, encodeGeneric(t, schema, c.a), encodeGeneric(t, schema, c.b))
+		assert(t, err, nil)
+		if got != c.want {
+			t.Errorf("Comparing %v and %v as %s: expected %d, got %d", c.a, c.b, c.schema, c.want, got)
+		}
+	}
+
+	hearts, spades := NewGenericEnum(enum.(*EnumSchema).Symbols), NewGenericEnum(enum.(*EnumSchema).Symbols)
+	hearts.Set("HEARTS")
+	spades.Set("SPADES")
+	got, err := CompareBinary(enum, encodeGeneric(t, enum, hearts), encodeGeneric(t, enum, spades))
+	assert(t, err, nil)
+	assert(t, got, 1)
+
+	record := MustParseSchema(`{"type": "record", "name": "R", "fields": [
+		{"name": "id", "type": "long", "order": "ignore"},
+		{"name": "age", "type": "int", "order": "descending"},
+		{"name": "name", "type": "string"}
+	]}`)
+	assert(t, record.(*RecordSchema).Fields[1].Order, "descending")
+	newRecord := func(id int64, age int32, name string) []byte {
+		r := NewGenericRecord(record)
+		r.Set("id", id)
+		r.Set("age", age)
+		r.Set("name", name)
+		return encodeGeneric(t, record, r)
+	}
+	got, err = CompareBinary(record, newRecord(1, 30, "a"), newRecord(2, 30, "a"))
+	assert(t, err, nil)
+	assert(t, got, 0)
+	got, err = CompareBinary(record, newRecord(1, 31, "a"), newRecord(1, 30, "b"))
+	assert(t, err, nil)
+	assert(t, got, -1)
+	got, err = CompareBinary(record, newRecord(1, 30, "a"), newRecord(1, 30, "b"))
+	assert(t, err, nil)
+	assert(t, got, -1)
+
+	mapSchema := MustParseSchema(`{"type": "map", "values": "int"}`)
+	empty := encodeGeneric(t, mapSchema, map[string]interface{}{})
+	_, err = CompareBinary(mapSchema, empty, empty)
+	assert(t, err, ErrMapNotComparable)
+	_, err = ParseSchema(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int", "order": "up"}]}`)
+	assert(t, err != nil, true)
+}
@@ -116,6 +116,13 @@ func TestBytes(t *testing.T) {
 
 	}
 
+	// Empty bytes are empty slices rather than nil.
+	for prefix, decoder := range bothDecoders([]byte{0x00}) {
+		if actual, err := decoder.ReadBytes(); err != nil || actual == nil || len(actual) != 0 {
+			t.Fatalf("Unexpected empty bytes %s: %#v, %v", prefix, actual, err)
+		}
+	}
+
 	for _, pair := range badBytes {
 		expected := pair.err
 		arr := pair.buf
@@ -155,3 +162,46 @@ func bothDecoders(input []byte) map[string]Decoder {
 		"io.Reader": NewBinaryDecoderReader(bytes.NewReader(input)),
 	}
 }
+
+func TestDecoderBudget(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewBinaryEncoder(&buf)
+	enc.WriteString("hello")
+	enc.WriteBytes([]byte("world!"))
+	enc.WriteLong(1 << 40)
+	data := buf.Bytes()
+
+	for name, dec := range map[string]Decoder{
+		"[]byte":    NewBinaryDecoderBudget(data, 10),
+		"io.Reader": NewBinaryDecoderReaderBudget(bytes.NewReader(data), 10),
+	} {
+		if s, err := dec.ReadString(); err != nil || s != "hello" {
+			t.Fatalf("Unexpected string %s: %q, %v", name, s, err)
+		}
+		if _, err := dec.ReadBytes(); err != ErrAllocationBudgetExceeded {
+			t.Fatalf("Unexpected error for bytes %s: expected %v, actual %v", name, ErrAllocationBudgetExceeded, err)
+		}
+	}
+
+	// Arrays of nulls take no space in the data, however many items they have.
+	nulls := MustParseSchema(`{"type": "array", "items": "null"}`)
+	buf.Reset()
+	enc.WriteLong(2)
+	enc.WriteLong(0)
+	enc.WriteLong(1 << 40)
+	data = buf.Bytes()
+	for name, dec := range map[string]Decoder{
+		"[]byte":    NewBinaryDecoderBudget(data, 64),
+		"io.Reader": NewBinaryDecoderReaderBudget(bytes.NewReader(data), 64),
+	} {
+		reader := NewGenericDatumReader()
+		reader.SetSchema(nulls)
+		var items []interface{}
+		if err := reader.Read(&items, dec); err != nil || len(items) != 2 {
+			t.Fatalf("Unexpected array %s: %v, %v", name, items, err)
+		}
+		if err := reader.Read(&items, dec); err != ErrAllocationBudgetExceeded {
+			t.Fatalf("Unexpected error for array %s: expected %v, actual %v", name, ErrAllocationBudgetExceeded, err)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package avro
 
 import (
+	"bufio"
 	"bytes"
+	"math"
 	"math/rand"
 	"testing"
 )
@@ -170,3 +172,78 @@ func testPrimitiveSerialization(t *testing.T, random func(int) interface{}, seri
 		}
 	}
 }
+
+// onlyWriter hides the io.ByteWriter implementation of a buffer.
+type onlyWriter struct {
+	buf *bytes.Buffer
+}
+
+func (w onlyWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// onlyReader hides the io.ByteReader implementation of a reader.
+type onlyReader struct {
+	r *bytes.Reader
+}
+
+func (r onlyReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func TestVarintSerialization(t *testing.T) {
+	longs := []int64{0, -1, 1, -64, 63, -65, 64, 8191, -8192, math.MaxInt32, math.MinInt32, math.MaxInt64, math.MinInt64}
+	for _, value := range longs {
+		var byteWriter, writer bytes.Buffer
+		NewBinaryEncoder(&byteWriter).WriteLong(value)
+		NewBinaryEncoder(onlyWriter{&writer}).WriteLong(value)
+		assert(t, byteWriter.Bytes(), writer.Bytes())
+		for name, dec := range map[string]Decoder{
+			"[]byte":        NewBinaryDecoder(writer.Bytes()),
+			"io.Reader":     NewBinaryDecoderReader(onlyReader{bytes.NewReader(writer.Bytes())}),
+			"io.ByteReader": NewBinaryDecoderReader(bufio.NewReader(bytes.NewReader(writer.Bytes()))),
+		} {
+			if decoded, err := dec.ReadLong(); err != nil || decoded != value {
+				t.Fatalf("Unexpected long %s: expected %v, actual %v, %v", name, value, decoded, err)
+			}
+		}
+
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			continue
+		}
+		writer.Reset()
+		NewBinaryEncoder(&writer).WriteInt(int32(value))
+		assert(t, writer.Bytes(), byteWriter.Bytes())
+		if decoded, err := NewBinaryDecoder(writer.Bytes()).ReadInt(); err != nil || decoded != int32(value) {
+			t.Fatalf("Unexpected int: expected %v, actual %v, %v", value, decoded, err)
+		}
+	}
+}
+
+func benchVarintDecoding(b *testing.B, newDecoder func([]byte) Decoder) {
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf)
+	for i := int64(0); i < 1000; i++ {
+		enc.WriteLong(i * i * i)
+	}
+	data := buf.Bytes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dec := newDecoder(data)
+		for j := 0; j < 1000; j++ {
+			if _, err := dec.ReadLong(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkVarintDecoding_bytes(b *testing.B) {
+	benchVarintDecoding(b, NewBinaryDecoder)
+}
+
+func BenchmarkVarintDecoding_ioReader(b *testing.B) {
+	benchVarintDecoding(b, func(data []byte) Decoder {
+		return NewBinaryDecoderReader(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,161 @@
+package avro
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+)
+
+// CanonicalForm returns the Parsing Canonical Form of a schema as defined by the Avro specification:
+// https://avro.apache.org/docs/current/spec.html#Parsing+Canonical+Form+for+Schemas
+//
+// Names are fully qualified, attributes irrelevant to reading data (doc, aliases, defaults and custom
+// properties) are dropped and all whitespace is removed, so two schemas with the same canonical form
+// describe the same binary encoding.
+// May return an error if the schema contains a type this package doesn't know of.
+func CanonicalForm(schema Schema) (string, error) {
+	job := canonicalJob{seen: make(map[string]bool)}
+	if err := job.write(schema); err != nil {
+		return "", err
+	}
+	return string(job.json.Bytes()), nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the Parsing Canonical Form of a schema.
+// May return an error if the canonical form can't be produced.
+func Fingerprint(schema Schema) ([]byte, error) {
+	return FingerprintUsing(schema, sha256.New)
+}
+
+// FingerprintMD5 returns the MD5 fingerprint of the Parsing Canonical Form of a schema, as used by
+// the SchemaNormalization of the Java implementation and some schema registries.
+// May return an error if the canonical form can't be produced.
+func FingerprintMD5(schema Schema) ([]byte, error) {
+	return FingerprintUsing(schema, md5.New)
+}
+
+// FingerprintUsing returns the fingerprint of the Parsing Canonical Form of a schema computed with
+// a hash function of the caller's choice, e.g. sha1.New or fnv.New64a.
+// May return an error if the canonical form can't be produced.
+func FingerprintUsing(schema Schema, h func() hash.Hash) ([]byte, error) {
+	job := canonicalJob{seen: make(map[string]bool)}
+	if err := job.write(schema); err != nil {
+		return nil, err
+	}
+	digest := h()
+	digest.Write(job.json.Bytes())
+	return digest.Sum(nil), nil
+}
+
+// FingerprintCRC64 returns the 64-bit Rabin fingerprint (CRC-64-AVRO) of the Parsing Canonical
+// Form of a schema, as embedded in the header of single-object encoded messages.
+// May return an error if the canonical form can't be produced.
+func FingerprintCRC64(schema Schema) (uint64, error) {
+	canonical, err := CanonicalForm(schema)
+	if err != nil {
+		return 0, err
+	}
+	return fingerprintCRC64([]byte(canonical)), nil
+}
+
+type canonicalJob struct {
+	json JSONWriter
+	// named types are only written out in full the first time they're encountered.
+	seen map[string]bool
+}
+
+func (job *canonicalJob) write(schema Schema) error {
+	switch s := schema.(type) {
+	case *NullSchema, *BooleanSchema, *IntSchema, *LongSchema, *FloatSchema, *DoubleSchema, *BytesSchema, *StringSchema:
+		job.json.String(s.GetName())
+	case *RecursiveSchema:
+		job.json.String(s.FullName())
+	case *preparedRecordSchema:
+		return job.write(&s.RecordSchema)
+	case *RecordSchema:
+		if job.writeNamed(s.FullName(), typeRecord) {
+			return nil
+		}
+		job.json.Field("fields").BeginArray()
+		for _, field := range s.Fields {
+			job.json.BeginObject().Field("name").String(field.Name).Field("type")
+			if err := job.write(field.Type); err != nil {
+				return err
+			}
+			job.json.EndObject()
+		}
+		job.json.EndArray().EndObject()
+	case *EnumSchema:
+		if job.writeNamed(s.FullName(), typeEnum) {
+			return nil
+		}
+		job.json.Field("symbols").BeginArray()
+		for _, symbol := range s.Symbols {
+			job.json.String(symbol)
+		}
+		job.json.EndArray().EndObject()
+	case *FixedSchema:
+		if job.writeNamed(s.FullName(), typeFixed) {
+			return nil
+		}
+		job.json.Field("size").Int(int64(s.Size)).EndObject()
+	case *ArraySchema:
+		job.json.BeginObject().Field("type").String("array").Field("items")
+		if err := job.write(s.Items); err != nil {
+			return err
+		}
+		job.json.EndObject()
+	case *MapSchema:
+		job.json.BeginObject().Field("type").String("map").Field("values")
+		if err := job.write(s.Values); err != nil {
+			return err
+		}
+		job.json.EndObject()
+	case *UnionSchema:
+		job.json.BeginArray()
+		for _, t := range s.Types {
+			if err := job.write(t); err != nil {
+				return err
+			}
+		}
+		job.json.EndArray()
+	default:
+		return fmt.Errorf("CanonicalForm: unsupported schema type %T", schema)
+	}
+	return nil
+}
+
+// writeNamed writes the start of a named type definition, or just its name if it was already defined.
+// Returns true in the latter case.
+func (job *canonicalJob) writeNamed(fullName string, typeName string) bool {
+	if job.seen[fullName] {
+		job.json.String(fullName)
+		return true
+	}
+	job.seen[fullName] = true
+	job.json.BeginObject().Field("name").String(fullName).Field("type").String(typeName)
+	return false
+}
+
+const crc64AvroEmpty uint64 = 0xc15d213aa4d7a795
+
+var crc64AvroTable = func() (table [256]uint64) {
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (crc64AvroEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return
+}()
+
+// fingerprintCRC64 computes the 64-bit Rabin fingerprint (CRC-64-AVRO) of the given data.
+func fingerprintCRC64(data []byte) uint64 {
+	fp := crc64AvroEmpty
+	for _, b := range data {
+		fp = (fp >> 8) ^ crc64AvroTable[byte(fp)^b]
+	}
+	return fp
+}
@@ -0,0 +1,82 @@
+package avro
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCanonicalForm(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Outer", "namespace": "com.example", "doc": "docs are dropped",
+		"fields": [
+			{"name": "e", "type": {"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE"]}, "default": "HEART"},
+			{"name": "e2", "type": "Suit"},
+			{"name": "f", "type": {"type": "fixed", "name": "org.Hash", "size": 4}},
+			{"name": "self", "type": ["null", "Outer"]},
+			{"name": "m", "type": {"type": "map", "values": {"type": "array", "items": "long"}}}
+		]}`)
+	expected := `{"name":"com.example.Outer","type":"record","fields":[` +
+		`{"name":"e","type":{"name":"com.example.Suit","type":"enum","symbols":["HEART","SPADE"]}},` +
+		`{"name":"e2","type":"com.example.Suit"},` +
+		`{"name":"f","type":{"name":"org.Hash","type":"fixed","size":4}},` +
+		`{"name":"self","type":["null","com.example.Outer"]},` +
+		`{"name":"m","type":{"type":"map","values":{"type":"array","items":"long"}}}]}`
+
+	canonical, err := CanonicalForm(s)
+	assert(t, err, nil)
+	assert(t, canonical, expected)
+
+	canonical, err = CanonicalForm(Prepare(s))
+	assert(t, err, nil)
+	assert(t, canonical, expected)
+
+	canonical, err = CanonicalForm(MustParseSchema(`{"type": "int"}`))
+	assert(t, err, nil)
+	assert(t, canonical, `"int"`)
+}
+
+func TestFingerprint(t *testing.T) {
+	fp, err := Fingerprint(MustParseSchema(`"int"`))
+	assert(t, err, nil)
+	assert(t, hex.EncodeToString(fp), "3f2b87a9fe7cc9b13835598c3981cd45e3e355309e5090aa0933d7becb6fba45")
+}
+
+func TestFingerprintMD5(t *testing.T) {
+	fp, err := FingerprintMD5(MustParseSchema(`"int"`))
+	assert(t, err, nil)
+	assert(t, hex.EncodeToString(fp), "ef524ea1b91e73173d938ade36c1db32")
+}
+
+func TestFingerprintUsing(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "doc": "An event", "fields": [{"name": "id", "type": "long"}]}`)
+	fp, err := FingerprintUsing(schema, sha1.New)
+	assert(t, err, nil)
+	sum := sha1.Sum([]byte(`{"name":"Event","type":"record","fields":[{"name":"id","type":"long"}]}`))
+	assert(t, hex.EncodeToString(fp), hex.EncodeToString(sum[:]))
+
+	fp, err = FingerprintUsing(schema, sha256.New)
+	assert(t, err, nil)
+	expected, err := Fingerprint(schema)
+	assert(t, err, nil)
+	assert(t, hex.EncodeToString(fp), hex.EncodeToString(expected))
+}
+
+func TestFingerprintCRC64(t *testing.T) {
+	// Test vectors from the Avro specification test suite
+	assert(t, int64(fingerprintCRC64([]byte(`"null"`))), int64(7195948357588979594))
+	assert(t, int64(fingerprintCRC64([]byte(`"int"`))), int64(8247732601305521295))
+
+	fp, err := FingerprintCRC64(MustParseSchema(`{"type": "int", "logicalType": "date"}`))
+	assert(t, err, nil)
+	assert(t, int64(fp), int64(8247732601305521295))
+
+	// Single-object headers embed the fingerprint in little-endian order.
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [{"name": "id", "type": "long"}]}`)
+	fp, err = FingerprintCRC64(schema)
+	assert(t, err, nil)
+	header, err := singleObjectPrefix(schema)
+	assert(t, err, nil)
+	assert(t, binary.LittleEndian.Uint64(header[2:]), fp)
+}
@@ -0,0 +1,161 @@
+package avro
+
+import (
+	"fmt"
+	"time"
+)
+
+// ChangeOp is the operation of a change data capture event, as held by the op field of its
+// envelope.
+type ChangeOp string
+
+// The operations of change events, as Debezium names them.
+const (
+	ChangeCreate   ChangeOp = "c"
+	ChangeUpdate   ChangeOp = "u"
+	ChangeDelete   ChangeOp = "d"
+	ChangeRead     ChangeOp = "r" // row read while snapshotting a table
+	ChangeTruncate ChangeOp = "t"
+)
+
+// Fields of change event envelopes.
+const (
+	changeBeforeField = "before"
+	changeAfterField  = "after"
+	changeOpField     = "op"
+	changeSourceField = "source"
+)
+
+// changeTimestampFields are the fields of change event timestamps, the most precise first.
+var changeTimestampFields = []struct {
+	name string
+	unit time.Duration
+}{{"ts_ns", time.Nanosecond}, {"ts_us", time.Microsecond}, {"ts_ms", time.Millisecond}}
+
+// ChangeEvent is a change data capture event decoded from the before/after/op/ts envelope written
+// by Debezium and similar tools.
+type ChangeEvent struct {
+	Op ChangeOp
+
+	// Before and After are the states of the changed row, nil for rows being created and read
+	// while snapshotting and for deleted rows respectively, and both for truncates.
+	Before *GenericRecord
+	After  *GenericRecord
+
+	// Source holds the metadata of the database the change comes from, nil if the envelope
+	// doesn't have it.
+	Source *GenericRecord
+
+	// Timestamp is when the change was processed, from the ts_ms, ts_us or ts_ns field of the
+	// envelope, and SourceTimestamp when it was made in the database, from those of the source.
+	// They are zero if missing.
+	Timestamp       time.Time
+	SourceTimestamp time.Time
+}
+
+// Payload returns the state of the row after the change, or before it for deletes.
+func (e *ChangeEvent) Payload() *GenericRecord {
+	if e.Op == ChangeDelete {
+		return e.Before
+	}
+	return e.After
+}
+
+// ChangeEnvelope returns the schema of the rows held by a change event envelope, the record of its
+// before and after fields, and whether s is an envelope at all: a record with those two optional
+// fields of the same record schema and a string or enum op field.
+func ChangeEnvelope(s Schema) (*RecordSchema, bool) {
+	envelope, ok := resolveSchema(s).(*RecordSchema)
+	if !ok {
+		return nil, false
+	}
+	op, _, ok := envelope.Field(changeOpField)
+	if !ok || op.Type.Type() != String && op.Type.Type() != Enum {
+		return nil, false
+	}
+	var payload *RecordSchema
+	for _, name := range []string{changeBeforeField, changeAfterField} {
+		field, _, ok := envelope.Field(name)
+		if !ok {
+			return nil, false
+		}
+		union, ok := field.Type.(*UnionSchema)
+		if !ok || len(union.NonNullTypes()) != 1 || len(union.Types) != 2 {
+			return nil, false
+		}
+		record, ok := resolveSchema(union.NonNullTypes()[0]).(*RecordSchema)
+		if !ok || payload != nil && record.FullName() != payload.FullName() {
+			return nil, false
+		}
+		payload = record
+	}
+	return payload, true
+}
+
+// ParseChangeEvent extracts the change event held by a GenericRecord decoded with a change event
+// envelope schema, as recognized by ChangeEnvelope.
+func ParseChangeEvent(record *GenericRecord) (*ChangeEvent, error) {
+	if record.Schema() != nil {
+		if _, ok := ChangeEnvelope(record.Schema()); !ok {
+			return nil, fmt.Errorf("Record %s is not a change event envelope", record.Schema().FullName())
+		}
+	}
+	e := &ChangeEvent{}
+	switch op := record.Get(changeOpField).(type) {
+	case string:
+		e.Op = ChangeOp(op)
+	case *GenericEnum:
+		e.Op = ChangeOp(op.Get())
+	default:
+		return nil, fmt.Errorf("Invalid change event op: %v", op)
+	}
+
+	var err error
+	if e.Before, err = changeRecord(record, changeBeforeField); err != nil {
+		return nil, err
+	}
+	if e.After, err = changeRecord(record, changeAfterField); err != nil {
+		return nil, err
+	}
+	if e.Source, err = changeRecord(record, changeSourceField); err != nil {
+		return nil, err
+	}
+	if e.Timestamp, err = changeTimestamp(record); err != nil {
+		return nil, err
+	}
+	if e.Source != nil {
+		if e.SourceTimestamp, err = changeTimestamp(e.Source); err != nil {
+			return nil, fmt.Errorf("Change event source: %s", err)
+		}
+	}
+	return e, nil
+}
+
+// changeRecord returns the record held by the given field of an envelope, nil if null or missing.
+func changeRecord(envelope *GenericRecord, name string) (*GenericRecord, error) {
+	switch v := envelope.Get(name).(type) {
+	case nil:
+		return nil, nil
+	case *GenericRecord:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("Invalid change event %s: %v", name, v)
+	}
+}
+
+// changeTimestamp returns the most precise timestamp of a record, longs or times of a timestamp
+// logical type, or a zero time if it has none.
+func changeTimestamp(record *GenericRecord) (time.Time, error) {
+	for _, field := range changeTimestampFields {
+		switch v := record.Get(field.name).(type) {
+		case nil:
+		case time.Time:
+			return v, nil
+		case int64:
+			return timestamp(v, field.unit), nil
+		default:
+			return time.Time{}, fmt.Errorf("Invalid change event %s: %v", field.name, v)
+		}
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,92 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+var changeEnvelopeSchema = MustParseSchema(`{"type": "record", "name": "Envelope", "namespace": "shop.orders", "fields": [
+	{"name": "before", "type": ["null", {"type": "record", "name": "Value", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "status", "type": "string"}
+	]}], "default": null},
+	{"name": "after", "type": ["null", "Value"], "default": null},
+	{"name": "source", "type": {"type": "record", "name": "Source", "fields": [
+		{"name": "db", "type": "string"},
+		{"name": "ts_ms", "type": "long"}
+	]}},
+	{"name": "op", "type": "string"},
+	{"name": "ts_ms", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}], "default": null}
+]}`)
+
+func TestChangeEnvelope(t *testing.T) {
+	payload, ok := ChangeEnvelope(changeEnvelopeSchema)
+	assert(t, ok, true)
+	assert(t, payload.FullName(), "shop.orders.Value")
+
+	_, ok = ChangeEnvelope(payload)
+	assert(t, ok, false)
+	_, ok = ChangeEnvelope(MustParseSchema(`{"type": "record", "name": "Envelope", "fields": [
+		{"name": "before", "type": ["null", {"type": "record", "name": "A", "fields": []}]},
+		{"name": "after", "type": ["null", {"type": "record", "name": "B", "fields": []}]},
+		{"name": "op", "type": "string"}
+	]}`))
+	assert(t, ok, false)
+	_, ok = ChangeEnvelope(new(StringSchema))
+	assert(t, ok, false)
+}
+
+func TestParseChangeEvent(t *testing.T) {
+	payload, _ := ChangeEnvelope(changeEnvelopeSchema)
+	before := NewGenericRecord(payload)
+	before.Set("id", int64(1))
+	before.Set("status", "new")
+	after := NewGenericRecord(payload)
+	after.Set("id", int64(1))
+	after.Set("status", "paid")
+	source := NewGenericRecord(changeEnvelopeSchema.(*RecordSchema).Fields[2].Type)
+	source.Set("db", "shop")
+	source.Set("ts_ms", int64(1500))
+	processed := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	envelope := NewGenericRecord(changeEnvelopeSchema)
+	envelope.Set("before", before)
+	envelope.Set("after", after)
+	envelope.Set("source", source)
+	envelope.Set("op", "u")
+	envelope.Set("ts_ms", processed)
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(changeEnvelopeSchema).Write(envelope, NewBinaryEncoder(&buf)), nil)
+	decoded := NewGenericRecord(changeEnvelopeSchema)
+	assert(t, NewGenericDatumReader().SetSchema(changeEnvelopeSchema).Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+
+	e, err := ParseChangeEvent(decoded)
+	assert(t, err, nil)
+	assert(t, e.Op, ChangeUpdate)
+	assert(t, e.Before.Get("status"), "new")
+	assert(t, e.After.Get("status"), "paid")
+	assert(t, e.Payload().Get("status"), "paid")
+	assert(t, e.Source.Get("db"), "shop")
+	assert(t, e.Timestamp, processed)
+	assert(t, e.SourceTimestamp, time.Unix(1, 5e8).UTC())
+
+	// Deletes only have the state before.
+	decoded.Set("op", "d")
+	decoded.Set("after", nil)
+	e, err = ParseChangeEvent(decoded)
+	assert(t, err, nil)
+	assert(t, e.Op, ChangeDelete)
+	assert(t, e.After == nil, true)
+	assert(t, e.Payload().Get("status"), "new")
+
+	decoded.Set("op", int32(1))
+	_, err = ParseChangeEvent(decoded)
+	assert(t, err.Error(), "Invalid change event op: 1")
+	decoded.Set("op", "c")
+	decoded.Set("ts_ms", "now")
+	_, err = ParseChangeEvent(decoded)
+	assert(t, err.Error(), "Invalid change event ts_ms: now")
+	_, err = ParseChangeEvent(before)
+	assert(t, err.Error(), "Record shop.orders.Value is not a change event envelope")
+}
@@ -8,31 +8,45 @@ package avro
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"go/format"
+	"sort"
 	"strings"
 )
 
 // CodeGenerator is a code generation tool for structs from given Avro schemas.
+//
+// The output is deterministic: types are emitted sorted by name, so the generated code only changes
+// when the schemas do.
 type CodeGenerator struct {
-	rawSchemas []string
+	rawSchemas  []string
+	packageName string
 
+	header            *bytes.Buffer
 	structs           map[string]*bytes.Buffer
-	codeSnippets      []*bytes.Buffer
-	schemaDefinitions *bytes.Buffer
+	schemaDefinitions map[string]*bytes.Buffer
 }
 
 // NewCodeGenerator creates a new CodeGenerator for given Avro schemas.
 func NewCodeGenerator(schemas []string) *CodeGenerator {
 	return &CodeGenerator{
 		rawSchemas:        schemas,
+		header:            &bytes.Buffer{},
 		structs:           make(map[string]*bytes.Buffer),
-		codeSnippets:      make([]*bytes.Buffer, 0),
-		schemaDefinitions: &bytes.Buffer{},
+		schemaDefinitions: make(map[string]*bytes.Buffer),
 	}
 }
 
+// SetPackageName sets the name of the package for the generated code.
+// By default the last component of the first schema's namespace is used.
+func (codegen *CodeGenerator) SetPackageName(name string) *CodeGenerator {
+	codegen.packageName = name
+	return codegen
+}
+
 type recordSchemaInfo struct {
 	schema        *RecordSchema
 	typeName      string
@@ -90,9 +104,6 @@ func (codegen *CodeGenerator) Generate() (string, error) {
 			return "", err
 		}
 
-		buffer := &bytes.Buffer{}
-		codegen.codeSnippets = append(codegen.codeSnippets, buffer)
-
 		// write package and import only once
 		if index == 0 {
 			err = codegen.writePackageName(schemaInfo)
@@ -121,28 +132,43 @@ func (codegen *CodeGenerator) Generate() (string, error) {
 }
 
 func (codegen *CodeGenerator) collectResult() string {
-	results := make([]string, len(codegen.codeSnippets)+1)
-	for i, snippet := range codegen.codeSnippets {
-		results[i] = snippet.String()
+	results := []string{codegen.header.String()}
+	for _, name := range sortedBufferNames(codegen.structs) {
+		results = append(results, codegen.structs[name].String())
+	}
+	for _, name := range sortedBufferNames(codegen.schemaDefinitions) {
+		results = append(results, codegen.schemaDefinitions[name].String())
 	}
-	results[len(results)-1] = codegen.schemaDefinitions.String()
 
 	return strings.Join(results, "\n")
 }
 
+func sortedBufferNames(buffers map[string]*bytes.Buffer) []string {
+	names := make([]string, 0, len(buffers))
+	for name := range buffers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (codegen *CodeGenerator) writePackageName(info *recordSchemaInfo) error {
-	buffer := codegen.codeSnippets[0]
-	_, err := buffer.WriteString("package ")
+	buffer := codegen.header
+	_, err := buffer.WriteString("// Code generated by avro codegen. DO NOT EDIT.\n\npackage ")
 	if err != nil {
 		return err
 	}
 
-	if info.schema.Namespace == "" {
-		info.schema.Namespace = "avro"
-	}
+	packageName := codegen.packageName
+	if packageName == "" {
+		if info.schema.Namespace == "" {
+			info.schema.Namespace = "avro"
+		}
 
-	packages := strings.Split(info.schema.Namespace, ".")
-	_, err = buffer.WriteString(fmt.Sprintf("%s\n\n", packages[len(packages)-1]))
+		packages := strings.Split(info.schema.Namespace, ".")
+		packageName = packages[len(packages)-1]
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("%s\n\n", packageName))
 	if err != nil {
 		return err
 	}
@@ -156,7 +182,6 @@ func (codegen *CodeGenerator) writeStruct(info *recordSchemaInfo) error {
 		return nil
 	}
 
-	codegen.codeSnippets = append(codegen.codeSnippets, buffer)
 	codegen.structs[info.typeName] = buffer
 
 	err := codegen.writeStructSchemaVar(info)
@@ -198,7 +223,6 @@ func (codegen *CodeGenerator) writeEnum(info *enumSchemaInfo) error {
 		return nil
 	}
 
-	codegen.codeSnippets = append(codegen.codeSnippets, buffer)
 	codegen.structs[info.typeName] = buffer
 
 	err := codegen.writeEnumConstants(info, buffer)
@@ -235,8 +259,8 @@ func (codegen *CodeGenerator) writeEnumConstants(info *enumSchemaInfo, buffer *b
 }
 
 func (codegen *CodeGenerator) writeImportStatement() error {
-	buffer := codegen.codeSnippets[0]
-	_, err := buffer.WriteString(`import "github.com/elodina/go-avro"`)
+	buffer := codegen.header
+	_, err := buffer.WriteString(`import avro "gopkg.in/avro.v0"`)
 	if err != nil {
 		return err
 	}
@@ -245,12 +269,49 @@ func (codegen *CodeGenerator) writeImportStatement() error {
 }
 
 func (codegen *CodeGenerator) writeStructSchemaVar(info *recordSchemaInfo) error {
-	buffer := codegen.schemaDefinitions
+	buffer := &bytes.Buffer{}
+	codegen.schemaDefinitions[info.typeName] = buffer
 	_, err := buffer.WriteString("// Generated by codegen. Please do not modify.\n")
 	if err != nil {
 		return err
 	}
 	_, err = buffer.WriteString(fmt.Sprintf("var %s, %s = avro.ParseSchema(`%s`)\n\n", info.schemaVarName, info.schemaErrName, strings.Replace(info.schema.String(), "`", "'", -1)))
+	if err != nil {
+		return err
+	}
+	return codegen.writeStructSchemaConstants(info, buffer)
+}
+
+// writeStructSchemaConstants writes the canonical form of the schema along with its fingerprints,
+// so that generated types can be registered or framed without parsing their schema at runtime.
+func (codegen *CodeGenerator) writeStructSchemaConstants(info *recordSchemaInfo, buffer *bytes.Buffer) error {
+	canonical, err := CanonicalForm(info.schema)
+	if err != nil {
+		return err
+	}
+	sha := sha256.Sum256([]byte(canonical))
+
+	_, err = buffer.WriteString(fmt.Sprintf("// %sCanonicalSchema is the Parsing Canonical Form of the %s schema.\n", info.typeName, info.typeName))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("const %sCanonicalSchema = `%s`\n\n", info.typeName, canonical))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("// %sFingerprintSHA256 is the hex encoded SHA-256 fingerprint of %sCanonicalSchema.\n", info.typeName, info.typeName))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("const %sFingerprintSHA256 = \"%s\"\n\n", info.typeName, hex.EncodeToString(sha[:])))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("// %sFingerprintCRC64 is the CRC-64-AVRO fingerprint of %sCanonicalSchema.\n", info.typeName, info.typeName))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.WriteString(fmt.Sprintf("const %sFingerprintCRC64 uint64 = 0x%016x\n\n", info.typeName, fingerprintCRC64([]byte(canonical))))
 	return err
 }
 
@@ -394,10 +455,8 @@ func (codegen *CodeGenerator) writeStructFieldType(schema Schema, buffer *bytes.
 
 func (codegen *CodeGenerator) writeStructUnionType(schema *UnionSchema, buffer *bytes.Buffer) error {
 	var unionType Schema
-	if schema.Types[0].Type() == Null {
-		unionType = schema.Types[1]
-	} else if schema.Types[1].Type() == Null {
-		unionType = schema.Types[0]
+	if nonNull := schema.NonNullTypes(); schema.IsNullable() && len(nonNull) == 1 {
+		unionType = nonNull[0]
 	}
 
 	if unionType != nil && codegen.isNullable(unionType) {
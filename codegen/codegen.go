@@ -7,6 +7,7 @@ package main
 // ***********************
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -29,6 +30,8 @@ func (i *schemas) Set(value string) error {
 
 var schema schemas
 var output = flag.String("out", "", "Output file name.")
+var pkg = flag.String("pkg", "", "Package name for the generated code. Defaults to the last component of the first schema's namespace.")
+var check = flag.Bool("check", false, "Do not write the output file, fail if it is not up to date with the schemas instead.")
 
 func main() {
 	parseAndValidateArgs()
@@ -40,10 +43,20 @@ func main() {
 		schemas = append(schemas, string(contents))
 	}
 
-	gen := avro.NewCodeGenerator(schemas)
+	gen := avro.NewCodeGenerator(schemas).SetPackageName(*pkg)
 	code, err := gen.Generate()
 	checkErr(err)
 
+	if *check {
+		existing, err := ioutil.ReadFile(*output)
+		checkErr(err)
+		if !bytes.Equal(existing, []byte(code)) {
+			fmt.Printf("%s is out of date with its schemas, regenerate it.\n", *output)
+			os.Exit(1)
+		}
+		return
+	}
+
 	createDirs()
 	err = ioutil.WriteFile(*output, []byte(code), 0664)
 	checkErr(err)
@@ -0,0 +1,45 @@
+package avro
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodeGeneratorSchemaConstants(t *testing.T) {
+	gen := NewCodeGenerator([]string{`{"type": "record", "name": "Event", "namespace": "events", "fields": [
+		{"name": "id", "type": "int"}
+	]}`})
+	code, err := gen.Generate()
+	assert(t, err, nil)
+
+	canonical, err := CanonicalForm(MustParseSchema(`{"type": "record", "name": "Event", "namespace": "events", "fields": [{"name": "id", "type": "int"}]}`))
+	assert(t, err, nil)
+	for _, expected := range []string{
+		"const EventCanonicalSchema = `" + canonical + "`",
+		"const EventFingerprintSHA256 = \"",
+		"const EventFingerprintCRC64 uint64 = 0x",
+		"func (o *Event) Schema() avro.Schema {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Generated code does not contain %q:\n%s", expected, code)
+		}
+	}
+}
+
+func TestCodeGeneratorDeterministic(t *testing.T) {
+	schemas := []string{
+		`{"type": "record", "name": "Zebra", "fields": [{"name": "a", "type": {"type": "record", "name": "Apple", "fields": [{"name": "b", "type": "int"}]}}]}`,
+		`{"type": "record", "name": "Mango", "fields": [{"name": "c", "type": "long"}]}`,
+	}
+	code, err := NewCodeGenerator(schemas).SetPackageName("events").Generate()
+	assert(t, err, nil)
+	again, err := NewCodeGenerator(schemas).SetPackageName("events").Generate()
+	assert(t, err, nil)
+	assert(t, code, again)
+
+	assert(t, strings.HasPrefix(code, "// Code generated by avro codegen. DO NOT EDIT.\n\npackage events\n"), true)
+	apple := strings.Index(code, "type Apple struct")
+	mango := strings.Index(code, "type Mango struct")
+	zebra := strings.Index(code, "type Zebra struct")
+	assert(t, apple >= 0 && apple < mango && mango < zebra, true)
+}
@@ -0,0 +1,101 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompatibilityPolicy describes which schema changes are allowed, following the compatibility
+// levels of the Confluent schema registry:
+//
+//   - backward: data written with the previous schema can be read with the proposed one,
+//   - forward: data written with the proposed schema can be read with the previous one,
+//   - full: both backward and forward.
+//
+// Transitive policies check the proposed schema against every schema of the history rather than
+// only the latest one. The zero value is the NONE level, allowing any change.
+type CompatibilityPolicy struct {
+	Backward   bool
+	Forward    bool
+	Transitive bool
+}
+
+// The compatibility levels of the Confluent schema registry.
+var (
+	CompatibilityNone               = CompatibilityPolicy{}
+	CompatibilityBackward           = CompatibilityPolicy{Backward: true}
+	CompatibilityBackwardTransitive = CompatibilityPolicy{Backward: true, Transitive: true}
+	CompatibilityForward            = CompatibilityPolicy{Forward: true}
+	CompatibilityForwardTransitive  = CompatibilityPolicy{Forward: true, Transitive: true}
+	CompatibilityFull               = CompatibilityPolicy{Backward: true, Forward: true}
+	CompatibilityFullTransitive     = CompatibilityPolicy{Backward: true, Forward: true, Transitive: true}
+)
+
+// ParseCompatibilityPolicy returns the CompatibilityPolicy of a Confluent compatibility level name,
+// e.g. "BACKWARD" or "full_transitive".
+func ParseCompatibilityPolicy(level string) (CompatibilityPolicy, error) {
+	var policy CompatibilityPolicy
+	name := strings.ToUpper(level)
+	if strings.HasSuffix(name, "_TRANSITIVE") {
+		policy.Transitive = true
+		name = strings.TrimSuffix(name, "_TRANSITIVE")
+	}
+	switch name {
+	case "NONE":
+		if !policy.Transitive {
+			return policy, nil
+		}
+	case "BACKWARD":
+		policy.Backward = true
+		return policy, nil
+	case "FORWARD":
+		policy.Forward = true
+		return policy, nil
+	case "FULL":
+		policy.Backward, policy.Forward = true, true
+		return policy, nil
+	}
+	return CompatibilityNone, fmt.Errorf("Unknown compatibility level %q", level)
+}
+
+// String returns the Confluent name of the compatibility level, e.g. "FULL_TRANSITIVE".
+func (p CompatibilityPolicy) String() string {
+	var name string
+	switch {
+	case p.Backward && p.Forward:
+		name = "FULL"
+	case p.Backward:
+		name = "BACKWARD"
+	case p.Forward:
+		name = "FORWARD"
+	default:
+		return "NONE"
+	}
+	if p.Transitive {
+		name += "_TRANSITIVE"
+	}
+	return name
+}
+
+// Check returns an error unless the proposed schema is compatible with the history of schemas,
+// oldest first, according to this policy. Only the latest schema of the history is checked
+// unless the policy is transitive, in which case the error is about the latest incompatible one.
+func (p CompatibilityPolicy) Check(proposed Schema, history ...Schema) error {
+	oldest := len(history) - 1
+	if p.Transitive {
+		oldest = 0
+	}
+	for i := len(history) - 1; i >= 0 && i >= oldest; i-- {
+		if p.Backward {
+			if problems := checkResolvable(history[i], proposed); len(problems) > 0 {
+				return fmt.Errorf("Schema is not backward compatible with version %d: %s", i, problems[0])
+			}
+		}
+		if p.Forward {
+			if problems := checkResolvable(proposed, history[i]); len(problems) > 0 {
+				return fmt.Errorf("Schema is not forward compatible with version %d: %s", i, problems[0])
+			}
+		}
+	}
+	return nil
+}
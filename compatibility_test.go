@@ -0,0 +1,43 @@
+package avro
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompatibilityPolicy(t *testing.T) {
+	for _, name := range []string{"NONE", "BACKWARD", "BACKWARD_TRANSITIVE", "FORWARD", "FORWARD_TRANSITIVE", "FULL", "FULL_TRANSITIVE"} {
+		policy, err := ParseCompatibilityPolicy(strings.ToLower(name))
+		assert(t, err, nil)
+		assert(t, policy.String(), name)
+	}
+	_, err := ParseCompatibilityPolicy("NONE_TRANSITIVE")
+	assert(t, err != nil, true)
+	_, err = ParseCompatibilityPolicy("SIDEWAYS")
+	assert(t, err != nil, true)
+
+	// v3 can read data written with v2, which always has an email, but not data written with v1.
+	v1 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "email", "type": "string", "default": ""}
+	]}`)
+	v3 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "email", "type": "string"}
+	]}`)
+
+	assert(t, CompatibilityNone.Check(v3, v1, v2), nil)
+	assert(t, CompatibilityBackward.Check(v2, v1), nil)
+	assert(t, CompatibilityFull.Check(v2, v1), nil)
+	assert(t, CompatibilityBackward.Check(v3, v1, v2), nil)
+	assert(t, CompatibilityBackward.Check(v3), nil)
+
+	err = CompatibilityBackwardTransitive.Check(v3, v1, v2)
+	assert(t, err != nil && strings.HasPrefix(err.Error(), "Schema is not backward compatible with version 0: "), true)
+	err = CompatibilityForward.Check(v3, v1, v2)
+	assert(t, err != nil && strings.HasPrefix(err.Error(), "Schema is not forward compatible with version 1: "), true)
+	assert(t, CompatibilityForwardTransitive.Check(v2, v1), nil)
+	assert(t, CompatibilityFullTransitive.Check(v3, v1, v2) != nil, true)
+}
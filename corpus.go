@@ -0,0 +1,225 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+)
+
+// CorpusOptions configures GenerateCorpus.
+type CorpusOptions struct {
+	// Seed of the random values, so that the same options generate the same corpus.
+	Seed int64
+
+	// MaxItems is the number of items of the largest arrays and maps generated, 100 if zero.
+	MaxItems int
+
+	// MaxDepth is how many records recursive data nests, 3 if zero. Past it, unions take branches
+	// which don't nest any further and arrays and maps are empty.
+	MaxDepth int
+}
+
+// Values which varint, zigzag and IEEE 754 encoders and decoders are likely to get wrong.
+var (
+	corpusInts    = []int32{0, 1, -1, 63, -64, 64, -65, math.MaxInt32, math.MinInt32}
+	corpusLongs   = []int64{0, 1, -1, 63, -64, math.MaxInt32, math.MinInt32, math.MaxInt32 + 1, math.MinInt32 - 1, math.MaxInt64, math.MinInt64}
+	corpusFloats  = []float64{0, math.Copysign(0, -1), 1.5, -2.25, math.Inf(1), math.Inf(-1), math.NaN(), math.SmallestNonzeroFloat64, math.MaxFloat64}
+	corpusStrings = []string{"", "a", "héllo, 世界", "\x00\x7f"}
+)
+
+// GenerateCorpus writes n datums of the given schema to files in dir, one binary encoding per file
+// named after the schema and the index of the datum, e.g. as seeds for fuzzers or to test other
+// Avro implementations with. opts may be nil to use the defaults.
+//
+// Every other datum is made of systematic choices, the k-th of them taking the k-th branch of every
+// union and the k-th of a list of boundary values such as the largest and smallest integers, and
+// being empty, of a single item, of a few or of MaxItems items for arrays and maps. The others are
+// made of random choices. All of them are valid encodings by construction.
+func GenerateCorpus(schema Schema, dir string, n int, opts *CorpusOptions) error {
+	if opts == nil {
+		opts = &CorpusOptions{}
+	}
+	g := &corpusGenerator{
+		rand:     rand.New(rand.NewSource(opts.Seed)),
+		maxItems: opts.MaxItems,
+		maxDepth: opts.MaxDepth,
+	}
+	if g.maxItems <= 0 {
+		g.maxItems = 100
+	}
+	if g.maxDepth <= 0 {
+		g.maxDepth = 3
+	}
+
+	buf := &bytes.Buffer{}
+	for i := 0; i < n; i++ {
+		g.systematic, g.variant = i%2 == 0, i/2
+		buf.Reset()
+		if err := g.generate(schema, newBinaryEncoder(buf), 0); err != nil {
+			return err
+		}
+		name := filepath.Join(dir, fmt.Sprintf("%s-%d.bin", schema.GetName(), i))
+		if err := ioutil.WriteFile(name, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type corpusGenerator struct {
+	rand       *rand.Rand
+	maxItems   int
+	maxDepth   int
+	systematic bool
+	variant    int // of the systematic choices
+}
+
+// choose picks one of n choices.
+func (g *corpusGenerator) choose(n int) int {
+	if g.systematic {
+		return g.variant % n
+	}
+	return g.rand.Intn(n)
+}
+
+// items picks the number of items of an array or map.
+func (g *corpusGenerator) items(depth int) int {
+	if depth >= g.maxDepth {
+		return 0
+	}
+	switch g.choose(4) {
+	case 0:
+		return 0
+	case 1:
+		return 1
+	case 2:
+		return 2 + g.rand.Intn(4)
+	}
+	return g.maxItems
+}
+
+func (g *corpusGenerator) bytes() []byte {
+	if i := g.choose(len(corpusStrings) + 1); i < len(corpusStrings) {
+		return []byte(corpusStrings[i])
+	}
+	b := make([]byte, g.rand.Intn(64))
+	g.rand.Read(b)
+	return b
+}
+
+// text is like bytes, but always valid UTF-8.
+func (g *corpusGenerator) text() string {
+	if i := g.choose(len(corpusStrings) + 1); i < len(corpusStrings) {
+		return corpusStrings[i]
+	}
+	runes := make([]rune, g.rand.Intn(64))
+	for i := range runes {
+		runes[i] = rune(' ' + g.rand.Intn(0x3000))
+	}
+	return string(runes)
+}
+
+// generate writes a datum of the schema s to enc, for a record at the given depth.
+func (g *corpusGenerator) generate(s Schema, enc Encoder, depth int) error {
+	switch s := resolveSchema(s).(type) {
+	case *RecordSchema:
+		if depth >= DefaultMaxDepth {
+			return fmt.Errorf("Record %s has no data that doesn't nest forever", s.FullName())
+		}
+		for _, field := range s.Fields {
+			if err := g.generate(field.Type, enc, depth+1); err != nil {
+				return err
+			}
+		}
+	case *EnumSchema:
+		enc.WriteInt(int32(g.choose(len(s.Symbols))))
+	case *FixedSchema:
+		b := make([]byte, s.Size)
+		g.rand.Read(b)
+		enc.WriteRaw(b)
+	case *ArraySchema:
+		if count := g.items(depth); count > 0 {
+			enc.WriteArrayStart(int64(count))
+			for i := 0; i < count; i++ {
+				if err := g.generate(s.Items, enc, depth); err != nil {
+					return err
+				}
+			}
+		}
+		enc.WriteArrayNext(0)
+	case *MapSchema:
+		if count := g.items(depth); count > 0 {
+			enc.WriteMapStart(int64(count))
+			for i := 0; i < count; i++ {
+				// Keys are distinct, as other implementations would drop entries otherwise.
+				enc.WriteString(fmt.Sprintf("%s%d", g.text(), i))
+				if err := g.generate(s.Values, enc, depth); err != nil {
+					return err
+				}
+			}
+		}
+		enc.WriteMapNext(0)
+	case *UnionSchema:
+		branch := g.choose(len(s.Types))
+		if depth >= g.maxDepth {
+			branch = shallowestBranch(s)
+		}
+		enc.WriteLong(int64(branch))
+		return g.generate(s.Types[branch], enc, depth)
+	case *BooleanSchema:
+		enc.WriteBoolean(g.choose(2) == 1)
+	case *IntSchema:
+		if i := g.choose(len(corpusInts) + 1); i < len(corpusInts) {
+			enc.WriteInt(corpusInts[i])
+		} else {
+			enc.WriteInt(int32(g.rand.Uint32()))
+		}
+	case *LongSchema:
+		if i := g.choose(len(corpusLongs) + 1); i < len(corpusLongs) {
+			enc.WriteLong(corpusLongs[i])
+		} else {
+			enc.WriteLong(int64(g.rand.Uint64()))
+		}
+	case *FloatSchema:
+		if i := g.choose(len(corpusFloats) + 1); i < len(corpusFloats) {
+			enc.WriteFloat(float32(corpusFloats[i]))
+		} else {
+			enc.WriteFloat(float32(g.rand.NormFloat64()))
+		}
+	case *DoubleSchema:
+		if i := g.choose(len(corpusFloats) + 1); i < len(corpusFloats) {
+			enc.WriteDouble(corpusFloats[i])
+		} else {
+			enc.WriteDouble(g.rand.NormFloat64() * 1e6)
+		}
+	case *BytesSchema:
+		enc.WriteBytes(g.bytes())
+	case *StringSchema:
+		enc.WriteString(g.text())
+	case *NullSchema:
+	default:
+		return fmt.Errorf("Can't generate data for schema %s", s.GetName())
+	}
+	return nil
+}
+
+// shallowestBranch returns the index of the first branch of the union which nests the least.
+func shallowestBranch(union *UnionSchema) int {
+	best, bestWeight := 0, 3
+	for i, t := range union.Types {
+		weight := 0
+		switch resolveSchema(t).(type) {
+		case *ArraySchema, *MapSchema:
+			weight = 1
+		case *RecordSchema:
+			weight = 2
+		}
+		if weight < bestWeight {
+			best, bestWeight = i, weight
+		}
+	}
+	return best
+}
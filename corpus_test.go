@@ -0,0 +1,59 @@
+package avro
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateCorpus(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Tree", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "label", "type": ["null", "string", "int", {"type": "array", "items": "int"}]},
+		{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "BLUE"]}},
+		{"name": "attrs", "type": {"type": "map", "values": "bytes"}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "children", "type": {"type": "array", "items": "Tree"}}
+	]}`)
+	dir, err := ioutil.TempDir("", "corpus")
+	assert(t, err, nil)
+	defer os.RemoveAll(dir)
+	assert(t, GenerateCorpus(schema, dir, 20, &CorpusOptions{Seed: 3, MaxItems: 10}), nil)
+
+	branches := make(map[string]bool)
+	longs := make(map[int64]bool)
+	sizes := make(map[int]bool)
+	reader := NewGenericDatumReader().SetSchema(schema)
+	for i := 0; i < 20; i++ {
+		data, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("Tree-%d.bin", i)))
+		assert(t, err, nil)
+		dec := NewBinaryDecoder(data).(*binaryDecoder)
+		record := NewGenericRecord(schema)
+		assert(t, reader.Read(record, dec), nil)
+		assert(t, dec.pos, int64(len(data)))
+
+		switch label := record.Get("label").(type) {
+		case nil:
+			branches["null"] = true
+		case string:
+			branches["string"] = true
+		case int32:
+			branches["int"] = true
+		case []interface{}:
+			branches["array"] = true
+		default:
+			t.Fatalf("Unexpected label %v", label)
+		}
+		longs[record.Get("id").(int64)] = true
+		sizes[len(record.Get("children").([]interface{}))] = true
+	}
+	assert(t, len(branches), 4)
+	assert(t, longs[math.MaxInt64] && longs[math.MinInt64], true)
+	assert(t, sizes[0] && sizes[1] && sizes[10], true)
+
+	infinite := MustParseSchema(`{"type": "record", "name": "Loop", "fields": [{"name": "next", "type": "Loop"}]}`)
+	assert(t, GenerateCorpus(infinite, dir, 1, nil) != nil, true)
+}
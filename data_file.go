@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 	"math"
 	"os"
+	"reflect"
+	"sync"
 )
 
 // Support decoding the avro Object Container File format.
@@ -44,16 +46,37 @@ type DataFileReader struct {
 	block         *DataBlock
 	dec           Decoder
 	datum         DatumReader
-	codec         fileCodec
+	schema        Schema
+	codec         Codec
 	err           error
+
+	filter  func(peek FieldAccessor) bool
+	matched bool // whether the filter accepted the next record already
+
+	// cr counts the bytes of the file read through it, used for everything but seeking, from base.
+	cr          *countingReader
+	base        int64
+	blockOffset int64 // of the current block, from the start of the file
+	progress    progressTracker
+
+	// offset of the first block in the file, only known if r is an io.Seeker.
+	dataStart int64
 }
 
-var codecs = map[string]fileCodec{
+var codecs = map[string]Codec{
 	"":        nullCodec{},
 	"null":    nullCodec{},
 	"deflate": flateCodec{},
 }
 
+// RegisterCodec makes the codec with the given name available to read object container files
+// and to read and write sequence streams with, e.g. for snappy or zstd compression implemented by
+// other packages. The null and deflate codecs are built in. Must be called before reading or
+// writing, e.g. from an init function.
+func RegisterCodec(name string, codec Codec) {
+	codecs[name] = codec
+}
+
 // The header for object container files
 type objFileHeader struct {
 	Magic []byte            `avro:"magic"`
@@ -68,7 +91,8 @@ func readObjFileHeader(dec Decoder) (*objFileHeader, error) {
 	return header, err
 }
 
-// NewDataFileReader enables reading an object container file from the filesystem.
+// NewDataFileReader enables reading an object container file from the filesystem, or from a URL
+// handled by a registered Opener.
 // May return an error if the file contains invalid data or is just missing.
 //
 // The second DatumReader argument is deprecated, only there for source compatibility.
@@ -85,7 +109,7 @@ func NewDataFileReader(filename string, ignoreMe ...DatumReader) (*DataFileReade
 			return nil, fmt.Errorf("Datum reader input deprecated, don't know what to do with %#v", ignoreMe[0])
 		}
 	}
-	f, err := os.Open(filename)
+	f, err := openFile(filename)
 	if err != nil {
 		return nil, err
 	}
@@ -100,39 +124,65 @@ func NewDataFileReader(filename string, ignoreMe ...DatumReader) (*DataFileReade
 }
 
 func newDataFileReader(input io.Reader) (reader *DataFileReader, err error) {
-	dec := NewBinaryDecoderReader(input) // Since dec doesn't buffer, we can share it.
-	reader = &DataFileReader{
-		sharedCopyBuf: make([]byte, 4096),
-		r:             input,
-		dec:           dec,
+	reader = newDataFileReaderAt(input, 0)
+	header, err := readObjFileHeader(reader.dec)
+	if err != nil {
+		return nil, fmt.Errorf("DataFileReader: Error reading header: %s", err.Error())
+	}
+	if err = reader.setHeader(header); err != nil {
+		return nil, err
 	}
 
-	if reader.header, err = readObjFileHeader(dec); err != nil {
-		return nil, fmt.Errorf("DataFileReader: Error reading header: %s", err.Error())
+	if seeker, ok := reader.r.(io.Seeker); ok {
+		// dec doesn't buffer, so this is exactly where the header ends.
+		if reader.dataStart, err = seeker.Seek(0, os.SEEK_CUR); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := reader.NextBlock(); err != nil {
+		return nil, err
 	}
 
-	if !bytes.Equal(reader.header.Magic, magic) {
-		return nil, ErrNotAvroFile // TODO: consider formatting error magic value in
+	return reader, nil
+}
+
+// newDataFileReaderAt creates a DataFileReader reading input from the given offset of a file, without
+// a header yet.
+func newDataFileReaderAt(input io.Reader, offset int64) *DataFileReader {
+	input, progress := unwrapProgress(input)
+	cr := &countingReader{r: input}
+	return &DataFileReader{
+		sharedCopyBuf: make([]byte, 4096),
+		r:             input,
+		dec:           NewBinaryDecoderReader(cr), // Since dec doesn't buffer, we can share it.
+		cr:            cr,
+		base:          offset,
+		progress:      progressTracker{f: progress},
 	}
+}
 
-	schema, err := ParseSchema(string(reader.header.Meta[schemaKey]))
+// setHeader checks the header of the file and sets the reader up for the schema and codec in it.
+func (reader *DataFileReader) setHeader(header *objFileHeader) error {
+	if !bytes.Equal(header.Magic, magic) {
+		return ErrNotAvroFile // TODO: consider formatting error magic value in
+	}
+	reader.header = header
+
+	schema, err := ParseSchema(string(header.Meta[schemaKey]))
 	if err != nil {
-		return nil, err
+		return err
 	}
+	reader.schema = schema
 	reader.datum = NewDatumReader(schema)
 
-	codecName := string(reader.header.Meta[codecKey])
+	codecName := string(header.Meta[codecKey])
 	if codec := codecs[codecName]; codec == nil {
-		return nil, fmt.Errorf("DataFileReader: Don't know how to decode codec %s", codecName)
+		return fmt.Errorf("DataFileReader: Don't know how to decode codec %s", codecName)
 	} else {
 		reader.codec = codec
 	}
-
-	if err := reader.NextBlock(); err != nil {
-		return nil, err
-	}
-
-	return reader, nil
+	return nil
 }
 
 func (reader *DataFileReader) stop(err error) error {
@@ -170,12 +220,22 @@ func (reader *DataFileReader) HasNext() bool {
 func (reader *DataFileReader) advance() bool {
 	if reader.block == nil {
 		return false
-	} else if reader.block.BlockRemaining == 0 {
-		if err := reader.NextBlock(); err != nil {
+	}
+	for {
+		// Skip over empty blocks too, such as the one DataFileWriter.Close writes at the end.
+		for reader.block.BlockRemaining == 0 {
+			if err := reader.NextBlock(); err != nil {
+				return false
+			}
+		}
+		if err := reader.skipUnmatched(); err != nil {
+			reader.stop(err)
 			return false
 		}
+		if reader.block.BlockRemaining > 0 {
+			return true
+		}
 	}
-	return true
 }
 
 // Next reads the next value from file and fills the given value with data.
@@ -191,6 +251,7 @@ func (reader *DataFileReader) Next(v interface{}) error {
 	}
 
 	err := reader.datum.Read(v, reader.block.decoder)
+	reader.matched = false
 	if err != nil {
 		return err
 	}
@@ -198,6 +259,62 @@ func (reader *DataFileReader) Next(v interface{}) error {
 	return nil
 }
 
+// ReadBlock decodes all records remaining in the current block at once, moving to the next block
+// first if the current one is exhausted. v must be a pointer to a slice of anything Next would
+// accept a pointer to, e.g. *[]MyRecord, *[]*MyRecord or *[]*GenericRecord. The slice is truncated
+// and then filled with the block's records, reusing its capacity when possible. With a filter set,
+// it gets only the matching records, moving past blocks without any.
+//
+// Will error with io.EOF if there are no more blocks. If a record fails to decode, v holds the
+// records decoded before it.
+func (reader *DataFileReader) ReadBlock(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return ErrNotSlicePointer
+	}
+	slice := rv.Elem()
+	slice.SetLen(0)
+	if !reader.advance() {
+		return reader.err
+	}
+
+	elemType := slice.Type().Elem()
+	for {
+		if err := reader.skipUnmatched(); err != nil {
+			rv.Elem().Set(slice)
+			return reader.stop(err)
+		} else if reader.block.BlockRemaining == 0 {
+			break
+		}
+		var elem reflect.Value
+		if elemType.Kind() == reflect.Ptr {
+			elem = reflect.New(elemType.Elem())
+		} else {
+			elem = reflect.New(elemType)
+		}
+		err := reader.datum.Read(elem.Interface(), reader.block.decoder)
+		reader.matched = false
+		if err != nil {
+			rv.Elem().Set(slice)
+			return err
+		}
+		if elemType.Kind() != reflect.Ptr {
+			elem = elem.Elem()
+		}
+		slice = reflect.Append(slice, elem)
+		reader.block.BlockRemaining--
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
+
+// ReadGenericBlock is like ReadBlock but returns the records of the block as new GenericRecords.
+func (reader *DataFileReader) ReadGenericBlock() ([]*GenericRecord, error) {
+	var records []*GenericRecord
+	err := reader.ReadBlock(&records)
+	return records, err
+}
+
 // NextBlock tells this DataFileReader to skip current block and move to next one.
 //
 // This is not typically needed as the Next() loop will automatically advance
@@ -228,7 +345,7 @@ func (reader *DataFileReader) actualNextBlock() error {
 
 		// Check the sync data at end of block is equal
 		syncBuffer := reader.sharedCopyBuf[:containerSyncSize]
-		_, err = io.ReadFull(reader.r, syncBuffer)
+		_, err = io.ReadFull(reader.cr, syncBuffer)
 		if err != nil {
 			return err
 		}
@@ -236,7 +353,9 @@ func (reader *DataFileReader) actualNextBlock() error {
 			return fmt.Errorf("was expecting sync %v, got %v", reader.header.Sync, syncBuffer)
 		}
 		reader.block = nil
+		reader.progress.block(reader.base+reader.cr.n, block.NumEntries)
 	}
+	reader.blockOffset = reader.base + reader.cr.n
 
 	// Read counts for the new block
 	blockCount, err := reader.dec.ReadLong()
@@ -258,7 +377,7 @@ func (reader *DataFileReader) actualNextBlock() error {
 	}
 
 	// Pipeline step 1: io.LimitReader ensures we don't read past the end of the block.
-	r := io.LimitReader(reader.r, blockSize)
+	r := io.LimitReader(reader.cr, blockSize)
 
 	// Pipeline step 2: Buffer for performance on underlying file object.
 	// Normally, bufio.Reader would read too far, but LimitReader prevents it.
@@ -268,15 +387,31 @@ func (reader *DataFileReader) actualNextBlock() error {
 
 	r, closer := reader.codec.CodecReader(r)
 
+	// Filters need the whole block to skip over records.
+	var decoder Decoder
+	if reader.filter != nil {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			if closer != nil {
+				closer()
+			}
+			return err
+		}
+		decoder = NewBinaryDecoder(data)
+	} else {
+		decoder = NewBinaryDecoderReader(r)
+	}
+
 	block := &DataBlock{
 		reader:         r,
 		closer:         closer,
-		decoder:        NewBinaryDecoderReader(r),
+		decoder:        decoder,
 		BlockRemaining: blockCount,
 		NumEntries:     blockCount,
 		BlockSize:      int(blockSize),
 	}
 	reader.block = block
+	reader.matched = false
 	reader.err = nil
 
 	return nil
@@ -299,22 +434,38 @@ func (reader *DataFileReader) Close() error {
 ////////// DATA FILE WRITER
 
 // DataFileWriter lets you write object container files.
+//
+// All its methods are safe to call from multiple goroutines; datums written concurrently end up in
+// the file in an unspecified order.
 type DataFileWriter struct {
-	output      io.Writer
+	// mu guards everything below, including the use of datumWriter.
+	mu sync.Mutex
+
+	output      *countingWriter
 	outputEnc   *binaryEncoder
 	datumWriter DatumWriter
 	sync        []byte
+	progress    progressTracker
 
 	// current block is buffered until flush
 	blockBuf   *bytes.Buffer
 	blockCount int64
-	blockEnc   *binaryEncoder
+
+	// datums are encoded here first, so that those failing to encode don't end up in the block
+	scratch    *bytes.Buffer
+	scratchEnc *binaryEncoder
 }
 
 // NewDataFileWriter creates a new DataFileWriter for given output and schema using the given DatumWriter to write the data to that Writer.
 // May return an error if writing fails.
 func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter) (writer *DataFileWriter, err error) {
-	encoder := newBinaryEncoder(output)
+	return newDataFileWriterMeta(output, schema, datumWriter, nil)
+}
+
+// newDataFileWriterMeta is like NewDataFileWriter, but adds the given entries to the metadata of the file.
+func newDataFileWriterMeta(output io.Writer, schema Schema, datumWriter DatumWriter, meta map[string][]byte) (writer *DataFileWriter, err error) {
+	counted := &countingWriter{w: output}
+	encoder := newBinaryEncoder(counted)
 	switch w := datumWriter.(type) {
 	case *SpecificDatumWriter:
 		w.SetSchema(schema)
@@ -332,19 +483,23 @@ func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter)
 		},
 		Sync: sync,
 	}
+	for key, value := range meta {
+		header.Meta[key] = value
+	}
 	headerWriter := NewSpecificDatumWriter()
 	headerWriter.SetSchema(objHeaderSchema)
 	if err = headerWriter.Write(header, encoder); err != nil {
 		return
 	}
-	blockBuf := &bytes.Buffer{}
+	scratch := &bytes.Buffer{}
 	writer = &DataFileWriter{
-		output:      output,
+		output:      counted,
 		outputEnc:   encoder,
 		datumWriter: datumWriter,
 		sync:        sync,
-		blockBuf:    blockBuf,
-		blockEnc:    newBinaryEncoder(blockBuf),
+		blockBuf:    &bytes.Buffer{},
+		scratch:     scratch,
+		scratchEnc:  newBinaryEncoder(scratch),
 	}
 
 	return
@@ -355,9 +510,97 @@ func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter)
 // Encoded datums are buffered internally and will not be written to the
 // underlying io.Writer until Flush() is called.
 func (w *DataFileWriter) Write(v interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.write(v)
+}
+
+func (w *DataFileWriter) write(v interface{}) error {
+	w.scratch.Reset()
+	if err := w.datumWriter.Write(v, w.scratchEnc); err != nil {
+		return err
+	}
+	if _, err := w.blockBuf.Write(w.scratch.Bytes()); err != nil {
+		return err
+	}
 	w.blockCount++
-	err := w.datumWriter.Write(v, w.blockEnc)
-	return err
+	return nil
+}
+
+// WriteBatch encodes all the given datums and writes them out together with any datums buffered by
+// Write as a single block, flushing once at the end.
+//
+// The datums are encoded to a scratch buffer first, so if any of them fails to encode nothing from
+// the batch gets written and previously buffered datums are left as they were.
+func (w *DataFileWriter) WriteBatch(records []interface{}) error {
+	return w.writeBatch(len(records), func(i int) interface{} { return records[i] })
+}
+
+// WriteSlice is like WriteBatch, but takes a slice of any type the DatumWriter accepts elements of,
+// e.g. []MyRecord, []*MyRecord or []*GenericRecord. Slice elements which aren't pointers already
+// are written through pointers to them.
+func (w *DataFileWriter) WriteSlice(slice interface{}) error {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		return ErrNotSlice
+	}
+	return w.writeBatch(rv.Len(), func(i int) interface{} {
+		if elem := rv.Index(i); elem.Kind() != reflect.Ptr {
+			return elem.Addr().Interface()
+		} else {
+			return elem.Interface()
+		}
+	})
+}
+
+// WriteChan writes out all datums received from ch until it is closed, flushing a block every
+// flushEvery datums (never if flushEvery is 0) and once more at the end.
+//
+// After the first error, the remaining datums are drained from ch without being written so that
+// producers don't block, and the error is returned once ch is closed.
+func (w *DataFileWriter) WriteChan(ch <-chan interface{}, flushEvery int) error {
+	var err error
+	for v := range ch {
+		if err == nil {
+			err = w.writeFlushing(v, flushEvery)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// writeFlushing writes v, flushing the current block if it has grown to flushEvery datums.
+func (w *DataFileWriter) writeFlushing(v interface{}, flushEvery int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.write(v); err != nil {
+		return err
+	}
+	if flushEvery > 0 && w.blockCount >= int64(flushEvery) {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *DataFileWriter) writeBatch(n int, get func(i int) interface{}) error {
+	if n == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.scratch.Reset()
+	for i := 0; i < n; i++ {
+		if err := w.datumWriter.Write(get(i), w.scratchEnc); err != nil {
+			return err
+		}
+	}
+	if _, err := w.blockBuf.Write(w.scratch.Bytes()); err != nil {
+		return err
+	}
+	w.blockCount += int64(n)
+	return w.flush()
 }
 
 // Flush out any previously written datums to our underlying io.Writer.
@@ -366,6 +609,12 @@ func (w *DataFileWriter) Write(v interface{}) error {
 // It's up to the library user to decide how often to flush; doing it
 // often will spend a lot of time on tiny I/O but save memory.
 func (w *DataFileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *DataFileWriter) flush() error {
 	if w.blockCount > 0 {
 		return w.actuallyFlush()
 	}
@@ -389,6 +638,7 @@ func (w *DataFileWriter) actuallyFlush() error {
 		return err
 	}
 
+	w.progress.block(w.output.n, w.blockCount)
 	w.blockBuf.Reset() // allow blockbuf's internal memory to be reused
 	w.blockCount = 0
 	return nil
@@ -398,7 +648,9 @@ func (w *DataFileWriter) actuallyFlush() error {
 // This is required to finish out the data file format.
 // After Close() is called, this DataFileWriter cannot be used anymore.
 func (w *DataFileWriter) Close() error {
-	err := w.Flush() // flush anything remaining
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.flush() // flush anything remaining
 	if err == nil {
 		// Do an empty flush to signal end of data file format
 		err = w.actuallyFlush()
@@ -406,14 +658,22 @@ func (w *DataFileWriter) Close() error {
 		if err == nil {
 			// Clean up references.
 			w.output, w.outputEnc, w.datumWriter = nil, nil, nil
-			w.blockBuf, w.blockEnc = nil, nil
+			w.blockBuf, w.scratch, w.scratchEnc = nil, nil, nil
 		}
 	}
 	return err
 }
 
-type fileCodec interface {
-	CodecReader(io.Reader) (io.Reader, func())
+// Codec compresses the blocks of object container files and the data of sequence streams.
+type Codec interface {
+	// CodecReader returns a reader of the data decompressed from r, and a function to call once
+	// done reading it, or nil.
+	CodecReader(r io.Reader) (io.Reader, func())
+
+	// CodecWriter returns a writer compressing the data written to it to w. Closing it writes out
+	// the rest of the compressed data, without closing w. If it has a Flush() error method, it is
+	// called to write out the data written so far, e.g. by SequenceWriter.Flush.
+	CodecWriter(w io.Writer) io.WriteCloser
 }
 
 type nullCodec struct{}
@@ -422,6 +682,16 @@ func (nullCodec) CodecReader(r io.Reader) (io.Reader, func()) {
 	return r, nil
 }
 
+func (nullCodec) CodecWriter(w io.Writer) io.WriteCloser {
+	return nopWriteCloser{w}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
 type flateCodec struct{}
 
 func (flateCodec) CodecReader(r io.Reader) (io.Reader, func()) {
@@ -429,6 +699,12 @@ func (flateCodec) CodecReader(r io.Reader) (io.Reader, func()) {
 	return flateReader, func() { flateReader.Close() }
 }
 
+func (flateCodec) CodecWriter(w io.Writer) io.WriteCloser {
+	// Only invalid compression levels make this fail.
+	flateWriter, _ := flate.NewWriter(w, flate.DefaultCompression)
+	return flateWriter
+}
+
 // DataBlock is a structure that holds a certain amount of entries and the actual buffer to read from.
 type DataBlock struct {
 	reader  io.Reader
@@ -0,0 +1,88 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+const dataFileCheckpointSchemaRaw = `{"type": "record", "name": "avro.DataFileCheckpoint", "fields": [
+	{"name": "offset", "type": "long"},
+	{"name": "records", "type": "long"},
+	{"name": "header", "type": "bytes"}
+]}`
+
+var dataFileCheckpointSchema = Prepare(MustParseSchema(dataFileCheckpointSchemaRaw))
+
+type dataFileCheckpoint struct {
+	Offset  int64  `avro:"offset"`
+	Records int64  `avro:"records"`
+	Header  []byte `avro:"header"`
+}
+
+// Checkpoint returns a token for the position of this DataFileReader in the file, which can be
+// persisted to resume reading from there with ResumeDataFileReader, e.g. after a restart. It holds
+// the offset of the current block in the file, the number of its records read already, and the file
+// header, so that resuming doesn't need to read the start of the file again.
+func (reader *DataFileReader) Checkpoint() ([]byte, error) {
+	checkpoint := &dataFileCheckpoint{Offset: reader.blockOffset}
+	if block := reader.block; block != nil {
+		checkpoint.Records = block.NumEntries - block.BlockRemaining
+	}
+	header := &bytes.Buffer{}
+	if err := NewSpecificDatumWriter().SetSchema(objHeaderSchema).Write(reader.header, newBinaryEncoder(header)); err != nil {
+		return nil, err
+	}
+	checkpoint.Header = header.Bytes()
+
+	token := &bytes.Buffer{}
+	if err := NewSpecificDatumWriter().SetSchema(dataFileCheckpointSchema).Write(checkpoint, newBinaryEncoder(token)); err != nil {
+		return nil, err
+	}
+	return token.Bytes(), nil
+}
+
+// ResumeDataFileReader creates a DataFileReader reading a container file from the position a token
+// returned by Checkpoint was taken at. If input is an io.Seeker, it seeks to the offset of the
+// checkpoint in the file, otherwise input must start at that offset already, e.g. when resuming a
+// stream. The records of the block read before the checkpoint are skipped.
+func ResumeDataFileReader(input io.Reader, token []byte) (*DataFileReader, error) {
+	checkpoint := &dataFileCheckpoint{}
+	if err := NewSpecificDatumReader().SetSchema(dataFileCheckpointSchema).Read(checkpoint, NewBinaryDecoder(token)); err != nil {
+		return nil, fmt.Errorf("Invalid checkpoint: %s", err)
+	}
+	header, err := readObjFileHeader(NewBinaryDecoder(checkpoint.Header))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid checkpoint: %s", err)
+	}
+
+	reader := newDataFileReaderAt(input, checkpoint.Offset)
+	if err = reader.setHeader(header); err != nil {
+		return nil, err
+	}
+	if seeker, ok := reader.r.(io.Seeker); ok {
+		reader.dataStart = int64(len(checkpoint.Header))
+		if _, err = seeker.Seek(checkpoint.Offset, os.SEEK_SET); err != nil {
+			return nil, err
+		}
+	}
+	if err = reader.NextBlock(); err != nil {
+		if err == io.EOF {
+			return reader, nil
+		}
+		return nil, err
+	}
+
+	if checkpoint.Records > reader.block.BlockRemaining {
+		return nil, fmt.Errorf("Invalid checkpoint: block at offset %d has %d records, not %d", checkpoint.Offset, reader.block.BlockRemaining, checkpoint.Records)
+	}
+	datum := &GenericDatumReader{schema: reader.schema}
+	for ; checkpoint.Records > 0; checkpoint.Records-- {
+		if _, err = datum.readValue(reader.schema, reader.block.decoder); err != nil {
+			return nil, err
+		}
+		reader.block.BlockRemaining--
+	}
+	return reader, nil
+}
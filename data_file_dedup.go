@@ -0,0 +1,175 @@
+package avro
+
+import (
+	"bufio"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// DedupOptions configures DedupDataFile.
+type DedupOptions struct {
+	// KeepLast keeps the last record with every key instead of the first one.
+	KeepLast bool
+
+	// Exact keeps the encoded keys to tell them apart. Otherwise only a 64 bit hash of every key is
+	// kept, which bounds memory use to a few bytes per distinct key but has a tiny chance of dropping
+	// a record whose key collides with another one.
+	Exact bool
+}
+
+// DedupDataFile copies the records of the object container file read from src to dst, a new
+// container file with the same schema and the null codec, dropping all but one record of every key.
+// The key of a record is made of the values of the given fields, which are paths as for
+// NewKeyExtractor to reach into nested records, e.g. "user.id". Keys are compared by their binary
+// encoding, and the records kept stay in their original order.
+//
+// Records are streamed when keeping the first record of every key. Keeping the last one needs a
+// second pass over the data, which is spilled to a temporary file for it.
+//
+// Returns the number of records dropped. Corrupt blocks are returned as a *DataFileCorruptError.
+func DedupDataFile(src io.Reader, dst io.Writer, key []string, options DedupOptions) (duplicates int64, err error) {
+	scanner, err := newDataFileScanner(src)
+	if err != nil {
+		return 0, err
+	}
+	keys, err := NewKeyExtractor(scanner.schema, key)
+	if err != nil {
+		return 0, err
+	}
+	writer, err := NewDataFileWriter(dst, scanner.schema, rawDatumWriter{})
+	if err != nil {
+		return 0, err
+	}
+	out := &rawOutput{writer: writer}
+	seen := newDedupIndex(options.Exact)
+
+	if !options.KeepLast {
+		var index int64
+		err = scanner.eachRecord(func(data []byte) error {
+			k, err := keys.ExtractEncoded(data)
+			if err != nil {
+				return err
+			}
+			index++
+			if _, found := seen.get(k); found {
+				duplicates++
+				return nil
+			}
+			seen.put(k, index)
+			return out.write(data)
+		})
+		if err != nil {
+			return duplicates, err
+		}
+		return duplicates, writer.Close()
+	}
+
+	spill, err := ioutil.TempFile("", "avro-dedup")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		spill.Close()
+		os.Remove(spill.Name())
+	}()
+	w := bufio.NewWriter(spill)
+	enc := newBinaryEncoder(w)
+	var records int64
+	err = scanner.eachRecord(func(data []byte) error {
+		k, err := keys.ExtractEncoded(data)
+		if err != nil {
+			return err
+		}
+		records++
+		seen.put(k, records)
+		enc.WriteBytes(data)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = w.Flush(); err != nil {
+		return 0, err
+	}
+	if _, err = spill.Seek(0, os.SEEK_SET); err != nil {
+		return 0, err
+	}
+
+	dec := NewBinaryDecoderReader(bufio.NewReader(spill))
+	for index := int64(1); index <= records; index++ {
+		data, err := dec.ReadBytes()
+		if err != nil {
+			return duplicates, err
+		}
+		k, err := keys.ExtractEncoded(data)
+		if err != nil {
+			return duplicates, err
+		}
+		if last, _ := seen.get(k); last != index {
+			duplicates++
+			continue
+		}
+		if err = out.write(data); err != nil {
+			return duplicates, err
+		}
+	}
+	return duplicates, writer.Close()
+}
+
+// DedupDataFileName is like DedupDataFile, but works on files from the filesystem.
+// The destination file is created or truncated.
+func DedupDataFileName(src, dst string, key []string, options DedupOptions) (duplicates int64, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	duplicates, err = DedupDataFile(in, out, key, options)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return duplicates, err
+}
+
+// dedupIndex maps keys, or their hashes unless exact, to the index of a record.
+type dedupIndex struct {
+	exact  map[string]int64
+	hashes map[uint64]int64
+}
+
+func newDedupIndex(exact bool) *dedupIndex {
+	if exact {
+		return &dedupIndex{exact: make(map[string]int64)}
+	}
+	return &dedupIndex{hashes: make(map[uint64]int64)}
+}
+
+func (d *dedupIndex) get(key []byte) (int64, bool) {
+	if d.exact != nil {
+		index, found := d.exact[string(key)]
+		return index, found
+	}
+	index, found := d.hashes[hashKey(key)]
+	return index, found
+}
+
+func (d *dedupIndex) put(key []byte, index int64) {
+	if d.exact != nil {
+		d.exact[string(key)] = index
+	} else {
+		d.hashes[hashKey(key)] = index
+	}
+}
+
+func hashKey(key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(key)
+	return h.Sum64()
+}
@@ -0,0 +1,192 @@
+package avro
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// FieldAccessor gives access to the fields of a record by name, returning nil for fields that
+// don't exist. GenericRecord implements it.
+type FieldAccessor interface {
+	Get(name string) interface{}
+}
+
+// SetFilter makes this DataFileReader only return the records for which filter returns true, or all
+// of them again if filter is nil. HasNext, Next and ReadBlock move past the other records.
+//
+// The filter is given the fields of every record of the file schema as a FieldAccessor, values being
+// decoded the way a GenericDatumReader would. Only the fields it gets are decoded, the others are
+// skipped over, and records are only decoded fully once they match, which makes selective scans of
+// wide records much cheaper. Filters of files whose schema isn't a record get nil for every field.
+//
+// The blocks of a filtered file are decompressed into memory to be read, including what's left of the
+// current block when the filter is set.
+func (reader *DataFileReader) SetFilter(filter func(peek FieldAccessor) bool) {
+	reader.filter = filter
+	reader.matched = false
+	if filter != nil {
+		if err := reader.bufferBlock(); err != nil {
+			reader.stop(err)
+		}
+	}
+}
+
+// ReadColumns decodes the given fields of all records remaining in the current block, moving to the
+// next block first if the current one is exhausted, and returns the values of every field in order,
+// decoded the way a GenericDatumReader would. The other fields are skipped over without decoding
+// them, so this is a cheap way to scan a few columns of wide records. With a filter set, only the
+// matching records are read.
+//
+// The file schema must be a record with all the given fields. Will error with io.EOF if there are no
+// more blocks.
+func (reader *DataFileReader) ReadColumns(fields []string) ([][]interface{}, error) {
+	record, ok := resolveSchema(reader.schema).(*RecordSchema)
+	if !ok {
+		return nil, fmt.Errorf("Can only read columns of records, not %s", reader.schema.GetName())
+	}
+	for _, name := range fields {
+		if _, _, ok := record.Field(name); !ok {
+			return nil, fmt.Errorf("Record %s has no field %s", record.FullName(), name)
+		}
+	}
+	if !reader.advance() {
+		return nil, reader.err
+	}
+	if err := reader.bufferBlock(); err != nil {
+		return nil, reader.stop(err)
+	}
+
+	columns := make([][]interface{}, len(fields))
+	for {
+		if err := reader.skipUnmatched(); err != nil {
+			return columns, reader.stop(err)
+		} else if reader.block.BlockRemaining == 0 {
+			return columns, nil
+		}
+		peek := reader.peek()
+		for i, name := range fields {
+			columns[i] = append(columns[i], peek.Get(name))
+		}
+		if peek.err == nil {
+			peek.err = peek.skip()
+		}
+		if peek.err != nil {
+			return columns, reader.stop(peek.err)
+		}
+		reader.matched = false
+		reader.block.BlockRemaining--
+	}
+}
+
+// bufferBlock reads what's left of the current block into memory, if it's not there already, for
+// the records in it to be skipped over.
+func (reader *DataFileReader) bufferBlock() error {
+	block := reader.block
+	if block == nil {
+		return nil
+	}
+	if _, ok := block.decoder.(*binaryDecoder); !ok {
+		data, err := ioutil.ReadAll(block.reader)
+		if err != nil {
+			return err
+		}
+		block.decoder = NewBinaryDecoder(data)
+	}
+	return nil
+}
+
+// skipUnmatched moves past the records of the current block which the filter, if any, rejects, up
+// to the next one it accepts.
+func (reader *DataFileReader) skipUnmatched() error {
+	for reader.filter != nil && !reader.matched && reader.block.BlockRemaining > 0 {
+		peek := reader.peek()
+		matched := reader.filter(peek)
+		if peek.err != nil {
+			return peek.err
+		}
+		if matched {
+			peek.bd.pos = peek.offsets[0]
+			reader.matched = true
+		} else if err := peek.skip(); err != nil {
+			return err
+		} else {
+			reader.block.BlockRemaining--
+		}
+	}
+	return nil
+}
+
+// peek returns a recordPeek for the next record of the current block, which must be in memory.
+func (reader *DataFileReader) peek() *recordPeek {
+	bd := reader.block.decoder.(*binaryDecoder)
+	peek := &recordPeek{datum: &GenericDatumReader{schema: reader.schema}, schema: reader.schema, bd: bd, offsets: []int64{bd.pos}}
+	if rs, ok := resolveSchema(reader.schema).(*RecordSchema); ok {
+		peek.record = rs
+		peek.values = make([]interface{}, len(rs.Fields))
+		peek.decoded = make([]bool, len(rs.Fields))
+	}
+	return peek
+}
+
+// recordPeek is the FieldAccessor filters get, decoding the fields of an encoded record on demand.
+type recordPeek struct {
+	datum   *GenericDatumReader
+	schema  Schema
+	record  *RecordSchema // nil if schema isn't a record
+	bd      *binaryDecoder
+	offsets []int64 // where the record starts, followed by the ends of the fields known so far
+	values  []interface{}
+	decoded []bool
+	err     error // first error decoding the record
+}
+
+func (p *recordPeek) Get(name string) interface{} {
+	if p.record == nil || p.err != nil {
+		return nil
+	}
+	_, index, ok := p.record.Field(name)
+	if !ok {
+		return nil
+	} else if p.decoded[index] {
+		return p.values[index]
+	}
+	if p.err = p.reach(index); p.err != nil {
+		return nil
+	}
+	dec := &binaryDecoder{buf: p.bd.buf, pos: p.offsets[index]}
+	value, err := p.datum.readValue(p.record.Fields[index].Type, dec)
+	if err != nil {
+		p.err = err
+		return nil
+	}
+	if len(p.offsets) == index+1 {
+		p.offsets = append(p.offsets, dec.pos)
+	}
+	p.values[index], p.decoded[index] = fieldValue(value), true
+	return p.values[index]
+}
+
+// reach skips over fields until the start of the one with the given index is known.
+func (p *recordPeek) reach(index int) error {
+	for len(p.offsets) <= index {
+		field := len(p.offsets) - 1
+		dec := &binaryDecoder{buf: p.bd.buf, pos: p.offsets[field]}
+		if err := dec.skip(p.record.Fields[field].Type, nil, DefaultMaxDepth); err != nil {
+			return err
+		}
+		p.offsets = append(p.offsets, dec.pos)
+	}
+	return nil
+}
+
+// skip moves the decoder past the whole record.
+func (p *recordPeek) skip() error {
+	if p.record == nil {
+		return p.bd.skip(p.schema, nil, DefaultMaxDepth)
+	}
+	if err := p.reach(len(p.record.Fields)); err != nil {
+		return err
+	}
+	p.bd.pos = p.offsets[len(p.record.Fields)]
+	return nil
+}
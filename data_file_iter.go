@@ -0,0 +1,27 @@
+//go:build go1.23
+// +build go1.23
+
+package avro
+
+import (
+	"iter"
+	"reflect"
+)
+
+// WriteAll writes out all datums produced by seq to w, flushing a block every flushEvery datums
+// (never if flushEvery is 0) and once more at the end. Datums which aren't pointers already are
+// written through pointers to them.
+//
+// Iteration stops at the first error, which is returned.
+func WriteAll[T any](w *DataFileWriter, seq iter.Seq[T], flushEvery int) error {
+	for v := range seq {
+		var datum interface{} = v
+		if reflect.ValueOf(datum).Kind() != reflect.Ptr {
+			datum = &v
+		}
+		if err := w.writeFlushing(datum, flushEvery); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,42 @@
+//go:build go1.23
+// +build go1.23
+
+package avro
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestWriteAll(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	records := []primitive{{LongField: 0}, {LongField: 1}, {LongField: 2}}
+	assert(t, WriteAll(dfw, slices.Values(records), 2), nil)
+	assert(t, dfw.Close(), nil)
+
+	reader, err := newDataFileReader(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	var block []primitive
+	assert(t, reader.ReadBlock(&block), nil)
+	assert(t, len(block), 2)
+	assert(t, reader.ReadBlock(&block), nil)
+	assert(t, block[0].LongField, int64(2))
+
+	calls := 0
+	bad := func(yield func(interface{}) bool) {
+		for _, v := range []interface{}{&primitive{}, 42, &primitive{}} {
+			calls++
+			if !yield(v) {
+				return
+			}
+		}
+	}
+	dfw, err = NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	assert(t, WriteAll(dfw, bad, 0) != nil, true)
+	assert(t, calls, 2)
+}
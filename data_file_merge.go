@@ -0,0 +1,80 @@
+package avro
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// MergeDataFiles reads the object container files from srcs in turn, each of which may have been
+// written with a different version of a schema, projects all their records to the reader schema
+// with a DatumProjector and writes them to dst as a single container file with the null codec.
+//
+// The headers of all files are read, and their schemas checked to resolve to the reader schema,
+// before anything is written. Returns the number of records written. Errors are prefixed with the
+// index of the file they're about in srcs.
+func MergeDataFiles(dst io.Writer, reader Schema, srcs ...io.Reader) (records int64, err error) {
+	scanners := make([]*dataFileScanner, len(srcs))
+	projectors := make([]DatumProjector, len(srcs))
+	for i, src := range srcs {
+		if scanners[i], err = newDataFileScanner(src); err != nil {
+			return 0, fmt.Errorf("File %d: %s", i, err)
+		}
+		if projectors[i], err = NewDatumProjector(scanners[i].schema, reader); err != nil {
+			return 0, fmt.Errorf("File %d: %s", i, err)
+		}
+	}
+
+	writer, err := NewDataFileWriter(dst, reader, NewGenericDatumWriter())
+	if err != nil {
+		return 0, err
+	}
+	for i, scanner := range scanners {
+		datum := &GenericDatumReader{schema: scanner.schema}
+		err = scanner.eachRecord(func(data []byte) error {
+			value, err := datum.readValue(scanner.schema, NewBinaryDecoder(data))
+			if err != nil {
+				return err
+			}
+			if value, err = projectors[i].Project(value); err != nil {
+				return err
+			}
+			if err = writer.Write(value); err != nil {
+				return err
+			}
+			records++
+			if writer.blockBuf.Len() >= rawBlockSize {
+				return writer.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return records, fmt.Errorf("File %d: %s", i, err)
+		}
+	}
+	return records, writer.Close()
+}
+
+// MergeDataFileNames is like MergeDataFiles, but works on files from the filesystem.
+// The destination file is created or truncated.
+func MergeDataFileNames(dst string, reader Schema, srcs ...string) (records int64, err error) {
+	inputs := make([]io.Reader, len(srcs))
+	for i, src := range srcs {
+		in, err := os.Open(src)
+		if err != nil {
+			return 0, err
+		}
+		defer in.Close()
+		inputs[i] = in
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	records, err = MergeDataFiles(out, reader, inputs...)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return records, err
+}
@@ -0,0 +1,80 @@
+package avro
+
+import "io"
+
+// Progress tells how far an operation on an object container file got.
+type Progress struct {
+	// Bytes of the file read or written so far, header included.
+	Bytes int64
+
+	// Records and Blocks read or written so far.
+	Records int64
+	Blocks  int64
+}
+
+// ProgressFunc is called with the progress of an operation on a container file after every block,
+// e.g. to show progress bars and estimate how long is left for huge files.
+type ProgressFunc func(Progress)
+
+// WithProgress wraps the reader of a container file given to a utility such as SplitDataFile,
+// SortDataFile, DedupDataFile, MergeDataFiles, RepairDataFile or ValidateDataFileReader, so that f
+// gets called after every block of it is read. The returned reader doesn't let the utilities seek in
+// r, and reading it directly counts nothing.
+func WithProgress(r io.Reader, f ProgressFunc) io.Reader {
+	return &progressReader{Reader: r, f: f}
+}
+
+type progressReader struct {
+	io.Reader
+	f ProgressFunc
+}
+
+// unwrapProgress returns the reader wrapped by WithProgress, if r is one, and the ProgressFunc to call.
+func unwrapProgress(r io.Reader) (io.Reader, ProgressFunc) {
+	if pr, ok := r.(*progressReader); ok {
+		return pr.Reader, pr.f
+	}
+	return r, nil
+}
+
+// progressTracker adds up the progress of an operation and reports it.
+type progressTracker struct {
+	f        ProgressFunc
+	progress Progress
+}
+
+// block reports a block of the given number of records, the file having bytes bytes so far.
+func (t *progressTracker) block(bytes, records int64) {
+	t.progress.Bytes = bytes
+	t.progress.Records += records
+	t.progress.Blocks++
+	if t.f != nil {
+		t.f(t.progress)
+	}
+}
+
+// SetProgress makes this DataFileReader call f after reading every block, or stop if f is nil.
+// The blocks already read count towards the progress reported.
+func (reader *DataFileReader) SetProgress(f ProgressFunc) {
+	reader.progress.f = f
+}
+
+// SetProgress makes this DataFileWriter call f after writing every block, or stop if f is nil.
+// The blocks already written count towards the progress reported.
+func (w *DataFileWriter) SetProgress(f ProgressFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.progress.f = f
+}
+
+// countingWriter keeps track of the number of bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
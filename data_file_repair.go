@@ -0,0 +1,66 @@
+package avro
+
+import (
+	"io"
+	"os"
+)
+
+// RepairDataFile copies all complete and valid blocks of an object container file from src into a new
+// container file written to dst, dropping everything from the first corrupt block on. This recovers the
+// data from files left truncated or with a corrupt tail, e.g. by writers that crashed.
+//
+// Returns the number of records salvaged. May return an error if the header of src is unreadable, in
+// which case nothing can be salvaged, or if writing to dst fails.
+func RepairDataFile(src io.Reader, dst io.Writer) (records int64, err error) {
+	scanner, err := newDataFileScanner(src)
+	if err != nil {
+		return 0, err
+	}
+
+	enc := newBinaryEncoder(dst)
+	headerWriter := NewSpecificDatumWriter()
+	headerWriter.SetSchema(objHeaderSchema)
+	if err = headerWriter.Write(scanner.header, enc); err != nil {
+		return 0, err
+	}
+
+	for {
+		block, err := scanner.next()
+		if err != nil {
+			if _, corrupt := err.(*DataFileCorruptError); corrupt || err == io.EOF {
+				return records, nil
+			}
+			return records, err
+		}
+
+		enc.WriteLong(block.count)
+		enc.WriteLong(int64(len(block.data)))
+		if _, err = dst.Write(block.data); err != nil {
+			return records, err
+		}
+		if _, err = dst.Write(scanner.header.Sync); err != nil {
+			return records, err
+		}
+		records += block.count
+	}
+}
+
+// RepairDataFileName is like RepairDataFile, but works on files from the filesystem.
+// The destination file is created or truncated.
+func RepairDataFileName(src, dst string) (records int64, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	records, err = RepairDataFile(in, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return records, err
+}
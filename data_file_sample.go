@@ -0,0 +1,120 @@
+package avro
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrNotSeekable happens when an operation needs to seek in a file that doesn't support it.
+var ErrNotSeekable = errors.New("Underlying reader is not seekable")
+
+// Sample returns approximately n records spread evenly across the whole file, decoded the same way
+// a GenericDatumReader would (records are *GenericRecord). If the file has fewer than n records, all
+// of them are returned.
+//
+// Only blocks holding sampled records are decompressed and decoded, all others are skipped by
+// seeking over them, which makes it cheap to peek into huge files.
+// Sample starts from the beginning of the file regardless of what was already read with Next, and
+// leaves the reader at the end of the file.
+func (reader *DataFileReader) Sample(n int) ([]interface{}, error) {
+	seeker, ok := reader.r.(io.Seeker)
+	if !ok {
+		return nil, ErrNotSeekable
+	}
+	if block := reader.block; block != nil {
+		block.runCloser()
+		reader.block = nil
+	}
+	reader.stop(io.EOF)
+
+	blocks, total, err := reader.scanBlocks(seeker)
+	if err != nil || n <= 0 || total == 0 {
+		return nil, err
+	}
+
+	// Pick the record in the middle of each of n equally sized slices of the file.
+	var targets []int64
+	if int64(n) >= total {
+		for i := int64(0); i < total; i++ {
+			targets = append(targets, i)
+		}
+	} else {
+		for i := int64(0); i < int64(n); i++ {
+			targets = append(targets, (2*i+1)*total/(2*int64(n)))
+		}
+	}
+
+	datum := &GenericDatumReader{schema: reader.schema}
+	samples := make([]interface{}, 0, len(targets))
+	var first int64 // index of the first record in the current block
+	for _, block := range blocks {
+		if len(targets) == 0 {
+			break
+		} else if targets[0] >= first+block.count {
+			first += block.count
+			continue
+		}
+
+		if _, err = seeker.Seek(block.offset, os.SEEK_SET); err != nil {
+			return samples, err
+		}
+		r, closer := reader.codec.CodecReader(bufio.NewReader(io.LimitReader(reader.r, block.size)))
+		dec := NewBinaryDecoderReader(r)
+		for i := first; len(targets) > 0 && targets[0] < first+block.count; i++ {
+			value, err := datum.readValue(reader.schema, dec)
+			if err != nil {
+				if closer != nil {
+					closer()
+				}
+				return samples, err
+			}
+			if i == targets[0] {
+				samples = append(samples, value)
+				targets = targets[1:]
+			}
+		}
+		if closer != nil {
+			closer()
+		}
+		first += block.count
+	}
+	return samples, nil
+}
+
+type blockPosition struct {
+	offset int64 // offset of the block data, after the counts
+	count  int64
+	size   int64
+}
+
+// scanBlocks reads the positions of all blocks in the file, seeking over their data.
+func (reader *DataFileReader) scanBlocks(seeker io.Seeker) (blocks []blockPosition, total int64, err error) {
+	if _, err = seeker.Seek(reader.dataStart, os.SEEK_SET); err != nil {
+		return nil, 0, err
+	}
+	for {
+		count, err := reader.dec.ReadLong()
+		if err == ErrUnexpectedEOF {
+			return blocks, total, nil
+		} else if err != nil {
+			return nil, 0, err
+		}
+		size, err := reader.dec.ReadLong()
+		if err != nil {
+			return nil, 0, err
+		}
+		offset, err := seeker.Seek(0, os.SEEK_CUR)
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, err = seeker.Seek(size+containerSyncSize, os.SEEK_CUR); err != nil {
+			return nil, 0, err
+		}
+		if count > 0 {
+			blocks = append(blocks, blockPosition{offset: offset, count: count, size: size})
+			total += count
+		}
+	}
+}
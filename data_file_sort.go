@@ -0,0 +1,299 @@
+package avro
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// rawBlockSize is the size of the blocks written by utilities copying encoded records.
+const rawBlockSize = 64 * 1024
+
+// SortDataFile sorts the records of the object container file read from src by the given fields of
+// its record schema, which are paths as for NewKeyExtractor, compared in turn with CompareBinary and
+// in reverse for fields with the "descending" order, and writes them to dst as a new container file with the same schema and the
+// null codec. Records with equal fields keep their relative order. The sort fields are recorded in
+// the SortedByKey metadata of the new file.
+//
+// At most maxMemory bytes of encoded records, or all of them if 0, are sorted in memory at once.
+// Larger files are sorted in runs spilled to temporary files, which are then merged. This makes it
+// possible to sort files of any size, e.g. before merge joins or deduplication. Corrupt blocks are
+// returned as a *DataFileCorruptError.
+func SortDataFile(src io.Reader, dst io.Writer, fields []string, maxMemory int64) error {
+	scanner, err := newDataFileScanner(src)
+	if err != nil {
+		return err
+	}
+	sorter, err := newRecordSorter(scanner.schema, fields)
+	if err != nil {
+		return err
+	}
+
+	var spills []*os.File
+	defer func() {
+		for _, spill := range spills {
+			spill.Close()
+			os.Remove(spill.Name())
+		}
+	}()
+	var runs []*sortRun
+	var run []sortedRecord
+	var runSize int64
+	err = scanner.eachRecord(func(data []byte) error {
+		record, err := sorter.record(&binaryDecoder{buf: data})
+		if err != nil {
+			return err
+		}
+		if maxMemory > 0 && runSize+int64(len(data)) > maxMemory && len(run) > 0 {
+			spill, err := sorter.spill(run)
+			if spill != nil {
+				spills = append(spills, spill)
+			}
+			if err != nil {
+				return err
+			}
+			runs = append(runs, &sortRun{dec: NewBinaryDecoderReader(bufio.NewReader(spill)), remaining: len(run), index: len(runs)})
+			run, runSize = nil, 0
+		}
+		run = append(run, record)
+		runSize += int64(len(data))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	writer, err := newDataFileWriterMeta(dst, scanner.schema, rawDatumWriter{}, sortedByMeta(fields))
+	if err != nil {
+		return err
+	}
+	out := &rawOutput{writer: writer}
+	if len(runs) == 0 {
+		sorter.sort(run)
+		if sorter.err != nil {
+			return sorter.err
+		}
+		for _, record := range run {
+			if err = out.write(record.data); err != nil {
+				return err
+			}
+		}
+		return writer.Close()
+	}
+
+	if len(run) > 0 {
+		sorter.sort(run)
+		runs = append(runs, &sortRun{records: run, remaining: len(run), index: len(runs)})
+	}
+	if err = sorter.merge(runs, out); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// SortDataFileName is like SortDataFile, but works on files from the filesystem.
+// The destination file is created or truncated.
+func SortDataFileName(src, dst string, fields []string, maxMemory int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	err = SortDataFile(in, out, fields, maxMemory)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// sortedRecord is an encoded record along with the encoded values of the fields it is sorted by.
+type sortedRecord struct {
+	data []byte
+	keys [][]byte
+}
+
+type recordSorter struct {
+	keys   *KeyExtractor
+	fields []*SchemaField // of the keys, to compare them with
+	err    error          // first error comparing records
+}
+
+func newRecordSorter(schema Schema, fields []string) (*recordSorter, error) {
+	if _, ok := schema.(*RecordSchema); !ok {
+		return nil, fmt.Errorf("Can only sort records, not %s", schema.GetName())
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("No fields to sort by")
+	}
+	keys, err := NewKeyExtractor(schema, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &recordSorter{keys: keys, fields: keys.KeySchema().Fields}, nil
+}
+
+// record splits the next record off bd, which it keeps referring to.
+func (s *recordSorter) record(bd *binaryDecoder) (sortedRecord, error) {
+	start := bd.pos
+	record := sortedRecord{keys: make([][]byte, len(s.fields))}
+	err := s.keys.root.extract(bd, record.keys, true)
+	record.data = bd.buf[start:bd.pos]
+	return record, err
+}
+
+// compare compares records by their sort fields. Errors are kept in s.err as sorting can't fail.
+func (s *recordSorter) compare(a, b sortedRecord) int {
+	for k, field := range s.fields {
+		c, err := CompareBinary(field.Type, a.keys[k], b.keys[k])
+		if err != nil && s.err == nil {
+			s.err = err
+		}
+		if field.Order == "descending" {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (s *recordSorter) sort(run []sortedRecord) {
+	sort.Stable(&sortedRun{s, run})
+}
+
+// sortedRun sorts a run of records with a recordSorter.
+type sortedRun struct {
+	sorter *recordSorter
+	run    []sortedRecord
+}
+
+func (r *sortedRun) Len() int           { return len(r.run) }
+func (r *sortedRun) Swap(i, j int)      { r.run[i], r.run[j] = r.run[j], r.run[i] }
+func (r *sortedRun) Less(i, j int) bool { return r.sorter.compare(r.run[i], r.run[j]) < 0 }
+
+// spill sorts a run and writes it to a temporary file, rewound to be read back.
+func (s *recordSorter) spill(run []sortedRecord) (*os.File, error) {
+	s.sort(run)
+	if s.err != nil {
+		return nil, s.err
+	}
+	f, err := ioutil.TempFile("", "avro-sort")
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	enc := newBinaryEncoder(w)
+	for _, record := range run {
+		enc.WriteBytes(record.data)
+	}
+	if err = w.Flush(); err != nil {
+		return f, err
+	}
+	_, err = f.Seek(0, os.SEEK_SET)
+	return f, err
+}
+
+// merge writes the records of sorted runs out in order, records of earlier runs first when equal.
+func (s *recordSorter) merge(runs []*sortRun, out *rawOutput) error {
+	queue := &sortQueue{sorter: s}
+	for _, run := range runs {
+		if err := run.next(s); err != nil {
+			return err
+		}
+		queue.runs = append(queue.runs, run)
+	}
+	heap.Init(queue)
+	for queue.Len() > 0 {
+		run := queue.runs[0]
+		if err := out.write(run.current.data); err != nil {
+			return err
+		}
+		if run.remaining == 0 {
+			heap.Pop(queue)
+			continue
+		}
+		if err := run.next(s); err != nil {
+			return err
+		}
+		heap.Fix(queue, 0)
+	}
+	return s.err
+}
+
+// sortRun is a sorted run being merged, either spilled or still in memory.
+type sortRun struct {
+	dec       Decoder
+	records   []sortedRecord
+	remaining int
+	index     int
+	current   sortedRecord
+}
+
+func (run *sortRun) next(s *recordSorter) error {
+	run.remaining--
+	if run.dec == nil {
+		run.current, run.records = run.records[0], run.records[1:]
+		return nil
+	}
+	data, err := run.dec.ReadBytes()
+	if err != nil {
+		return err
+	}
+	run.current, err = s.record(&binaryDecoder{buf: data})
+	return err
+}
+
+type sortQueue struct {
+	sorter *recordSorter
+	runs   []*sortRun
+}
+
+func (q *sortQueue) Len() int      { return len(q.runs) }
+func (q *sortQueue) Swap(i, j int) { q.runs[i], q.runs[j] = q.runs[j], q.runs[i] }
+func (q *sortQueue) Less(i, j int) bool {
+	if c := q.sorter.compare(q.runs[i].current, q.runs[j].current); c != 0 {
+		return c < 0
+	}
+	return q.runs[i].index < q.runs[j].index
+}
+func (q *sortQueue) Push(x interface{}) { q.runs = append(q.runs, x.(*sortRun)) }
+func (q *sortQueue) Pop() interface{} {
+	run := q.runs[len(q.runs)-1]
+	q.runs = q.runs[:len(q.runs)-1]
+	return run
+}
+
+// rawOutput writes encoded records to a DataFileWriter in blocks of about rawBlockSize bytes.
+type rawOutput struct {
+	writer   *DataFileWriter
+	buffered int
+}
+
+func (out *rawOutput) write(data []byte) error {
+	if err := out.writer.Write(data); err != nil {
+		return err
+	}
+	if out.buffered += len(data); out.buffered >= rawBlockSize {
+		out.buffered = 0
+		return out.writer.Flush()
+	}
+	return nil
+}
+
+// rawDatumWriter writes values which are encoded already.
+type rawDatumWriter struct{}
+
+func (rawDatumWriter) Write(obj interface{}, enc Encoder) error {
+	enc.WriteRaw(obj.([]byte))
+	return nil
+}
@@ -0,0 +1,237 @@
+package avro
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SortedByKey is the metadata key of object container files whose records are sorted, as written by
+// SortDataFile. Its value is the comma-separated names of the fields the records are sorted by, in
+// order, compared the way SortDataFile does.
+const SortedByKey = "sorted.by"
+
+func sortedByMeta(fields []string) map[string][]byte {
+	return map[string][]byte{SortedByKey: []byte(strings.Join(fields, ","))}
+}
+
+// SortedBy returns the fields the records of this file are sorted by according to its metadata, or
+// nil if it doesn't say it's sorted. Use IsSorted to check that it actually is.
+func (reader *DataFileReader) SortedBy() []string {
+	value, ok := reader.header.Meta[SortedByKey]
+	if !ok || len(value) == 0 {
+		return nil
+	}
+	return strings.Split(string(value), ",")
+}
+
+// IsSorted reads the object container file from src and tells whether its records are sorted by the
+// given fields, in the order SortDataFile would sort them in, or by the fields in its SortedByKey
+// metadata if fields is empty. Returns false as soon as two records are out of order.
+func IsSorted(src io.Reader, fields []string) (bool, error) {
+	scanner, err := newDataFileScanner(src)
+	if err != nil {
+		return false, err
+	}
+	if len(fields) == 0 {
+		value, ok := scanner.header.Meta[SortedByKey]
+		if !ok || len(value) == 0 {
+			return false, fmt.Errorf("File has no %s metadata, fields to check are needed", SortedByKey)
+		}
+		fields = strings.Split(string(value), ",")
+	}
+	sorter, err := newRecordSorter(scanner.schema, fields)
+	if err != nil {
+		return false, err
+	}
+
+	sorted, started := true, false
+	var previous sortedRecord
+	err = scanner.eachRecord(func(data []byte) error {
+		record, err := sorter.record(&binaryDecoder{buf: data})
+		if err != nil {
+			return err
+		}
+		if started && sorter.compare(previous, record) > 0 {
+			sorted = false
+			return io.EOF
+		}
+		previous, started = record, true
+		return sorter.err
+	})
+	if err == io.EOF {
+		err = nil
+	}
+	return sorted && err == nil, err
+}
+
+// SortedMergeReader reads the records of several object container files sorted by the same fields,
+// e.g. by SortDataFile, as a single stream in that order, so that consumers such as merge joins can
+// process them in one pass. Records with equal fields come in the order of the files they are in.
+//
+// The files may have different schemas, as long as the fields they are sorted by have the same
+// types. Records are checked to be in order as they are read.
+type SortedMergeReader struct {
+	sorter  *recordSorter // of the first file, to compare records with
+	sources []*mergeSource
+	queue   *mergeQueue
+	source  int // of the last record read
+	err     error
+}
+
+// mergeSource is a file being merged along with its next record.
+type mergeSource struct {
+	index     int
+	scanner   *dataFileScanner
+	sorter    *recordSorter
+	datum     DatumReader
+	bd        *binaryDecoder
+	remaining int64
+	current   sortedRecord
+}
+
+// NewSortedMergeReader creates a SortedMergeReader for the files read from srcs, all sorted by the
+// given fields. Errors are prefixed with the index of the file they're about in srcs.
+func NewSortedMergeReader(fields []string, srcs ...io.Reader) (*SortedMergeReader, error) {
+	r := &SortedMergeReader{source: -1}
+	r.queue = &mergeQueue{reader: r}
+	for i, src := range srcs {
+		scanner, err := newDataFileScanner(src)
+		if err != nil {
+			return nil, fmt.Errorf("File %d: %s", i, err)
+		}
+		sorter, err := newRecordSorter(scanner.schema, fields)
+		if err != nil {
+			return nil, fmt.Errorf("File %d: %s", i, err)
+		}
+		if r.sorter == nil {
+			r.sorter = sorter
+		} else if err = sameSortFields(r.sorter, sorter); err != nil {
+			return nil, fmt.Errorf("File %d: %s", i, err)
+		}
+		r.sources = append(r.sources, &mergeSource{index: i, scanner: scanner, sorter: sorter, datum: NewDatumReader(scanner.schema)})
+	}
+
+	for _, source := range r.sources {
+		ok, err := source.next()
+		if err != nil {
+			return nil, fmt.Errorf("File %d: %s", source.index, err)
+		}
+		if ok {
+			r.queue.sources = append(r.queue.sources, source)
+		}
+	}
+	heap.Init(r.queue)
+	return r, nil
+}
+
+// sameSortFields checks that records sorted by other can be compared with those sorted by s.
+func sameSortFields(s, other *recordSorter) error {
+	for k, field := range other.fields {
+		expected := s.fields[k]
+		actual, err := CanonicalForm(field.Type)
+		if err != nil {
+			return err
+		}
+		want, err := CanonicalForm(expected.Type)
+		if err != nil {
+			return err
+		}
+		if actual != want || field.Order != expected.Order {
+			return fmt.Errorf("Field %s has type %s, not %s", field.Name, actual, want)
+		}
+	}
+	return nil
+}
+
+// next moves to the next record of the file, returning false at its end.
+func (source *mergeSource) next() (bool, error) {
+	for source.remaining == 0 {
+		bd, count, err := source.scanner.nextRecords()
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		source.bd, source.remaining = bd, count
+	}
+	previous := source.current
+	record, err := source.sorter.record(source.bd)
+	if err != nil {
+		return false, err
+	}
+	source.remaining--
+	source.current = record
+	if previous.data != nil && source.sorter.compare(previous, record) > 0 {
+		return false, fmt.Errorf("Records are not sorted by the merge fields")
+	}
+	return true, source.sorter.err
+}
+
+// HasNext tells whether there are records left to read. It is false after an error, see Err.
+func (r *SortedMergeReader) HasNext() bool {
+	return r.err == nil && r.queue.Len() > 0
+}
+
+// Next fills v with the next record in order, decoded by a DatumReader for the schema of its file.
+// v can be anything DataFileReader.Next accepts.
+//
+// Will error with io.EOF if you're past the end, loop HasNext() to prevent.
+func (r *SortedMergeReader) Next(v interface{}) error {
+	if r.err != nil {
+		return r.err
+	} else if r.queue.Len() == 0 {
+		return io.EOF
+	}
+	source := r.queue.sources[0]
+	r.source = source.index
+	if err := source.datum.Read(v, NewBinaryDecoder(source.current.data)); err != nil {
+		r.err = fmt.Errorf("File %d: %s", source.index, err)
+		return r.err
+	}
+
+	ok, err := source.next()
+	if err != nil {
+		r.err = fmt.Errorf("File %d: %s", source.index, err)
+	} else if ok {
+		heap.Fix(r.queue, 0)
+	} else {
+		heap.Pop(r.queue)
+	}
+	if r.err == nil && r.sorter.err != nil {
+		r.err = r.sorter.err
+	}
+	return nil
+}
+
+// Source returns the index in srcs of the file the last record read with Next is from, or -1 before
+// the first one.
+func (r *SortedMergeReader) Source() int {
+	return r.source
+}
+
+// Err returns the error which stopped reading, if any.
+func (r *SortedMergeReader) Err() error {
+	return r.err
+}
+
+type mergeQueue struct {
+	reader  *SortedMergeReader
+	sources []*mergeSource
+}
+
+func (q *mergeQueue) Len() int      { return len(q.sources) }
+func (q *mergeQueue) Swap(i, j int) { q.sources[i], q.sources[j] = q.sources[j], q.sources[i] }
+func (q *mergeQueue) Less(i, j int) bool {
+	if c := q.reader.sorter.compare(q.sources[i].current, q.sources[j].current); c != 0 {
+		return c < 0
+	}
+	return q.sources[i].index < q.sources[j].index
+}
+func (q *mergeQueue) Push(x interface{}) { q.sources = append(q.sources, x.(*mergeSource)) }
+func (q *mergeQueue) Pop() interface{} {
+	source := q.sources[len(q.sources)-1]
+	q.sources = q.sources[:len(q.sources)-1]
+	return source
+}
@@ -0,0 +1,110 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SplitDataFile splits the object container file read from src into multiple container files, named
+// by formatting dstPattern with their index starting from 0, e.g. "part-%03d.avro". Every file gets
+// the header of src and holds at most maxBytes bytes or maxRecords records, a limit of 0 meaning no
+// limit. Files are only split at block boundaries and blocks are copied as they are, without
+// decoding and encoding them again, so a single block exceeding a limit gets a file of its own.
+// Empty blocks, such as the one ending files written by DataFileWriter, are dropped.
+//
+// This allows processing the parts of a large file in parallel. Returns the names of the files
+// written, which are created or truncated, including those written before an error. Corrupt
+// blocks are returned as a *DataFileCorruptError.
+func SplitDataFile(src io.Reader, dstPattern string, maxBytes, maxRecords int64) (files []string, err error) {
+	if name := fmt.Sprintf(dstPattern, 0); strings.Contains(name, "%!") || name == fmt.Sprintf(dstPattern, 1) {
+		return nil, fmt.Errorf("Pattern %q doesn't number files", dstPattern)
+	}
+	scanner, err := newDataFileScanner(src)
+	if err != nil {
+		return nil, err
+	}
+	var header bytes.Buffer
+	headerWriter := NewSpecificDatumWriter()
+	headerWriter.SetSchema(objHeaderSchema)
+	if err = headerWriter.Write(scanner.header, newBinaryEncoder(&header)); err != nil {
+		return nil, err
+	}
+
+	var out *os.File
+	var enc Encoder
+	var size, records int64
+	defer func() {
+		if out != nil {
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}()
+	next := func() error {
+		if out != nil {
+			err := out.Close()
+			out = nil
+			if err != nil {
+				return err
+			}
+		}
+		name := fmt.Sprintf(dstPattern, len(files))
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		out, enc = f, newBinaryEncoder(f)
+		files = append(files, name)
+		size, records = int64(header.Len()), 0
+		_, err = out.Write(header.Bytes())
+		return err
+	}
+
+	for {
+		block, err := scanner.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return files, err
+		}
+		if block.count == 0 {
+			continue
+		}
+		blockSize := int64(varintLen(block.count)+varintLen(int64(len(block.data)))+len(block.data)) + containerSyncSize
+		if out == nil || (maxBytes > 0 && size+blockSize > maxBytes || maxRecords > 0 && records+block.count > maxRecords) {
+			if err = next(); err != nil {
+				return files, err
+			}
+		}
+
+		enc.WriteLong(block.count)
+		enc.WriteLong(int64(len(block.data)))
+		if _, err = out.Write(block.data); err != nil {
+			return files, err
+		}
+		if _, err = out.Write(scanner.header.Sync); err != nil {
+			return files, err
+		}
+		size += blockSize
+		records += block.count
+	}
+	if out == nil {
+		// An empty file still gets a part with the header only.
+		err = next()
+	}
+	return files, err
+}
+
+// varintLen returns the length of the zig-zag varint encoding of n.
+func varintLen(n int64) int {
+	u := uint64((n << 1) ^ (n >> 63))
+	length := 1
+	for u >= 0x80 {
+		u >>= 7
+		length++
+	}
+	return length
+}
@@ -2,7 +2,13 @@ package avro
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -103,3 +109,789 @@ func testComplex7(t *testing.T, reader *DataFileReader) {
 	assert(t, reader.Err(), nil)
 	assert(t, reader.err, io.EOF) // underlying error is EOF
 }
+
+func TestValidateDataFile(t *testing.T) {
+	for _, name := range []string{"test/complex7.deflate.avro", "test/complex7.null.avro"} {
+		records, err := ValidateDataFile(name)
+		assert(t, err, nil)
+		assert(t, records, int64(7))
+	}
+}
+
+func TestValidateDataFileCorrupt(t *testing.T) {
+	encoded := testDataFile(t, 3)
+	records, err := ValidateDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	assert(t, records, int64(3))
+
+	// Truncated in the middle of the last block
+	records, err = ValidateDataFileReader(bytes.NewReader(encoded[:len(encoded)-20]))
+	corrupt, ok := err.(*DataFileCorruptError)
+	assert(t, ok, true)
+	assert(t, corrupt.Block, 2)
+	assert(t, records, int64(2))
+
+	// Broken sync marker after the first block
+	broken := append([]byte{}, encoded...)
+	headerEnd := bytes.Index(broken, testSync) + len(testSync)
+	broken[headerEnd+bytes.Index(broken[headerEnd:], testSync)] ^= 0xff
+	_, err = ValidateDataFileReader(bytes.NewReader(broken))
+	corrupt, ok = err.(*DataFileCorruptError)
+	assert(t, ok, true)
+	assert(t, corrupt.Block, 0)
+
+	// Not an avro file
+	_, err = ValidateDataFileReader(bytes.NewReader([]byte("hello world")))
+	corrupt, ok = err.(*DataFileCorruptError)
+	assert(t, ok, true)
+	assert(t, corrupt.Block, -1)
+}
+
+var testSync = []byte("1234567890abcdef")
+
+// testDataFile writes an object container file of primitives with one record per block.
+func testDataFile(t *testing.T, records int) []byte {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < records; i++ {
+		if err = dfw.Write(&primitive{LongField: int64(i), StringField: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+		if err = dfw.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = dfw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRepairDataFile(t *testing.T) {
+	encoded := testDataFile(t, 3)
+
+	var repaired bytes.Buffer
+	records, err := RepairDataFile(bytes.NewReader(encoded[:len(encoded)-20]), &repaired)
+	assert(t, err, nil)
+	assert(t, records, int64(2))
+
+	records, err = ValidateDataFileReader(bytes.NewReader(repaired.Bytes()))
+	assert(t, err, nil)
+	assert(t, records, int64(2))
+
+	reader, err := newDataFileReader(bytes.NewReader(repaired.Bytes()))
+	assert(t, err, nil)
+	var p primitive
+	for i := 0; reader.HasNext(); i++ {
+		assert(t, reader.Next(&p), nil)
+		assert(t, p.LongField, int64(i))
+	}
+	assert(t, reader.Err(), nil)
+
+	_, err = RepairDataFile(bytes.NewReader([]byte("garbage")), &repaired)
+	assert(t, err != nil, true)
+}
+
+func TestSplitDataFile(t *testing.T) {
+	encoded := testDataFile(t, 5)
+	dir, err := ioutil.TempDir("", "avro-split")
+	assert(t, err, nil)
+	defer os.RemoveAll(dir)
+	pattern := filepath.Join(dir, "part-%d.avro")
+
+	files, err := SplitDataFile(bytes.NewReader(encoded), pattern, 0, 2)
+	assert(t, err, nil)
+	assert(t, len(files), 3)
+	var next int64
+	for i, file := range files {
+		assert(t, file, filepath.Join(dir, fmt.Sprintf("part-%d.avro", i)))
+		records, err := ValidateDataFile(file)
+		assert(t, err, nil)
+		assert(t, records, []int64{2, 2, 1}[i])
+
+		reader, err := NewDataFileReader(file)
+		assert(t, err, nil)
+		var p primitive
+		for reader.HasNext() {
+			assert(t, reader.Next(&p), nil)
+			assert(t, p.LongField, next)
+			next++
+		}
+		assert(t, reader.Close(), nil)
+	}
+	assert(t, next, int64(5))
+	twoRecords, err := os.Stat(files[0])
+	assert(t, err, nil)
+
+	// Every part is at least a header and a block, so a tiny limit puts every block apart.
+	files, err = SplitDataFile(bytes.NewReader(encoded), pattern, 1, 0)
+	assert(t, err, nil)
+	assert(t, len(files), 5)
+	files, err = SplitDataFile(bytes.NewReader(encoded), pattern, twoRecords.Size(), 0)
+	assert(t, err, nil)
+	assert(t, len(files), 3)
+
+	_, err = SplitDataFile(bytes.NewReader(encoded), filepath.Join(dir, "part.avro"), 0, 1)
+	assert(t, err != nil, true)
+	files, err = SplitDataFile(bytes.NewReader(encoded[:len(encoded)-20]), pattern, 0, 2)
+	_, corrupt := err.(*DataFileCorruptError)
+	assert(t, corrupt, true)
+	assert(t, len(files), 2)
+}
+
+func TestSortDataFile(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 100; i++ {
+		// Sorted by stringField, then longField: records of the same value keep their order.
+		p := &primitive{StringField: fmt.Sprintf("s%d", (i*7)%10), LongField: int64(i % 3), IntField: int32(i)}
+		assert(t, dfw.Write(p), nil)
+		if i%8 == 0 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+
+	for _, maxMemory := range []int64{0, 200} {
+		var sorted bytes.Buffer
+		assert(t, SortDataFile(bytes.NewReader(buf.Bytes()), &sorted, []string{"stringField", "longField"}, maxMemory), nil)
+		reader, err := newDataFileReader(bytes.NewReader(sorted.Bytes()))
+		assert(t, err, nil)
+		var records []primitive
+		for reader.HasNext() {
+			var p primitive
+			assert(t, reader.Next(&p), nil)
+			records = append(records, p)
+		}
+		assert(t, reader.Err(), nil)
+		assert(t, len(records), 100)
+		for i := 1; i < len(records); i++ {
+			prev, p := records[i-1], records[i]
+			ordered := prev.StringField < p.StringField || prev.StringField == p.StringField &&
+				(prev.LongField < p.LongField || prev.LongField == p.LongField && prev.IntField < p.IntField)
+			if !ordered {
+				t.Fatalf("Records %d and %d out of order with %d bytes of memory: %+v, %+v", i-1, i, maxMemory, prev, p)
+			}
+		}
+	}
+
+	err = SortDataFile(bytes.NewReader(buf.Bytes()), ioutil.Discard, []string{"missing"}, 0)
+	assert(t, err != nil, true)
+	err = SortDataFile(bytes.NewReader(buf.Bytes()[:buf.Len()-20]), ioutil.Discard, []string{"longField"}, 0)
+	_, corrupt := err.(*DataFileCorruptError)
+	assert(t, corrupt, true)
+}
+
+func TestSortedMergeReader(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	write := func(values ...int) []byte {
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+		assert(t, err, nil)
+		for i, v := range values {
+			assert(t, dfw.Write(&primitive{LongField: int64(v), IntField: int32(i)}), nil)
+			if i%2 == 1 {
+				assert(t, dfw.Flush(), nil)
+			}
+		}
+		assert(t, dfw.Close(), nil)
+		return buf.Bytes()
+	}
+	fields := []string{"longField"}
+	sort := func(data []byte) []byte {
+		var sorted bytes.Buffer
+		assert(t, SortDataFile(bytes.NewReader(data), &sorted, fields, 0), nil)
+		return sorted.Bytes()
+	}
+
+	unsorted := write(5, 1, 3, 3, 9)
+	ok, err := IsSorted(bytes.NewReader(unsorted), fields)
+	assert(t, err, nil)
+	assert(t, ok, false)
+	_, err = IsSorted(bytes.NewReader(unsorted), nil)
+	assert(t, err != nil, true)
+	reader, err := newDataFileReader(bytes.NewReader(unsorted))
+	assert(t, err, nil)
+	assert(t, reader.SortedBy() == nil, true)
+
+	a, b := sort(unsorted), sort(write(4, 0, 3))
+	reader, err = newDataFileReader(bytes.NewReader(a))
+	assert(t, err, nil)
+	assert(t, reader.SortedBy(), fields)
+	ok, err = IsSorted(bytes.NewReader(a), nil)
+	assert(t, err, nil)
+	assert(t, ok, true)
+
+	merged, err := NewSortedMergeReader(fields, bytes.NewReader(a), bytes.NewReader(write()), bytes.NewReader(b))
+	assert(t, err, nil)
+	assert(t, merged.Source(), -1)
+	var values, sources []int
+	for merged.HasNext() {
+		var p primitive
+		assert(t, merged.Next(&p), nil)
+		values = append(values, int(p.LongField)*10+int(p.IntField))
+		sources = append(sources, merged.Source())
+	}
+	assert(t, merged.Err(), nil)
+	assert(t, merged.Next(&primitive{}), io.EOF)
+	// Equal records come from earlier files first, in their order.
+	assert(t, values, []int{1, 11, 32, 33, 32, 40, 50, 94})
+	assert(t, sources, []int{2, 0, 0, 0, 2, 2, 0, 0})
+
+	merged, err = NewSortedMergeReader(fields, bytes.NewReader(unsorted))
+	assert(t, err, nil)
+	for merged.HasNext() {
+		assert(t, merged.Next(&primitive{}), nil)
+	}
+	assert(t, merged.Err().Error(), "File 0: Records are not sorted by the merge fields")
+
+	other := MustParseSchema(`{"type": "record", "name": "Other", "fields": [{"name": "longField", "type": "int"}]}`)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, other, NewGenericDatumWriter())
+	assert(t, err, nil)
+	assert(t, dfw.Close(), nil)
+	_, err = NewSortedMergeReader(fields, bytes.NewReader(a), buf)
+	assert(t, err.Error(), `File 1: Field longField has type "int", not "long"`)
+}
+
+func TestDedupDataFile(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "id", "type": "long", "aliases": ["uid"]}
+		]}},
+		{"name": "kind", "type": "string"},
+		{"name": "seq", "type": "int"}
+	]}`)
+	userSchema := schema.(*RecordSchema).Fields[0].Type
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewGenericDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 20; i++ {
+		user := NewGenericRecord(userSchema)
+		user.Set("name", fmt.Sprintf("user%d", i))
+		user.Set("id", int64(i%4))
+		event := NewGenericRecord(schema)
+		event.Set("user", user)
+		event.Set("kind", []string{"click", "view"}[i%2])
+		event.Set("seq", int32(i))
+		assert(t, dfw.Write(event), nil)
+		if i%6 == 0 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+
+	read := func(data []byte) []int32 {
+		reader, err := newDataFileReader(bytes.NewReader(data))
+		assert(t, err, nil)
+		var seqs []int32
+		for reader.HasNext() {
+			record := NewGenericRecord(schema)
+			assert(t, reader.Next(record), nil)
+			seqs = append(seqs, record.Get("seq").(int32))
+		}
+		assert(t, reader.Err(), nil)
+		return seqs
+	}
+	for _, exact := range []bool{false, true} {
+		var deduped bytes.Buffer
+		duplicates, err := DedupDataFile(bytes.NewReader(buf.Bytes()), &deduped, []string{"user.id"}, DedupOptions{Exact: exact})
+		assert(t, err, nil)
+		assert(t, duplicates, int64(16))
+		assert(t, read(deduped.Bytes()), []int32{0, 1, 2, 3})
+
+		deduped.Reset()
+		duplicates, err = DedupDataFile(bytes.NewReader(buf.Bytes()), &deduped, []string{"kind", "user.id"}, DedupOptions{KeepLast: true, Exact: exact})
+		assert(t, err, nil)
+		assert(t, duplicates, int64(16))
+		assert(t, read(deduped.Bytes()), []int32{16, 17, 18, 19})
+	}
+
+	_, err = DedupDataFile(bytes.NewReader(buf.Bytes()), ioutil.Discard, []string{"kind.id"}, DedupOptions{})
+	assert(t, err != nil, true)
+	_, err = DedupDataFile(bytes.NewReader(buf.Bytes()), ioutil.Discard, []string{"user.email"}, DedupOptions{})
+	assert(t, err != nil, true)
+
+	// Key and sort fields are resolved as the paths of KeyExtractors are, by alias too.
+	var deduped bytes.Buffer
+	duplicates, err := DedupDataFile(bytes.NewReader(buf.Bytes()), &deduped, []string{"user.uid"}, DedupOptions{})
+	assert(t, err, nil)
+	assert(t, duplicates, int64(16))
+	var sorted bytes.Buffer
+	assert(t, SortDataFile(bytes.NewReader(buf.Bytes()), &sorted, []string{"user.uid"}, 0), nil)
+	assert(t, read(sorted.Bytes())[:6], []int32{0, 4, 8, 12, 16, 1})
+}
+
+func TestMergeDataFiles(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": "int"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": "long"},
+		{"name": "email", "type": "string", "default": "none"}
+	]}`)
+	write := func(schema Schema, names ...string) []byte {
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewGenericDatumWriter())
+		assert(t, err, nil)
+		for i, name := range names {
+			record := NewGenericRecord(schema)
+			record.Set("name", name)
+			if schema == v1 {
+				record.Set("age", int32(i))
+			} else {
+				record.Set("age", int64(i))
+				record.Set("email", name+"@example.com")
+			}
+			assert(t, dfw.Write(record), nil)
+		}
+		assert(t, dfw.Close(), nil)
+		return buf.Bytes()
+	}
+	old, current := write(v1, "alice", "bob"), write(v2, "carol")
+
+	var merged bytes.Buffer
+	records, err := MergeDataFiles(&merged, v2, bytes.NewReader(old), bytes.NewReader(current))
+	assert(t, err, nil)
+	assert(t, records, int64(3))
+	reader, err := newDataFileReader(bytes.NewReader(merged.Bytes()))
+	assert(t, err, nil)
+	var emails []string
+	var ages []int64
+	for reader.HasNext() {
+		record := NewGenericRecord(v2)
+		assert(t, reader.Next(record), nil)
+		emails = append(emails, record.Get("email").(string))
+		ages = append(ages, record.Get("age").(int64))
+	}
+	assert(t, reader.Err(), nil)
+	assert(t, emails, []string{"none", "none", "carol@example.com"})
+	assert(t, ages, []int64{0, 1, 0})
+
+	// v2 data can't be read with v1, whose age is an int.
+	_, err = MergeDataFiles(ioutil.Discard, v1, bytes.NewReader(old), bytes.NewReader(current))
+	assert(t, err != nil && strings.HasPrefix(err.Error(), "File 1: "), true)
+}
+
+func TestDataFileSample(t *testing.T) {
+	encoded := testDataFile(t, 10)
+
+	reader, err := newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	samples, err := reader.Sample(3)
+	assert(t, err, nil)
+	assert(t, len(samples), 3)
+	for i, expected := range []int64{1, 5, 8} {
+		assert(t, samples[i].(*GenericRecord).Get("longField"), expected)
+	}
+	assert(t, reader.HasNext(), false)
+
+	reader, err = newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	var p primitive
+	assert(t, reader.Next(&p), nil)
+	samples, err = reader.Sample(20)
+	assert(t, err, nil)
+	assert(t, len(samples), 10)
+	assert(t, samples[9].(*GenericRecord).Get("longField"), int64(9))
+
+	_, err = (&DataFileReader{r: &bytes.Buffer{}}).Sample(1)
+	assert(t, err, ErrNotSeekable)
+}
+
+func TestDataFileReadBlock(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 5; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		if i == 2 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	encoded := buf.Bytes()
+
+	reader, err := newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	var first primitive
+	assert(t, reader.Next(&first), nil)
+	var records []primitive
+	assert(t, reader.ReadBlock(&records), nil)
+	assert(t, len(records), 2)
+	assert(t, records[1].LongField, int64(2))
+	var pointers []*primitive
+	assert(t, reader.ReadBlock(&pointers), nil)
+	assert(t, len(pointers), 2)
+	assert(t, pointers[0].LongField, int64(3))
+	assert(t, reader.ReadBlock(&pointers), io.EOF)
+	assert(t, len(pointers), 0)
+	assert(t, reader.ReadBlock(pointers), ErrNotSlicePointer)
+
+	reader, err = newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	generic, err := reader.ReadGenericBlock()
+	assert(t, err, nil)
+	assert(t, len(generic), 3)
+	assert(t, generic[2].Get("longField"), int64(2))
+}
+
+func TestDataFileReaderFilter(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 10; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i), StringField: fmt.Sprint("record ", i)}), nil)
+		if i%4 == 3 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	encoded := buf.Bytes()
+
+	reader, err := newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	var first primitive
+	assert(t, reader.Next(&first), nil)
+	var peeked []interface{}
+	reader.SetFilter(func(peek FieldAccessor) bool {
+		peeked = append(peeked, peek.Get("longField"))
+		return peek.Get("longField").(int64)%3 == 0 && peek.Get("missing") == nil
+	})
+	var longs []int64
+	var strings []string
+	for reader.HasNext() {
+		var record primitive
+		assert(t, reader.Next(&record), nil)
+		longs = append(longs, record.LongField)
+		strings = append(strings, record.StringField)
+	}
+	assert(t, reader.Err(), nil)
+	assert(t, longs, []int64{3, 6, 9})
+	assert(t, strings, []string{"record 3", "record 6", "record 9"})
+	assert(t, len(peeked), 9)
+
+	reader, err = newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	reader.SetFilter(func(peek FieldAccessor) bool {
+		return peek.Get("stringField") == "record 5" || peek.Get("stringField") == "record 7"
+	})
+	generic, err := reader.ReadGenericBlock()
+	assert(t, err, nil)
+	assert(t, len(generic), 2)
+	assert(t, generic[1].Get("longField"), int64(7))
+	_, err = reader.ReadGenericBlock()
+	assert(t, err, io.EOF)
+
+	reader, err = newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	reader.SetFilter(func(peek FieldAccessor) bool { return false })
+	assert(t, reader.HasNext(), false)
+	assert(t, reader.Err(), nil)
+
+	reader, err = newDataFileReader(bytes.NewReader(encoded[:len(encoded)-40]))
+	assert(t, err, nil)
+	reader.SetFilter(func(peek FieldAccessor) bool { return peek.Get("stringField") == "record 9" })
+	assert(t, reader.HasNext(), false)
+	if reader.Err() == nil {
+		t.Fatal("Expected an error reading a truncated file")
+	}
+}
+
+func TestDataFileReadColumns(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 5; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i), StringField: fmt.Sprint(i), BytesField: []byte{byte(i)}}), nil)
+		if i == 2 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	encoded := buf.Bytes()
+
+	reader, err := newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	var first primitive
+	assert(t, reader.Next(&first), nil)
+	columns, err := reader.ReadColumns([]string{"stringField", "longField"})
+	assert(t, err, nil)
+	assert(t, columns, [][]interface{}{{"1", "2"}, {int64(1), int64(2)}})
+	columns, err = reader.ReadColumns([]string{"bytesField"})
+	assert(t, err, nil)
+	assert(t, columns, [][]interface{}{{[]byte{3}, []byte{4}}})
+	_, err = reader.ReadColumns([]string{"bytesField"})
+	assert(t, err, io.EOF)
+
+	reader, err = newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	reader.SetFilter(func(peek FieldAccessor) bool { return peek.Get("longField").(int64)%2 == 1 })
+	columns, err = reader.ReadColumns([]string{"longField"})
+	assert(t, err, nil)
+	assert(t, columns, [][]interface{}{{int64(1)}})
+	columns, err = reader.ReadColumns([]string{"longField"})
+	assert(t, err, nil)
+	assert(t, columns, [][]interface{}{{int64(3)}})
+
+	_, err = reader.ReadColumns([]string{"missing"})
+	assert(t, err.Error(), "Record example.avro.Primitive has no field missing")
+}
+
+func TestDataFileProgress(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	var written []Progress
+	dfw.SetProgress(func(p Progress) { written = append(written, p) })
+	for i := 0; i < 5; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		if i%2 == 1 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	encoded := buf.Bytes()
+	// Blocks of 2, 2 and 1 records, then the empty one marking the end.
+	assert(t, len(written), 4)
+	assert(t, written[1].Records, int64(4))
+	assert(t, written[3], Progress{Bytes: int64(len(encoded)), Records: 5, Blocks: 4})
+
+	reader, err := newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	var read []Progress
+	reader.SetProgress(func(p Progress) { read = append(read, p) })
+	for reader.HasNext() {
+		var record primitive
+		assert(t, reader.Next(&record), nil)
+	}
+	assert(t, read, written)
+
+	var scanned []Progress
+	records, err := ValidateDataFileReader(WithProgress(bytes.NewReader(encoded), func(p Progress) { scanned = append(scanned, p) }))
+	assert(t, err, nil)
+	assert(t, records, int64(5))
+	assert(t, scanned, written)
+}
+
+func TestDataFileCheckpoint(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 7; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		if i%3 == 2 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	encoded := buf.Bytes()
+
+	rest := func(reader *DataFileReader) []int64 {
+		var longs []int64
+		for reader.HasNext() {
+			var record primitive
+			assert(t, reader.Next(&record), nil)
+			longs = append(longs, record.LongField)
+		}
+		assert(t, reader.Err(), nil)
+		return longs
+	}
+	reader, err := newDataFileReader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	for i := 0; i < 4; i++ {
+		var record primitive
+		assert(t, reader.Next(&record), nil)
+	}
+	token, err := reader.Checkpoint()
+	assert(t, err, nil)
+	assert(t, rest(reader), []int64{4, 5, 6})
+
+	resumed, err := ResumeDataFileReader(bytes.NewReader(encoded), token)
+	assert(t, err, nil)
+	assert(t, rest(resumed), []int64{4, 5, 6})
+
+	// Streams resume from the offset of the checkpoint, without the header.
+	var checkpoint dataFileCheckpoint
+	assert(t, NewSpecificDatumReader().SetSchema(dataFileCheckpointSchema).Read(&checkpoint, NewBinaryDecoder(token)), nil)
+	assert(t, checkpoint.Records, int64(1))
+	stream := struct{ io.Reader }{bytes.NewReader(encoded[checkpoint.Offset:])}
+	resumed, err = ResumeDataFileReader(stream, token)
+	assert(t, err, nil)
+	var record primitive
+	assert(t, resumed.Next(&record), nil)
+	assert(t, record.LongField, int64(4))
+	again, err := resumed.Checkpoint()
+	assert(t, err, nil)
+	assert(t, rest(resumed), []int64{5, 6})
+
+	resumed, err = ResumeDataFileReader(bytes.NewReader(encoded), again)
+	assert(t, err, nil)
+	assert(t, rest(resumed), []int64{5, 6})
+	end, err := resumed.Checkpoint()
+	assert(t, err, nil)
+	resumed, err = ResumeDataFileReader(bytes.NewReader(encoded), end)
+	assert(t, err, nil)
+	assert(t, rest(resumed), []int64(nil))
+
+	_, err = ResumeDataFileReader(bytes.NewReader(encoded), token[:3])
+	if err == nil {
+		t.Fatal("Expected an error resuming from a truncated checkpoint")
+	}
+}
+
+func TestDataFileWriteBatch(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	assert(t, dfw.Write(&primitive{LongField: 0}), nil)
+	assert(t, dfw.WriteBatch([]interface{}{&primitive{LongField: 1}, &primitive{LongField: 2}}), nil)
+	assert(t, dfw.WriteBatch([]interface{}{&primitive{LongField: 3}, 42}) != nil, true)
+	assert(t, dfw.WriteSlice([]primitive{{LongField: 3}, {LongField: 4}}), nil)
+	assert(t, dfw.WriteSlice([]*primitive{{LongField: 5}}), nil)
+	assert(t, dfw.WriteSlice(primitive{}), ErrNotSlice)
+	assert(t, dfw.Close(), nil)
+
+	reader, err := newDataFileReader(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	var blocks [][]primitive
+	for {
+		var records []primitive
+		if err := reader.ReadBlock(&records); err != nil {
+			assert(t, err, io.EOF)
+			break
+		}
+		blocks = append(blocks, records)
+	}
+	assert(t, len(blocks), 3)
+	assert(t, len(blocks[0]), 3)
+	assert(t, blocks[1][1].LongField, int64(4))
+	assert(t, blocks[2][0].LongField, int64(5))
+}
+
+func TestDataFileWriteChan(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	ch := make(chan interface{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			ch <- &primitive{LongField: int64(i)}
+		}
+		close(ch)
+	}()
+	assert(t, dfw.WriteChan(ch, 2), nil)
+	assert(t, dfw.Close(), nil)
+
+	reader, err := newDataFileReader(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	var sizes []int
+	for {
+		records, err := reader.ReadGenericBlock()
+		if err != nil {
+			assert(t, err, io.EOF)
+			break
+		}
+		sizes = append(sizes, len(records))
+	}
+	assert(t, sizes, []int{2, 2, 1})
+
+	dfw, err = NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	ch = make(chan interface{}, 3)
+	ch <- 42
+	ch <- &primitive{}
+	ch <- &primitive{}
+	close(ch)
+	assert(t, dfw.WriteChan(ch, 0) != nil, true)
+	assert(t, len(ch), 0)
+}
+
+func TestDataFileWriteFailure(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	record := func(s interface{}) *GenericRecord {
+		r := NewGenericRecord(schema)
+		r.Set("booleanField", true)
+		r.Set("intField", int32(1))
+		r.Set("longField", int64(2))
+		r.Set("floatField", float32(3))
+		r.Set("doubleField", float64(4))
+		r.Set("bytesField", []byte{5})
+		r.Set("stringField", s)
+		return r
+	}
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewGenericDatumWriter())
+	assert(t, err, nil)
+	ch := make(chan interface{}, 2)
+	ch <- record("first")
+	ch <- record(42) // fails after encoding the fields before stringField
+	close(ch)
+	assert(t, dfw.WriteChan(ch, 0) != nil, true)
+	assert(t, dfw.Write(record(42)) != nil, true)
+	assert(t, dfw.Write(record("last")), nil)
+	assert(t, dfw.Close(), nil)
+
+	reader, err := newDataFileReader(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	records, err := reader.ReadGenericBlock()
+	assert(t, err, nil)
+	assert(t, len(records), 2)
+	assert(t, records[0].Get("stringField"), "first")
+	assert(t, records[1].Get("stringField"), "last")
+}
+
+func TestDataFileWriterConcurrent(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if err := dfw.Write(&primitive{LongField: int64(g*100 + i), StringField: "concurrent"}); err != nil {
+					t.Error(err)
+				}
+				if i%10 == 0 {
+					if err := dfw.Flush(); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert(t, dfw.Close(), nil)
+
+	reader, err := newDataFileReader(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	seen := make(map[int64]bool)
+	for reader.HasNext() {
+		var p primitive
+		assert(t, reader.Next(&p), nil)
+		assert(t, p.StringField, "concurrent")
+		seen[p.LongField] = true
+	}
+	assert(t, reader.Err(), nil)
+	assert(t, len(seen), 800)
+}
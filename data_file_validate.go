@@ -0,0 +1,243 @@
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+// DataFileCorruptError describes the first problem found while scanning an object container file.
+type DataFileCorruptError struct {
+	// Offset from the start of the file of the header or block that is corrupt.
+	Offset int64
+
+	// Index of the corrupt block, or -1 if the header is corrupt.
+	Block int
+
+	// The underlying problem.
+	Err error
+}
+
+// Error returns a description of where and how the file is corrupt.
+func (e *DataFileCorruptError) Error() string {
+	if e.Block < 0 {
+		return fmt.Sprintf("DataFile corrupt at offset %d (header): %s", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("DataFile corrupt at offset %d (block %d): %s", e.Offset, e.Block, e.Err)
+}
+
+// ValidateDataFile scans a whole object container file from the filesystem and verifies it.
+// See ValidateDataFileReader for details.
+func ValidateDataFile(filename string) (records int64, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return ValidateDataFileReader(f)
+}
+
+// ValidateDataFileReader scans a whole object container file and verifies the header magic, that the
+// codec is supported, that every block is complete and ends with the sync marker, and that every record
+// decodes against the schema embedded in the header. The codecs supported by this package carry no
+// checksums, so there are none to verify.
+//
+// Returns the number of records in the file. The first problem found is returned as a
+// *DataFileCorruptError, I/O errors other than a premature end of file are returned as is.
+func ValidateDataFileReader(input io.Reader) (records int64, err error) {
+	scanner, err := newDataFileScanner(input)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		block, err := scanner.next()
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, err
+		}
+		records += block.count
+	}
+}
+
+// dataFileScanner reads an object container file block by block, verifying each block on the way.
+type dataFileScanner struct {
+	cr     *countingReader
+	dec    Decoder
+	header *objFileHeader
+	schema Schema
+	codec  Codec
+	datum  DatumReader
+	block  int
+
+	progress progressTracker
+}
+
+// rawBlock is a verified block as it is encoded in the file.
+type rawBlock struct {
+	offset int64
+	count  int64
+	data   []byte
+}
+
+func newDataFileScanner(input io.Reader) (*dataFileScanner, error) {
+	input, progress := unwrapProgress(input)
+	// Count on top of the buffered reader so that offsets are exact.
+	cr := &countingReader{r: bufio.NewReader(input)}
+	dec := NewBinaryDecoderReader(cr)
+	corrupt := func(err error) error {
+		return &DataFileCorruptError{Offset: 0, Block: -1, Err: err}
+	}
+
+	header, err := readObjFileHeader(dec)
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	if !bytes.Equal(header.Magic, magic) {
+		return nil, corrupt(ErrNotAvroFile)
+	}
+	schema, err := ParseSchema(string(header.Meta[schemaKey]))
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	codecName := string(header.Meta[codecKey])
+	codec := codecs[codecName]
+	if codec == nil {
+		return nil, corrupt(fmt.Errorf("Unknown codec %s", codecName))
+	}
+	datum := NewGenericDatumReader()
+	datum.SetSchema(Prepare(schema))
+
+	return &dataFileScanner{
+		cr:     cr,
+		dec:    dec,
+		header: header,
+		schema: schema,
+		codec:  codec,
+		datum:  datum,
+
+		progress: progressTracker{f: progress},
+	}, nil
+}
+
+// next reads and verifies the next block. Returns io.EOF at the clean end of the file, and a
+// *DataFileCorruptError if the block is corrupt.
+func (s *dataFileScanner) next() (*rawBlock, error) {
+	start := s.cr.n
+	corrupt := func(err error) error {
+		return &DataFileCorruptError{Offset: start, Block: s.block, Err: err}
+	}
+
+	count, err := s.dec.ReadLong()
+	if err == ErrUnexpectedEOF && s.cr.n == start {
+		// Clean end of file between blocks.
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, corrupt(err)
+	}
+	size, err := s.dec.ReadLong()
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	if count < 0 || size < 0 || size > math.MaxInt32 {
+		return nil, corrupt(fmt.Errorf("Invalid block header: %d entries in %d bytes", count, size))
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(s.cr, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) < size {
+		return nil, corrupt(fmt.Errorf("Block truncated, %d bytes missing", size-int64(len(data))))
+	}
+	sync := make([]byte, containerSyncSize)
+	if _, err = io.ReadFull(s.cr, sync); err != nil {
+		return nil, corrupt(fmt.Errorf("Reading sync marker: %s", eofUnexpected(err)))
+	}
+	if !bytes.Equal(sync, s.header.Sync) {
+		return nil, corrupt(fmt.Errorf("was expecting sync %v, got %v", s.header.Sync, sync))
+	}
+
+	r, closer := s.codec.CodecReader(bytes.NewReader(data))
+	err = validateBlock(s.datum, r, count)
+	if closer != nil {
+		closer()
+	}
+	if err != nil {
+		return nil, corrupt(err)
+	}
+
+	s.block++
+	s.progress.block(s.cr.n, count)
+	return &rawBlock{offset: start, count: count, data: data}, nil
+}
+
+// eachRecord calls f with the encoded data of every record in the rest of the file.
+func (s *dataFileScanner) eachRecord(f func(data []byte) error) error {
+	for {
+		bd, count, err := s.nextRecords()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		for i := int64(0); i < count; i++ {
+			start := bd.pos
+			if err = bd.skip(s.schema, nil, DefaultMaxDepth); err != nil {
+				return err
+			}
+			if err = f(bd.buf[start:bd.pos]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// nextRecords reads the next block and returns a decoder for its decompressed records, along with
+// their number. Returns io.EOF at the clean end of the file.
+func (s *dataFileScanner) nextRecords() (*binaryDecoder, int64, error) {
+	block, err := s.next()
+	if err != nil {
+		return nil, 0, err
+	}
+	r, closer := s.codec.CodecReader(bytes.NewReader(block.data))
+	data, err := ioutil.ReadAll(r)
+	if closer != nil {
+		closer()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return &binaryDecoder{buf: data}, block.count, nil
+}
+
+func validateBlock(datum DatumReader, r io.Reader, count int64) error {
+	dec := NewBinaryDecoderReader(r)
+	for i := int64(0); i < count; i++ {
+		var v interface{}
+		if err := datum.Read(&v, dec); err != nil {
+			return fmt.Errorf("Decoding record %d of %d: %s", i, count, err)
+		}
+	}
+	var extra [1]byte
+	if n, _ := r.Read(extra[:]); n > 0 {
+		return fmt.Errorf("Block has data after its %d records", count)
+	}
+	return nil
+}
+
+// countingReader keeps track of the number of bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
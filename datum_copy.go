@@ -0,0 +1,188 @@
+package avro
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// DeepCopy returns a copy of v, a GenericRecord, a specific struct or any other value a DatumWriter
+// would accept for the given schema, that shares no mutable data with it. The copy follows the
+// schema: records, arrays, maps, bytes and fixed values in it are copied recursively, while struct
+// fields not mapped to the schema are copied shallowly.
+//
+// This allows handing the same record to multiple consumers which may modify it.
+func DeepCopy(schema Schema, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	copied, err := deepCopy(schema, reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return copied.Interface(), nil
+}
+
+// deepCopy returns a copy of v of the same type.
+func deepCopy(schema Schema, v reflect.Value) (reflect.Value, error) {
+	schema = resolveSchema(schema)
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := deepCopy(schema, v.Elem())
+		if err != nil {
+			return v, err
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(elem)
+		return copied, nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return v, nil
+	}
+
+	if schema.Type() == Union {
+		i := writerBranch(schema.(*UnionSchema), v.Interface())
+		if i < 0 {
+			return v, fmt.Errorf("Value %v doesn't match any branch of union", v.Interface())
+		}
+		return deepCopy(schema.(*UnionSchema).Types[i], v)
+	}
+
+	if isDecimalType(v.Type()) {
+		// Copies of big.Rat structs would share the words of their numbers.
+		r := dereference(v).Interface().(big.Rat)
+		return decimalValue(new(big.Rat).Set(&r), v.Type()), nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if record, ok := v.Interface().(*GenericRecord); ok {
+			copied, err := copyGenericRecord(schema, record)
+			return reflect.ValueOf(copied), err
+		}
+		elem, err := deepCopy(schema, v.Elem())
+		if err != nil {
+			return v, err
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(elem)
+		return copied, nil
+	}
+
+	switch schema.Type() {
+	case Bytes, Fixed:
+		if v.Kind() == reflect.Slice {
+			return reflect.ValueOf(append([]byte(nil), v.Bytes()...)).Convert(v.Type()), nil
+		}
+	case Array:
+		if v.Kind() == reflect.Slice {
+			copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				elem, err := deepCopy(schema.(*ArraySchema).Items, v.Index(i))
+				if err != nil {
+					return v, err
+				}
+				copied.Index(i).Set(elem)
+			}
+			return copied, nil
+		}
+	case Map:
+		if v.Kind() == reflect.Map {
+			copied := reflect.MakeMap(v.Type())
+			for _, key := range v.MapKeys() {
+				elem, err := deepCopy(schema.(*MapSchema).Values, v.MapIndex(key))
+				if err != nil {
+					return v, err
+				}
+				copied.SetMapIndex(key, elem)
+			}
+			return copied, nil
+		}
+	case Record:
+		if v.Kind() == reflect.Struct {
+			return copyStruct(schema.(*RecordSchema), v)
+		}
+	}
+
+	// Anything else is immutable or copied by value.
+	return v, nil
+}
+
+func copyStruct(schema *RecordSchema, v reflect.Value) (reflect.Value, error) {
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	ri := reflectEnsureRi(v.Type())
+	for _, field := range schema.Fields {
+		index, ok := ri.names[field.Name]
+		if !ok {
+			continue
+		}
+		dst, src := unsharedField(copied, v, index)
+		if !dst.IsValid() {
+			// in a nil embedded struct
+			continue
+		}
+		value, err := deepCopy(field.Type, src)
+		if err != nil {
+			return v, err
+		}
+		dst.Set(value)
+	}
+	return copied, nil
+}
+
+// unsharedField returns a field of the copy dst of the struct src along with the same field of
+// src, copying the embedded structs leading to it that dst still shares with src.
+func unsharedField(dst, src reflect.Value, index []int) (reflect.Value, reflect.Value) {
+	for i, x := range index {
+		if i > 0 && dst.Kind() == reflect.Ptr {
+			if dst.IsNil() {
+				return reflect.Value{}, reflect.Value{}
+			}
+			if dst.Pointer() == src.Pointer() {
+				embedded := reflect.New(dst.Type().Elem())
+				embedded.Elem().Set(dst.Elem())
+				dst.Set(embedded)
+			}
+			dst, src = dst.Elem(), src.Elem()
+		}
+		dst, src = dst.Field(x), src.Field(x)
+	}
+	return dst, src
+}
+
+func copyGenericRecord(schema Schema, record *GenericRecord) (*GenericRecord, error) {
+	copied := &GenericRecord{
+		values:   make([]interface{}, len(record.values)),
+		presence: append([]FieldPresence(nil), record.presence...),
+		layout:   record.layout,
+		schema:   record.schema,
+	}
+	if record.lazy != nil {
+		// The raw data itself is never modified.
+		copied.lazy = &lazyFields{reader: record.lazy.reader, fields: record.lazy.fields, raw: append([][]byte(nil), record.lazy.raw...)}
+	}
+	if record.extra != nil {
+		copied.extra = make(map[string]interface{}, len(record.extra))
+		for name, value := range record.extra {
+			copied.extra[name] = value
+		}
+	}
+
+	var fields []*SchemaField
+	if s, ok := schema.(*RecordSchema); ok {
+		fields = s.Fields
+	}
+	for i, value := range record.values {
+		if value == nil || i >= len(fields) {
+			copied.values[i] = value
+			continue
+		}
+		elem, err := deepCopy(fields[i].Type, reflect.ValueOf(value))
+		if err != nil {
+			return nil, fmt.Errorf("Field %s.%s: %s", schema.FullName(), fields[i].Name, err)
+		}
+		copied.values[i] = elem.Interface()
+	}
+	return copied, nil
+}
@@ -0,0 +1,86 @@
+package avro
+
+import "testing"
+
+type copyLeaf struct {
+	Value string `avro:"value"`
+}
+
+type CopyEmbedded struct {
+	Hash []byte `avro:"hash"`
+}
+
+type copyRoot struct {
+	*CopyEmbedded
+	Leaves  []*copyLeaf            `avro:"leaves"`
+	Counts  map[string][]int64     `avro:"counts"`
+	Next    *copyRoot              `avro:"next"`
+	Ignored []string               `avro:"-"`
+	Any     map[string]interface{} `avro:"any"`
+}
+
+var copySchema = MustParseSchema(`{"type": "record", "name": "Root", "fields": [
+	{"name": "hash", "type": ["null", "bytes"]},
+	{"name": "leaves", "type": {"type": "array", "items": {"type": "record", "name": "Leaf", "fields": [
+		{"name": "value", "type": "string"}
+	]}}},
+	{"name": "counts", "type": {"type": "map", "values": {"type": "array", "items": "long"}}},
+	{"name": "next", "type": ["null", "Root"]},
+	{"name": "any", "type": {"type": "map", "values": ["null", "bytes"]}}
+]}`)
+
+func TestDeepCopySpecific(t *testing.T) {
+	root := &copyRoot{
+		CopyEmbedded: &CopyEmbedded{Hash: []byte{1}},
+		Leaves:       []*copyLeaf{{Value: "a"}},
+		Counts:       map[string][]int64{"x": {1, 2}},
+		Next:         &copyRoot{Leaves: []*copyLeaf{{Value: "b"}}},
+		Ignored:      []string{"shallow"},
+		Any:          map[string]interface{}{"b": []byte{1}, "n": nil},
+	}
+	value, err := DeepCopy(copySchema, root)
+	assert(t, err, nil)
+	copied := value.(*copyRoot)
+
+	root.Hash[0] = 100
+	root.Leaves[0].Value = "changed"
+	root.Counts["x"][0] = 100
+	root.Next.Leaves[0].Value = "changed"
+	root.Any["b"].([]byte)[0] = 100
+	assert(t, copied.CopyEmbedded != root.CopyEmbedded, true)
+	assert(t, copied.Hash, []byte{1})
+	assert(t, copied.Leaves[0].Value, "a")
+	assert(t, copied.Counts["x"][0], int64(1))
+	assert(t, copied.Next.Leaves[0].Value, "b")
+	assert(t, copied.Next.CopyEmbedded == nil, true)
+	assert(t, copied.Next.Next == nil, true)
+	assert(t, copied.Any["b"], []byte{1})
+	assert(t, copied.Any["n"], nil)
+	assert(t, &copied.Ignored[0] == &root.Ignored[0], true)
+}
+
+func TestDeepCopyGeneric(t *testing.T) {
+	leafSchema := copySchema.(*RecordSchema).Fields[1].Type.(*ArraySchema).Items
+	leaf := NewGenericRecord(leafSchema)
+	leaf.Set("value", "a")
+	record := NewGenericRecord(copySchema)
+	record.Set("leaves", []interface{}{leaf})
+	record.Set("counts", map[string]interface{}{"x": []interface{}{int64(1)}})
+	record.Set("any", map[string]interface{}{"b": []byte{1}})
+	record.Set("extra", "kept")
+
+	value, err := DeepCopy(copySchema, record)
+	assert(t, err, nil)
+	copied := value.(*GenericRecord)
+
+	leaf.Set("value", "changed")
+	record.Get("counts").(map[string]interface{})["x"].([]interface{})[0] = int64(100)
+	record.Get("any").(map[string]interface{})["b"].([]byte)[0] = 100
+	record.Set("next", record)
+	assert(t, copied.Get("leaves").([]interface{})[0].(*GenericRecord).Get("value"), "a")
+	assert(t, copied.Get("counts"), map[string]interface{}{"x": []interface{}{int64(1)}})
+	assert(t, copied.Get("any"), map[string]interface{}{"b": []byte{1}})
+	assert(t, copied.Get("next"), nil)
+	assert(t, copied.Get("extra"), "kept")
+	assert(t, copied.Presence("leaves"), FieldSet)
+}
@@ -1,6 +1,7 @@
 package avro
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -27,6 +28,20 @@ type DatumReader interface {
 	Read(v interface{}, dec Decoder) error
 }
 
+// DefaultMaxDepth is the maximum number of records that data may nest, as recursive schemas
+// allow, for datum readers and DatumProjectors not given another limit with SetMaxDepth and
+// SetProjectorMaxDepth.
+// Deeper data fails with ErrMaxDepthExceeded, rather than exhausting the stack.
+var DefaultMaxDepth = 1000
+
+// maxDepth returns the given maximum record depth, or DefaultMaxDepth if it isn't set.
+func maxDepth(limit int) int {
+	if limit <= 0 {
+		return DefaultMaxDepth
+	}
+	return limit
+}
+
 var enumSymbolsToIndexCache = make(map[string]map[string]int32)
 var enumSymbolsToIndexCacheLock sync.Mutex
 
@@ -76,6 +91,42 @@ func (enum *GenericEnum) Set(symbol string) {
 	}
 }
 
+// String returns the current symbol of this enum, so that it prints readably.
+func (enum *GenericEnum) String() string {
+	if enum.index < 0 || int(enum.index) >= len(enum.Symbols) {
+		return fmt.Sprintf("<invalid enum index %d>", enum.index)
+	}
+	return enum.Symbols[enum.index]
+}
+
+// MarshalJSON serializes this enum as a JSON string of its current symbol.
+func (enum *GenericEnum) MarshalJSON() ([]byte, error) {
+	if enum.index < 0 || int(enum.index) >= len(enum.Symbols) {
+		return nil, fmt.Errorf("Enum index %d out of range for %d symbols", enum.index, len(enum.Symbols))
+	}
+	return json.Marshal(enum.Symbols[enum.index])
+}
+
+// UnmarshalJSON sets this enum from a JSON string of one of its symbols. The enum must have been
+// created with its symbols already, e.g. with NewGenericEnum. Errors if the symbol doesn't exist.
+func (enum *GenericEnum) UnmarshalJSON(data []byte) error {
+	var symbol string
+	if err := json.Unmarshal(data, &symbol); err != nil {
+		return err
+	}
+	if index, exists := enum.symbolsToIndex[symbol]; exists {
+		enum.index = index
+		return nil
+	}
+	for index, s := range enum.Symbols {
+		if s == symbol {
+			enum.index = int32(index)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown enum symbol %q, expected one of %v", symbol, enum.Symbols)
+}
+
 // NewDatumReader creates a DatumReader that can handle both GenericRecord and
 // also aribtrary structs.
 //
@@ -140,6 +191,7 @@ func (reader *SpecificDatumReader) SetSchema(schema Schema) DatumReader {
 // your struct field as follows: SomeValue int32 `avro:"some_field"`).
 // May return an error indicating a read failure.
 func (reader *SpecificDatumReader) Read(v interface{}, dec Decoder) error {
+	resetBudget(dec)
 	if reader, ok := v.(Unmarshaler); ok {
 		return reader.UnmarshalAvro(dec)
 	}
@@ -154,14 +206,62 @@ func (reader *SpecificDatumReader) Read(v interface{}, dec Decoder) error {
 	return reader.fillRecord(reader.schema, rv, dec)
 }
 
+// RegisterUnionType tells this SpecificDatumReader which Go type to instantiate when a record with the given
+// full name is decoded into an interface{} field, as happens with unions of several records.
+// The type may be a struct type or a pointer to a struct type. Records without a registered type are
+// decoded as *GenericRecord.
+// Must be called before calling Read.
+func (reader *SpecificDatumReader) RegisterUnionType(fullName string, t reflect.Type) {
+	if reader.unionTypes == nil {
+		reader.unionTypes = make(map[string]reflect.Type)
+	}
+	reader.unionTypes[fullName] = t
+}
+
+// SetReuseBuffers makes Read decode bytes and fixed fields into the slices already held by the
+// struct being filled when they have enough capacity, and fill nested structs in place, instead of
+// allocating new ones. Reading every record into the same struct, or into structs with preallocated
+// slices, then saves allocations for schemas with many hashes, UUIDs and such.
+//
+// Values read before are overwritten, so slices taken from them must not be retained.
+// Must be called before calling Read.
+func (reader *SpecificDatumReader) SetReuseBuffers(reuse bool) *SpecificDatumReader {
+	reader.reuseBuffers = reuse
+	return reader
+}
+
+// SetMaxDepth sets the maximum number of records data read may nest, DefaultMaxDepth if not set
+// or zero. Deeper data fails to read with ErrMaxDepthExceeded.
+// Must be called before calling Read.
+func (reader *SpecificDatumReader) SetMaxDepth(depth int) *SpecificDatumReader {
+	reader.maxDepth = depth
+	return reader
+}
+
 // It turns out that SpecificDatumReader as an instance is not needed
 // once you get started on the actual decoding. It seems at first like we're just saving
 // pointer passing but it actually means more, because now we don't need access to
 // the instance and can memoize the decoding functions easier/cheaper.
-type sDatumReader struct{}
+//
+// The only state carried is configuration that is read-only while decoding, and the depth of the
+// record being decoded, which is passed down by value.
+type sDatumReader struct {
+	unionTypes   map[string]reflect.Type
+	reuseBuffers bool
+	maxDepth     int
+	unknownEnum  UnknownEnum
+	logicalTypes bool
+	depth        int
+}
+
+// logicalInterface tells whether values of logical types are read into v, an interface{} field,
+// as the Go values they stand for.
+func (reader sDatumReader) logicalInterface(v reflect.Value) bool {
+	return reader.logicalTypes && v.Kind() == reflect.Interface
+}
 
 func (reader sDatumReader) findAndSet(v reflect.Value, field *SchemaField, dec Decoder) error {
-	structField, err := findField(v, field.Name)
+	structField, err := findField(v, field, true)
 	if err != nil {
 		return err
 	}
@@ -183,16 +283,39 @@ func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, d
 	case Boolean:
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadBoolean() })
 	case Int:
+		if isDate(field) && (reflectField.Type() == timeType || reader.logicalInterface(reflectField)) {
+			value, err := dec.ReadInt()
+			return reflect.ValueOf(date(value)), err
+		}
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadInt() })
 	case Long:
+		if unit := timestampUnit(field); unit != 0 && (reflectField.Type() == timeType || reader.logicalInterface(reflectField)) {
+			value, err := dec.ReadLong()
+			return reflect.ValueOf(timestamp(value, unit)), err
+		}
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadLong() })
 	case Float:
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadFloat() })
 	case Double:
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadDouble() })
 	case Bytes:
+		if isDecimal(field) && reflectField.IsValid() && (isDecimalType(reflectField.Type()) || reader.logicalInterface(reflectField)) {
+			value, err := dec.ReadBytes()
+			return decimalValue(decimal(field, value), reflectField.Type()), err
+		}
+		if reader.reuseBuffers {
+			value, err := readBytesInto(dec, reusableBytes(reflectField))
+			return reflect.ValueOf(value), err
+		}
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadBytes() })
 	case String:
+		if isUUID(field) && reflectField.IsValid() && reflectField.Kind() != reflect.String && reflectField.Kind() != reflect.Interface {
+			value, err := dec.ReadString()
+			if err != nil {
+				return reflect.ValueOf(value), err
+			}
+			return uuidValue(value, reflectField.Type())
+		}
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadString() })
 	case Array:
 		return reader.mapArray(field, reflectField, dec)
@@ -203,7 +326,7 @@ func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, d
 	case Union:
 		return reader.mapUnion(field, reflectField, dec)
 	case Fixed:
-		return reader.mapFixed(field, dec)
+		return reader.mapFixed(field, reflectField, dec)
 	case Record:
 		return reader.mapRecord(field, reflectField, dec)
 	case Recursive:
@@ -242,7 +365,7 @@ func (reader sDatumReader) mapArray(field Schema, reflectField reflect.Value, de
 			break
 		}
 
-		arrayPart := reflect.MakeSlice(reflectField.Type(), int(arrayLength), int(arrayLength))
+		arrayPart := makeSlice(dec, reflectField.Type(), int(arrayLength))
 		var i int64
 		for ; i < arrayLength; i++ {
 			current := arrayPart.Index(int(i))
@@ -303,7 +426,7 @@ func (reader sDatumReader) mapMap(field Schema, reflectField reflect.Value, dec
 			}
 			val, err := reader.readValue(field.(*MapSchema).Values, dest, dec)
 			if err != nil {
-				return reflect.ValueOf(mapLength), nil
+				return reflect.ValueOf(mapLength), err
 			}
 			if !elemIsPointer && val.Kind() == reflect.Ptr {
 				resultMap.SetMapIndex(key, val.Elem())
@@ -329,7 +452,7 @@ func (reader sDatumReader) mapEnum(field Schema, dec Decoder) (reflect.Value, er
 	}
 
 	schema := field.(*EnumSchema)
-	fullName := GetFullName(schema)
+	fullName := schema.FullName()
 
 	var symbolsToIndex map[string]int32
 	enumSymbolsToIndexCacheLock.Lock()
@@ -340,7 +463,10 @@ func (reader sDatumReader) mapEnum(field Schema, dec Decoder) (reflect.Value, er
 	enumSymbolsToIndexCacheLock.Unlock()
 
 	if int(enumIndex) >= len(schema.Symbols) {
-		return reflect.Value{}, fmt.Errorf("Enum index %d too high for enum %s", enumIndex, field.GetName())
+		if reader.unknownEnum == UnknownEnumSymbol {
+			return reflect.ValueOf(unknownEnumValue(schema)), nil
+		}
+		return reflect.Value{}, &unknownEnumIndexError{enumIndex, field.GetName()}
 	}
 
 	enum := &GenericEnum{
@@ -360,22 +486,52 @@ func (reader sDatumReader) mapUnion(field Schema, reflectField reflect.Value, de
 	if unionIndex < 0 || int(unionIndex) >= len(types) {
 		return reflect.Value{}, fmt.Errorf("Invalid union index %d", unionIndex)
 	}
-	return reader.readValue(types[unionIndex], reflectField, dec)
+	value, err := reader.readValue(types[unionIndex], reflectField, dec)
+	if _, unknown := err.(*unknownEnumIndexError); unknown && reader.unknownEnum == UnknownEnumNull &&
+		types[unionIndex].Type() == Enum && hasNullBranch(field.(*UnionSchema)) {
+		// Read as the null branch.
+		return reflect.ValueOf(nil), nil
+	}
+	return value, err
 }
 
-func (reader sDatumReader) mapFixed(field Schema, dec Decoder) (reflect.Value, error) {
-	fixed := make([]byte, field.(*FixedSchema).Size)
+func (reader sDatumReader) mapFixed(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	size := field.(*FixedSchema).Size
+	var fixed []byte
+	if buf := reusableBytes(reflectField); reader.reuseBuffers && cap(buf) >= size {
+		fixed = buf[:size]
+	} else {
+		fixed = makeBytes(dec, size)
+	}
 	if err := dec.ReadFixed(fixed); err != nil {
 		return reflect.ValueOf(fixed), err
 	}
+	if isDecimal(field) && reflectField.IsValid() && (isDecimalType(reflectField.Type()) || reader.logicalInterface(reflectField)) {
+		return decimalValue(decimal(field, fixed), reflectField.Type()), nil
+	}
 	return reflect.ValueOf(fixed), nil
 }
 
 func (reader sDatumReader) mapRecord(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
 	var t reflect.Type
 	switch reflectField.Kind() {
-	case reflect.Ptr, reflect.Array, reflect.Map, reflect.Slice, reflect.Chan:
+	case reflect.Interface:
+		return reader.mapInterfaceRecord(field, dec)
+	case reflect.Ptr:
+		if reader.reuseBuffers && !reflectField.IsNil() {
+			return reflectField, reader.fillRecord(field, reflectField, dec)
+		}
 		t = reflectField.Type().Elem()
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.Chan:
+		t = reflectField.Type().Elem()
+	case reflect.Struct:
+		// Struct values rather than pointers.
+		if reader.reuseBuffers && reflectField.CanAddr() {
+			return reflectField, reader.fillRecord(field, reflectField.Addr(), dec)
+		}
+		record := reflect.New(reflectField.Type())
+		err := reader.fillRecord(field, record, dec)
+		return record.Elem(), err
 	default:
 		t = reflectField.Type()
 	}
@@ -384,7 +540,51 @@ func (reader sDatumReader) mapRecord(field Schema, reflectField reflect.Value, d
 	return record, err
 }
 
+// reusableBytes returns the []byte held by v, if any.
+func reusableBytes(v reflect.Value) []byte {
+	if v.IsValid() && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return v.Bytes()
+	}
+	return nil
+}
+
+// readBytesInto reads a bytes value into buf if it's large enough, or a new slice otherwise.
+func readBytesInto(dec Decoder, buf []byte) ([]byte, error) {
+	if d, ok := dec.(interface {
+		readBytesInto(buf []byte) ([]byte, error)
+	}); ok {
+		return d.readBytesInto(buf)
+	}
+	return dec.ReadBytes()
+}
+
+// mapInterfaceRecord decodes a record into a value of the type registered for its full name,
+// or into a *GenericRecord if there is none.
+func (reader sDatumReader) mapInterfaceRecord(field Schema, dec Decoder) (reflect.Value, error) {
+	t, ok := reader.unionTypes[field.FullName()]
+	if !ok {
+		record, err := (&GenericDatumReader{maxDepth: reader.maxDepth}).mapRecord(field, dec, reader.depth)
+		return reflect.ValueOf(record), err
+	}
+
+	pointer := t.Kind() == reflect.Ptr
+	if pointer {
+		t = t.Elem()
+	}
+	record := reflect.New(t)
+	if err := reader.fillRecord(field, record, dec); err != nil {
+		return record, err
+	}
+	if !pointer {
+		return record.Elem(), nil
+	}
+	return record, nil
+}
+
 func (this sDatumReader) fillRecord(field Schema, record reflect.Value, dec Decoder) error {
+	if this.depth++; this.depth > maxDepth(this.maxDepth) {
+		return ErrMaxDepthExceeded
+	}
 	if pf, ok := field.(*preparedRecordSchema); ok {
 		plan, err := pf.getPlan(record.Type().Elem())
 		if err != nil {
@@ -394,8 +594,8 @@ func (this sDatumReader) fillRecord(field Schema, record reflect.Value, dec Deco
 		rf := record.Elem()
 		for i := range plan.decodePlan {
 			entry := &plan.decodePlan[i]
-			structField := rf.FieldByIndex(entry.index)
-			value, err := entry.dec(structField, dec)
+			structField := fieldByIndex(rf, entry.index, true)
+			value, err := entry.dec(this, structField, dec)
 
 			if err != nil {
 				return err
@@ -421,7 +621,16 @@ func (this sDatumReader) fillRecord(field Schema, record reflect.Value, dec Deco
 // and any values, GenericEnums) with data.
 // Each value passed to Read is expected to be a pointer.
 type GenericDatumReader struct {
-	schema Schema
+	schema       Schema
+	lazy         bool
+	reuseBuffers bool
+	maxDepth     int
+	redaction    *Redaction
+	recordTypes  map[string]reflect.Type
+	unknownEnum  UnknownEnum
+	logicalTypes bool
+	middleware   []FieldMiddleware
+	fieldCodecs  *fieldCodecCache
 }
 
 // NewGenericDatumReader creates a new GenericDatumReader.
@@ -436,6 +645,114 @@ func (reader *GenericDatumReader) SetSchema(schema Schema) DatumReader {
 	return reader
 }
 
+// SetLazy enables or disables lazy decoding of record fields. In lazy mode, reading a record only
+// finds where each of its fields starts and ends, and keeps a slice of the raw data per field which
+// gets decoded the first time the field is accessed. This saves most of the work for consumers only
+// inspecting a few fields of wide records, at the expense of retaining the data being read.
+//
+// Lazy decoding only applies when reading from a Decoder created by NewBinaryDecoder and the data
+// must not be modified while records read from it are in use. Accessing fields of a lazily decoded
+// GenericRecord modifies it, so it must not be shared between goroutines without locking.
+//
+// The data of every field is still checked as records are read, so that Read fails on data it
+// would fail to decode otherwise, unknown enums included, and accessing fields can't. Fields which
+// may hold records of types registered with RegisterRecordType are decoded right away. Redaction
+// applies to lazily decoded records, field middleware doesn't.
+func (reader *GenericDatumReader) SetLazy(lazy bool) *GenericDatumReader {
+	reader.lazy = lazy
+	return reader
+}
+
+// SetReuseBuffers makes Read decode into the record it is given, reusing the bytes and fixed values
+// it already holds when they have enough capacity, as well as nested records, instead of
+// allocating new ones. Reading every record into the same GenericRecord then saves allocations for
+// schemas with many hashes, UUIDs and such.
+//
+// Values read before are overwritten, so they must not be retained. Doesn't apply to records read
+// lazily.
+func (reader *GenericDatumReader) SetReuseBuffers(reuse bool) *GenericDatumReader {
+	reader.reuseBuffers = reuse
+	return reader
+}
+
+// SetMaxDepth sets the maximum number of records data read may nest, DefaultMaxDepth if not set
+// or zero. Deeper data fails to read with ErrMaxDepthExceeded.
+func (reader *GenericDatumReader) SetMaxDepth(depth int) *GenericDatumReader {
+	reader.maxDepth = depth
+	return reader
+}
+
+// RegisterRecordType makes Read decode nested records with the given full name into values of the
+// given Go type instead of *GenericRecords, the way a SpecificDatumReader does with the types
+// registered with its RegisterUnionType. The type may be a struct type or a pointer to a struct type.
+// Records at the top level are still decoded as GenericRecords, so that these can hold typed structs.
+// Must be called before calling Read.
+func (reader *GenericDatumReader) RegisterRecordType(fullName string, t reflect.Type) *GenericDatumReader {
+	if reader.recordTypes == nil {
+		reader.recordTypes = make(map[string]reflect.Type)
+	}
+	reader.recordTypes[fullName] = t
+	return reader
+}
+
+// Redaction sanitizes the fields of records carrying a property as they're decoded, e.g. to log
+// records or send them to analytics sinks without personal data.
+type Redaction struct {
+	// Property marks the fields to redact, e.g. "pii". Fields are redacted if they have the property
+	// with any value but false or null.
+	Property string
+
+	// Mask is the value redacted fields are set to, null if not given. It doesn't have to match the
+	// type of the fields, so masked records may not be writeable with their schema.
+	Mask interface{}
+
+	// Drop leaves redacted fields out instead of masking them.
+	Drop bool
+}
+
+func (r *Redaction) redacts(field *SchemaField) bool {
+	prop, ok := field.Prop(r.Property)
+	return ok && prop != nil && prop != false
+}
+
+// SetRedaction makes Read mask or drop the fields of records, nested ones included, with the property
+// of the given Redaction, or stop redacting if nil. Redacted fields are still decoded, to move past
+// them, but their values aren't kept. Their presence is FieldMasked or FieldDropped.
+func (reader *GenericDatumReader) SetRedaction(redaction *Redaction) *GenericDatumReader {
+	reader.redaction = redaction
+	return reader
+}
+
+// redact reads the value of field, of a record nested in depth ones, if it must be redacted, and
+// sets the redacted value in record.
+func (reader *GenericDatumReader) redact(record *GenericRecord, index int, field *SchemaField, dec Decoder, depth int) (bool, error) {
+	if reader.redaction == nil || !reader.redaction.redacts(field) {
+		return false, nil
+	}
+	if _, err := reader.read(field.Type, dec, depth); err != nil {
+		return true, err
+	}
+	reader.setRedacted(record, index)
+	return true, nil
+}
+
+func (reader *GenericDatumReader) setRedacted(record *GenericRecord, index int) {
+	if reader.redaction.Drop {
+		record.setByIndex(index, nil, FieldDropped)
+	} else {
+		record.setByIndex(index, reader.redaction.Mask, FieldMasked)
+	}
+}
+
+// nested returns the depth of the fields of a record read at the given depth, or
+// ErrMaxDepthExceeded if that's too deep.
+func (reader *GenericDatumReader) nested(depth int) (int, error) {
+	if depth >= maxDepth(reader.maxDepth) {
+		return 0, ErrMaxDepthExceeded
+	}
+	return depth + 1, nil
+}
+
 // Read reads a single entry using this GenericDatumReader.
 // Accepts a value to fill with data and a Decoder to read from. Given value MUST be of pointer type.
 // May return an error indicating a read failure.
@@ -448,9 +765,13 @@ func (reader *GenericDatumReader) Read(v interface{}, dec Decoder) error {
 	if reader.schema == nil {
 		return ErrSchemaNotSet
 	}
+	if record, ok := v.(*GenericRecord); ok && reader.reuseBuffers && !reader.lazy && reusableRecord(record, reader.schema) {
+		resetBudget(dec)
+		return reader.fillRecord(record, assertRecordSchema(resolveSchema(reader.schema)), dec, 0)
+	}
 
 	//read the value
-	value, err := reader.readValue(reader.schema, dec)
+	value, err := reader.readDatum(dec)
 	if err != nil {
 		return err
 	}
@@ -459,6 +780,9 @@ func (reader *GenericDatumReader) Read(v interface{}, dec Decoder) error {
 	// dereference the value if needed
 	if newValue.Kind() == reflect.Ptr {
 		newValue = newValue.Elem()
+	} else if !newValue.IsValid() {
+		// null
+		newValue = reflect.Zero(rv.Type())
 	}
 
 	//set the new value
@@ -467,64 +791,180 @@ func (reader *GenericDatumReader) Read(v interface{}, dec Decoder) error {
 	return nil
 }
 
-func (reader *GenericDatumReader) findAndSet(record *GenericRecord, field *SchemaField, dec Decoder) error {
-	value, err := reader.readValue(field.Type, dec)
+// readDatum reads a single datum with the schema of this GenericDatumReader, as Read does before
+// setting it, restoring the allocation budget of dec first.
+func (reader *GenericDatumReader) readDatum(dec Decoder) (interface{}, error) {
+	resetBudget(dec)
+	return reader.readValue(reader.schema, dec)
+}
+
+func (reader *GenericDatumReader) findAndSet(record *GenericRecord, index int, field *SchemaField, dec Decoder, depth int) error {
+	if redacted, err := reader.redact(record, index, field, dec, depth); redacted {
+		return err
+	}
+	var value interface{}
+	var err error
+	if reader.fieldCodecs != nil {
+		value, err = reader.fieldCodec(field, depth).Read(dec)
+	} else {
+		value, err = reader.read(field.Type, dec, depth)
+	}
 	if err != nil {
 		return err
 	}
+	return reader.setField(record, index, value)
+}
 
+func (reader *GenericDatumReader) setField(record *GenericRecord, index int, value interface{}) error {
 	switch typedValue := value.(type) {
 	case *GenericEnum:
 		if typedValue.GetIndex() >= int32(len(typedValue.Symbols)) {
 			return errors.New("Enum index invalid!")
 		}
-		record.Set(field.Name, typedValue.Symbols[typedValue.GetIndex()])
+		record.setByIndex(index, typedValue.Symbols[typedValue.GetIndex()], FieldFromData)
 
 	default:
-		record.Set(field.Name, value)
+		record.setByIndex(index, value, FieldFromData)
 	}
 
 	return nil
 }
 
+// reusableRecord tells whether record holds values of the given record schema.
+func reusableRecord(record *GenericRecord, schema Schema) bool {
+	schema = resolveSchema(schema)
+	return schema.Type() == Record && record.schema != nil && resolveSchema(record.schema) == schema &&
+		len(record.values) == len(assertRecordSchema(schema).Fields)
+}
+
+// fillRecord decodes a record, nested in depth ones, into the given one in place, reusing the buffers
+// of its values.
+func (reader *GenericDatumReader) fillRecord(record *GenericRecord, schema *RecordSchema, dec Decoder, depth int) error {
+	depth, err := reader.nested(depth)
+	if err != nil {
+		return err
+	}
+	record.lazy = nil
+	for i, field := range schema.Fields {
+		if redacted, err := reader.redact(record, i, field, dec, depth); redacted {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		var value interface{}
+		if reader.fieldCodecs != nil {
+			value, err = reader.fieldCodec(field, depth).Read(dec)
+		} else {
+			value, err = reader.readReusing(field.Type, record.values[i], dec, depth)
+		}
+		if err != nil {
+			return err
+		}
+		if err = reader.setField(record, i, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readReusing is like read, but reuses the buffers of the previous value if possible.
+func (reader *GenericDatumReader) readReusing(field Schema, previous interface{}, dec Decoder, depth int) (interface{}, error) {
+	if reader.logicalTypes && isDecimal(field) {
+		return reader.read(field, dec, depth)
+	}
+	switch field.Type() {
+	case Bytes:
+		buf, _ := previous.([]byte)
+		return readBytesInto(dec, buf)
+	case Fixed:
+		if buf, ok := previous.([]byte); ok && cap(buf) >= field.(*FixedSchema).Size {
+			fixed := buf[:field.(*FixedSchema).Size]
+			return fixed, dec.ReadFixed(fixed)
+		}
+	case Record, Recursive:
+		if record, ok := previous.(*GenericRecord); ok && reusableRecord(record, field) {
+			return record, reader.fillRecord(record, assertRecordSchema(resolveSchema(field)), dec, depth)
+		}
+	}
+	return reader.read(field, dec, depth)
+}
+
+// readValue reads a value of the given schema outside of any record.
 func (reader *GenericDatumReader) readValue(field Schema, dec Decoder) (interface{}, error) {
+	return reader.read(field, dec, 0)
+}
+
+// read reads a value of the given schema, nested in depth records.
+func (reader *GenericDatumReader) read(field Schema, dec Decoder, depth int) (interface{}, error) {
 	switch field.Type() {
 	case Null:
 		return nil, nil
 	case Boolean:
 		return dec.ReadBoolean()
 	case Int:
-		return dec.ReadInt()
+		value, err := dec.ReadInt()
+		if reader.logicalTypes && isDate(field) && err == nil {
+			return date(value), nil
+		}
+		return value, err
 	case Long:
-		return dec.ReadLong()
+		value, err := dec.ReadLong()
+		if unit := timestampUnit(field); reader.logicalTypes && unit != 0 && err == nil {
+			return timestamp(value, unit), nil
+		}
+		return value, err
 	case Float:
 		return dec.ReadFloat()
 	case Double:
 		return dec.ReadDouble()
 	case Bytes:
-		return dec.ReadBytes()
+		value, err := dec.ReadBytes()
+		if reader.logicalTypes && isDecimal(field) && err == nil {
+			return decimal(field, value), nil
+		}
+		return value, err
 	case String:
 		return dec.ReadString()
 	case Array:
-		return reader.mapArray(field, dec)
+		return reader.mapArray(field, dec, depth)
 	case Enum:
 		return reader.mapEnum(field, dec)
 	case Map:
-		return reader.mapMap(field, dec)
+		return reader.mapMap(field, dec, depth)
 	case Union:
-		return reader.mapUnion(field, dec)
+		return reader.mapUnion(field, dec, depth)
 	case Fixed:
-		return reader.mapFixed(field, dec)
+		value, err := reader.mapFixed(field, dec)
+		if reader.logicalTypes && isDecimal(field) && err == nil {
+			return decimal(field, value), nil
+		}
+		return value, err
 	case Record:
-		return reader.mapRecord(field, dec)
+		return reader.mapNestedRecord(field, dec, depth)
 	case Recursive:
-		return reader.mapRecord(field.(*RecursiveSchema).Actual, dec)
+		return reader.mapNestedRecord(field.(*RecursiveSchema).Actual, dec, depth)
 	}
 
 	return nil, fmt.Errorf("Unknown field type: %d", field.Type())
 }
 
-func (reader *GenericDatumReader) mapArray(field Schema, dec Decoder) ([]interface{}, error) {
+// mapNestedRecord decodes a record into a value of the type registered for its full name if it's
+// nested in another one, or into a *GenericRecord otherwise.
+func (reader *GenericDatumReader) mapNestedRecord(field Schema, dec Decoder, depth int) (interface{}, error) {
+	if _, ok := reader.recordTypes[field.FullName()]; ok && depth > 0 {
+		specific := sDatumReader{unionTypes: reader.recordTypes, maxDepth: reader.maxDepth, logicalTypes: reader.logicalTypes,
+			depth: depth}
+		value, err := specific.mapInterfaceRecord(field, dec)
+		if err != nil {
+			return nil, err
+		}
+		return value.Interface(), nil
+	}
+	return reader.mapRecord(field, dec, depth)
+}
+
+func (reader *GenericDatumReader) mapArray(field Schema, dec Decoder, depth int) ([]interface{}, error) {
 	arrayLength, err := dec.ReadArrayStart()
 	if err != nil {
 		return nil, err
@@ -535,20 +975,25 @@ func (reader *GenericDatumReader) mapArray(field Schema, dec Decoder) ([]interfa
 		if arrayLength == 0 {
 			break
 		}
-		arrayPart := make([]interface{}, arrayLength, arrayLength)
+		var arrayPart []interface{}
+		if decoderAllocator(dec) != nil {
+			arrayPart = makeSlice(dec, interfaceSliceType, int(arrayLength)).Interface().([]interface{})
+		} else {
+			arrayPart = make([]interface{}, arrayLength, arrayLength)
+		}
 		var i int64
 		for ; i < arrayLength; i++ {
-			val, err := reader.readValue(field.(*ArraySchema).Items, dec)
+			val, err := reader.read(field.(*ArraySchema).Items, dec, depth)
 			if err != nil {
 				return nil, err
 			}
 			arrayPart[i] = val
 		}
-		//concatenate arrays
-		concatArray := make([]interface{}, len(array)+int(arrayLength), cap(array)+int(arrayLength))
-		copy(concatArray, array)
-		copy(concatArray, arrayPart)
-		array = concatArray
+		if array == nil {
+			array = arrayPart
+		} else {
+			array = append(array, arrayPart...)
+		}
 		arrayLength, err = dec.ArrayNext()
 		if err != nil {
 			return nil, err
@@ -566,7 +1011,7 @@ func (reader *GenericDatumReader) mapEnum(field Schema, dec Decoder) (*GenericEn
 	}
 
 	schema := field.(*EnumSchema)
-	fullName := GetFullName(schema)
+	fullName := schema.FullName()
 
 	var symbolsToIndex map[string]int32
 	enumSymbolsToIndexCacheLock.Lock()
@@ -576,6 +1021,12 @@ func (reader *GenericDatumReader) mapEnum(field Schema, dec Decoder) (*GenericEn
 	}
 	enumSymbolsToIndexCacheLock.Unlock()
 
+	if int(enumIndex) >= len(schema.Symbols) {
+		if reader.unknownEnum == UnknownEnumSymbol {
+			return unknownEnumValue(schema), nil
+		}
+		return nil, &unknownEnumIndexError{enumIndex, field.GetName()}
+	}
 	enum := &GenericEnum{
 		Symbols:        schema.Symbols,
 		symbolsToIndex: symbolsToIndex,
@@ -584,7 +1035,7 @@ func (reader *GenericDatumReader) mapEnum(field Schema, dec Decoder) (*GenericEn
 	return enum, nil
 }
 
-func (reader *GenericDatumReader) mapMap(field Schema, dec Decoder) (map[string]interface{}, error) {
+func (reader *GenericDatumReader) mapMap(field Schema, dec Decoder, depth int) (map[string]interface{}, error) {
 	mapLength, err := dec.ReadMapStart()
 	if err != nil {
 		return nil, err
@@ -601,7 +1052,7 @@ func (reader *GenericDatumReader) mapMap(field Schema, dec Decoder) (map[string]
 			if err != nil {
 				return nil, err
 			}
-			val, err := reader.readValue(field.(*MapSchema).Values, dec)
+			val, err := reader.read(field.(*MapSchema).Values, dec, depth)
 			if err != nil {
 				return nil, err
 			}
@@ -616,33 +1067,46 @@ func (reader *GenericDatumReader) mapMap(field Schema, dec Decoder) (map[string]
 	return resultMap, nil
 }
 
-func (reader *GenericDatumReader) mapUnion(field Schema, dec Decoder) (interface{}, error) {
+func (reader *GenericDatumReader) mapUnion(field Schema, dec Decoder, depth int) (interface{}, error) {
 	unionType, err := dec.ReadInt()
 	if err != nil {
 		return nil, err
 	}
 	if unionType >= 0 && unionType < int32(len(field.(*UnionSchema).Types)) {
 		union := field.(*UnionSchema).Types[unionType]
-		return reader.readValue(union, dec)
+		value, err := reader.read(union, dec, depth)
+		if _, unknown := err.(*unknownEnumIndexError); unknown && reader.unknownEnum == UnknownEnumNull &&
+			union.Type() == Enum && hasNullBranch(field.(*UnionSchema)) {
+			// Read as the null branch.
+			return nil, nil
+		}
+		return value, err
 	}
 
 	return nil, ErrUnionTypeOverflow
 }
 
 func (reader *GenericDatumReader) mapFixed(field Schema, dec Decoder) ([]byte, error) {
-	fixed := make([]byte, field.(*FixedSchema).Size)
+	fixed := makeBytes(dec, field.(*FixedSchema).Size)
 	if err := dec.ReadFixed(fixed); err != nil {
 		return nil, err
 	}
 	return fixed, nil
 }
 
-func (reader *GenericDatumReader) mapRecord(field Schema, dec Decoder) (*GenericRecord, error) {
+func (reader *GenericDatumReader) mapRecord(field Schema, dec Decoder, depth int) (*GenericRecord, error) {
+	depth, err := reader.nested(depth)
+	if err != nil {
+		return nil, err
+	}
 	record := NewGenericRecord(field)
 
 	recordSchema := assertRecordSchema(field)
+	if bd, ok := dec.(*binaryDecoder); ok && reader.lazy {
+		return record, reader.mapLazyRecord(record, recordSchema, bd, depth)
+	}
 	for i := 0; i < len(recordSchema.Fields); i++ {
-		err := reader.findAndSet(record, recordSchema.Fields[i], dec)
+		err := reader.findAndSet(record, i, recordSchema.Fields[i], dec, depth)
 		if err != nil {
 			return nil, err
 		}
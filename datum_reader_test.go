@@ -2,7 +2,9 @@ package avro
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"sync"
 	"testing"
 )
@@ -321,6 +323,61 @@ func specificSelfRecursive(t *testing.T, prepare bool) {
 	assert(t, dest.C[1].B.Label, "inner2Child")
 }
 
+func TestSpecificMissingField_NoPrepare(t *testing.T) {
+	specificMissingField(t, false, "Field does not exist: [opt_string], did you mean avro.MissingField.Opt_stirng?")
+}
+func TestSpecificMissingField_Prepare(t *testing.T) {
+	specificMissingField(t, true, "Field does not exist: [opt_string], did you mean avro.MissingField.Opt_stirng?")
+}
+
+type MissingField struct {
+	Id         int32
+	Opt_stirng string
+}
+
+func specificMissingField(t *testing.T, prepare bool, message string) {
+	schema := maybePrepare(prepare, MustParseSchema(`{"type": "record", "name": "MissingField", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "opt_string", "type": "string"}
+	]}`))
+	input := testEncodeBytes(schema, &struct {
+		Id         int32
+		Opt_string string
+	}{1, "value"})
+
+	r := NewSpecificDatumReader()
+	r.SetSchema(schema)
+	// Reading again must fail the same way rather than use a broken cached plan.
+	for i := 0; i < 2; i++ {
+		var dest MissingField
+		err := r.Read(&dest, NewBinaryDecoder(input))
+		assert(t, err.Error(), message)
+	}
+
+	_, err := findField(reflect.ValueOf(&struct{ Unrelated string }{}), &SchemaField{Name: "id"}, false)
+	assert(t, err, NewFieldDoesNotExistError("id"))
+}
+
+func TestSpecificFieldAlias(t *testing.T) {
+	type renamed struct {
+		Id      int32
+		OldName string
+	}
+	for _, prepare := range []bool{false, true} {
+		schema := maybePrepare(prepare, MustParseSchema(`{"type": "record", "name": "Renamed", "fields": [
+			{"name": "id", "type": "int"},
+			{"name": "new_name", "type": "string", "aliases": ["oldName"]}
+		]}`))
+		input := testEncodeBytes(schema, &renamed{7, "value"})
+
+		r := NewSpecificDatumReader()
+		r.SetSchema(schema)
+		var dest renamed
+		assert(t, r.Read(&dest, NewBinaryDecoder(input)), nil)
+		assert(t, dest, renamed{7, "value"})
+	}
+}
+
 func TestSpecificCoRecursive_NoPrepare(t *testing.T) {
 	specificCoRecursive(t, false)
 }
@@ -516,6 +573,10 @@ func TestSpecificReaderMapOfRecords(t *testing.T) {
 	}
 	assert(t, dest2.A["abc"].InnerA, int32(7))
 	assert(t, dest2.A["def"].InnerA, int32(9))
+
+	// A truncated map value fails to read instead of leaving a bogus value.
+	err = reader.Read(&dest2, NewBinaryDecoder(b1[:len(b1)-2]))
+	assert(t, err != nil, true)
 }
 
 func TestGenericDatumReaderEmptyMap(t *testing.T) {
@@ -638,7 +699,7 @@ func TestEnumNegativeRegression(t *testing.T) {
 
 	buf = []byte{0x78} // This is the encoding of the varint 60
 	err = reader.Read(genericDest, NewBinaryDecoder(buf))
-	assert(t, err.Error(), "Enum index invalid!")
+	assert(t, err.Error(), "Enum index 60 too high for enum Type")
 
 	playingCard.Type = nil
 	err = reader.Read(&playingCard, NewBinaryDecoder(buf))
@@ -646,6 +707,32 @@ func TestEnumNegativeRegression(t *testing.T) {
 
 }
 
+func TestGenericEnumStringAndJSON(t *testing.T) {
+	enum := NewGenericEnum([]string{"HEART", "SPADE", "CLUB"})
+	enum.Set("SPADE")
+	assert(t, fmt.Sprint(enum), "SPADE")
+	data, err := json.Marshal(enum)
+	assert(t, err, nil)
+	assert(t, string(data), `"SPADE"`)
+
+	assert(t, json.Unmarshal([]byte(`"CLUB"`), enum), nil)
+	assert(t, enum.GetIndex(), int32(2))
+	assert(t, json.Unmarshal([]byte(`"DIAMOND"`), enum).Error(), `Unknown enum symbol "DIAMOND", expected one of [HEART SPADE CLUB]`)
+	assert(t, json.Unmarshal([]byte(`1`), enum) != nil, true)
+	assert(t, enum.GetIndex(), int32(2))
+
+	enum.SetIndex(5)
+	assert(t, enum.String(), "<invalid enum index 5>")
+	_, err = json.Marshal(enum)
+	assert(t, err != nil, true)
+
+	var card GenericRecord
+	reader := NewGenericDatumReader()
+	reader.SetSchema(schemaEnumA)
+	assert(t, reader.Read(&card, NewBinaryDecoder([]byte{0x2})), nil)
+	assert(t, card.String(), `{"type":"SPADE"}`)
+}
+
 func parallelF(numRoutines, numLoops int, f func(routine, loop int)) {
 	var wg sync.WaitGroup
 	wg.Add(numRoutines)
@@ -657,6 +744,7 @@ func parallelF(numRoutines, numLoops int, f func(routine, loop int)) {
 			}
 		}(i)
 	}
+	wg.Wait()
 }
 
 func BenchmarkSpecificDatumReader_complex(b *testing.B) {
@@ -828,3 +916,247 @@ func maybePrepare(prepare bool, s Schema) Schema {
 	}
 	return s
 }
+
+func TestSpecificRegisterUnionType(t *testing.T) {
+	type Cat struct {
+		Name string `avro:"name"`
+	}
+	type Dog struct {
+		Barks int32 `avro:"barks"`
+	}
+	type Owner struct {
+		Pet interface{} `avro:"pet"`
+	}
+	sch := MustParseSchema(`{"type": "record", "name": "Owner", "namespace": "ns", "fields": [
+		{"name": "pet", "type": [
+			"null",
+			{"type": "record", "name": "Cat", "namespace": "ns", "fields": [{"name": "name", "type": "string"}]},
+			{"type": "record", "name": "Dog", "namespace": "ns", "fields": [{"name": "barks", "type": "int"}]}
+		]}
+	]}`)
+	catBuf := []byte{0x02, 0x06, 'T', 'o', 'm'}
+	dogBuf := []byte{0x04, 0x06}
+
+	for _, prepare := range []bool{false, true} {
+		reader := NewSpecificDatumReader()
+		reader.SetSchema(maybePrepare(prepare, sch))
+		reader.RegisterUnionType("ns.Cat", reflect.TypeOf(&Cat{}))
+		reader.RegisterUnionType("ns.Dog", reflect.TypeOf(Dog{}))
+
+		var owner Owner
+		err := reader.Read(&owner, NewBinaryDecoder(catBuf))
+		assert(t, err, nil)
+		assert(t, owner.Pet, &Cat{Name: "Tom"})
+
+		err = reader.Read(&owner, NewBinaryDecoder(dogBuf))
+		assert(t, err, nil)
+		assert(t, owner.Pet, Dog{Barks: 3})
+
+		// Unregistered records fall back to GenericRecord
+		reader = NewSpecificDatumReader()
+		reader.SetSchema(maybePrepare(prepare, sch))
+		err = reader.Read(&owner, NewBinaryDecoder(catBuf))
+		assert(t, err, nil)
+		assert(t, owner.Pet.(*GenericRecord).Get("name"), "Tom")
+	}
+}
+
+func TestDatumReaderReuseBuffers(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Hashed", "fields": [
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "payload", "type": "bytes"},
+		{"name": "inner", "type": {"type": "record", "name": "Inner", "fields": [
+			{"name": "id", "type": "Hash"}
+		]}},
+		{"name": "parent", "type": "Inner"}
+	]}`)
+	type inner struct {
+		Id []byte
+	}
+	type hashed struct {
+		Hash    []byte
+		Payload []byte
+		Inner   inner
+		Parent  *inner
+	}
+	first := testEncodeBytes(schema, &hashed{[]byte{1, 2, 3, 4}, []byte("first"), inner{[]byte{5, 6, 7, 8}}, &inner{[]byte{9, 9, 9, 9}}})
+	second := testEncodeBytes(schema, &hashed{[]byte{4, 3, 2, 1}, []byte("two"), inner{[]byte{8, 7, 6, 5}}, &inner{[]byte{0, 0, 0, 0}}})
+
+	for _, prepare := range []bool{false, true} {
+		s := maybePrepare(prepare, schema)
+		reader := NewSpecificDatumReader().SetReuseBuffers(true)
+		reader.SetSchema(s)
+		var dest hashed
+		assert(t, reader.Read(&dest, NewBinaryDecoder(first)), nil)
+		hash, payload, id, parent := &dest.Hash[0], &dest.Payload[0], &dest.Inner.Id[0], dest.Parent
+		assert(t, reader.Read(&dest, NewBinaryDecoder(second)), nil)
+		assert(t, dest, hashed{[]byte{4, 3, 2, 1}, []byte("two"), inner{[]byte{8, 7, 6, 5}}, &inner{[]byte{0, 0, 0, 0}}})
+		assert(t, &dest.Hash[0] == hash && &dest.Payload[0] == payload && &dest.Inner.Id[0] == id && dest.Parent == parent, true)
+	}
+
+	reader := NewGenericDatumReader().SetReuseBuffers(true)
+	reader.SetSchema(schema)
+	record := NewGenericRecord(schema)
+	assert(t, reader.Read(record, NewBinaryDecoder(first)), nil)
+	hash, nested := &record.Get("hash").([]byte)[0], record.Get("inner").(*GenericRecord)
+	assert(t, reader.Read(record, NewBinaryDecoder(second)), nil)
+	assert(t, record.Get("hash"), []byte{4, 3, 2, 1})
+	assert(t, record.Get("payload"), []byte("two"))
+	assert(t, record.Get("inner") == nested && &record.Get("hash").([]byte)[0] == hash, true)
+	assert(t, nested.Get("id"), []byte{8, 7, 6, 5})
+	assert(t, record.Get("parent").(*GenericRecord).Get("id"), []byte{0, 0, 0, 0})
+}
+
+type depthNode struct {
+	Next *depthNode
+}
+
+func TestDatumReaderMaxDepth(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "next", "type": ["null", "Node"]}
+	]}`)
+	// A list of n nodes: every one but the last has a next one.
+	list := func(n int) []byte {
+		return append(bytes.Repeat([]byte{2}, n-1), 0)
+	}
+
+	for _, prepare := range []bool{false, true} {
+		reader := NewSpecificDatumReader().SetMaxDepth(10)
+		reader.SetSchema(maybePrepare(prepare, schema))
+		assert(t, reader.Read(&depthNode{}, NewBinaryDecoder(list(10))), nil)
+		assert(t, reader.Read(&depthNode{}, NewBinaryDecoder(list(11))), ErrMaxDepthExceeded)
+	}
+	specific := NewSpecificDatumReader()
+	specific.SetSchema(schema)
+	assert(t, specific.Read(&depthNode{}, NewBinaryDecoder(list(DefaultMaxDepth))), nil)
+	assert(t, specific.Read(&depthNode{}, NewBinaryDecoder(list(DefaultMaxDepth+1))), ErrMaxDepthExceeded)
+
+	for _, lazy := range []bool{false, true} {
+		reader := NewGenericDatumReader().SetLazy(lazy).SetMaxDepth(10)
+		reader.SetSchema(schema)
+		assert(t, reader.Read(NewGenericRecord(schema), NewBinaryDecoder(list(10))), nil)
+		assert(t, reader.Read(NewGenericRecord(schema), NewBinaryDecoder(list(11))), ErrMaxDepthExceeded)
+	}
+
+	reader := NewGenericDatumReader().SetReuseBuffers(true).SetMaxDepth(10)
+	reader.SetSchema(schema)
+	record := NewGenericRecord(schema)
+	assert(t, reader.Read(record, NewBinaryDecoder(list(10))), nil)
+	assert(t, reader.Read(record, NewBinaryDecoder(list(11))), ErrMaxDepthExceeded)
+
+	projector, err := NewDatumProjector(schema, schema)
+	assert(t, err, nil)
+	generic := NewGenericDatumReader().SetMaxDepth(DefaultMaxDepth + 1)
+	generic.SetSchema(schema)
+	deep := NewGenericRecord(schema)
+	assert(t, generic.Read(deep, NewBinaryDecoder(list(DefaultMaxDepth+1))), nil)
+	_, err = projector.Project(deep)
+	assert(t, err, ErrMaxDepthExceeded)
+	_, err = projector.Project(deep.Get("next"))
+	assert(t, err, nil)
+	assert(t, SetProjectorMaxDepth(projector, DefaultMaxDepth+1), nil)
+	_, err = projector.Project(deep)
+	assert(t, err, nil)
+	assert(t, SetProjectorMaxDepth(projector, 10), nil)
+	_, err = projector.Project(record)
+	assert(t, err, nil)
+	record.Set("next", deep)
+	_, err = projector.Project(record)
+	assert(t, err, ErrMaxDepthExceeded)
+}
+
+func TestGenericDatumReaderRecordTypes(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Order", "fields": [
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "age", "type": "int"}
+		]}},
+		{"name": "referrer", "type": ["null", "User"]},
+		{"name": "others", "type": {"type": "array", "items": "User"}}
+	]}`)
+	type user struct {
+		Name string `avro:"name"`
+		Age  int32  `avro:"age"`
+	}
+	order := NewGenericRecord(schema)
+	order.Set("user", &user{Name: "ann", Age: 30})
+	order.Set("referrer", &user{Name: "bob", Age: 40})
+	order.Set("others", []interface{}{&user{Name: "cy", Age: 1}})
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write(order, NewBinaryEncoder(&buf)), nil)
+
+	for _, lazy := range []bool{false, true} {
+		reader := NewGenericDatumReader().RegisterRecordType("User", reflect.TypeOf(&user{})).SetLazy(lazy)
+		reader.SetSchema(schema)
+		decoded := NewGenericRecord(schema)
+		assert(t, reader.Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+		assert(t, decoded.Get("user"), &user{Name: "ann", Age: 30})
+		assert(t, decoded.Get("referrer"), &user{Name: "bob", Age: 40})
+		assert(t, decoded.Get("others"), []interface{}{&user{Name: "cy", Age: 1}})
+	}
+
+	reader := NewGenericDatumReader().RegisterRecordType("User", reflect.TypeOf(user{}))
+	reader.SetSchema(schema)
+	decoded := NewGenericRecord(schema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded.Get("user"), user{Name: "ann", Age: 30})
+
+	// Top-level records stay generic.
+	reader = NewGenericDatumReader().RegisterRecordType("User", reflect.TypeOf(&user{}))
+	reader.SetSchema(schema.(*RecordSchema).Fields[0].Type)
+	top := NewGenericRecord(schema.(*RecordSchema).Fields[0].Type)
+	assert(t, reader.Read(top, NewBinaryDecoder([]byte{6, 'a', 'n', 'n', 2})), nil)
+	assert(t, top.Get("age"), int32(1))
+
+	// Types failing to decode fail lazy reads too.
+	type nameless struct {
+		Age int32 `avro:"age"`
+	}
+	for _, lazy := range []bool{false, true} {
+		reader = NewGenericDatumReader().RegisterRecordType("User", reflect.TypeOf(&nameless{})).SetLazy(lazy)
+		reader.SetSchema(schema)
+		assert(t, reader.Read(NewGenericRecord(schema), NewBinaryDecoder(buf.Bytes())) != nil, true)
+	}
+}
+
+func TestDatumReaderRedaction(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "email", "type": "string", "pii": true},
+		{"name": "address", "type": {"type": "record", "name": "Address", "fields": [
+			{"name": "street", "type": "string", "pii": "street"},
+			{"name": "city", "type": "string", "pii": false}
+		]}}
+	]}`)
+	type address struct {
+		Street string
+		City   string
+	}
+	type user struct {
+		Id      int64
+		Email   string
+		Address address
+	}
+	data := testEncodeBytes(schema, &user{1, "alice@example.com", address{"1 Main St", "Springfield"}})
+
+	read := func(reader *GenericDatumReader) *GenericRecord {
+		reader.SetSchema(schema)
+		record := NewGenericRecord(schema)
+		assert(t, reader.Read(record, NewBinaryDecoder(data)), nil)
+		return record
+	}
+	for _, reader := range []*GenericDatumReader{NewGenericDatumReader(), NewGenericDatumReader().SetLazy(true), NewGenericDatumReader().SetReuseBuffers(true)} {
+		record := read(reader.SetRedaction(&Redaction{Property: "pii", Mask: "***"}))
+		assert(t, record.String(), `{"address":{"city":"Springfield","street":"***"},"email":"***","id":1}`)
+		assert(t, record.Presence("email"), FieldMasked)
+		assert(t, record.Get("address").(*GenericRecord).Presence("city"), FieldFromData)
+
+		record = read(reader.SetRedaction(&Redaction{Property: "pii", Drop: true}))
+		assert(t, record.String(), `{"address":{"city":"Springfield"},"id":1}`)
+		assert(t, record.Get("email"), nil)
+		assert(t, record.Presence("email"), FieldDropped)
+
+		record = read(reader.SetRedaction(nil))
+		assert(t, record.Get("email"), "alice@example.com")
+	}
+}
@@ -16,20 +16,107 @@ limitations under the License. */
 package avro
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"sync"
 )
 
-func findField(where reflect.Value, name string) (reflect.Value, error) {
+// findField looks up the struct field mapped to the given avro field.
+// If alloc is set, nil embedded struct pointers on the way to the field are allocated so it can be set.
+func findField(where reflect.Value, field *SchemaField, alloc bool) (reflect.Value, error) {
 	if where.Kind() == reflect.Ptr {
 		where = where.Elem()
 	}
-	rm := reflectEnsureRi(where.Type())
-	if rf, ok := rm.names[name]; ok {
-		return where.FieldByIndex(rf), nil
+	index, err := structFieldIndex(where.Type(), field)
+	if err != nil {
+		return reflect.Value{}, err
 	}
-	return reflect.Value{}, NewFieldDoesNotExistError(name)
+	return fieldByIndex(where, index, alloc), nil
+}
+
+// structFieldIndex returns the index of the field of struct type t mapped to the given avro field,
+// by its name or else by one of its aliases.
+func structFieldIndex(t reflect.Type, field *SchemaField) ([]int, error) {
+	rm := reflectEnsureRi(t)
+	if index, ok := rm.names[field.Name]; ok {
+		return index, nil
+	}
+	for _, alias := range field.Aliases {
+		if index, ok := rm.names[alias]; ok {
+			return index, nil
+		}
+	}
+	return nil, missingFieldError(t, field.Name)
+}
+
+// missingFieldError reports that struct type t has no field for the given avro field name,
+// suggesting a struct field with a similar name if there is one, as these are usually typos.
+func missingFieldError(t reflect.Type, name string) error {
+	err := NewFieldDoesNotExistError(name)
+	if similar := similarField(t, name); similar != "" {
+		return fmt.Errorf("%s, did you mean %s.%s?", err, t, similar)
+	}
+	return err
+}
+
+// similarField returns the Go name of the exported field of t whose name is closest to the given
+// one, ignoring case and underscores, if it's at most 2 edits away.
+func similarField(t reflect.Type, name string) string {
+	normalize := func(s string) string { return strings.Replace(strings.ToLower(s), "_", "", -1) }
+	best, bestDistance := "", 3
+	for candidate, index := range reflectEnsureRi(t).names {
+		distance := editDistance(normalize(name), normalize(candidate))
+		field := t.FieldByIndex(index).Name
+		if distance < bestDistance || (distance == bestDistance && field < best) {
+			best, bestDistance = field, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			current[j] = previous[j-1]
+			if a[i-1] != b[j-1] {
+				current[j]++
+			}
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but copes with nil embedded struct pointers along
+// the path: they are allocated when alloc is set, otherwise the zero value of the target field is returned.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Zero(v.Type().Elem().FieldByIndex(index[i:]).Type)
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
 }
 
 func reflectEnsureRi(t reflect.Type) *reflectInfo {
@@ -65,6 +152,8 @@ type reflectInfo struct {
 //
 // fill will recurse into anonymous structs incrementing the index prefix
 // so that untagged anonymous structs can be used as the source of truth.
+// Embedded pointers to structs are followed too, as long as they are exported
+// so that they can be allocated on decode.
 func (rm *reflectInfo) fill(t reflect.Type, indexPrefix []int) {
 	// simple infinite recursion preventer: stop when we are >10 deep.
 	if len(indexPrefix) > 10 {
@@ -80,10 +169,12 @@ func (rm *reflectInfo) fill(t reflect.Type, indexPrefix []int) {
 	var toInvestigate [][]int
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
-		tag := f.Tag.Get("avro")
+		tag, omit := parseTag(f.Tag.Get("avro"))
 		idx := append(append([]int{}, indexPrefix...), f.Index...)
 
-		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+		if omit {
+			continue
+		} else if f.Anonymous && tag == "" && isEmbeddableStruct(f) {
 			toInvestigate = append(toInvestigate, idx)
 		} else if strings.ToLower(f.Name[:1]) != f.Name[:1] {
 			if tag != "" {
@@ -96,6 +187,36 @@ func (rm *reflectInfo) fill(t reflect.Type, indexPrefix []int) {
 	}
 	for _, idx := range toInvestigate {
 		// recurse into anonymous structs now that we handled the base ones.
-		rm.fill(t.Field(idx[len(idx)-1]).Type, idx)
+		ft := t.Field(idx[len(idx)-1]).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		rm.fill(ft, idx)
+	}
+}
+
+// parseTag splits an avro struct tag into the field name, if any, and whether the field is
+// excluded from mapping altogether, which is the case for `avro:"-"` and `avro:",omit"`.
+// Excluded fields are neither read nor written, which suits struct-internal state.
+func parseTag(tag string) (name string, omit bool) {
+	if tag == "-" {
+		return "", true
+	}
+	options := strings.Split(tag, ",")
+	for _, option := range options[1:] {
+		if option == "omit" {
+			return "", true
+		}
+	}
+	return options[0], false
+}
+
+func isEmbeddableStruct(f reflect.StructField) bool {
+	switch f.Type.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Ptr:
+		return f.PkgPath == "" && f.Type.Elem().Kind() == reflect.Struct
 	}
+	return false
 }
@@ -1,9 +1,14 @@
 package avro
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
 // ***********************
@@ -35,10 +40,9 @@ func NewDatumWriter(schema Schema) DatumWriter {
 		panic("NewDatumWriter: Must provide a non-nil schema.")
 	}
 
-	return &anyDatumWriter{
-		sdr: SpecificDatumWriter{schema: schema},
-		gdr: GenericDatumWriter{schema: schema},
-	}
+	w := &anyDatumWriter{sdr: SpecificDatumWriter{schema: schema}}
+	w.gdr.SetSchema(schema)
+	return w
 }
 
 // Decides between generic/specific datum writer
@@ -67,9 +71,97 @@ func (w *anyDatumWriter) Write(obj interface{}, enc Encoder) error {
 var _ DatumWriter = (*GenericDatumWriter)(nil)
 var _ DatumWriter = (*SpecificDatumWriter)(nil)
 
+// blockEncoder is implemented by encoders with settings for how arrays and maps are written.
+type blockEncoder interface {
+	// maxBlockItems returns the maximum number of items per block, or 0 for no limit.
+	maxBlockItems() int
+
+	// sizedBlocks returns whether blocks are written with a negative item count followed by
+	// their size in bytes, which lets readers skip them without decoding their items.
+	sizedBlocks() bool
+
+	// sortedKeys returns whether map entries are written sorted by key.
+	sortedKeys() bool
+
+	// newBlockEncoder returns an encoder with the same settings writing a block's items to w.
+	newBlockEncoder(w io.Writer) Encoder
+}
+
+// writeBlocks writes the n items of a non-empty array or map, in blocks as configured by the
+// encoder, followed by the terminating empty block.
+func writeBlocks(enc Encoder, n int, start func(int64), next func(int64), item func(enc Encoder, i int) error) error {
+	size, sized := n, false
+	be, ok := enc.(blockEncoder)
+	if ok {
+		if be.maxBlockItems() > 0 && be.maxBlockItems() < n {
+			size = be.maxBlockItems()
+		}
+		sized = be.sizedBlocks()
+	}
+
+	header := start
+	for i := 0; i < n; i += size {
+		count := size
+		if n-i < count {
+			count = n - i
+		}
+		if !sized {
+			header(int64(count))
+			for j := i; j < i+count; j++ {
+				if err := item(enc, j); err != nil || encodedSizeExceeded(enc) {
+					return encodedSizeErrorAt(enc, err, "[]")
+				}
+			}
+		} else {
+			block := &bytes.Buffer{}
+			blockEnc := be.newBlockEncoder(block)
+			for j := i; j < i+count; j++ {
+				if err := item(blockEnc, j); err != nil || encodedSizeExceeded(blockEnc) {
+					return encodedSizeErrorAt(blockEnc, err, "[]")
+				}
+			}
+			header(int64(-count))
+			enc.WriteLong(int64(block.Len()))
+			enc.WriteRaw(block.Bytes())
+		}
+		header = next
+	}
+	next(0)
+
+	return nil
+}
+
+// sortKeys sorts the keys of a map about to be written if the encoder wants them sorted.
+func sortKeys(enc Encoder, keys []string) {
+	if be, ok := enc.(blockEncoder); ok && be.sortedKeys() {
+		sort.Strings(keys)
+	}
+}
+
+// sortValueKeys is like sortKeys, for the string keys of a map read with reflection.
+func sortValueKeys(enc Encoder, keys []reflect.Value) {
+	if be, ok := enc.(blockEncoder); ok && be.sortedKeys() {
+		sort.Sort(valueKeys(keys))
+	}
+}
+
+// valueKeys sorts the string keys of a map read with reflection.
+type valueKeys []reflect.Value
+
+func (k valueKeys) Len() int           { return len(k) }
+func (k valueKeys) Swap(i, j int)      { k[i], k[j] = k[j], k[i] }
+func (k valueKeys) Less(i, j int) bool { return k[i].String() < k[j].String() }
+
 // SpecificDatumWriter implements DatumWriter and is used for writing Go structs in Avro format.
 type SpecificDatumWriter struct {
-	schema Schema
+	schema         Schema
+	maxDepth       int
+	depth          int // of the record being written
+	strict         bool
+	maxEncodedSize int
+
+	// pointers to the records being written, tracked once they nest deep enough to be a cycle
+	path map[uintptr]bool
 }
 
 // NewSpecificDatumWriter creates a new SpecificDatumWriter.
@@ -84,6 +176,35 @@ func (writer *SpecificDatumWriter) SetSchema(schema Schema) DatumWriter {
 	return writer
 }
 
+// SetMaxDepth sets the maximum number of records values written may nest, DefaultMaxDepth if not set
+// or zero. Deeper values fail to write with ErrMaxDepthExceeded, and values referencing themselves,
+// such as structs pointing back to one of their parents, with ErrRecordCycle. Records shared by
+// several parents are fine, and written as many times as they're referenced.
+// Must be called before calling Write.
+func (writer *SpecificDatumWriter) SetMaxDepth(depth int) *SpecificDatumWriter {
+	writer.maxDepth = depth
+	return writer
+}
+
+// SetStrict sets whether writing structs with exported fields the schema has no field for fails,
+// which usually means the schema and the Go types have drifted apart. Such fields are silently
+// ignored if not set. Fields excluded with `avro:"-"` or `avro:",omit"` are never reported.
+// Must be called before calling Write.
+func (writer *SpecificDatumWriter) SetStrict(strict bool) *SpecificDatumWriter {
+	writer.strict = strict
+	return writer
+}
+
+// SetMaxEncodedSize sets the maximum number of bytes a datum may be encoded in, or 0 for no limit.
+// Writing a larger datum stops as soon as it goes over and fails with an *EncodedSizeError telling
+// the field reached, e.g. so that producers don't build messages a broker would reject whole.
+// Only binary Encoders, such as those from NewBinaryEncoder, can be written to with a limit.
+// Must be called before calling Write.
+func (writer *SpecificDatumWriter) SetMaxEncodedSize(n int) *SpecificDatumWriter {
+	writer.maxEncodedSize = n
+	return writer
+}
+
 // Write writes a single Go struct using this SpecificDatumWriter according to provided Schema.
 // Accepts a value to write and Encoder to write to. Field names should match field names in Avro schema but be exported
 // (e.g. "some_value" in Avro schema is expected to be Some_value in struct) or you may provide Go struct tags to
@@ -91,6 +212,13 @@ func (writer *SpecificDatumWriter) SetSchema(schema Schema) DatumWriter {
 // you should define your struct field as follows: SomeValue int32 `avro:"some_field"`).
 // May return an error indicating a write failure.
 func (writer *SpecificDatumWriter) Write(obj interface{}, enc Encoder) error {
+	if writer.maxEncodedSize > 0 {
+		unlimited := *writer
+		unlimited.maxEncodedSize = 0
+		return writeLimited(enc, writer.maxEncodedSize, writer.schema, func(enc Encoder) error {
+			return unlimited.Write(obj, enc)
+		})
+	}
 	if writer, ok := obj.(Marshaler); ok {
 		return writer.MarshalAvro(enc)
 	}
@@ -140,66 +268,108 @@ func (writer *SpecificDatumWriter) write(v reflect.Value, enc Encoder, s Schema)
 	return nil
 }
 
+// primitiveValue returns the value held by v, following interfaces and pointers, and whether it
+// is of the given kind. Primitives are then written through the typed reflect accessors rather
+// than boxed into an interface{} and asserted again.
+func primitiveValue(v reflect.Value, kind reflect.Kind) (reflect.Value, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	v = dereference(v)
+	return v, v.Kind() == kind
+}
+
 func (writer *SpecificDatumWriter) writeBoolean(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
-		return fmt.Errorf("Invalid boolean value: %v", v.Interface())
+	value, ok := primitiveValue(v, reflect.Bool)
+	if !ok {
+		return fmt.Errorf("Invalid boolean value: %v", v)
 	}
 
-	enc.WriteBoolean(v.Interface().(bool))
+	enc.WriteBoolean(value.Bool())
 	return nil
 }
 
 func (writer *SpecificDatumWriter) writeInt(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
-		return fmt.Errorf("Invalid int value: %v", v.Interface())
+	value, ok := primitiveValue(v, reflect.Int32)
+	if isDate(s) && value.IsValid() && value.Type() == timeType {
+		enc.WriteInt(dateValue(value.Interface().(time.Time)))
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("Invalid int value: %v", v)
 	}
 
-	enc.WriteInt(v.Interface().(int32))
+	enc.WriteInt(int32(value.Int()))
 	return nil
 }
 
 func (writer *SpecificDatumWriter) writeLong(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
-		return fmt.Errorf("Invalid long value: %v", v.Interface())
+	value, ok := primitiveValue(v, reflect.Int64)
+	if timestampUnit(s) != 0 && value.IsValid() && value.Type() == timeType {
+		enc.WriteLong(timestampValue(s, value.Interface().(time.Time)))
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("Invalid long value: %v", v)
 	}
 
-	enc.WriteLong(v.Interface().(int64))
+	enc.WriteLong(value.Int())
 	return nil
 }
 
 func (writer *SpecificDatumWriter) writeFloat(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
-		return fmt.Errorf("Invalid float value: %v", v.Interface())
+	value, ok := primitiveValue(v, reflect.Float32)
+	if !ok {
+		return fmt.Errorf("Invalid float value: %v", v)
 	}
 
-	enc.WriteFloat(v.Interface().(float32))
+	enc.WriteFloat(float32(value.Float()))
 	return nil
 }
 
 func (writer *SpecificDatumWriter) writeDouble(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
-		return fmt.Errorf("Invalid double value: %v", v.Interface())
+	value, ok := primitiveValue(v, reflect.Float64)
+	if !ok {
+		return fmt.Errorf("Invalid double value: %v", v)
 	}
 
-	enc.WriteDouble(v.Interface().(float64))
+	enc.WriteDouble(value.Float())
 	return nil
 }
 
 func (writer *SpecificDatumWriter) writeBytes(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
-		return fmt.Errorf("Invalid bytes value: %v", v.Interface())
+	if isDecimal(s) && v.IsValid() && isDecimalType(reflect.TypeOf(v.Interface())) {
+		decimal, err := decimalBytes(s, v)
+		if err != nil {
+			return err
+		}
+		enc.WriteBytes(decimal)
+		return nil
+	}
+	value, ok := primitiveValue(v, reflect.Slice)
+	if !ok || value.Type().Elem().Kind() != reflect.Uint8 {
+		return fmt.Errorf("Invalid bytes value: %v", v)
 	}
 
-	enc.WriteBytes(v.Interface().([]byte))
+	enc.WriteBytes(value.Bytes())
 	return nil
 }
 
 func (writer *SpecificDatumWriter) writeString(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
-		return fmt.Errorf("Invalid string value: %v", v.Interface())
+	if isUUID(s) {
+		uuid, err := uuidString(v)
+		if err != nil {
+			return err
+		}
+		enc.WriteString(uuid)
+		return nil
+	}
+	value, ok := primitiveValue(v, reflect.String)
+	if !ok || value.Type() != specificTypes[String] {
+		return fmt.Errorf("Invalid string value: %v", v)
 	}
 
-	enc.WriteString(v.Interface().(string))
+	enc.WriteString(value.String())
 	return nil
 }
 
@@ -213,16 +383,9 @@ func (writer *SpecificDatumWriter) writeArray(v reflect.Value, enc Encoder, s Sc
 		return nil
 	}
 
-	//TODO should probably write blocks of some length
-	enc.WriteArrayStart(int64(v.Len()))
-	for i := 0; i < v.Len(); i++ {
-		if err := writer.write(v.Index(i), enc, s.(*ArraySchema).Items); err != nil {
-			return err
-		}
-	}
-	enc.WriteArrayNext(0)
-
-	return nil
+	return writeBlocks(enc, v.Len(), enc.WriteArrayStart, enc.WriteArrayNext, func(enc Encoder, i int) error {
+		return writer.write(v.Index(i), enc, s.(*ArraySchema).Items)
+	})
 }
 
 func (writer *SpecificDatumWriter) writeMap(v reflect.Value, enc Encoder, s Schema) error {
@@ -234,28 +397,33 @@ func (writer *SpecificDatumWriter) writeMap(v reflect.Value, enc Encoder, s Sche
 		enc.WriteMapNext(0)
 		return nil
 	}
-	//TODO should probably write blocks of some length
-	enc.WriteMapStart(int64(v.Len()))
-	for _, key := range v.MapKeys() {
-		err := writer.writeString(key, enc, &StringSchema{})
+	keys := v.MapKeys()
+	sortValueKeys(enc, keys)
+	return writeBlocks(enc, len(keys), enc.WriteMapStart, enc.WriteMapNext, func(enc Encoder, i int) error {
+		err := writer.writeString(keys[i], enc, &StringSchema{})
 		if err != nil {
 			return err
 		}
-		if err = writer.write(v.MapIndex(key), enc, s.(*MapSchema).Values); err != nil {
-			return err
-		}
-	}
-	enc.WriteMapNext(0)
-
-	return nil
+		return writer.write(v.MapIndex(keys[i]), enc, s.(*MapSchema).Values)
+	})
 }
 
 func (writer *SpecificDatumWriter) writeEnum(v reflect.Value, enc Encoder, s Schema) error {
-	if !s.Validate(v) {
+	enum, ok := v.Interface().(*GenericEnum)
+	if !ok || enum == nil {
 		return fmt.Errorf("Invalid enum value: %v", v.Interface())
 	}
 
-	enc.WriteInt(v.Interface().(*GenericEnum).GetIndex())
+	// Out of range indices would only fail once read, possibly by someone else.
+	schema := s.(*EnumSchema)
+	index := enum.GetIndex()
+	if index < 0 || int(index) >= len(schema.Symbols) {
+		return fmt.Errorf("Enum index %d out of range for enum %s with %d symbols", index, schema.GetName(), len(schema.Symbols))
+	}
+	if int(index) < len(enum.Symbols) && enum.Symbols[index] != schema.Symbols[index] {
+		return fmt.Errorf("Enum symbol %s at index %d doesn't match symbol %s of enum %s", enum.Symbols[index], index, schema.Symbols[index], schema.GetName())
+	}
+	enc.WriteInt(index)
 
 	return nil
 }
@@ -274,6 +442,14 @@ func (writer *SpecificDatumWriter) writeUnion(v reflect.Value, enc Encoder, s Sc
 
 func (writer *SpecificDatumWriter) writeFixed(v reflect.Value, enc Encoder, s Schema) error {
 	fs := s.(*FixedSchema)
+	if isDecimal(fs) && v.IsValid() && isDecimalType(reflect.TypeOf(v.Interface())) {
+		decimal, err := decimalBytes(fs, v)
+		if err != nil {
+			return err
+		}
+		enc.WriteRaw(decimal)
+		return nil
+	}
 
 	if !fs.Validate(v) {
 		return fmt.Errorf("Invalid fixed value: %v", v.Interface())
@@ -288,27 +464,101 @@ func (writer *SpecificDatumWriter) writeRecord(v reflect.Value, enc Encoder, s S
 	if !s.Validate(v) {
 		return fmt.Errorf("Encoding Record %s: Invalid record value: %v", s.GetName(), v.Interface())
 	}
+	writer, done, err := writer.nested(v)
+	if err != nil {
+		return err
+	}
+	defer done()
 
 	rs := assertRecordSchema(s)
+	if writer.strict {
+		t := v.Type()
+		if v.Kind() == reflect.Interface {
+			t = v.Elem().Type()
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			if extra := extraStructFields(t, rs); len(extra) > 0 {
+				return fmt.Errorf("Encoding Record %s: Go type %s has fields not in the schema: %s", s.GetName(), t, strings.Join(extra, ", "))
+			}
+		}
+	}
 	for i := range rs.Fields {
 		schemaField := rs.Fields[i]
-		field, err := findField(v, schemaField.Name)
+		field, err := findField(v, schemaField, false)
 		if err != nil {
 			return err
 		}
-		if err := writer.write(field, enc, schemaField.Type); err != nil {
-			return err
+		if err := writer.write(field, enc, schemaField.Type); err != nil || encodedSizeExceeded(enc) {
+			return encodedSizeErrorAt(enc, err, schemaField.Name)
 		}
 	}
 
 	return nil
 }
 
+// extraStructFields returns the names of the exported fields of the struct type t, sorted, which
+// none of the fields of rs maps to.
+func extraStructFields(t reflect.Type, rs *RecordSchema) []string {
+	ri := reflectEnsureRi(t)
+	mapped := make(map[string]bool, len(rs.Fields))
+	for _, field := range rs.Fields {
+		if index, err := structFieldIndex(t, field); err == nil {
+			mapped[fmt.Sprint(index)] = true
+		}
+	}
+	var extra []string
+	seen := make(map[string]bool, len(ri.names))
+	for _, index := range ri.names {
+		key := fmt.Sprint(index)
+		if !mapped[key] && !seen[key] {
+			seen[key] = true
+			extra = append(extra, t.FieldByIndex(index).Name)
+		}
+	}
+	sort.Strings(extra)
+	return extra
+}
+
+// cycleCheckDepth is the depth from which SpecificDatumWriter checks records for cycles, which
+// data this deep is likely to be.
+const cycleCheckDepth = 64
+
+// nested returns a writer for the fields of the record v, which is one level deeper than the current
+// one, and a function to call when they're written. Fails with ErrMaxDepthExceeded if that's too
+// deep, or ErrRecordCycle if v is a pointer to a record being written already.
+func (writer *SpecificDatumWriter) nested(v reflect.Value) (*SpecificDatumWriter, func(), error) {
+	if writer.depth >= maxDepth(writer.maxDepth) {
+		return nil, nil, ErrMaxDepthExceeded
+	}
+	nested := *writer
+	nested.depth++
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if nested.depth < cycleCheckDepth || v.Kind() != reflect.Ptr {
+		return &nested, func() {}, nil
+	}
+	pointer := v.Pointer()
+	if nested.path[pointer] {
+		return nil, nil, ErrRecordCycle
+	} else if nested.path == nil {
+		nested.path = make(map[uintptr]bool)
+	}
+	nested.path[pointer] = true
+	return &nested, func() { delete(nested.path, pointer) }, nil
+}
+
 // GenericDatumWriter implements DatumWriter and is used for writing GenericRecords or other Avro supported types
 // (full list is: interface{}, bool, int32, int64, float32, float64, string, slices of any type, maps with string keys
 // and any values, GenericEnums) to a given Encoder.
 type GenericDatumWriter struct {
-	schema Schema
+	schema         Schema
+	maxEncodedSize int
+	middleware     []FieldMiddleware
+	writeFunc      genericWriteFunc // built for the schema and middleware
 }
 
 // NewGenericDatumWriter creates a new GenericDatumWriter.
@@ -320,6 +570,14 @@ func NewGenericDatumWriter() *GenericDatumWriter {
 // Note that it must be called before calling Write.
 func (writer *GenericDatumWriter) SetSchema(schema Schema) DatumWriter {
 	writer.schema = schema
+	writer.buildWriteFunc()
+	return writer
+}
+
+// SetMaxEncodedSize sets the maximum number of bytes a datum may be encoded in, as
+// SpecificDatumWriter.SetMaxEncodedSize does. Must be called before calling Write.
+func (writer *GenericDatumWriter) SetMaxEncodedSize(n int) *GenericDatumWriter {
+	writer.maxEncodedSize = n
 	return writer
 }
 
@@ -327,289 +585,14 @@ func (writer *GenericDatumWriter) SetSchema(schema Schema) DatumWriter {
 // Accepts a value to write and Encoder to write to.
 // May return an error indicating a write failure.
 func (writer *GenericDatumWriter) Write(obj interface{}, enc Encoder) error {
-	return writer.write(obj, enc, writer.schema)
-}
-
-func (writer *GenericDatumWriter) write(v interface{}, enc Encoder, s Schema) error {
-	switch s.Type() {
-	case Null:
-	case Boolean:
-		return writer.writeBoolean(v, enc)
-	case Int:
-		return writer.writeInt(v, enc)
-	case Long:
-		return writer.writeLong(v, enc)
-	case Float:
-		return writer.writeFloat(v, enc)
-	case Double:
-		return writer.writeDouble(v, enc)
-	case Bytes:
-		return writer.writeBytes(v, enc)
-	case String:
-		return writer.writeString(v, enc)
-	case Array:
-		return writer.writeArray(v, enc, s)
-	case Map:
-		return writer.writeMap(v, enc, s)
-	case Enum:
-		return writer.writeEnum(v, enc, s)
-	case Union:
-		return writer.writeUnion(v, enc, s)
-	case Fixed:
-		return writer.writeFixed(v, enc, s)
-	case Record:
-		return writer.writeRecord(v, enc, s)
-	case Recursive:
-		return writer.writeRecord(v, enc, s.(*RecursiveSchema).Actual)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeBoolean(v interface{}, enc Encoder) error {
-	switch value := v.(type) {
-	case bool:
-		enc.WriteBoolean(value)
-	default:
-		return fmt.Errorf("%v is not a boolean", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeInt(v interface{}, enc Encoder) error {
-	switch value := v.(type) {
-	case int32:
-		enc.WriteInt(value)
-	default:
-		return fmt.Errorf("%v is not an int32", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeLong(v interface{}, enc Encoder) error {
-	switch value := v.(type) {
-	case int64:
-		enc.WriteLong(value)
-	default:
-		return fmt.Errorf("%v is not an int64", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeFloat(v interface{}, enc Encoder) error {
-	switch value := v.(type) {
-	case float32:
-		enc.WriteFloat(value)
-	default:
-		return fmt.Errorf("%v is not a float32", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeDouble(v interface{}, enc Encoder) error {
-	switch value := v.(type) {
-	case float64:
-		enc.WriteDouble(value)
-	default:
-		return fmt.Errorf("%v is not a float64", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeBytes(v interface{}, enc Encoder) error {
-	switch value := v.(type) {
-	case []byte:
-		enc.WriteBytes(value)
-	default:
-		return fmt.Errorf("%v is not a []byte", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeString(v interface{}, enc Encoder) error {
-	switch value := v.(type) {
-	case string:
-		enc.WriteString(value)
-	default:
-		return fmt.Errorf("%v is not a string", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeArray(v interface{}, enc Encoder, s Schema) error {
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
-		return errors.New("Not a slice or array type")
-	}
-
-	if rv.Len() == 0 {
-		enc.WriteArrayNext(0)
-		return nil
-	}
-
-	//TODO should probably write blocks of some length
-	enc.WriteArrayStart(int64(rv.Len()))
-	for i := 0; i < rv.Len(); i++ {
-		err := writer.write(rv.Index(i).Interface(), enc, s.(*ArraySchema).Items)
-		if err != nil {
-			return err
-		}
-	}
-	enc.WriteArrayNext(0)
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeMap(v interface{}, enc Encoder, s Schema) error {
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Map {
-		return errors.New("Not a map type")
-	}
-
-	if rv.Len() == 0 {
-		enc.WriteMapNext(0)
-		return nil
-	}
-
-	//TODO should probably write blocks of some length
-	enc.WriteMapStart(int64(rv.Len()))
-	for _, key := range rv.MapKeys() {
-		err := writer.writeString(key.Interface(), enc)
-		if err != nil {
-			return err
-		}
-		err = writer.write(rv.MapIndex(key).Interface(), enc, s.(*MapSchema).Values)
-		if err != nil {
-			return err
-		}
-	}
-	enc.WriteMapNext(0)
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeEnum(v interface{}, enc Encoder, s Schema) error {
-	switch v.(type) {
-	case *GenericEnum:
-		{
-			rs := s.(*EnumSchema)
-			for i := range rs.Symbols {
-				if rs.Name == rs.Symbols[i] {
-					err := writer.writeInt(i, enc)
-					if err != nil {
-						return err
-					}
-					break
-				}
-			}
-		}
-	case string:
-		{
-			rs := s.(*EnumSchema)
-			for i := range rs.Symbols {
-				if v.(string) == rs.Symbols[i] {
-					enc.WriteInt(int32(i))
-					break
-				}
-			}
-		}
-	default:
-		return fmt.Errorf("%v is not a *GenericEnum", v)
-	}
-
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeUnion(v interface{}, enc Encoder, s Schema) error {
-	unionSchema := s.(*UnionSchema)
-
-	index := unionSchema.GetType(reflect.ValueOf(v))
-	if index != -1 {
-		enc.WriteInt(int32(index))
-		return writer.write(v, enc, unionSchema.Types[index])
-	}
-
-	return fmt.Errorf("Could not write %v as %s", v, s)
-}
-
-func (writer *GenericDatumWriter) isWritableAs(v interface{}, s Schema) bool {
-	ok := false
-	switch s.(type) {
-	case *NullSchema:
-		return v == nil
-	case *BooleanSchema:
-		_, ok = v.(bool)
-	case *IntSchema:
-		_, ok = v.(int32)
-	case *LongSchema:
-		_, ok = v.(int64)
-	case *FloatSchema:
-		_, ok = v.(float32)
-	case *DoubleSchema:
-		_, ok = v.(float64)
-	case *StringSchema:
-		_, ok = v.(string)
-	case *BytesSchema:
-		_, ok = v.([]byte)
-	case *ArraySchema:
-		{
-			kind := reflect.ValueOf(v).Kind()
-			return kind == reflect.Array || kind == reflect.Slice
-		}
-	case *MapSchema:
-		return reflect.ValueOf(v).Kind() == reflect.Map
-	case *EnumSchema:
-		_, ok = v.(*GenericEnum)
-	case *UnionSchema:
-		panic("Nested unions not supported") //this is a part of spec: http://avro.apache.org/docs/current/spec.html#binary_encode_complex
-	case *RecordSchema:
-		_, ok = v.(*GenericRecord)
-	case *preparedRecordSchema:
-		_, ok = v.(*GenericRecord)
-	}
-
-	return ok
-}
-
-func (writer *GenericDatumWriter) writeFixed(v interface{}, enc Encoder, s Schema) error {
-	fs := s.(*FixedSchema)
-
-	if !fs.Validate(reflect.ValueOf(v)) {
-		return fmt.Errorf("Invalid fixed value: %v", v)
+	write := writer.writeFunc
+	if write == nil {
+		write = ensureGenericWriteFunc(writer.schema)
 	}
-
-	// Write the raw bytes. The length is known by the schema
-	enc.WriteRaw(v.([]byte))
-	return nil
-}
-
-func (writer *GenericDatumWriter) writeRecord(v interface{}, enc Encoder, s Schema) error {
-	switch value := v.(type) {
-	case *GenericRecord:
-		{
-			rs := assertRecordSchema(s)
-			for i := range rs.Fields {
-				schemaField := rs.Fields[i]
-				field := value.Get(schemaField.Name)
-				if field == nil {
-					field = schemaField.Default
-				}
-				err := writer.write(field, enc, schemaField.Type)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	default:
-		return fmt.Errorf("%v is not a *GenericRecord", v)
+	if writer.maxEncodedSize > 0 {
+		return writeLimited(enc, writer.maxEncodedSize, writer.schema, func(enc Encoder) error {
+			return write(obj, enc)
+		})
 	}
-
-	return nil
+	return write(obj, enc)
 }
@@ -2,7 +2,11 @@ package avro
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 	"math/rand"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -333,6 +337,275 @@ func TestGenericDatumWriterFixed(t *testing.T) {
 	assert(t, err, nil)
 }
 
+func TestGenericDatumWriterUnionsAndEnums(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "name", "type": ["null", "string"]},
+		{"name": "score", "type": ["null", "double"]},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B", "C"]}},
+		{"name": "kinds", "type": {"type": "array", "items": "Kind"}},
+		{"name": "next", "type": ["null", "Rec"]}
+	]}`)
+	w := NewGenericDatumWriter()
+	w.SetSchema(schema)
+	write := func(rec *GenericRecord) ([]byte, error) {
+		var buf bytes.Buffer
+		err := w.Write(rec, NewBinaryEncoder(&buf))
+		return buf.Bytes(), err
+	}
+
+	enum := NewGenericEnum([]string{"A", "B", "C"})
+	enum.Set("C")
+	next := NewGenericRecord(schema)
+	next.Set("name", "")
+	next.Set("score", math.NaN())
+	next.Set("kind", "B")
+	next.Set("kinds", []interface{}{})
+	rec := NewGenericRecord(schema)
+	rec.Set("name", "x")
+	rec.Set("score", 1.5)
+	rec.Set("kind", enum)
+	rec.Set("kinds", []interface{}{"A", enum})
+	rec.Set("next", next)
+	buf, err := write(rec)
+	assert(t, err, nil)
+	// Empty strings and NaN fall into the null branch of unions.
+	assert(t, buf, []byte{2, 2, 'x', 2, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f, 4, 4, 0, 4, 0, 2, 0, 0, 2, 0, 0})
+
+	rec.Set("kind", "D")
+	_, err = write(rec)
+	assert(t, err.Error(), "D is not a symbol of enum Kind")
+	rec.Set("kind", 1)
+	_, err = write(rec)
+	assert(t, err.Error(), "1 is not a *GenericEnum")
+	enum.SetIndex(3)
+	rec.Set("kind", enum)
+	_, err = write(rec)
+	assert(t, err.Error(), "Enum index 3 out of range for enum Kind with 3 symbols")
+}
+
+func TestGenericDatumWriterSpecificRecords(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Order", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "age", "type": "int"}
+		]}},
+		{"name": "referrer", "type": ["null", "User"]},
+		{"name": "others", "type": {"type": "array", "items": "User"}}
+	]}`)
+	type user struct {
+		Name string `avro:"name"`
+		Age  int32  `avro:"age"`
+	}
+	order := NewGenericRecord(schema)
+	order.Set("id", int64(1))
+	order.Set("user", user{Name: "ann", Age: 30})
+	order.Set("referrer", &user{Name: "bob", Age: 40})
+	order.Set("others", []interface{}{&user{Name: "cy", Age: 1}})
+
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write(order, NewBinaryEncoder(&buf)), nil)
+	decoded := NewGenericRecord(schema)
+	assert(t, NewDatumReader(schema).Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded.Get("user").(*GenericRecord).Get("name"), "ann")
+	assert(t, decoded.Get("referrer").(*GenericRecord).Get("age"), int32(40))
+	assert(t, decoded.Get("others").([]interface{})[0].(*GenericRecord).Get("name"), "cy")
+
+	order.Set("user", struct{ Name int }{1})
+	assert(t, NewDatumWriter(schema).Write(order, NewBinaryEncoder(&buf)) != nil, true)
+	order.Set("user", (*user)(nil))
+	assert(t, NewDatumWriter(schema).Write(order, NewBinaryEncoder(&buf)) != nil, true)
+}
+
+type writerNode struct {
+	Value int32       `avro:"value"`
+	Next  *writerNode `avro:"next"`
+}
+
+func TestSpecificDatumWriterCycles(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"]}
+	]}`)
+	write := func(w *SpecificDatumWriter, node *writerNode) error {
+		w.SetSchema(schema)
+		return w.Write(node, NewBinaryEncoder(&bytes.Buffer{}))
+	}
+
+	var list *writerNode
+	for i := 0; i < 100; i++ {
+		list = &writerNode{Value: int32(i), Next: list}
+	}
+	assert(t, write(NewSpecificDatumWriter(), list), nil)
+	assert(t, write(NewSpecificDatumWriter().SetMaxDepth(50), list), ErrMaxDepthExceeded)
+
+	// Shared records are written every time.
+	shared := &writerNode{Value: 1}
+	var buf bytes.Buffer
+	w := NewSpecificDatumWriter()
+	w.SetSchema(schema)
+	assert(t, w.Write(&writerNode{Next: shared}, NewBinaryEncoder(&buf)), nil)
+	assert(t, w.Write(&writerNode{Next: shared}, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), []byte{0, 2, 2, 0, 0, 2, 2, 0})
+
+	loop := &writerNode{Value: 1}
+	loop.Next = &writerNode{Value: 2, Next: loop}
+	assert(t, write(NewSpecificDatumWriter(), loop), ErrRecordCycle)
+	self := &writerNode{}
+	self.Next = self
+	assert(t, write(NewSpecificDatumWriter(), self), ErrRecordCycle)
+}
+
+func TestSpecificDatumWriterStrict(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": "int"}
+	]}`)
+	type embedded struct {
+		Age int32
+	}
+	type user struct {
+		Name     string `avro:"name"`
+		Email    string
+		Internal string `avro:"-"`
+		Phone    string `avro:"phone"`
+		cache    string
+		embedded
+	}
+	write := func(w *SpecificDatumWriter, v interface{}) ([]byte, error) {
+		var buf bytes.Buffer
+		w.SetSchema(schema)
+		err := w.Write(v, NewBinaryEncoder(&buf))
+		return buf.Bytes(), err
+	}
+
+	u := &user{Name: "a", Email: "a@b.c", embedded: embedded{Age: 1}}
+	data, err := write(NewSpecificDatumWriter(), u)
+	assert(t, err, nil)
+	assert(t, data, []byte{2, 'a', 2})
+
+	_, err = write(NewSpecificDatumWriter().SetStrict(true), u)
+	assert(t, err.Error(), "Encoding Record User: Go type avro.user has fields not in the schema: Email, Phone")
+
+	type exact struct {
+		Name     string `avro:"name"`
+		Age      int32  `avro:"age"`
+		Internal string `avro:",omit"`
+	}
+	data, err = write(NewSpecificDatumWriter().SetStrict(true), exact{Name: "a", Age: 1})
+	assert(t, err, nil)
+	assert(t, data, []byte{2, 'a', 2})
+}
+
+func TestSpecificDatumWriterEnumValidation(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Card", "fields": [
+		{"name": "suit", "type": {"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE"]}}
+	]}`)
+	w := NewSpecificDatumWriter()
+	w.SetSchema(schema)
+	write := func(suit *GenericEnum) ([]byte, error) {
+		var buf bytes.Buffer
+		err := w.Write(&struct{ Suit *GenericEnum }{suit}, NewBinaryEncoder(&buf))
+		return buf.Bytes(), err
+	}
+
+	suit := NewGenericEnum([]string{"HEART", "SPADE"})
+	suit.Set("SPADE")
+	buf, err := write(suit)
+	assert(t, err, nil)
+	assert(t, buf, []byte{2})
+
+	suit.SetIndex(2)
+	_, err = write(suit)
+	assert(t, err.Error(), "Enum index 2 out of range for enum Suit with 2 symbols")
+	suit.SetIndex(-1)
+	_, err = write(suit)
+	assert(t, err.Error(), "Enum index -1 out of range for enum Suit with 2 symbols")
+
+	swapped := NewGenericEnum([]string{"SPADE", "HEART"})
+	_, err = write(swapped)
+	assert(t, err.Error(), "Enum symbol SPADE at index 0 doesn't match symbol HEART of enum Suit")
+
+	// Enums without symbols are written by index.
+	buf, err = write(&GenericEnum{index: 1})
+	assert(t, err, nil)
+	assert(t, buf, []byte{2})
+	_, err = write(nil)
+	assert(t, err.Error(), "Invalid enum value: <nil>")
+}
+
+type celsius int32
+
+func TestDatumWriterTypedValues(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Typed", "fields": [
+		{"name": "ids", "type": {"type": "array", "items": "long"}},
+		{"name": "tags", "type": {"type": "map", "values": "string"}},
+		{"name": "flags", "type": {"type": "array", "items": "boolean"}},
+		{"name": "temp", "type": "int"},
+		{"name": "ratio", "type": "double"}
+	]}`)
+	rec := NewGenericRecord(schema)
+	rec.Set("ids", []int64{1, 1 << 40})
+	rec.Set("tags", map[string]string{"k": "v"})
+	rec.Set("flags", []bool{})
+	rec.Set("temp", int32(-3))
+	rec.Set("ratio", 0.5)
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(rec, NewBinaryEncoder(&buf)), nil)
+	generic := buf.Bytes()
+
+	type typed struct {
+		Ids   []int64           `avro:"ids"`
+		Tags  map[string]string `avro:"tags"`
+		Flags []bool            `avro:"flags"`
+		Temp  celsius           `avro:"temp"`
+		Ratio *float64          `avro:"ratio"`
+	}
+	ratio := 0.5
+	assert(t, testEncodeBytes(schema, &typed{[]int64{1, 1 << 40}, map[string]string{"k": "v"}, nil, -3, &ratio}), generic)
+
+	decoded := NewGenericRecord(schema)
+	reader := NewGenericDatumReader()
+	reader.SetSchema(schema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(generic)), nil)
+	assert(t, decoded.Get("ids"), []interface{}{int64(1), int64(1 << 40)})
+	assert(t, decoded.Get("tags"), map[string]interface{}{"k": "v"})
+
+	// Typed slices not matching the items are still checked item by item.
+	rec.Set("ids", []int32{1})
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(rec, NewBinaryEncoder(&bytes.Buffer{})).Error(), "1 is not an int64")
+}
+
+func BenchmarkGenericDatumWriter(b *testing.B) {
+	schema := MustParseSchema(`{"type": "record", "name": "Wide", "fields": [
+		{"name": "a", "type": "long"}, {"name": "b", "type": "long"}, {"name": "c", "type": "int"},
+		{"name": "d", "type": "double"}, {"name": "e", "type": "string"}, {"name": "f", "type": ["null", "string"]},
+		{"name": "g", "type": ["null", "long"]}, {"name": "h", "type": {"type": "enum", "name": "E", "symbols": ["X", "Y", "Z"]}},
+		{"name": "i", "type": {"type": "array", "items": "long"}}, {"name": "j", "type": {"type": "map", "values": "string"}}
+	]}`)
+	rec := NewGenericRecord(schema)
+	rec.Set("a", int64(1))
+	rec.Set("b", int64(1<<40))
+	rec.Set("c", int32(42))
+	rec.Set("d", 3.14)
+	rec.Set("e", "some string")
+	rec.Set("f", "other string")
+	rec.Set("g", int64(7))
+	rec.Set("h", "Z")
+	rec.Set("i", []interface{}{int64(1), int64(2), int64(3)})
+	rec.Set("j", map[string]interface{}{"k": "v"})
+	w := NewGenericDatumWriter()
+	w.SetSchema(schema)
+	var buf bytes.Buffer
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := w.Write(rec, NewBinaryEncoder(&buf)); err != nil {
+			b.Fatal(err)
+		}
+		buf.Reset()
+	}
+}
+
 func randomPrimitiveObject() *primitive {
 	p := &primitive{}
 	p.BooleanField = rand.Int()%2 == 0
@@ -364,16 +637,29 @@ func randomPrimitiveObject() *primitive {
 }
 
 func BenchmarkEncodeVarint32(b *testing.B) {
-	enc := newBinaryEncoder(nil)
+	buf := &bytes.Buffer{}
+	enc := newBinaryEncoder(buf)
 	for i := 0; i < b.N; i++ {
-		enc.encodeVarint32(int32(i))
+		enc.WriteInt(int32(i))
+		buf.Reset()
 	}
 }
 
 func BenchmarkEncodeVarint64(b *testing.B) {
-	enc := newBinaryEncoder(nil)
+	buf := &bytes.Buffer{}
+	enc := newBinaryEncoder(buf)
 	for i := 0; i < b.N; i++ {
-		enc.encodeVarint64(int64(i))
+		enc.WriteLong(int64(i))
+		buf.Reset()
+	}
+}
+
+func BenchmarkEncodeVarint64_small(b *testing.B) {
+	buf := &bytes.Buffer{}
+	enc := newBinaryEncoder(buf)
+	for i := 0; i < b.N; i++ {
+		enc.WriteLong(int64(i & 0x3f))
+		buf.Reset()
 	}
 }
 
@@ -601,3 +887,222 @@ var _Employee_schema, _Employee_schema_err = ParseSchema(`{
         }
     ]
 }`)
+
+type EventHeader struct {
+	ID        string `avro:"id"`
+	Timestamp int64  `avro:"timestamp"`
+}
+
+func TestSpecificDatumEmbedded(t *testing.T) {
+	sch := MustParseSchema(`{"type":"record","name":"Event","fields":[{"name":"id","type":"string"},{"name":"timestamp","type":"long"},{"name":"payload","type":"string"}]}`)
+
+	type byValue struct {
+		EventHeader
+		Payload string `avro:"payload"`
+	}
+	type byPointer struct {
+		*EventHeader
+		Payload string `avro:"payload"`
+	}
+
+	in := &byValue{EventHeader{"abc", 1234}, "hello"}
+	buf := testEncodeBytes(sch, in)
+	assert(t, testEncodeBytes(sch, &byPointer{&EventHeader{"abc", 1234}, "hello"}), buf)
+
+	for _, prepare := range []bool{false, true} {
+		s := maybePrepare(prepare, sch)
+		var outValue byValue
+		err := NewDatumReader(s).Read(&outValue, NewBinaryDecoder(buf))
+		assert(t, err, nil)
+		assert(t, &outValue, in)
+
+		var outPointer byPointer
+		err = NewDatumReader(s).Read(&outPointer, NewBinaryDecoder(buf))
+		assert(t, err, nil)
+		assert(t, outPointer.EventHeader, &in.EventHeader)
+		assert(t, outPointer.Payload, in.Payload)
+	}
+
+	// A nil embedded pointer writes the zero values of its fields.
+	assert(t, testEncodeBytes(sch, &byPointer{Payload: "hello"}), testEncodeBytes(sch, &byValue{Payload: "hello"}))
+}
+
+func TestValidateSpecific(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "emails", "type": {"type": "array", "items": "string"}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "manager", "type": ["null", "User"]},
+		{"name": "labels", "type": {"type": "map", "values": ["null", "int"]}}
+	]}`)
+
+	type user struct {
+		ID      int64             `avro:"id"`
+		Emails  []string          `avro:"emails"`
+		Hash    []byte            `avro:"hash"`
+		Manager *user             `avro:"manager"`
+		Labels  map[string]int32  `avro:"labels"`
+		Local   map[string]string `avro:"-"`
+	}
+	assert(t, ValidateSpecific(schema, &user{}), nil)
+	assert(t, ValidateSpecific(schema, &user{Hash: []byte{1, 2, 3, 4}}), nil)
+
+	type drifted struct {
+		ID      int               `avro:"id"`
+		Emails  []*string         `avro:"emails"`
+		Hash    []byte            `avro:"hash"`
+		Manager *drifted          `avro:"manager"`
+		Labels  map[string]string `avro:"labels"`
+		Gone    string            `avro:"gone"`
+	}
+	err := ValidateSpecific(schema, &drifted{Hash: []byte{1}})
+	assert(t, err.(*SpecificValidationError).Problems, []string{
+		"User.id: Go type int can't be written as long, needs int64",
+		"User.emails[]: Go type *string can't be written as string, needs string",
+		"User.hash: 1 bytes can't be written as fixed Hash of size 4",
+		"User.labels{}: Go type string matches no branch of union " + schema.(*RecordSchema).Fields[4].Type.(*MapSchema).Values.String(),
+		`User: Go field avro.drifted.Gone is tagged "gone" but the schema has no such field`,
+	})
+
+	type partial struct {
+		ID int64 `avro:"id"`
+	}
+	err = ValidateSpecific(schema, partial{})
+	assert(t, len(err.(*SpecificValidationError).Problems), 4)
+	assert(t, err.(*SpecificValidationError).Problems[0], "User.emails: Go type avro.partial has no field for it")
+}
+
+func TestSpecificDatumWriterOmittedFields(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Named", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "nick", "type": ["null", "string"]}
+	]}`)
+
+	type cache struct {
+		Nick []byte
+	}
+	type named struct {
+		// Would take the "name" field otherwise, being first.
+		Name   []byte `avro:",omit"`
+		*cache `avro:"-"`
+		Mu     *sync.Mutex `avro:"-"`
+		Label  string      `avro:"name"`
+		Nick   string      `avro:"nick"`
+		Stale  string      `avro:"stale,omit"`
+	}
+	assert(t, ValidateSpecific(schema, &named{}), nil)
+	names := reflectEnsureRi(reflect.TypeOf(named{})).names
+	assert(t, len(names), 2)
+
+	v := &named{Name: []byte("ignored"), cache: &cache{}, Mu: &sync.Mutex{}, Label: "label", Nick: "nick"}
+	var decoded named
+	assert(t, NewSpecificDatumReader().SetSchema(schema).Read(&decoded, NewBinaryDecoder(testEncodeBytes(schema, v))), nil)
+	assert(t, decoded, named{Label: "label", Nick: "nick"})
+}
+
+func TestDatumWriterBlockSize(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Blocks", "fields": [
+		{"name": "items", "type": {"type": "array", "items": "int"}},
+		{"name": "counts", "type": {"type": "map", "values": "long"}}
+	]}`)
+	type blocks struct {
+		Items  []int32
+		Counts map[string]int64
+	}
+	v := &blocks{Items: []int32{1, 2, 3, 4, 5}, Counts: map[string]int64{"a": 1, "b": 2, "c": 3}}
+
+	buf := &bytes.Buffer{}
+	assert(t, NewSpecificDatumWriter().SetSchema(schema).Write(v, NewBinaryEncoderBlockSize(buf, 2)), nil)
+	// Blocks of 2, 2 and 1 items, then the terminating empty block.
+	assert(t, buf.Bytes()[:11], []byte{4, 2, 4, 4, 6, 8, 2, 10, 0, 4, 2})
+
+	dec := NewBinaryDecoder(buf.Bytes())
+	var decoded blocks
+	assert(t, NewSpecificDatumReader().SetSchema(schema).Read(&decoded, dec), nil)
+	assert(t, decoded, *v)
+
+	record := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(record, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, record.Get("items"), []interface{}{int32(1), int32(2), int32(3), int32(4), int32(5)})
+	assert(t, len(record.Get("counts").(map[string]interface{})), 3)
+
+	generic := &bytes.Buffer{}
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(record, NewBinaryEncoderBlockSize(generic, 2)), nil)
+	assert(t, generic.Bytes()[:11], buf.Bytes()[:11])
+	assert(t, generic.Len(), buf.Len())
+}
+
+func TestDatumWriterSizedBlocks(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Sized", "fields": [
+		{"name": "items", "type": {"type": "array", "items": {"type": "array", "items": "int"}}},
+		{"name": "after", "type": "string"}
+	]}`)
+	type sized struct {
+		Items [][]int32
+		After string
+	}
+	v := &sized{Items: [][]int32{{1}, {2, 3}, {}}, After: "end"}
+
+	buf := &bytes.Buffer{}
+	assert(t, NewSpecificDatumWriter().SetSchema(schema).Write(v, NewBinaryEncoderSizedBlocks(buf, 2)), nil)
+	// Blocks of -2 items in 9 bytes, -1 item in 1 byte, then the terminating empty block.
+	assert(t, buf.Bytes()[:14], []byte{3, 18, 1, 2, 2, 0, 3, 4, 4, 6, 0, 1, 2, 0})
+
+	var decoded sized
+	assert(t, NewSpecificDatumReader().SetSchema(schema).Read(&decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded.Items, [][]int32{{1}, {2, 3}, {}})
+	assert(t, decoded.After, "end")
+
+	record := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetLazy(true).SetSchema(schema).Read(record, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, record.Get("after"), "end")
+	assert(t, len(record.Get("items").([]interface{})), 3)
+
+	bd := NewBinaryDecoder(buf.Bytes()).(*binaryDecoder)
+	assert(t, bd.skip(schema.(*RecordSchema).Fields[0].Type, nil, DefaultMaxDepth), nil)
+	assert(t, bd.pos, int64(15))
+
+	_, err := NewBinaryDecoder([]byte{3, 100, 0}).ReadArrayStart()
+	assert(t, err, ErrUnexpectedEOF)
+}
+
+func TestDatumWriterCanonical(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Signed", "fields": [
+		{"name": "tags", "type": {"type": "map", "values": "int"}},
+		{"name": "nested", "type": {"type": "map", "values": {"type": "map", "values": ["null", "string"]}}}
+	]}`)
+	type signed struct {
+		Tags   map[string]int32
+		Nested map[string]map[string]interface{}
+	}
+	tags := make(map[string]int32)
+	for i := 0; i < 50; i++ {
+		tags[fmt.Sprintf("k%02d", i)] = int32(i)
+	}
+	nested := map[string]map[string]interface{}{"b": {"y": "2", "x": nil}, "a": {"z": "1"}}
+
+	encode := func(writer DatumWriter, v interface{}) []byte {
+		buf := &bytes.Buffer{}
+		assert(t, writer.Write(v, NewBinaryEncoderCanonical(buf)), nil)
+		return buf.Bytes()
+	}
+	record := NewGenericRecord(schema)
+	record.Set("tags", tags)
+	record.Set("nested", map[string]interface{}{"b": map[string]interface{}{"y": "2", "x": nil}, "a": map[string]interface{}{"z": "1"}})
+	expected := encode(NewSpecificDatumWriter().SetSchema(schema), &signed{tags, nested})
+	for i := 0; i < 10; i++ {
+		assert(t, encode(NewSpecificDatumWriter().SetSchema(schema), &signed{tags, nested}), expected)
+		assert(t, encode(NewGenericDatumWriter().SetSchema(schema), record), expected)
+	}
+
+	// A single block of 50 entries, the first of which is k00.
+	assert(t, expected[:6], []byte{100, 6, 'k', '0', '0', 0})
+	sorted := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(sorted, NewBinaryDecoder(expected)), nil)
+	assert(t, sorted.Get("nested"), record.Get("nested"))
+
+	small := MustParseSchema(`{"type": "map", "values": "boolean"}`)
+	buf := &bytes.Buffer{}
+	assert(t, NewGenericDatumWriter().SetSchema(small).Write(map[string]bool{"b": true, "a": false}, NewBinaryEncoderCanonical(buf)), nil)
+	assert(t, buf.Bytes(), []byte{4, 2, 'a', 0, 2, 'b', 1, 0})
+}
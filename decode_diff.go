@@ -0,0 +1,101 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// decodeResult is the outcome of one of the decoding paths DiffDecode compares.
+type decodeResult struct {
+	name    string
+	err     error
+	read    int64  // bytes of data read
+	encoded []byte // value decoded, encoded again canonically
+}
+
+// DiffDecode decodes data of the given schema in all three ways the library offers and returns an
+// error describing how they diverge, or nil if they agree, e.g. for fuzzers and for testing schema
+// changes against sample data. The paths compared are a GenericDatumReader, a SpecificDatumReader
+// into a new pointer returned by newTarget and a GenericDatumReader followed by an identity
+// DatumProjector.
+//
+// The paths agree if they all fail, or if they all succeed reading the same number of bytes, and the
+// values they decode encode to the same bytes with NewBinaryEncoderCanonical. Which error the paths
+// fail with isn't compared, as their messages do differ.
+func DiffDecode(schema Schema, data []byte, newTarget func() interface{}) error {
+	projector, err := NewDatumProjector(schema, schema)
+	if err != nil {
+		return err
+	}
+
+	generic := decodeResult{name: "generic"}
+	generic.read, generic.err = decodeDiffRead(data, func(dec Decoder) error {
+		value, err := (&GenericDatumReader{schema: schema}).readValue(schema, dec)
+		if err != nil {
+			return err
+		}
+		generic.encoded, err = decodeDiffEncode(NewGenericDatumWriter().SetSchema(schema), value)
+		return err
+	})
+
+	specific := decodeResult{name: "specific"}
+	specific.read, specific.err = decodeDiffRead(data, func(dec Decoder) error {
+		v := newTarget()
+		if err := NewSpecificDatumReader().SetSchema(schema).Read(v, dec); err != nil {
+			return err
+		}
+		var err error
+		specific.encoded, err = decodeDiffEncode(NewDatumWriter(schema), v)
+		return err
+	})
+
+	projected := decodeResult{name: "projected"}
+	projected.read, projected.err = decodeDiffRead(data, func(dec Decoder) error {
+		value, err := (&GenericDatumReader{schema: schema}).readValue(schema, dec)
+		if err != nil {
+			return err
+		}
+		if value, err = projector.Project(value); err != nil {
+			return err
+		}
+		projected.encoded, err = decodeDiffEncode(NewGenericDatumWriter().SetSchema(schema), value)
+		return err
+	})
+
+	for _, other := range []decodeResult{specific, projected} {
+		switch {
+		case (generic.err == nil) != (other.err == nil):
+			if generic.err != nil {
+				return fmt.Errorf("Generic decoding failed but %s decoding didn't: %s", other.name, generic.err)
+			}
+			return fmt.Errorf("Generic decoding succeeded but %s decoding failed: %s", other.name, other.err)
+		case generic.err != nil:
+		case generic.read != other.read:
+			return fmt.Errorf("Generic decoding read %d bytes, %s decoding %d", generic.read, other.name, other.read)
+		case !bytes.Equal(generic.encoded, other.encoded):
+			return fmt.Errorf("Generic decoding encodes to %v, %s decoding to %v", generic.encoded, other.name, other.encoded)
+		}
+	}
+	return nil
+}
+
+// decodeDiffRead runs a decoding path on data, returning how many bytes it read. Panics count as
+// failures, as targets which don't fit the schema make SpecificDatumReaders panic.
+func decodeDiffRead(data []byte, read func(dec Decoder) error) (n int64, err error) {
+	dec := &binaryDecoder{buf: data}
+	defer func() {
+		if r := recover(); r != nil {
+			n, err = dec.pos, fmt.Errorf("Panic: %v", r)
+		}
+	}()
+	err = read(dec)
+	return dec.pos, err
+}
+
+func decodeDiffEncode(writer DatumWriter, v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writer.Write(v, NewBinaryEncoderCanonical(buf)); err != nil {
+		return nil, fmt.Errorf("Decoded value doesn't encode: %s", err)
+	}
+	return buf.Bytes(), nil
+}
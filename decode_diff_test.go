@@ -0,0 +1,44 @@
+package avro
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestDiffDecode(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Fuzz", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "long"}},
+		{"name": "value", "type": ["null", "int", "string"]},
+		{"name": "next", "type": ["null", "Fuzz"]}
+	]}`)
+	newTarget := func() interface{} { return &fuzzRecord{} }
+
+	var buf bytes.Buffer
+	record := &fuzzRecord{Name: "a", Tags: []string{"b"}, Attrs: map[string]int64{"x": 1, "y": 2}, Value: "c"}
+	record.Next = &fuzzRecord{Value: int32(3)}
+	assert(t, NewSpecificDatumWriter().SetSchema(schema).Write(record, NewBinaryEncoder(&buf)), nil)
+	valid := buf.Bytes()
+	assert(t, DiffDecode(schema, valid, newTarget), nil)
+	assert(t, DiffDecode(schema, valid[:len(valid)-1], newTarget), nil)
+
+	// A target the schema doesn't fit makes the specific decoding diverge.
+	type other struct {
+		Name int64 `avro:"name"`
+	}
+	err := DiffDecode(schema, valid, func() interface{} { return &other{} })
+	assert(t, err != nil, true)
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		input := append([]byte{}, valid...)
+		for j := random.Intn(4); j >= 0; j-- {
+			input[random.Intn(len(input))] = byte(random.Intn(256))
+		}
+		if err := DiffDecode(schema, input, newTarget); err != nil {
+			t.Fatalf("%v: %s", input, err)
+		}
+	}
+}
@@ -65,15 +65,78 @@ const maxLongBufSize = 10
 type binaryDecoder struct {
 	buf []byte
 	pos int64
+	allocationBudget
+	allocatorHook
 }
 
 type binaryDecoderReader struct {
-	r io.Reader
+	r       io.Reader
+	br      io.ByteReader // r, if it is one
+	scratch [8]byte
+	allocationBudget
+	allocatorHook
+}
+
+// budgetItemSize is what every array or map item counts for in allocation budgets.
+const budgetItemSize = 16
+
+// allocationBudget limits the bytes a decoder allocates for strings, bytes, arrays and maps.
+type allocationBudget struct {
+	budget    int64 // 0 for none
+	remaining int64
+}
+
+// resetBudget restores the whole budget, as datum readers do before reading every datum.
+func (ab *allocationBudget) resetBudget() {
+	ab.remaining = ab.budget
+}
+
+// allocate charges n bytes to the budget, or fails if there isn't enough left.
+func (ab *allocationBudget) allocate(n int64) error {
+	if ab.budget <= 0 {
+		return nil
+	}
+	if n > ab.remaining {
+		ab.remaining = 0
+		return ErrAllocationBudgetExceeded
+	}
+	ab.remaining -= n
+	return nil
+}
+
+// allocateItems charges count array or map items to the budget.
+func (ab *allocationBudget) allocateItems(count int64) error {
+	if ab.budget > 0 && count > ab.remaining/budgetItemSize {
+		ab.remaining = 0
+		return ErrAllocationBudgetExceeded
+	}
+	return ab.allocate(count * budgetItemSize)
+}
+
+// resetBudget restores the allocation budget of dec, if it has one.
+func resetBudget(dec Decoder) {
+	if d, ok := dec.(interface {
+		resetBudget()
+	}); ok {
+		d.resetBudget()
+	}
 }
 
 // NewBinaryDecoder creates a new BinaryDecoder to read from a given buffer.
 func NewBinaryDecoder(buf []byte) Decoder {
-	return &binaryDecoder{buf, 0}
+	return &binaryDecoder{buf: buf}
+}
+
+// NewBinaryDecoderBudget creates a new BinaryDecoder to read from a given buffer, that fails with
+// ErrAllocationBudgetExceeded once the strings, bytes, arrays and maps of a datum would take more
+// than budget bytes, every array or map item counting for 16 bytes on top of its value. Datum
+// readers restore the budget before reading every datum; when reading directly from the decoder,
+// it applies to all values read. Fields of lazily decoded records aren't counted.
+//
+// This bounds the memory a single crafted datum can make a reader allocate, e.g. with huge item
+// counts for arrays of nulls, which take no space in the data.
+func NewBinaryDecoderBudget(buf []byte, budget int64) Decoder {
+	return &binaryDecoder{buf: buf, allocationBudget: allocationBudget{budget: budget, remaining: budget}}
 }
 
 // NewBinaryDecoderReader creates a new BinaryDecoder to read from a given io.Reader.
@@ -82,119 +145,123 @@ func NewBinaryDecoder(buf []byte) Decoder {
 // If this is some high-latency object like a network socket or file, consider
 // passing some sort of buffered reader like a bufio.Reader.
 func NewBinaryDecoderReader(r io.Reader) Decoder {
-	return &binaryDecoderReader{
-		r: r,
-	}
+	return newBinaryDecoderReader(r)
 }
 
-// ReadInt reads an int value. Returns a decoded value and an error if it occurs.
-func (bd *binaryDecoder) ReadInt() (int32, error) {
-	if err := checkEOF(bd.buf, bd.pos, 1); err != nil {
-		return 0, ErrUnexpectedEOF
-	}
-	var value uint32
-	var b uint8
-	var offset int
-	bufLen := int64(len(bd.buf))
+// NewBinaryDecoderReaderBudget creates a new BinaryDecoder to read from a given io.Reader, with an
+// allocation budget as described for NewBinaryDecoderBudget. As the data isn't known in advance,
+// this keeps lengths read from untrusted streams from allocating arbitrary amounts of memory.
+func NewBinaryDecoderReaderBudget(r io.Reader, budget int64) Decoder {
+	bdr := newBinaryDecoderReader(r)
+	bdr.allocationBudget = allocationBudget{budget: budget, remaining: budget}
+	return bdr
+}
 
-	for {
-		if offset == maxIntBufSize {
-			return 0, ErrIntOverflow
-		}
+func newBinaryDecoderReader(r io.Reader) *binaryDecoderReader {
+	br, _ := r.(io.ByteReader)
+	return &binaryDecoderReader{r: r, br: br}
+}
 
-		if bd.pos >= bufLen {
-			return 0, ErrUnexpectedEOF
+// uvarint decodes an unsigned varint of at most maxLen bytes at the start of buf. Returns it along
+// with its length, 0 if buf ends before it does, or -1 if it's longer than maxLen bytes.
+func uvarint(buf []byte, maxLen int) (uint64, int) {
+	var value uint64
+	for i, b := range buf {
+		if i == maxLen {
+			return 0, -1
+		}
+		value |= uint64(b&0x7F) << uint(7*i)
+		if b < 0x80 {
+			return value, i + 1
 		}
+	}
+	if len(buf) >= maxLen {
+		return 0, -1
+	}
+	return 0, 0
+}
 
-		b = bd.buf[bd.pos]
-		value |= uint32(b&0x7F) << uint(7*offset)
+// readByte reads a single byte, at once if the io.Reader is an io.ByteReader such as a
+// bufio.Reader.
+func (bdr *binaryDecoderReader) readByte() (byte, error) {
+	if bdr.br != nil {
+		b, err := bdr.br.ReadByte()
+		return b, eofUnexpected(err)
+	}
+	_, err := io.ReadFull(bdr.r, bdr.scratch[:1])
+	return bdr.scratch[0], eofUnexpected(err)
+}
+
+// ReadInt reads an int value. Returns a decoded value and an error if it occurs.
+func (bd *binaryDecoder) ReadInt() (int32, error) {
+	if bd.pos < int64(len(bd.buf)) && bd.buf[bd.pos] < 0x80 {
+		// Values from -64 to 63 take a single byte.
+		value := uint32(bd.buf[bd.pos])
 		bd.pos++
-		offset++
-		if b&0x80 == 0 {
-			break
-		}
+		return int32((value >> 1) ^ -(value & 1)), nil
+	}
+	ux, n := uvarint(bd.buf[bd.pos:], maxIntBufSize)
+	if n == 0 {
+		return 0, ErrUnexpectedEOF
+	} else if n < 0 {
+		return 0, ErrIntOverflow
 	}
+	bd.pos += int64(n)
+	value := uint32(ux)
 	return int32((value >> 1) ^ -(value & 1)), nil
 }
 
 func (bdr *binaryDecoderReader) ReadInt() (int32, error) {
 	var value uint32
-	var offset int
-	var dest [1]byte
-
-	for {
+	for offset := 0; ; offset++ {
 		if offset == maxIntBufSize {
 			return 0, ErrIntOverflow
 		}
-
-		_, err := io.ReadFull(bdr.r, dest[:])
+		b, err := bdr.readByte()
 		if err != nil {
-			return 0, eofUnexpected(err)
+			return 0, err
 		}
-
-		value |= uint32(dest[0]&0x7F) << uint(7*offset)
-		offset++
-		if dest[0]&0x80 == 0 {
-			break
+		value |= uint32(b&0x7F) << uint(7*offset)
+		if b < 0x80 {
+			return int32((value >> 1) ^ -(value & 1)), nil
 		}
 	}
-	return int32((value >> 1) ^ -(value & 1)), nil
 }
 
 // ReadLong reads a long value. Returns a decoded value and an error if it occurs.
 func (bd *binaryDecoder) ReadLong() (int64, error) {
-	var value uint64
-	var b uint8
-	var offset int
-	bufLen := int64(len(bd.buf))
-
-	for {
-		if offset == maxLongBufSize {
-			return 0, ErrLongOverflow
-		}
-
-		if bd.pos >= bufLen {
-			return 0, ErrInvalidLong
-		}
-
-		b = bd.buf[bd.pos]
-		value |= uint64(b&0x7F) << uint(7*offset)
+	if bd.pos < int64(len(bd.buf)) && bd.buf[bd.pos] < 0x80 {
+		// Values from -64 to 63 take a single byte.
+		value := uint64(bd.buf[bd.pos])
 		bd.pos++
-		offset++
-
-		if b&0x80 == 0 {
-			break
-		}
+		return int64((value >> 1) ^ -(value & 1)), nil
+	}
+	value, n := uvarint(bd.buf[bd.pos:], maxLongBufSize)
+	if n == 0 {
+		return 0, ErrInvalidLong
+	} else if n < 0 {
+		return 0, ErrLongOverflow
 	}
+	bd.pos += int64(n)
 	return int64((value >> 1) ^ -(value & 1)), nil
 }
 
 // ReadLong reads a long value. Returns a decoded value and an error if it occurs.
 func (bdr *binaryDecoderReader) ReadLong() (int64, error) {
 	var value uint64
-	var offset int
-	var dest [1]byte
-
-	for {
+	for offset := 0; ; offset++ {
 		if offset == maxLongBufSize {
 			return 0, ErrLongOverflow
 		}
-
-		n, err := bdr.r.Read(dest[:])
-		if n == 0 {
-			return 0, ErrUnexpectedEOF
-		} else if err != nil {
-			return 0, eofUnexpected(err)
+		b, err := bdr.readByte()
+		if err != nil {
+			return 0, err
 		}
-
-		value |= uint64(dest[0]&0x7F) << uint(7*offset)
-		offset++
-
-		if dest[0]&0x80 == 0 {
-			break
+		value |= uint64(b&0x7F) << uint(7*offset)
+		if b < 0x80 {
+			return int64((value >> 1) ^ -(value & 1)), nil
 		}
 	}
-	return int64((value >> 1) ^ -(value & 1)), nil
 }
 
 // ReadString reads a string value. Returns a decoded value and an error if it occurs.
@@ -209,7 +276,10 @@ func (bd *binaryDecoder) ReadString() (string, error) {
 	if err := checkEOF(bd.buf, bd.pos, int(length)); err != nil {
 		return "", err
 	}
-	value := string(bd.buf[bd.pos : bd.pos+length])
+	if err := bd.allocate(length); err != nil {
+		return "", err
+	}
+	value := bd.makeString(bd.buf[bd.pos : bd.pos+length])
 	bd.pos += length
 	return value, nil
 }
@@ -221,18 +291,23 @@ func (bdr *binaryDecoderReader) ReadString() (string, error) {
 	} else if l64 < 0 {
 		return "", ErrInvalidStringLength
 	}
-	length := int(l64)
+	if err := bdr.allocate(l64); err != nil {
+		return "", err
+	}
 	/*
-		if buf, err := bdr.r.Peek(length); err == nil {
+		if buf, err := bdr.r.Peek(int(l64)); err == nil {
 			s := string(buf) // copy the buf before discarding.
 			bdr.r.Discard(length)
 			return s, nil
 		}*/
 
-	buf := make([]byte, length)
+	buf := bdr.makeBytes(l64)
 	if _, err := io.ReadFull(bdr.r, buf); err != nil {
 		return "", eofUnexpected(err)
 	}
+	if bdr.allocator != nil {
+		return allocatedString(buf), nil
+	}
 	return string(buf), nil
 }
 
@@ -252,12 +327,10 @@ func (bd *binaryDecoder) ReadBoolean() (bool, error) {
 
 // ReadBoolean reads a boolean value. Returns a decoded value and an error if it occurs.
 func (bdr *binaryDecoderReader) ReadBoolean() (bool, error) {
-	var dest [1]byte
-	_, err := io.ReadFull(bdr.r, dest[:])
+	b, err := bdr.readByte()
 	if err != nil {
-		return false, eofUnexpected(err)
+		return false, err
 	}
-	b := dest[0]
 	if b != 0 && b != 1 {
 		err = ErrInvalidBool
 	}
@@ -266,6 +339,12 @@ func (bdr *binaryDecoderReader) ReadBoolean() (bool, error) {
 
 // ReadBytes reads a bytes value. Returns a decoded value and an error if it occurs.
 func (bd *binaryDecoder) ReadBytes() ([]byte, error) {
+	return bd.readBytesInto(nil)
+}
+
+// readBytesInto reads a bytes value into buf if it is not nil and is large enough, or a new slice
+// otherwise.
+func (bd *binaryDecoder) readBytesInto(buf []byte) ([]byte, error) {
 	//TODO make something with these if's!!
 	if err := checkEOF(bd.buf, bd.pos, 1); err != nil {
 		return nil, ErrUnexpectedEOF
@@ -281,7 +360,14 @@ func (bd *binaryDecoder) ReadBytes() ([]byte, error) {
 		return nil, ErrUnexpectedEOF
 	}
 
-	bytes := make([]byte, length)
+	bytes := buf
+	if bytes == nil || int64(cap(bytes)) < length {
+		if err = bd.allocate(length); err != nil {
+			return nil, err
+		}
+		bytes = bd.makeBytes(length)
+	}
+	bytes = bytes[:length]
 	copy(bytes[:], bd.buf[bd.pos:bd.pos+length])
 	bd.pos += length
 	return bytes, err
@@ -289,6 +375,12 @@ func (bd *binaryDecoder) ReadBytes() ([]byte, error) {
 
 // ReadBytes reads a bytes value. Returns a decoded value and an error if it occurs.
 func (bdr *binaryDecoderReader) ReadBytes() ([]byte, error) {
+	return bdr.readBytesInto(nil)
+}
+
+// readBytesInto reads a bytes value into buf if it is not nil and is large enough, or a new slice
+// otherwise.
+func (bdr *binaryDecoderReader) readBytesInto(buf []byte) ([]byte, error) {
 	length, err := bdr.ReadLong()
 	if err != nil {
 		return nil, err
@@ -296,7 +388,13 @@ func (bdr *binaryDecoderReader) ReadBytes() ([]byte, error) {
 		return nil, ErrNegativeBytesLength
 	}
 
-	buf := make([]byte, length)
+	if buf == nil || int64(cap(buf)) < length {
+		if err = bdr.allocate(length); err != nil {
+			return nil, err
+		}
+		buf = bdr.makeBytes(length)
+	}
+	buf = buf[:length]
 	_, err = io.ReadFull(bdr.r, buf)
 	return buf, eofUnexpected(err)
 }
@@ -315,11 +413,10 @@ func (bd *binaryDecoder) ReadFloat() (float32, error) {
 
 // ReadFloat reads a float value. Returns a decoded value and an error if it occurs.
 func (bdr *binaryDecoderReader) ReadFloat() (f float32, err error) {
-	var dest [4]byte
-	if _, err = io.ReadFull(bdr.r, dest[:]); err != nil {
+	if _, err = io.ReadFull(bdr.r, bdr.scratch[:4]); err != nil {
 		return f, eofUnexpected(err)
 	}
-	bits := binary.LittleEndian.Uint32(dest[:])
+	bits := binary.LittleEndian.Uint32(bdr.scratch[:4])
 	f = math.Float32frombits(bits)
 	return f, nil
 }
@@ -338,11 +435,10 @@ func (bd *binaryDecoder) ReadDouble() (float64, error) {
 
 // ReadDouble reads a double value. Returns a decoded value and an error if it occurs.
 func (bdr *binaryDecoderReader) ReadDouble() (f float64, err error) {
-	var dest [8]byte
-	if _, err = io.ReadFull(bdr.r, dest[:]); err != nil {
+	if _, err = io.ReadFull(bdr.r, bdr.scratch[:8]); err != nil {
 		return f, eofUnexpected(err)
 	}
-	bits := binary.LittleEndian.Uint64(dest[:])
+	bits := binary.LittleEndian.Uint64(bdr.scratch[:8])
 	f = math.Float64frombits(bits)
 	return f, nil
 }
@@ -386,19 +482,30 @@ func checkEOF(buf []byte, pos int64, length int) error {
 }
 
 func (bd *binaryDecoder) readItemCount() (int64, error) {
-	count, err := bd.ReadLong()
+	count, _, err := bd.readBlockHeader()
 	if err != nil {
-		return 0, err
+		return count, err
 	}
+	return count, bd.allocateItems(count)
+}
 
-	if count < 0 {
-		_, err = bd.ReadLong()
-		if err != nil {
-			return 0, err
-		}
-		count = -count
+// readBlockHeader reads the item count of an array or map block, and its size in bytes if the
+// writer gave it, or -1. The size is checked against the remaining data.
+func (bd *binaryDecoder) readBlockHeader() (count int64, size int64, err error) {
+	count, err = bd.ReadLong()
+	if err != nil || count >= 0 {
+		return count, -1, err
+	}
+
+	size, err = bd.ReadLong()
+	if err != nil {
+		return 0, -1, err
+	} else if size < 0 {
+		return 0, -1, ErrNegativeBytesLength
+	} else if err = checkEOF(bd.buf, bd.pos, int(size)); err != nil {
+		return 0, -1, err
 	}
-	return count, err
+	return -count, size, nil
 }
 
 func (bdr *binaryDecoderReader) readItemCount() (int64, error) {
@@ -414,7 +521,7 @@ func (bdr *binaryDecoderReader) readItemCount() (int64, error) {
 		}
 		count = -count
 	}
-	return count, err
+	return count, bdr.allocateItems(count)
 }
 
 func eofUnexpected(err error) error {
@@ -0,0 +1,97 @@
+package avro
+
+import (
+	"hash"
+	"io"
+)
+
+// DigestEncoder is an Encoder which hashes the bytes it writes as it goes, so that checksums of the
+// output can be produced without reading it again. It keeps both the digest of the current datum and
+// that of all bytes written.
+//
+// It is also an io.Writer, so it can be given to NewDataFileWriter to get the digest of a whole
+// container file.
+type DigestEncoder struct {
+	Encoder
+	datum hash.Hash
+	total hash.Hash
+	out   io.Writer
+}
+
+// NewDigestEncoder creates a new DigestEncoder writing to w, with hashes made with newHash, e.g.
+// sha256.New or the constructor of any other hash.Hash. Values are encoded by an Encoder made with
+// newEncoder writing to w, e.g. NewBinaryEncoderCanonical to sign datums, or NewBinaryEncoder if nil.
+func NewDigestEncoder(w io.Writer, newHash func() hash.Hash, newEncoder func(io.Writer) Encoder) *DigestEncoder {
+	if newEncoder == nil {
+		newEncoder = NewBinaryEncoder
+	}
+	e := &DigestEncoder{datum: newHash(), total: newHash()}
+	e.out = io.MultiWriter(w, e.datum, e.total)
+	e.Encoder = newEncoder(e.out)
+	return e
+}
+
+// Write writes p as is and hashes it. It implements io.Writer.
+func (e *DigestEncoder) Write(p []byte) (int, error) {
+	return e.out.Write(p)
+}
+
+// DatumDigest returns the digest of the bytes written since the previous call to DatumDigest, which
+// are those of one datum when called after writing every one of them.
+func (e *DigestEncoder) DatumDigest() []byte {
+	sum := e.datum.Sum(nil)
+	e.datum.Reset()
+	return sum
+}
+
+// Digest returns the digest of all bytes written.
+func (e *DigestEncoder) Digest() []byte {
+	return e.total.Sum(nil)
+}
+
+func (e *DigestEncoder) maxBlockItems() int {
+	if be, ok := e.Encoder.(blockEncoder); ok {
+		return be.maxBlockItems()
+	}
+	return 0
+}
+
+func (e *DigestEncoder) sizedBlocks() bool {
+	if be, ok := e.Encoder.(blockEncoder); ok {
+		return be.sizedBlocks()
+	}
+	return false
+}
+
+func (e *DigestEncoder) sortedKeys() bool {
+	if be, ok := e.Encoder.(blockEncoder); ok {
+		return be.sortedKeys()
+	}
+	return false
+}
+
+// newBlockEncoder returns an encoder for the items of a block, which are hashed once written out
+// through e as a whole.
+func (e *DigestEncoder) newBlockEncoder(w io.Writer) Encoder {
+	if be, ok := e.Encoder.(blockEncoder); ok {
+		return be.newBlockEncoder(w)
+	}
+	return NewBinaryEncoder(w)
+}
+
+// limited returns a DigestEncoder hashing what the limited encoder it wraps lets through.
+func (e *DigestEncoder) limited(max int) Encoder {
+	le, ok := e.Encoder.(limitableEncoder)
+	if !ok {
+		return nil
+	}
+	inner := le.limited(max)
+	if inner == nil {
+		return nil
+	}
+	return &DigestEncoder{Encoder: inner, datum: e.datum, total: e.total, out: e.out}
+}
+
+func (e *DigestEncoder) sizeGuard() *sizeGuard {
+	return encoderSizeGuard(e.Encoder)
+}
@@ -0,0 +1,57 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"hash/fnv"
+	"io"
+	"testing"
+)
+
+func TestDigestEncoder(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Entry", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "tags", "type": {"type": "map", "values": "string"}}
+	]}`)
+	entries := []*GenericRecord{NewGenericRecord(schema), NewGenericRecord(schema)}
+	entries[0].Set("id", int64(1))
+	entries[0].Set("tags", map[string]interface{}{"b": "2", "a": "1"})
+	entries[1].Set("id", int64(2))
+	entries[1].Set("tags", map[string]interface{}{})
+
+	buf := &bytes.Buffer{}
+	enc := NewDigestEncoder(buf, sha256.New, NewBinaryEncoderCanonical)
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(schema)
+	var start int
+	for _, entry := range entries {
+		assert(t, writer.Write(entry, enc), nil)
+		sum := sha256.Sum256(buf.Bytes()[start:])
+		assert(t, enc.DatumDigest(), sum[:])
+		start = buf.Len()
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	assert(t, enc.Digest(), sum[:])
+	assert(t, buf.Bytes()[:9], []byte{2, 4, 2, 'a', 2, '1', 2, 'b', 2})
+
+	// Block settings of the wrapped encoder are kept.
+	buf.Reset()
+	sized := NewDigestEncoder(buf, sha256.New, func(w io.Writer) Encoder { return NewBinaryEncoderSizedBlocks(w, 1) })
+	assert(t, writer.Write(entries[0], sized), nil)
+	sum = sha256.Sum256(buf.Bytes())
+	assert(t, sized.DatumDigest(), sum[:])
+	assert(t, buf.Bytes()[:3], []byte{2, 1, 8})
+
+	buf.Reset()
+	file := NewDigestEncoder(buf, func() hash.Hash { return fnv.New64a() }, nil)
+	dfw, err := NewDataFileWriter(file, schema, NewGenericDatumWriter())
+	assert(t, err, nil)
+	for _, entry := range entries {
+		assert(t, dfw.Write(entry), nil)
+	}
+	assert(t, dfw.Close(), nil)
+	h := fnv.New64a()
+	h.Write(buf.Bytes())
+	assert(t, file.Digest(), h.Sum(nil))
+}
@@ -51,6 +51,20 @@ This could be mapped to a SpecificRecord by using structs:
 If the `avro:` struct tag is omitted, the default mapping lower-cases the first
 letter only. It's better to just explicitly define where possible.
 
+Untagged embedded structs (or exported pointers to structs) are traversed as if
+their fields were declared on the outer struct, so a shared set of fields can be
+composed into several record types:
+
+	type EventHeader struct {
+		ID        string `avro:"id"`
+		Timestamp int64  `avro:"timestamp"`
+	}
+
+	type LoginEvent struct {
+		EventHeader
+		User string `avro:"user"`
+	}
+
 Mapped types:
 
   - avro 'int' is always 32-bit, so maps to golang 'int32'
@@ -0,0 +1,145 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EncodedSizeError is returned by DatumWriters with a maximum encoded size when a datum doesn't fit
+// in it. Encoding stops on the field it went over with, and the output then holds an incomplete
+// datum of at most MaxSize bytes, which should be discarded.
+type EncodedSizeError struct {
+	MaxSize int
+
+	// Path of the field being written when the datum went over MaxSize, e.g. "Order.lines[].sku",
+	// with items of arrays and values of maps as "[]".
+	Path string
+}
+
+// Error describes how the datum went over its maximum size.
+func (e *EncodedSizeError) Error() string {
+	return fmt.Sprintf("Encoded datum exceeds %d bytes at %s", e.MaxSize, e.Path)
+}
+
+var errEncodedSizeExceeded = errors.New("Encoded size exceeded")
+
+// sizeGuard is an io.Writer letting through no more than a number of bytes, and dropping any write
+// that doesn't fit.
+type sizeGuard struct {
+	w         io.Writer
+	remaining int
+	max       int // of the whole datum, for errors
+	exceeded  bool
+}
+
+func (g *sizeGuard) Write(p []byte) (int, error) {
+	if g.exceeded || len(p) > g.remaining {
+		g.exceeded = true
+		return 0, errEncodedSizeExceeded
+	}
+	g.remaining -= len(p)
+	return g.w.Write(p)
+}
+
+func (g *sizeGuard) WriteString(s string) (int, error) {
+	if g.exceeded || len(s) > g.remaining {
+		g.exceeded = true
+		return 0, errEncodedSizeExceeded
+	}
+	g.remaining -= len(s)
+	return io.WriteString(g.w, s)
+}
+
+func (g *sizeGuard) WriteByte(c byte) error {
+	_, err := g.Write([]byte{c})
+	return err
+}
+
+// limitableEncoder is implemented by Encoders that can be limited to a maximum encoded size,
+// including those wrapping one that can be.
+type limitableEncoder interface {
+	// limited returns an encoder writing like this one but no more than max bytes, or nil if the
+	// encoder it wraps can't be limited.
+	limited(max int) Encoder
+
+	// sizeGuard returns the guard of a limited encoder, or nil if it isn't limited.
+	sizeGuard() *sizeGuard
+}
+
+// limit has be write through a sizeGuard letting through the given number of bytes.
+func (be *binaryEncoder) limit(remaining, max int) {
+	be.guard = &sizeGuard{w: be.buffer, remaining: remaining, max: max}
+	be.buffer, be.byteWriter = be.guard, be.guard
+}
+
+func (be *binaryEncoder) limited(max int) Encoder {
+	limited := *be
+	limited.limit(max, max)
+	return &limited
+}
+
+func (be *binaryEncoder) sizeGuard() *sizeGuard {
+	return be.guard
+}
+
+// limitEncoder returns an encoder writing like enc but no more than max bytes. Only binary encoders,
+// or encoders wrapping one such as DigestEncoder, can be limited.
+func limitEncoder(enc Encoder, max int) (Encoder, error) {
+	var limited Encoder
+	if le, ok := enc.(limitableEncoder); ok {
+		limited = le.limited(max)
+	}
+	if limited == nil {
+		return nil, fmt.Errorf("A maximum encoded size needs a binary Encoder, not %T", enc)
+	}
+	return limited, nil
+}
+
+// encodedSizeExceeded returns whether enc was limited and went over its limit.
+func encodedSizeExceeded(enc Encoder) bool {
+	guard := encoderSizeGuard(enc)
+	return guard != nil && guard.exceeded
+}
+
+// encoderSizeGuard returns the guard of enc if it was limited, or nil.
+func encoderSizeGuard(enc Encoder) *sizeGuard {
+	if le, ok := enc.(limitableEncoder); ok {
+		return le.sizeGuard()
+	}
+	return nil
+}
+
+// encodedSizeErrorAt returns an *EncodedSizeError at the given path if enc went over its limit and
+// err is nil, or err with its path prefixed by at if it's an *EncodedSizeError already. Other errors
+// are returned as is.
+func encodedSizeErrorAt(enc Encoder, err error, at string) error {
+	if e, ok := err.(*EncodedSizeError); ok {
+		if e.Path != "" && !strings.HasPrefix(e.Path, "[") {
+			at += "."
+		}
+		e.Path = at + e.Path
+		return e
+	}
+	if err == nil && encodedSizeExceeded(enc) {
+		return &EncodedSizeError{MaxSize: encoderSizeGuard(enc).max, Path: at}
+	}
+	return err
+}
+
+// writeLimited writes a datum of the given schema with write, to enc but no more than max bytes.
+func writeLimited(enc Encoder, max int, schema Schema, write func(enc Encoder) error) error {
+	limited, err := limitEncoder(enc, max)
+	if err != nil {
+		return err
+	}
+	if err = write(limited); err != nil || encodedSizeExceeded(limited) {
+		root := "datum"
+		if schema != nil {
+			root = schema.GetName()
+		}
+		return encodedSizeErrorAt(limited, err, root)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+var encodedSizeSchema = MustParseSchema(`{"type": "record", "name": "Order", "fields": [
+	{"name": "id", "type": "long"},
+	{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+		{"name": "qty", "type": "int"},
+		{"name": "sku", "type": "string"}
+	]}}}
+]}`)
+
+type encodedSizeLine struct {
+	Qty int32  `avro:"qty"`
+	Sku string `avro:"sku"`
+}
+
+type encodedSizeOrder struct {
+	ID    int64              `avro:"id"`
+	Lines []*encodedSizeLine `avro:"lines"`
+}
+
+func TestSetMaxEncodedSize(t *testing.T) {
+	order := &encodedSizeOrder{ID: 1, Lines: []*encodedSizeLine{{1, "a"}, {2, strings.Repeat("b", 100)}}}
+	var full bytes.Buffer
+	assert(t, NewSpecificDatumWriter().SetSchema(encodedSizeSchema).Write(order, NewBinaryEncoder(&full)), nil)
+
+	var buf bytes.Buffer
+	writer := NewSpecificDatumWriter().SetMaxEncodedSize(full.Len())
+	writer.SetSchema(encodedSizeSchema)
+	assert(t, writer.Write(order, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), full.Bytes())
+
+	buf.Reset()
+	writer.SetMaxEncodedSize(50)
+	err := writer.Write(order, NewBinaryEncoder(&buf))
+	assert(t, err, &EncodedSizeError{MaxSize: 50, Path: "Order.lines[].sku"})
+	assert(t, err.Error(), "Encoded datum exceeds 50 bytes at Order.lines[].sku")
+	assert(t, buf.Len() <= 50, true)
+
+	record := NewGenericRecord(encodedSizeSchema)
+	record.Set("id", int64(1))
+	record.Set("lines", []interface{}{})
+	generic := NewGenericDatumWriter().SetMaxEncodedSize(1)
+	generic.SetSchema(encodedSizeSchema)
+	assert(t, generic.Write(record, NewBinaryEncoder(&buf)), &EncodedSizeError{MaxSize: 1, Path: "Order.lines"})
+
+	// Struct values of GenericRecords and sized blocks are guarded too.
+	record.Set("lines", []interface{}{&encodedSizeLine{1, strings.Repeat("c", 100)}})
+	generic.SetMaxEncodedSize(20)
+	assert(t, generic.Write(record, NewBinaryEncoderSizedBlocks(&buf, 0)), &EncodedSizeError{MaxSize: 20, Path: "Order.lines[].sku"})
+
+	// Wrapped binary encoders are limited and only hash what gets written.
+	buf.Reset()
+	digest := NewDigestEncoder(&buf, sha256.New, nil)
+	assert(t, generic.Write(record, digest), &EncodedSizeError{MaxSize: 20, Path: "Order.lines[].sku"})
+	sum := sha256.Sum256(buf.Bytes())
+	assert(t, digest.DatumDigest(), sum[:])
+	assert(t, generic.Write(record, &DigestEncoder{}).Error(), "A maximum encoded size needs a binary Encoder, not *avro.DigestEncoder")
+}
@@ -55,7 +55,13 @@ type Encoder interface {
 
 // BinaryEncoder implements Encoder and provides low-level support for serializing Avro values.
 type binaryEncoder struct {
-	buffer io.Writer
+	buffer     io.Writer
+	byteWriter io.ByteWriter // buffer, if it is one
+	blockSize  int
+	sized      bool
+	sorted     bool
+	guard      *sizeGuard // buffer, if limited to a maximum size
+	scratch    [binary.MaxVarintLen64]byte
 }
 
 // NewBinaryEncoder creates a new BinaryEncoder that will write to a given io.Writer.
@@ -63,8 +69,40 @@ func NewBinaryEncoder(buffer io.Writer) Encoder {
 	return newBinaryEncoder(buffer)
 }
 
+// NewBinaryEncoderBlockSize creates a new BinaryEncoder that will write to a given io.Writer, and
+// have DatumWriters split arrays and maps with more than blockSize items into several blocks of at
+// most blockSize items. This lets readers process huge collections block by block.
+func NewBinaryEncoderBlockSize(buffer io.Writer, blockSize int) Encoder {
+	be := newBinaryEncoder(buffer)
+	be.blockSize = blockSize
+	return be
+}
+
+// NewBinaryEncoderSizedBlocks is like NewBinaryEncoderBlockSize, but has every array and map block
+// written with a negative item count followed by the block size in bytes, as the spec allows, so
+// that readers can skip whole blocks without decoding them. A blockSize of 0 writes every
+// collection as a single block.
+func NewBinaryEncoderSizedBlocks(buffer io.Writer, blockSize int) Encoder {
+	be := newBinaryEncoder(buffer)
+	be.blockSize = blockSize
+	be.sized = true
+	return be
+}
+
+// NewBinaryEncoderCanonical creates a new BinaryEncoder that will write to a given io.Writer, and
+// have DatumWriters encode equal datums to identical bytes, so that they can be hashed, signed or
+// compared across producers: map entries are written sorted by key, every array and map is written
+// as a single block, and values of unions are written with the first branch of the union that
+// accepts them. Equal datums must have values of the same Go types for that.
+func NewBinaryEncoderCanonical(buffer io.Writer) Encoder {
+	be := newBinaryEncoder(buffer)
+	be.sorted = true
+	return be
+}
+
 func newBinaryEncoder(buffer io.Writer) *binaryEncoder {
-	return &binaryEncoder{buffer: buffer}
+	byteWriter, _ := buffer.(io.ByteWriter)
+	return &binaryEncoder{buffer: buffer, byteWriter: byteWriter}
 }
 
 // WriteNull writes a null value. Doesn't actually do anything in this implementation.
@@ -87,26 +125,34 @@ func (be *binaryEncoder) WriteBoolean(x bool) {
 
 // WriteInt writes an int value.
 func (be *binaryEncoder) WriteInt(x int32) {
-	_, _ = be.buffer.Write(be.encodeVarint32(x))
+	be.writeUvarint(uint64(uint32(x<<1) ^ uint32(x>>31)))
 }
 
 // WriteLong writes a long value.
 func (be *binaryEncoder) WriteLong(x int64) {
-	_, _ = be.buffer.Write(be.encodeVarint64(x))
+	be.writeUvarint(uint64(x<<1) ^ uint64(x>>63))
+}
+
+// writeUvarint writes a zig-zag encoded value. Values of a single byte, which most counts, lengths
+// and union indexes are, go straight to buffers which are an io.ByteWriter.
+func (be *binaryEncoder) writeUvarint(ux uint64) {
+	if ux < 0x80 && be.byteWriter != nil {
+		_ = be.byteWriter.WriteByte(byte(ux))
+		return
+	}
+	_, _ = be.buffer.Write(be.scratch[:binary.PutUvarint(be.scratch[:], ux)])
 }
 
 // WriteFloat writes a float value.
 func (be *binaryEncoder) WriteFloat(x float32) {
-	bytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bytes, math.Float32bits(x))
-	_, _ = be.buffer.Write(bytes)
+	binary.LittleEndian.PutUint32(be.scratch[:4], math.Float32bits(x))
+	_, _ = be.buffer.Write(be.scratch[:4])
 }
 
 // WriteDouble writes a double value.
 func (be *binaryEncoder) WriteDouble(x float64) {
-	bytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bytes, math.Float64bits(x))
-	_, _ = be.buffer.Write(bytes)
+	binary.LittleEndian.PutUint64(be.scratch[:8], math.Float64bits(x))
+	_, _ = be.buffer.Write(be.scratch[:8])
 }
 
 // WriteRaw writes raw bytes to this Encoder.
@@ -151,40 +197,28 @@ func (be *binaryEncoder) WriteMapNext(count int64) {
 	be.writeItemCount(count)
 }
 
-func (be *binaryEncoder) writeItemCount(count int64) {
-	be.WriteLong(count)
+func (be *binaryEncoder) maxBlockItems() int {
+	return be.blockSize
 }
 
-func (be *binaryEncoder) encodeVarint32(n int32) []byte {
-	var buf [5]byte
-	ux := uint32(n) << 1
-	if n < 0 {
-		ux = ^ux
-	}
-	i := 0
-	for ux >= 0x80 {
-		buf[i] = byte(ux) | 0x80
-		ux >>= 7
-		i++
-	}
-	buf[i] = byte(ux)
+func (be *binaryEncoder) sizedBlocks() bool {
+	return be.sized
+}
 
-	return buf[0 : i+1]
+func (be *binaryEncoder) sortedKeys() bool {
+	return be.sorted
 }
 
-func (be *binaryEncoder) encodeVarint64(x int64) []byte {
-	var buf [10]byte
-	ux := uint64(x) << 1
-	if x < 0 {
-		ux = ^ux
+func (be *binaryEncoder) newBlockEncoder(w io.Writer) Encoder {
+	enc := newBinaryEncoder(w)
+	enc.blockSize, enc.sized, enc.sorted = be.blockSize, be.sized, be.sorted
+	if be.guard != nil {
+		// Blocks can't take more than what's left.
+		enc.limit(be.guard.remaining, be.guard.max)
 	}
-	i := 0
-	for ux >= 0x80 {
-		buf[i] = byte(ux) | 0x80
-		ux >>= 7
-		i++
-	}
-	buf[i] = byte(ux)
+	return enc
+}
 
-	return buf[0 : i+1]
+func (be *binaryEncoder) writeItemCount(count int64) {
+	be.WriteLong(count)
 }
@@ -48,9 +48,36 @@ var ErrUnionTypeOverflow = errors.New("Union type overflow")
 // Happens when avro schema is unparsable or is invalid in any other way.
 var ErrInvalidSchema = errors.New("Invalid schema")
 
+// Happens when trying to set a custom property whose name is a reserved schema attribute.
+var ErrReservedProperty = errors.New("Property name is reserved")
+
+// Happens when trying to set a custom property on a union, which can't have any.
+var ErrUnionProperty = errors.New("Unions can't have properties")
+
+// Happens when a value that should be a slice is anything else.
+var ErrNotSlice = errors.New("Value is not a slice")
+
+// Happens when a value that should be a pointer to a slice is anything else.
+var ErrNotSlicePointer = errors.New("Value is not a pointer to a slice")
+
+// Happens when a message doesn't start with a known header identifying its writer schema.
+var ErrUnknownMessageFormat = errors.New("Unknown message format")
+
 // Happens when a datum reader has no set schema.
 var ErrSchemaNotSet = errors.New("Schema not set")
 
+// Happens when decoding, projecting or writing data nesting more records than the maximum depth allows.
+var ErrMaxDepthExceeded = errors.New("Maximum record depth exceeded")
+
+// Happens when writing a struct which references itself, directly or through the records it contains.
+var ErrRecordCycle = errors.New("Record references itself")
+
+// Happens when a datum would make a decoder allocate more than its allocation budget.
+var ErrAllocationBudgetExceeded = errors.New("Allocation budget exceeded")
+
+// Happens when comparing data containing maps, which have no sort order.
+var ErrMapNotComparable = errors.New("Maps can't be compared")
+
 // Specify a custom error message for indicating which necessary field in the struct is missing.
 func NewFieldDoesNotExistError(field string) error {
 	return errors.New(fmt.Sprintf("Field does not exist: [%v]", field))
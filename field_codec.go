@@ -0,0 +1,87 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EncodeField encodes v with the schema of the given field of a record, producing the same bytes the
+// field takes in encoded records, e.g. to derive Kafka message keys from one field without encoding
+// whole records. The field may be a dotted path to a field of a nested record, such as
+// "user.address", going through optional records too, so sub-records can be encoded on their own.
+//
+// Structs and pointers to them are written the way a SpecificDatumWriter would, other values the
+// way a GenericDatumWriter would.
+func EncodeField(schema *RecordSchema, field string, v interface{}) ([]byte, error) {
+	s, err := fieldSchemaAt(schema, field)
+	if err != nil {
+		return nil, err
+	}
+	var writer DatumWriter
+	if rv := reflect.Indirect(reflect.ValueOf(v)); rv.Kind() == reflect.Struct && !isGenericValue(v) {
+		writer = NewSpecificDatumWriter().SetSchema(s)
+	} else {
+		writer = NewGenericDatumWriter().SetSchema(s)
+	}
+	buf := &bytes.Buffer{}
+	if err = writer.Write(v, NewBinaryEncoder(buf)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeField decodes data encoded by EncodeField for the given field of a record into v, which must
+// be a pointer. Records are read the way NewDatumReader would, into structs or GenericRecords, other
+// values the way a GenericDatumReader would, into a pointer to a value of the matching Go type. v
+// can also be an *interface{} to get the value a GenericDatumReader decodes.
+func DecodeField(schema *RecordSchema, field string, data []byte, v interface{}) error {
+	s, err := fieldSchemaAt(schema, field)
+	if err != nil {
+		return err
+	}
+	dec := NewBinaryDecoder(data)
+	if p, ok := v.(*interface{}); ok {
+		value, err := (&GenericDatumReader{schema: s}).readValue(s, dec)
+		if err != nil {
+			return err
+		}
+		*p = value
+		return nil
+	}
+	if IsRecord(s) {
+		return NewDatumReader(s).Read(v, dec)
+	}
+	return NewGenericDatumReader().SetSchema(s).Read(v, dec)
+}
+
+func isGenericValue(v interface{}) bool {
+	switch v.(type) {
+	case *GenericRecord, **GenericRecord, *GenericEnum, GenericEnum:
+		return true
+	}
+	return false
+}
+
+// fieldSchemaAt returns the schema of the field at a dotted path in a record.
+func fieldSchemaAt(schema *RecordSchema, path string) (Schema, error) {
+	var s Schema = schema
+	for _, name := range strings.Split(path, ".") {
+		record, ok := resolveSchema(s).(*RecordSchema)
+		if union, isUnion := s.(*UnionSchema); isUnion {
+			if types := union.NonNullTypes(); len(types) == 1 {
+				record, ok = resolveSchema(types[0]).(*RecordSchema)
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("Field %s of %s is not in a record", name, path)
+		}
+		field, _, ok := record.Field(name)
+		if !ok {
+			return nil, fmt.Errorf("Record %s has no field %s", record.FullName(), name)
+		}
+		s = field.Type
+	}
+	return s, nil
+}
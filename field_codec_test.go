@@ -0,0 +1,65 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeField(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Order", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "address", "type": ["null", {"type": "record", "name": "Address", "fields": [
+				{"name": "city", "type": "string"}
+			]}]}
+		]}}
+	]}`).(*RecordSchema)
+
+	data, err := EncodeField(schema, "id", int64(-2))
+	assert(t, err, nil)
+	assert(t, data, []byte{3})
+	var id int64
+	assert(t, DecodeField(schema, "id", data, &id), nil)
+	assert(t, id, int64(-2))
+
+	// Sub-records, also from structs, encode as they do within records, with the union branch of
+	// optional ones.
+	type address struct {
+		City string `avro:"city"`
+	}
+	data, err = EncodeField(schema, "user.address", &address{City: "Oslo"})
+	assert(t, err, nil)
+	assert(t, data, []byte{2, 8, 'O', 's', 'l', 'o'})
+	var value interface{}
+	assert(t, DecodeField(schema, "user.address", data, &value), nil)
+	assert(t, value.(*GenericRecord).Get("city"), "Oslo")
+	var city string
+	assert(t, DecodeField(schema, "user.address.city", data[1:], &city), nil)
+	assert(t, city, "Oslo")
+
+	user := NewGenericRecord(schema.Fields[1].Type)
+	user.Set("name", "ann")
+	user.Set("address", nil)
+	data, err = EncodeField(schema, "user", user)
+	assert(t, err, nil)
+	assert(t, data, []byte{6, 'a', 'n', 'n', 0})
+
+	order := NewGenericRecord(schema)
+	order.Set("id", int64(7))
+	order.Set("user", user)
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write(order, NewBinaryEncoder(&buf)), nil)
+	assert(t, bytes.HasSuffix(buf.Bytes(), data), true)
+
+	var decoded *GenericRecord
+	assert(t, DecodeField(schema, "user", data, &decoded), nil)
+	assert(t, decoded.Get("name"), "ann")
+
+	_, err = EncodeField(schema, "user.missing", "x")
+	assert(t, err.Error(), "Record User has no field missing")
+	_, err = EncodeField(schema, "id.value", "x")
+	assert(t, err.Error(), "Field value of id.value is not in a record")
+	_, err = EncodeField(schema, "id", "x")
+	assert(t, err != nil, true)
+}
@@ -0,0 +1,140 @@
+package avro
+
+import "sync"
+
+// FieldCodec reads and writes the values of a record field, as GenericDatumReader and
+// GenericDatumWriter do.
+type FieldCodec interface {
+	// Read decodes a value of the field.
+	Read(dec Decoder) (interface{}, error)
+
+	// Write encodes v as a value of the field.
+	Write(v interface{}, enc Encoder) error
+}
+
+// FieldMiddleware wraps the codec of a record field, returning the codec to use instead, e.g. to
+// convert units, trim strings or count values as they are read or written. It can return next as
+// is for fields it leaves alone.
+//
+// Middleware is called once per field when the codec of the field is first needed, rather than per
+// value, so codecs should be built there and not in Read and Write. Fields of recursive records may
+// be wrapped once per depth they are read at.
+type FieldMiddleware func(field *SchemaField, next FieldCodec) FieldCodec
+
+// FieldCodecFuncs implements FieldCodec with functions, delegating to Next whenever one of them is
+// nil, so that middleware can wrap reads or writes only.
+type FieldCodecFuncs struct {
+	Next      FieldCodec
+	ReadFunc  func(dec Decoder) (interface{}, error)
+	WriteFunc func(v interface{}, enc Encoder) error
+}
+
+// Read decodes a value with ReadFunc, or Next if nil.
+func (c *FieldCodecFuncs) Read(dec Decoder) (interface{}, error) {
+	if c.ReadFunc == nil {
+		return c.Next.Read(dec)
+	}
+	return c.ReadFunc(dec)
+}
+
+// Write encodes v with WriteFunc, or Next if nil.
+func (c *FieldCodecFuncs) Write(v interface{}, enc Encoder) error {
+	if c.WriteFunc == nil {
+		return c.Next.Write(v, enc)
+	}
+	return c.WriteFunc(v, enc)
+}
+
+// genericFieldCodec is the FieldCodec middleware wraps, reading values as reader does for a record
+// nested in depth ones and writing them with write.
+type genericFieldCodec struct {
+	reader *GenericDatumReader
+	field  *SchemaField
+	depth  int
+	write  genericWriteFunc
+}
+
+func (c *genericFieldCodec) Read(dec Decoder) (interface{}, error) {
+	return c.reader.read(c.field.Type, dec, c.depth)
+}
+
+func (c *genericFieldCodec) Write(v interface{}, enc Encoder) error {
+	return c.write(v, enc)
+}
+
+// wrapFieldCodec wraps codec with middleware, the first of which gets to see values first.
+func wrapFieldCodec(field *SchemaField, codec FieldCodec, middleware []FieldMiddleware) FieldCodec {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		codec = middleware[i](field, codec)
+	}
+	return codec
+}
+
+// fieldCodecCache holds the wrapped codecs of the fields a GenericDatumReader has read, by field
+// and depth, since the codecs read nested records at the depth they were built for.
+type fieldCodecCache struct {
+	lock   sync.RWMutex
+	codecs map[fieldCodecKey]FieldCodec
+}
+
+type fieldCodecKey struct {
+	field *SchemaField
+	depth int
+}
+
+// SetFieldMiddleware makes Read decode the fields of records, nested ones included, with codecs
+// wrapped by the given middleware, the first of which gets to see values first, or stop wrapping
+// them if there is none. Middleware doesn't apply to redacted fields, nor to records read lazily.
+// Must be called before calling Read.
+func (reader *GenericDatumReader) SetFieldMiddleware(middleware ...FieldMiddleware) *GenericDatumReader {
+	reader.middleware = middleware
+	reader.fieldCodecs = nil
+	if len(middleware) > 0 {
+		reader.fieldCodecs = &fieldCodecCache{codecs: make(map[fieldCodecKey]FieldCodec)}
+	}
+	return reader
+}
+
+// fieldCodec returns the wrapped codec of a field of a record nested in depth ones.
+func (reader *GenericDatumReader) fieldCodec(field *SchemaField, depth int) FieldCodec {
+	cache := reader.fieldCodecs
+	key := fieldCodecKey{field, depth}
+	cache.lock.RLock()
+	codec, ok := cache.codecs[key]
+	cache.lock.RUnlock()
+	if ok {
+		return codec
+	}
+
+	codec = wrapFieldCodec(field, &genericFieldCodec{reader, field, depth, ensureGenericWriteFunc(field.Type)}, reader.middleware)
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if existing, ok := cache.codecs[key]; ok {
+		return existing
+	}
+	cache.codecs[key] = codec
+	return codec
+}
+
+// SetFieldMiddleware makes Write encode the fields of records, nested ones included, with codecs
+// wrapped by the given middleware, the first of which gets to see values first, or stop wrapping
+// them if there is none. Values are passed to the middleware after missing ones are replaced by
+// the defaults of their fields.
+// Must be called before calling Write.
+func (writer *GenericDatumWriter) SetFieldMiddleware(middleware ...FieldMiddleware) *GenericDatumWriter {
+	writer.middleware = middleware
+	writer.buildWriteFunc()
+	return writer
+}
+
+// buildWriteFunc builds the write function of this writer, which differs from the one shared by all
+// writers of its schema if it has middleware.
+func (writer *GenericDatumWriter) buildWriteFunc() {
+	writer.writeFunc = nil
+	if writer.schema != nil && len(writer.middleware) > 0 {
+		b := &genericWriteFuncBuilder{records: make(map[Schema]*genericWriteFunc), middleware: writer.middleware}
+		writer.writeFunc = b.build(writer.schema)
+	} else if writer.schema != nil {
+		writer.writeFunc = ensureGenericWriteFunc(writer.schema)
+	}
+}
@@ -0,0 +1,101 @@
+package avro
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const middlewareSchema = `{"type": "record", "name": "Reading", "fields": [
+	{"name": "sensor", "type": "string"},
+	{"name": "celsius", "type": "double", "unit": "K"},
+	{"name": "next", "type": ["null", "Reading"], "default": null}
+]}`
+
+// kelvin stores fields with a unit of K in kelvins, while records hold them in degrees celsius.
+func kelvin(field *SchemaField, next FieldCodec) FieldCodec {
+	if unit, _ := field.Prop("unit"); unit != "K" {
+		return next
+	}
+	return &FieldCodecFuncs{
+		ReadFunc: func(dec Decoder) (interface{}, error) {
+			v, err := next.Read(dec)
+			if err != nil {
+				return nil, err
+			}
+			return v.(float64) - 273.15, nil
+		},
+		WriteFunc: func(v interface{}, enc Encoder) error {
+			return next.Write(v.(float64)+273.15, enc)
+		},
+	}
+}
+
+func trimStrings(field *SchemaField, next FieldCodec) FieldCodec {
+	if field.Type.Type() != String {
+		return next
+	}
+	return &FieldCodecFuncs{Next: next, ReadFunc: func(dec Decoder) (interface{}, error) {
+		v, err := next.Read(dec)
+		if s, ok := v.(string); ok {
+			v = strings.TrimSpace(s)
+		}
+		return v, err
+	}}
+}
+
+func TestFieldMiddleware(t *testing.T) {
+	schema := MustParseSchema(middlewareSchema)
+	inner := NewGenericRecord(schema)
+	inner.Set("sensor", " b ")
+	inner.Set("celsius", float64(-273.15))
+	outer := NewGenericRecord(schema)
+	outer.Set("sensor", "a")
+	outer.Set("celsius", float64(20))
+	outer.Set("next", inner)
+
+	var buf bytes.Buffer
+	writer := NewGenericDatumWriter().SetFieldMiddleware(kelvin)
+	writer.SetSchema(schema)
+	assert(t, writer.Write(outer, NewBinaryEncoder(&buf)), nil)
+
+	// Values are stored in kelvins, for nested records too.
+	plain := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(plain, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, plain.Get("celsius"), float64(293.15))
+	assert(t, plain.Get("next").(*GenericRecord).Get("celsius"), float64(0))
+
+	var wrapped []string
+	count := func(field *SchemaField, next FieldCodec) FieldCodec {
+		wrapped = append(wrapped, field.Name)
+		return next
+	}
+	reader := NewGenericDatumReader().SetFieldMiddleware(count, trimStrings, kelvin)
+	reader.SetSchema(schema)
+	for i := 0; i < 2; i++ {
+		decoded := NewGenericRecord(schema)
+		assert(t, reader.Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+		assert(t, decoded.Get("celsius"), float64(20))
+		assert(t, decoded.Get("next").(*GenericRecord).Get("sensor"), "b")
+		assert(t, decoded.Get("next").(*GenericRecord).Get("celsius"), float64(-273.15))
+	}
+	// Codecs are wrapped once per field and depth.
+	assert(t, wrapped, []string{"sensor", "celsius", "next", "sensor", "celsius", "next"})
+
+	// Reading in place goes through the middleware too.
+	reader = NewGenericDatumReader().SetFieldMiddleware(kelvin).SetReuseBuffers(true)
+	reader.SetSchema(schema)
+	reused := NewGenericRecord(schema)
+	reused.Set("sensor", "")
+	reused.Set("celsius", float64(0))
+	reused.Set("next", nil)
+	assert(t, reader.Read(reused, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, reused.Get("celsius"), float64(20))
+
+	// Writers without middleware aren't affected.
+	buf.Reset()
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(outer, NewBinaryEncoder(&buf)), nil)
+	decoded := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded.Get("celsius"), float64(20))
+}
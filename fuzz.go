@@ -0,0 +1,61 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Decode limits of the datum readers of fuzz targets, so that crafted inputs can't make them nest
+// or allocate their way out of memory, which fuzzers would report as crashes.
+const (
+	fuzzMaxDepth         = 100
+	fuzzAllocationBudget = 1 << 20
+)
+
+// NewFuzzTarget returns a fuzz function for data of the given schema, in the form go-fuzz and
+// similar fuzzers expect, so that projects can fuzz decoding their own schemas without boilerplate:
+//
+//	var fuzz = avro.NewFuzzTarget(schema, func() interface{} { return &MyRecord{} })
+//
+//	func Fuzz(data []byte) int { return fuzz(data) }
+//
+// newTarget returns a new pointer to decode every input into, as NewDatumReader accepts, e.g. a
+// pointer to a struct or a **GenericRecord. Inputs are decoded with a maximum record depth and an
+// allocation budget, so that they fail instead of exhausting memory. The fuzz function returns 0
+// for inputs which don't decode, and 1 for those which do.
+//
+// Inputs which decode are encoded again, decoded from that and encoded once more, and the fuzz
+// function panics if that fails, or if both encodings differ, as data which round-trips through the
+// library should stay the same.
+func NewFuzzTarget(schema Schema, newTarget func() interface{}) func([]byte) int {
+	reader := &anyDatumReader{
+		sdr: SpecificDatumReader{schema: schema, sDatumReader: sDatumReader{maxDepth: fuzzMaxDepth}},
+		gdr: GenericDatumReader{schema: schema, maxDepth: fuzzMaxDepth},
+	}
+	writer := NewDatumWriter(schema)
+	roundTrip := func(data []byte) ([]byte, error) {
+		v := newTarget()
+		if err := reader.Read(v, NewBinaryDecoderBudget(data, fuzzAllocationBudget)); err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		if err := writer.Write(v, NewBinaryEncoderCanonical(buf)); err != nil {
+			panic(fmt.Sprintf("Decoded value doesn't encode: %s", err))
+		}
+		return buf.Bytes(), nil
+	}
+
+	return func(data []byte) int {
+		encoded, err := roundTrip(data)
+		if err != nil {
+			return 0
+		}
+		again, err := roundTrip(encoded)
+		if err != nil {
+			panic(fmt.Sprintf("Encoded value doesn't decode: %s", err))
+		} else if !bytes.Equal(encoded, again) {
+			panic(fmt.Sprintf("Value encodes to %v, then to %v once decoded", encoded, again))
+		}
+		return 1
+	}
+}
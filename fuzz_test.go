@@ -0,0 +1,53 @@
+package avro
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+type fuzzRecord struct {
+	Name  string           `avro:"name"`
+	Tags  []string         `avro:"tags"`
+	Attrs map[string]int64 `avro:"attrs"`
+	Value interface{}      `avro:"value"`
+	Next  *fuzzRecord      `avro:"next"`
+}
+
+func TestNewFuzzTarget(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Fuzz", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "long"}},
+		{"name": "value", "type": ["null", "int", "string"]},
+		{"name": "next", "type": ["null", "Fuzz"]}
+	]}`)
+	specific := NewFuzzTarget(schema, func() interface{} { return &fuzzRecord{} })
+	generic := NewFuzzTarget(schema, func() interface{} { return new(*GenericRecord) })
+
+	var buf bytes.Buffer
+	record := &fuzzRecord{Name: "a", Tags: []string{"b", "c"}, Attrs: map[string]int64{"x": 1, "y": 2}, Value: int32(3)}
+	record.Next = &fuzzRecord{Name: "d", Value: "e"}
+	assert(t, NewSpecificDatumWriter().SetSchema(schema).Write(record, NewBinaryEncoder(&buf)), nil)
+	valid := buf.Bytes()
+	assert(t, specific(valid), 1)
+	assert(t, generic(valid), 1)
+
+	assert(t, specific([]byte{2, 'a', 0xff}), 0)
+	// Many items taking no space in the data are stopped by the allocation budget.
+	assert(t, specific([]byte{0, 0xfe, 0xff, 0xff, 0xff, 0x0f}), 0)
+	// So are records nesting too deep.
+	deep := bytes.Repeat([]byte{0, 0, 0, 0, 2}, 200)
+	assert(t, specific(deep), 0)
+	assert(t, generic(deep), 0)
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		input := append([]byte{}, valid...)
+		for j := random.Intn(4); j >= 0; j-- {
+			input[random.Intn(len(input))] = byte(random.Intn(256))
+		}
+		specific(input[:random.Intn(len(input)+1)])
+		generic(input)
+	}
+}
@@ -2,17 +2,36 @@ package fuzzes
 
 import (
 	"bytes"
+	"flag"
 	"io/ioutil"
 	"log"
 	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 
 	avro "gopkg.in/avro.v0"
 )
 
+var updateCorpus = flag.Bool("corpus", false, "write the generated inputs to the fuzz corpora")
+
+// corpusFolder returns the corpus folder inputs are generated in, which is a temporary one unless
+// the tests are run with -corpus, and a function to call once done.
+func corpusFolder(t *testing.T, corpus string) (string, func()) {
+	if *updateCorpus {
+		return corpus, func() {}
+	}
+	folder, err := ioutil.TempDir("", "corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return folder, func() { os.RemoveAll(folder) }
+}
+
 func TestGenerateSpecificComplexFuzz(t *testing.T) {
-	const folder = "specificreadercomplex/corpus/"
+	folder, done := corpusFolder(t, "specificreadercomplex/corpus")
+	defer done()
 	w := avro.NewDatumWriter(ComplexSchema)
 
 	var buf bytes.Buffer
@@ -31,7 +50,7 @@ func TestGenerateSpecificComplexFuzz(t *testing.T) {
 		if err != nil {
 			log.Fatal(err)
 		}
-		ioutil.WriteFile(folder+name, buf.Bytes(), 0644)
+		ioutil.WriteFile(filepath.Join(folder, name), buf.Bytes(), 0644)
 	}
 
 	writeOut("strings-only.bin", &Complex{
@@ -52,6 +71,9 @@ func TestGenerateSpecificComplexFuzz(t *testing.T) {
 	writeOut("union-bool.bin", &Complex{
 		UnionField: true,
 	})
+	if err := avro.GenerateCorpus(ComplexSchema, folder, 20, nil); err != nil {
+		log.Fatal(err)
+	}
 }
 
 var fixed16 = []byte("0123456789abcdef")
@@ -67,7 +89,8 @@ func fixComplex(v *Complex) {
 }
 
 func TestGenerateGenericFuzz(t *testing.T) {
-	const folder = "genericreader/corpus/"
+	folder, done := corpusFolder(t, "genericreader/corpus")
+	defer done()
 	w := avro.NewDatumWriter(CombinedSchema)
 
 	var buf bytes.Buffer
@@ -81,7 +104,7 @@ func TestGenerateGenericFuzz(t *testing.T) {
 		if err != nil {
 			log.Fatal(err)
 		}
-		ioutil.WriteFile(folder+name, buf.Bytes(), 0644)
+		ioutil.WriteFile(filepath.Join(folder, name), buf.Bytes(), 0644)
 	}
 
 	writeOut("strings-only.bin", &Combined{
@@ -109,4 +132,7 @@ func TestGenerateGenericFuzz(t *testing.T) {
 			StringField:  "abcdefg",
 		},
 	})
+	if err := avro.GenerateCorpus(CombinedSchema, folder, 20, nil); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -3,6 +3,8 @@
 # Usage: run-fuzz.sh <module>
 # 
 # Example: run-fuzz.sh specificreadercomplex
+#
+# The corpora can be regenerated with: go test ./fuzzes -corpus
 
 FUZZARCHIVE=${1}-fuzz.zip
 
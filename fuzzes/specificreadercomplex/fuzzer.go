@@ -3,29 +3,19 @@
 package specificreadercomplex
 
 import (
-	"bytes"
-
 	avro "gopkg.in/avro.v0"
 	"gopkg.in/avro.v0/fuzzes"
 )
 
-var buf bytes.Buffer
-var reader = avro.NewSpecificDatumReader().SetSchema(fuzzes.ComplexSchema)
-var prepared = avro.NewSpecificDatumReader().SetSchema(avro.Prepare(fuzzes.ComplexSchema))
+func newComplex() interface{} { return &fuzzes.Complex{} }
 
-func Fuzz(input []byte) int {
-	var dest fuzzes.Complex
-	// First run on un-prepared schema
-	err := reader.Read(&dest, avro.NewBinaryDecoder(input))
-	if err != nil {
-		return 0
-	}
+var fuzz = avro.NewFuzzTarget(fuzzes.ComplexSchema, newComplex)
+var prepared = avro.NewFuzzTarget(avro.Prepare(fuzzes.ComplexSchema), newComplex)
 
-	// Second run on prepared schema
-	dest = fuzzes.Complex{}
-	err = prepared.Read(&dest, avro.NewBinaryDecoder(input))
-	if err != nil {
+func Fuzz(input []byte) int {
+	// First run on un-prepared schema, then on prepared schema
+	if fuzz(input) == 0 {
 		return 0
 	}
-	return 1
+	return prepared(input)
 }
@@ -15,7 +15,9 @@ limitations under the License. */
 
 package avro
 
-import "encoding/json"
+import (
+	"encoding/json"
+)
 
 // AvroRecord is an interface for anything that has an Avro schema and can be serialized/deserialized by this library.
 type AvroRecord interface {
@@ -23,29 +25,109 @@ type AvroRecord interface {
 	Schema() Schema
 }
 
+// FieldPresence tells where the value of a GenericRecord field comes from.
+type FieldPresence int
+
+const (
+	// FieldUnset means the field was never given a value.
+	FieldUnset FieldPresence = iota
+	// FieldSet means the value was set explicitly with Set or SetByIndex.
+	FieldSet
+	// FieldFromData means the value was decoded from data, even if it is null.
+	FieldFromData
+	// FieldFromDefault means the field was missing from the writer schema and got the default of the reader schema.
+	FieldFromDefault
+	// FieldMasked means the value decoded was replaced with the mask of a Redaction.
+	FieldMasked
+	// FieldDropped means the value decoded was discarded because of a Redaction. Dropped fields are
+	// null and left out of Map and String.
+	FieldDropped
+)
+
 // GenericRecord is a generic instance of a record schema.
-// Fields are accessible by their name.
+// Fields are accessible by their name or, for hot loops, by their position in the schema.
 type GenericRecord struct {
-	fields map[string]interface{}
-	schema Schema
+	values   []interface{}
+	presence []FieldPresence
+	lazy     *lazyFields // set if the record was decoded lazily
+	layout   *recordLayout
+	extra    map[string]interface{} // values set for names not defined in the schema
+	schema   Schema
 }
 
 // NewGenericRecord creates a new GenericRecord.
 func NewGenericRecord(schema Schema) *GenericRecord {
+	layout := ensureRecordLayout(schema)
 	return &GenericRecord{
-		fields: make(map[string]interface{}),
-		schema: schema,
+		values:   make([]interface{}, len(layout.names)),
+		presence: make([]FieldPresence, len(layout.names)),
+		layout:   layout,
+		schema:   schema,
 	}
 }
 
 // Get gets a value by its name.
 func (gr *GenericRecord) Get(name string) interface{} {
-	return gr.fields[name]
+	if gr.layout != nil {
+		if i, ok := gr.layout.index[name]; ok {
+			return gr.GetByIndex(i)
+		}
+	}
+	return gr.extra[name]
 }
 
 // Set sets a value for a given name.
+// Values of record fields may be *GenericRecords, or structs and pointers to them, which writers
+// encode as a SpecificDatumWriter would.
 func (gr *GenericRecord) Set(name string, value interface{}) {
-	gr.fields[name] = value
+	if gr.layout != nil {
+		if i, ok := gr.layout.index[name]; ok {
+			gr.setByIndex(i, value, FieldSet)
+			return
+		}
+	}
+	if gr.extra == nil {
+		gr.extra = make(map[string]interface{})
+	}
+	gr.extra[name] = value
+}
+
+// GetByIndex gets a value by the position of its field in the record schema.
+// Panics if the index is out of range.
+func (gr *GenericRecord) GetByIndex(index int) interface{} {
+	if gr.lazy != nil {
+		gr.lazy.decode(gr, index)
+	}
+	return gr.values[index]
+}
+
+// SetByIndex sets a value by the position of its field in the record schema.
+// Panics if the index is out of range.
+func (gr *GenericRecord) SetByIndex(index int, value interface{}) {
+	gr.setByIndex(index, value, FieldSet)
+}
+
+func (gr *GenericRecord) setByIndex(index int, value interface{}, presence FieldPresence) {
+	gr.values[index] = value
+	gr.presence[index] = presence
+	if gr.lazy != nil {
+		gr.lazy.raw[index] = nil
+	}
+}
+
+// Presence tells where the value of the field with the given name comes from, e.g. to tell apart
+// a null sent by the writer from a null default filled in for a field the writer doesn't know.
+// Values set for names not defined in the schema are always FieldSet.
+func (gr *GenericRecord) Presence(name string) FieldPresence {
+	if gr.layout != nil {
+		if i, ok := gr.layout.index[name]; ok {
+			return gr.presence[i]
+		}
+	}
+	if _, ok := gr.extra[name]; ok {
+		return FieldSet
+	}
+	return FieldUnset
 }
 
 // Schema returns a schema for this GenericRecord.
@@ -63,10 +145,11 @@ func (gr *GenericRecord) String() string {
 	return string(buf)
 }
 
-// Map returns a map representation of this GenericRecord.
+// Map returns a map representation of this GenericRecord, holding the fields which were given a
+// value.
 func (gr *GenericRecord) Map() map[string]interface{} {
-	m := make(map[string]interface{})
-	for k, v := range gr.fields {
+	m := make(map[string]interface{}, len(gr.values)+len(gr.extra))
+	gr.each(func(k string, v interface{}) {
 		if r, ok := v.(*GenericRecord); ok {
 			v = r.Map()
 		}
@@ -81,6 +164,50 @@ func (gr *GenericRecord) Map() map[string]interface{} {
 			v = slice
 		}
 		m[k] = v
-	}
+	})
 	return m
 }
+
+// each calls f for every schema field that has a value in order, followed by any values set for
+// names not in the schema.
+func (gr *GenericRecord) each(f func(name string, value interface{})) {
+	if gr.layout != nil {
+		for i, name := range gr.layout.names {
+			if gr.presence[i] == FieldUnset || gr.presence[i] == FieldDropped {
+				continue
+			}
+			f(name, gr.GetByIndex(i))
+		}
+	}
+	for name, value := range gr.extra {
+		f(name, value)
+	}
+}
+
+// recordLayout maps record field names to their positions. It is computed once per schema and shared
+// between all GenericRecords of that schema.
+type recordLayout struct {
+	names []string
+	index map[string]int
+}
+
+func ensureRecordLayout(schema Schema) *recordLayout {
+	switch schema.(type) {
+	case *RecordSchema, *preparedRecordSchema, *RecursiveSchema:
+		rs := resolveSchema(schema).(*RecordSchema)
+		rs.layoutOnce.Do(func() {
+			rs.layout = buildRecordLayout(rs.Fields)
+		})
+		return rs.layout
+	}
+	return buildRecordLayout(nil)
+}
+
+func buildRecordLayout(fields []*SchemaField) *recordLayout {
+	layout := &recordLayout{names: make([]string, len(fields)), index: make(map[string]int)}
+	for i, field := range fields {
+		layout.names[i] = field.Name
+		layout.index[field.Name] = i
+	}
+	return layout
+}
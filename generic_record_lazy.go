@@ -0,0 +1,216 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+)
+
+// lazyFields holds the raw data of the fields of a lazily decoded GenericRecord.
+type lazyFields struct {
+	reader *GenericDatumReader
+	fields []*SchemaField
+	depth  int      // of the fields
+	raw    [][]byte // nil once decoded
+}
+
+func (lazy *lazyFields) decode(record *GenericRecord, index int) {
+	raw := lazy.raw[index]
+	if raw == nil {
+		return
+	}
+	value, err := lazy.reader.read(lazy.fields[index].Type, NewBinaryDecoder(raw), lazy.depth)
+	if err != nil {
+		// The data was checked when skipping over it, and fields which skipping can't check are
+		// decoded right away. Should decoding fail anyway, the field reads as null rather than
+		// panicking in Get.
+		value = nil
+	}
+	record.values[index] = fieldValue(value)
+	lazy.raw[index] = nil
+}
+
+func (reader *GenericDatumReader) mapLazyRecord(record *GenericRecord, schema *RecordSchema, bd *binaryDecoder, depth int) error {
+	lazy := &lazyFields{reader: reader, fields: schema.Fields, depth: depth, raw: make([][]byte, len(schema.Fields))}
+	for i, field := range schema.Fields {
+		if reader.reachesRecordType(field.Type, make(map[Schema]bool)) {
+			// Skipping can't check that the data decodes into the registered types.
+			if err := reader.decodeLazyField(record, i, field, bd, depth); err != nil {
+				return err
+			}
+			continue
+		}
+		start := bd.pos
+		// The data is checked so that decoding it later can't fail.
+		if err := bd.skip(field.Type, reader, maxDepth(reader.maxDepth)-depth); err != nil {
+			return err
+		}
+		if reader.redaction != nil && reader.redaction.redacts(field) {
+			reader.setRedacted(record, i)
+			continue
+		}
+		// Field data which decodes to nothing doesn't need to be kept around.
+		if bd.pos > start {
+			lazy.raw[i] = bd.buf[start:bd.pos:bd.pos]
+		}
+		record.presence[i] = FieldFromData
+	}
+	record.lazy = lazy
+	return nil
+}
+
+// decodeLazyField decodes a field of a lazily decoded record right away.
+func (reader *GenericDatumReader) decodeLazyField(record *GenericRecord, index int, field *SchemaField, bd *binaryDecoder, depth int) error {
+	if redacted, err := reader.redact(record, index, field, bd, depth); redacted {
+		return err
+	}
+	value, err := reader.read(field.Type, bd, depth)
+	if err != nil {
+		return err
+	}
+	record.setByIndex(index, fieldValue(value), FieldFromData)
+	return nil
+}
+
+// reachesRecordType tells whether values of schema may hold records of a type registered with
+// RegisterRecordType.
+func (reader *GenericDatumReader) reachesRecordType(schema Schema, seen map[Schema]bool) bool {
+	if len(reader.recordTypes) == 0 {
+		return false
+	}
+	schema = resolveSchema(schema)
+	if seen[schema] {
+		return false
+	}
+	seen[schema] = true
+	switch schema.Type() {
+	case Record:
+		if _, ok := reader.recordTypes[schema.FullName()]; ok {
+			return true
+		}
+		for _, field := range assertRecordSchema(schema).Fields {
+			if reader.reachesRecordType(field.Type, seen) {
+				return true
+			}
+		}
+	case Array:
+		return reader.reachesRecordType(schema.(*ArraySchema).Items, seen)
+	case Map:
+		return reader.reachesRecordType(schema.(*MapSchema).Values, seen)
+	case Union:
+		for _, t := range schema.(*UnionSchema).Types {
+			if reader.reachesRecordType(t, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skip moves past a value of the given schema without decoding it. If check is set, the value is
+// still checked to decode with it, unknown enums included, otherwise array and map blocks prefixed
+// with their size in bytes are skipped at once. Fails with ErrMaxDepthExceeded if the value nests
+// more than depth records.
+func (bd *binaryDecoder) skip(schema Schema, check *GenericDatumReader, depth int) error {
+	switch schema.Type() {
+	case Null:
+		return nil
+	case Boolean:
+		_, err := bd.ReadBoolean()
+		return err
+	case Int:
+		_, err := bd.ReadInt()
+		return err
+	case Long:
+		_, err := bd.ReadLong()
+		return err
+	case Float:
+		return bd.skipBytes(4)
+	case Double:
+		return bd.skipBytes(8)
+	case Bytes, String:
+		length, err := bd.ReadLong()
+		if err != nil {
+			return err
+		} else if length < 0 {
+			return ErrNegativeBytesLength
+		}
+		return bd.skipBytes(length)
+	case Fixed:
+		return bd.skipBytes(int64(schema.(*FixedSchema).Size))
+	case Enum:
+		index, err := bd.ReadEnum()
+		if err != nil {
+			return err
+		} else if index < 0 {
+			return errors.New("Enum index invalid!")
+		} else if index >= int32(len(schema.(*EnumSchema).Symbols)) && (check == nil || check.unknownEnum != UnknownEnumSymbol) {
+			return &unknownEnumIndexError{index, schema.GetName()}
+		}
+		return nil
+	case Union:
+		index, err := bd.ReadInt()
+		if err != nil {
+			return err
+		} else if index < 0 || index >= int32(len(schema.(*UnionSchema).Types)) {
+			return ErrUnionTypeOverflow
+		}
+		branch := schema.(*UnionSchema).Types[index]
+		err = bd.skip(branch, check, depth)
+		if _, unknown := err.(*unknownEnumIndexError); unknown && check != nil && check.unknownEnum == UnknownEnumNull &&
+			branch.Type() == Enum && hasNullBranch(schema.(*UnionSchema)) {
+			return nil
+		}
+		return err
+	case Array:
+		return bd.skipBlocks(check != nil, func() error { return bd.skip(schema.(*ArraySchema).Items, check, depth) })
+	case Map:
+		return bd.skipBlocks(check != nil, func() error {
+			if err := bd.skip(&StringSchema{}, check, depth); err != nil {
+				return err
+			}
+			return bd.skip(schema.(*MapSchema).Values, check, depth)
+		})
+	case Record:
+		if depth <= 0 {
+			return ErrMaxDepthExceeded
+		}
+		for _, field := range assertRecordSchema(schema).Fields {
+			if err := bd.skip(field.Type, check, depth-1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Recursive:
+		return bd.skip(schema.(*RecursiveSchema).Actual, check, depth)
+	}
+
+	return fmt.Errorf("Unknown field type: %d", schema.Type())
+}
+
+// skipBlocks moves past the blocks of an array or map, calling skipItem for each item unless
+// the block size is known and its items needn't be checked.
+func (bd *binaryDecoder) skipBlocks(check bool, skipItem func() error) error {
+	for {
+		count, size, err := bd.readBlockHeader()
+		if err != nil || count == 0 {
+			return err
+		}
+		if size >= 0 && !check {
+			bd.pos += size
+			continue
+		}
+		for ; count > 0; count-- {
+			if err = skipItem(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (bd *binaryDecoder) skipBytes(n int64) error {
+	if int64(len(bd.buf))-bd.pos < n {
+		return ErrUnexpectedEOF
+	}
+	bd.pos += n
+	return nil
+}
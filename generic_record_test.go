@@ -0,0 +1,168 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+var genericRecordSchema = MustParseSchema(`{
+    "type": "record",
+    "name": "Rec",
+    "fields": [
+        {"name": "a", "type": "int"},
+        {"name": "b", "type": "string"}
+    ]
+}`)
+
+func TestGenericRecordByIndex(t *testing.T) {
+	rec := NewGenericRecord(genericRecordSchema)
+	rec.Set("b", "hello")
+	rec.SetByIndex(0, int32(7))
+
+	assert(t, rec.Get("a"), int32(7))
+	assert(t, rec.GetByIndex(1), "hello")
+	assert(t, rec.Map(), map[string]interface{}{"a": int32(7), "b": "hello"})
+}
+
+func TestGenericRecordUnknownField(t *testing.T) {
+	rec := NewGenericRecord(genericRecordSchema)
+	rec.Set("c", true)
+	assert(t, rec.Get("c"), true)
+	assert(t, rec.Get("a"), nil)
+	assert(t, rec.Map(), map[string]interface{}{"c": true})
+	assert(t, rec.String(), `{"c":true}`)
+
+	var zero GenericRecord
+	zero.Set("a", int32(1))
+	assert(t, zero.Get("a"), int32(1))
+}
+
+func TestGenericRecordRoundTrip(t *testing.T) {
+	rec := NewGenericRecord(genericRecordSchema)
+	rec.Set("a", int32(42))
+	rec.Set("b", "foo")
+	var buf bytes.Buffer
+	err := NewDatumWriter(genericRecordSchema).Write(rec, NewBinaryEncoder(&buf))
+	assert(t, err, nil)
+
+	dest := NewGenericRecord(genericRecordSchema)
+	err = NewDatumReader(genericRecordSchema).Read(dest, NewBinaryDecoder(buf.Bytes()))
+	assert(t, err, nil)
+	assert(t, dest.GetByIndex(0), int32(42))
+	assert(t, dest.Get("b"), "foo")
+}
+
+func BenchmarkGenericRecordGetSet(b *testing.B) {
+	rec := NewGenericRecord(genericRecordSchema)
+	for i := 0; i < b.N; i++ {
+		rec.Set("a", int32(i))
+		_ = rec.Get("b")
+	}
+}
+
+func TestGenericRecordPresence(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "sent", "type": ["null", "string"]}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "sent", "type": ["null", "string"]},
+		{"name": "added", "type": ["null", "string"], "default": null}
+	]}`)
+
+	record := NewGenericRecord(writer)
+	assert(t, record.Presence("sent"), FieldUnset)
+	record.Set("sent", nil)
+	assert(t, record.Presence("sent"), FieldSet)
+	record.Set("other", 1)
+	assert(t, record.Presence("other"), FieldSet)
+	assert(t, record.Presence("missing"), FieldUnset)
+
+	decoded := decodeGeneric(t, writer, record).(*GenericRecord)
+	assert(t, decoded.Presence("sent"), FieldFromData)
+
+	projector, err := NewDatumProjector(writer, reader)
+	assert(t, err, nil)
+	value, err := projector.Project(decoded)
+	assert(t, err, nil)
+	projected := value.(*GenericRecord)
+	assert(t, projected.Get("sent"), nil)
+	assert(t, projected.Presence("sent"), FieldFromData)
+	assert(t, projected.Get("added"), nil)
+	assert(t, projected.Presence("added"), FieldFromDefault)
+}
+
+func TestGenericRecordLazy(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Wide", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "tags", "type": {"type": "map", "values": {"type": "array", "items": "string"}}},
+		{"name": "blob", "type": {"type": "fixed", "name": "Blob", "size": 3}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+		{"name": "nothing", "type": "null"},
+		{"name": "inner", "type": ["null", {"type": "record", "name": "Inner", "fields": [{"name": "x", "type": "double"}]}]},
+		{"name": "name", "type": "string"}
+	]}`)
+	inner := NewGenericRecord(schema.(*RecordSchema).Fields[5].Type.(*UnionSchema).Types[1])
+	inner.Set("x", 1.5)
+	record := NewGenericRecord(schema)
+	record.Set("id", int64(42))
+	record.Set("tags", map[string]interface{}{"k": []interface{}{"v1", "v2"}})
+	record.Set("blob", []byte{1, 2, 3})
+	record.Set("kind", "B")
+	record.Set("inner", inner)
+	record.Set("name", "last")
+
+	buf := &bytes.Buffer{}
+	assert(t, NewDatumWriter(schema).Write(record, NewBinaryEncoder(buf)), nil)
+
+	lazy := &GenericRecord{}
+	reader := NewGenericDatumReader().SetLazy(true)
+	reader.SetSchema(schema)
+	assert(t, reader.Read(lazy, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, lazy.lazy.raw[0] != nil, true)
+	assert(t, lazy.Get("name"), "last")
+	assert(t, lazy.lazy.raw[0] != nil, true)
+	assert(t, lazy.lazy.raw[6] == nil, true)
+	assert(t, lazy.Presence("id"), FieldFromData)
+	assert(t, lazy.Get("id"), int64(42))
+	assert(t, lazy.Get("kind"), "B")
+	assert(t, lazy.Get("inner").(*GenericRecord).Get("x"), 1.5)
+	lazy.Set("id", int64(1))
+	assert(t, lazy.Get("id"), int64(1))
+	assert(t, lazy.Map()["tags"], map[string]interface{}{"k": []interface{}{"v1", "v2"}})
+
+	// round trips through the writer like any other record
+	out := &bytes.Buffer{}
+	assert(t, NewDatumWriter(schema).Write(lazy, NewBinaryEncoder(out)), nil)
+	assert(t, out.Len(), buf.Len())
+
+	assert(t, reader.Read(&GenericRecord{}, NewBinaryDecoder(buf.Bytes()[:buf.Len()-2])), ErrUnexpectedEOF)
+}
+
+func TestRecordBuilder(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "User", "namespace": "example", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": "string"},
+		{"name": "email", "type": ["null", "string"]},
+		{"name": "roles", "type": {"type": "array", "items": "string"}, "default": ["user"]}
+	]}`)
+
+	_, err := NewRecordBuilder(schema).Set("email", "a@example.com").Build()
+	assert(t, err.Error(), "Record example.User is missing values for fields id, name")
+	_, err = NewRecordBuilder(schema).Set("id", int64(1)).Set("nmae", "alice").Build()
+	assert(t, err.Error(), "Record example.User has no fields nmae")
+	_, err = NewRecordBuilder(MustParseSchema(`"int"`)).Build()
+	assert(t, err.Error(), "Can't build records of non-record schema int")
+
+	builder := NewRecordBuilder(schema).Set("id", int64(1)).Set("name", "alice")
+	assert(t, builder.Has("name"), true)
+	assert(t, builder.Has("email"), false)
+	record, err := builder.Build()
+	assert(t, err, nil)
+	assert(t, record.Get("email"), nil)
+	assert(t, record.Get("roles"), []interface{}{"user"})
+	assert(t, record.Presence("name"), FieldSet)
+	assert(t, record.Presence("roles"), FieldFromDefault)
+
+	_, err = builder.Clear("name").Build()
+	assert(t, err.Error(), "Record example.User is missing values for fields name")
+}
@@ -0,0 +1,560 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// genericWriteFunc writes a value of the schema it was built for, as GenericDatumWriter does.
+type genericWriteFunc func(v interface{}, enc Encoder) error
+
+// ensureGenericWriteFunc returns the write function of schema, so that writing values doesn't go
+// through the schema again. Those of records are built once and kept on their schema, to be shared
+// between all GenericDatumWriters, those of other schemas are built by every writer they're set on.
+func ensureGenericWriteFunc(schema Schema) genericWriteFunc {
+	switch schema.(type) {
+	case *RecordSchema, *preparedRecordSchema, *RecursiveSchema:
+		rs := resolveSchema(schema).(*RecordSchema)
+		rs.writeFuncOnce.Do(func() {
+			rs.writeFunc = (&genericWriteFuncBuilder{records: make(map[Schema]*genericWriteFunc)}).build(rs)
+		})
+		return rs.writeFunc
+	}
+	return (&genericWriteFuncBuilder{records: make(map[Schema]*genericWriteFunc)}).build(schema)
+}
+
+type genericWriteFuncBuilder struct {
+	// write functions of the records being or already built, for recursive schemas to refer to.
+	records map[Schema]*genericWriteFunc
+	// wrapping the codecs of record fields, for writers with middleware.
+	middleware []FieldMiddleware
+}
+
+func (b *genericWriteFuncBuilder) build(s Schema) genericWriteFunc {
+	switch s.Type() {
+	case Boolean:
+		return writeGenericBoolean
+	case Int:
+		if isDate(s) {
+			return func(v interface{}, enc Encoder) error {
+				if t, ok := v.(time.Time); ok {
+					enc.WriteInt(dateValue(t))
+					return nil
+				}
+				return writeGenericInt(v, enc)
+			}
+		}
+		return writeGenericInt
+	case Long:
+		if timestampUnit(s) != 0 {
+			return func(v interface{}, enc Encoder) error {
+				if t, ok := v.(time.Time); ok {
+					enc.WriteLong(timestampValue(s, t))
+					return nil
+				}
+				return writeGenericLong(v, enc)
+			}
+		}
+		return writeGenericLong
+	case Float:
+		return writeGenericFloat
+	case Double:
+		return writeGenericDouble
+	case Bytes:
+		if isDecimal(s) {
+			return func(v interface{}, enc Encoder) error {
+				if isDecimalType(reflect.TypeOf(v)) {
+					decimal, err := decimalBytes(s, reflect.ValueOf(v))
+					if err != nil {
+						return err
+					}
+					enc.WriteBytes(decimal)
+					return nil
+				}
+				return writeGenericBytes(v, enc)
+			}
+		}
+		return writeGenericBytes
+	case String:
+		if isUUID(s) {
+			return func(v interface{}, enc Encoder) error {
+				uuid, err := uuidString(reflect.ValueOf(v))
+				if err != nil {
+					return err
+				}
+				enc.WriteString(uuid)
+				return nil
+			}
+		}
+		return writeGenericString
+	case Array:
+		return b.buildArray(s.(*ArraySchema))
+	case Map:
+		return b.buildMap(s.(*MapSchema))
+	case Enum:
+		return buildGenericEnum(s.(*EnumSchema))
+	case Union:
+		return b.buildUnion(s.(*UnionSchema))
+	case Fixed:
+		return buildGenericFixed(s.(*FixedSchema))
+	case Record:
+		return b.buildRecord(s)
+	case Recursive:
+		return b.buildRecord(s.(*RecursiveSchema).Actual)
+	}
+
+	// Null and unknown types write nothing.
+	return func(interface{}, Encoder) error { return nil }
+}
+
+func writeGenericBoolean(v interface{}, enc Encoder) error {
+	value, ok := v.(bool)
+	if !ok {
+		return fmt.Errorf("%v is not a boolean", v)
+	}
+	enc.WriteBoolean(value)
+	return nil
+}
+
+func writeGenericInt(v interface{}, enc Encoder) error {
+	value, ok := v.(int32)
+	if !ok {
+		return fmt.Errorf("%v is not an int32", v)
+	}
+	enc.WriteInt(value)
+	return nil
+}
+
+func writeGenericLong(v interface{}, enc Encoder) error {
+	value, ok := v.(int64)
+	if !ok {
+		return fmt.Errorf("%v is not an int64", v)
+	}
+	enc.WriteLong(value)
+	return nil
+}
+
+func writeGenericFloat(v interface{}, enc Encoder) error {
+	value, ok := v.(float32)
+	if !ok {
+		return fmt.Errorf("%v is not a float32", v)
+	}
+	enc.WriteFloat(value)
+	return nil
+}
+
+func writeGenericDouble(v interface{}, enc Encoder) error {
+	value, ok := v.(float64)
+	if !ok {
+		return fmt.Errorf("%v is not a float64", v)
+	}
+	enc.WriteDouble(value)
+	return nil
+}
+
+func writeGenericBytes(v interface{}, enc Encoder) error {
+	value, ok := v.([]byte)
+	if !ok {
+		return fmt.Errorf("%v is not a []byte", v)
+	}
+	enc.WriteBytes(value)
+	return nil
+}
+
+func writeGenericString(v interface{}, enc Encoder) error {
+	value, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("%v is not a string", v)
+	}
+	enc.WriteString(value)
+	return nil
+}
+
+// writeArrayItems writes an array of n items.
+func writeArrayItems(enc Encoder, n int, item func(enc Encoder, i int) error) error {
+	if n == 0 {
+		enc.WriteArrayNext(0)
+		return nil
+	}
+	return writeBlocks(enc, n, enc.WriteArrayStart, enc.WriteArrayNext, item)
+}
+
+// writeMapEntries writes a map with the given keys, item writing the value of the i-th one.
+func writeMapEntries(enc Encoder, keys []string, value func(enc Encoder, i int) error) error {
+	if len(keys) == 0 {
+		enc.WriteMapNext(0)
+		return nil
+	}
+	sortKeys(enc, keys)
+	return writeBlocks(enc, len(keys), enc.WriteMapStart, enc.WriteMapNext, func(enc Encoder, i int) error {
+		enc.WriteString(keys[i])
+		return value(enc, i)
+	})
+}
+
+func (b *genericWriteFuncBuilder) buildArray(s *ArraySchema) genericWriteFunc {
+	writeItem := b.build(s.Items)
+	typed := !isUUID(s.Items) // UUIDs are validated item by item
+	return func(v interface{}, enc Encoder) error {
+		if items, ok := v.([]interface{}); ok {
+			return writeArrayItems(enc, len(items), func(enc Encoder, i int) error {
+				return writeItem(items[i], enc)
+			})
+		}
+		if typed {
+			if ok, err := writeTypedArray(s.Items.Type(), v, enc); ok {
+				return err
+			}
+		}
+
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return errors.New("Not a slice or array type")
+		}
+		return writeArrayItems(enc, rv.Len(), func(enc Encoder, i int) error {
+			return writeItem(rv.Index(i).Interface(), enc)
+		})
+	}
+}
+
+// writeTypedArray writes slices of the Go type of primitive items without boxing every item into
+// an interface{}. Returns false if v is any other value.
+func writeTypedArray(items int, v interface{}, enc Encoder) (bool, error) {
+	var err error
+	switch values := v.(type) {
+	case []bool:
+		if items != Boolean {
+			return false, nil
+		}
+		err = writeArrayItems(enc, len(values), func(enc Encoder, i int) error { enc.WriteBoolean(values[i]); return nil })
+	case []int32:
+		if items != Int {
+			return false, nil
+		}
+		err = writeArrayItems(enc, len(values), func(enc Encoder, i int) error { enc.WriteInt(values[i]); return nil })
+	case []int64:
+		if items != Long {
+			return false, nil
+		}
+		err = writeArrayItems(enc, len(values), func(enc Encoder, i int) error { enc.WriteLong(values[i]); return nil })
+	case []float32:
+		if items != Float {
+			return false, nil
+		}
+		err = writeArrayItems(enc, len(values), func(enc Encoder, i int) error { enc.WriteFloat(values[i]); return nil })
+	case []float64:
+		if items != Double {
+			return false, nil
+		}
+		err = writeArrayItems(enc, len(values), func(enc Encoder, i int) error { enc.WriteDouble(values[i]); return nil })
+	case []string:
+		if items != String {
+			return false, nil
+		}
+		err = writeArrayItems(enc, len(values), func(enc Encoder, i int) error { enc.WriteString(values[i]); return nil })
+	case [][]byte:
+		if items != Bytes {
+			return false, nil
+		}
+		err = writeArrayItems(enc, len(values), func(enc Encoder, i int) error { enc.WriteBytes(values[i]); return nil })
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+func (b *genericWriteFuncBuilder) buildMap(s *MapSchema) genericWriteFunc {
+	writeValue := b.build(s.Values)
+	typed := !isUUID(s.Values) // UUIDs are validated value by value
+	return func(v interface{}, enc Encoder) error {
+		if values, ok := v.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			return writeMapEntries(enc, keys, func(enc Encoder, i int) error {
+				return writeValue(values[keys[i]], enc)
+			})
+		}
+		if typed {
+			if ok, err := writeTypedMap(s.Values.Type(), v, enc); ok {
+				return err
+			}
+		}
+
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Map {
+			return errors.New("Not a map type")
+		}
+		if rv.Len() == 0 {
+			enc.WriteMapNext(0)
+			return nil
+		}
+		keys := rv.MapKeys()
+		sortValueKeys(enc, keys)
+		return writeBlocks(enc, len(keys), enc.WriteMapStart, enc.WriteMapNext, func(enc Encoder, i int) error {
+			if err := writeGenericString(keys[i].Interface(), enc); err != nil {
+				return err
+			}
+			return writeValue(rv.MapIndex(keys[i]).Interface(), enc)
+		})
+	}
+}
+
+// writeTypedMap is like writeTypedArray, for maps with string keys.
+func writeTypedMap(values int, v interface{}, enc Encoder) (bool, error) {
+	var keys []string
+	var value func(enc Encoder, i int) error
+	switch m := v.(type) {
+	case map[string]bool:
+		if values != Boolean {
+			return false, nil
+		}
+		for key := range m {
+			keys = append(keys, key)
+		}
+		value = func(enc Encoder, i int) error { enc.WriteBoolean(m[keys[i]]); return nil }
+	case map[string]int32:
+		if values != Int {
+			return false, nil
+		}
+		for key := range m {
+			keys = append(keys, key)
+		}
+		value = func(enc Encoder, i int) error { enc.WriteInt(m[keys[i]]); return nil }
+	case map[string]int64:
+		if values != Long {
+			return false, nil
+		}
+		for key := range m {
+			keys = append(keys, key)
+		}
+		value = func(enc Encoder, i int) error { enc.WriteLong(m[keys[i]]); return nil }
+	case map[string]float32:
+		if values != Float {
+			return false, nil
+		}
+		for key := range m {
+			keys = append(keys, key)
+		}
+		value = func(enc Encoder, i int) error { enc.WriteFloat(m[keys[i]]); return nil }
+	case map[string]float64:
+		if values != Double {
+			return false, nil
+		}
+		for key := range m {
+			keys = append(keys, key)
+		}
+		value = func(enc Encoder, i int) error { enc.WriteDouble(m[keys[i]]); return nil }
+	case map[string]string:
+		if values != String {
+			return false, nil
+		}
+		for key := range m {
+			keys = append(keys, key)
+		}
+		value = func(enc Encoder, i int) error { enc.WriteString(m[keys[i]]); return nil }
+	case map[string][]byte:
+		if values != Bytes {
+			return false, nil
+		}
+		for key := range m {
+			keys = append(keys, key)
+		}
+		value = func(enc Encoder, i int) error { enc.WriteBytes(m[keys[i]]); return nil }
+	default:
+		return false, nil
+	}
+	return true, writeMapEntries(enc, keys, value)
+}
+
+func buildGenericEnum(s *EnumSchema) genericWriteFunc {
+	indexes := make(map[string]int32, len(s.Symbols))
+	for i, symbol := range s.Symbols {
+		indexes[symbol] = int32(i)
+	}
+	return func(v interface{}, enc Encoder) error {
+		var symbol string
+		switch value := v.(type) {
+		case *GenericEnum:
+			if index := value.GetIndex(); index < 0 || int(index) >= len(value.Symbols) {
+				return fmt.Errorf("Enum index %d out of range for enum %s with %d symbols", index, s.GetName(), len(value.Symbols))
+			}
+			symbol = value.Get()
+		case string:
+			symbol = value
+		case int32:
+			if value < 0 || int(value) >= len(s.Symbols) {
+				return fmt.Errorf("Enum index %d out of range for enum %s with %d symbols", value, s.GetName(), len(s.Symbols))
+			}
+			enc.WriteInt(value)
+			return nil
+		default:
+			return fmt.Errorf("%v is not a *GenericEnum", v)
+		}
+		index, ok := indexes[symbol]
+		if !ok {
+			return fmt.Errorf("%s is not a symbol of enum %s", symbol, s.GetName())
+		}
+		enc.WriteInt(index)
+		return nil
+	}
+}
+
+// isSpecificRecord tells whether v is a struct, or a non-nil pointer to one, other than a GenericRecord.
+func isSpecificRecord(v interface{}) bool {
+	if _, ok := v.(GenericRecord); ok {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv.Kind() == reflect.Struct
+}
+
+// Samples of the Go values whose union branch only depends on their type, or on whether they are
+// NaN or empty strings, which NullSchema accepts.
+var unionBranchSamples = [...]interface{}{false, int32(0), int64(0), float32(0), float32(math.NaN()), float64(0), math.NaN(), "x", ""}
+
+// unionBranchSample returns the index of the sample matching the same branch of any union as v,
+// or -1 if there is none.
+func unionBranchSample(v interface{}) int {
+	switch value := v.(type) {
+	case bool:
+		return 0
+	case int32:
+		return 1
+	case int64:
+		return 2
+	case float32:
+		if value != value {
+			return 4
+		}
+		return 3
+	case float64:
+		if value != value {
+			return 6
+		}
+		return 5
+	case string:
+		if value == "" {
+			return 8
+		}
+		return 7
+	}
+	return -1
+}
+
+func (b *genericWriteFuncBuilder) buildUnion(s *UnionSchema) genericWriteFunc {
+	writeBranches := make([]genericWriteFunc, len(s.Types))
+	for i, t := range s.Types {
+		writeBranches[i] = b.build(t)
+	}
+	var sampleBranches [len(unionBranchSamples)]int
+	for i, sample := range unionBranchSamples {
+		sampleBranches[i] = s.GetType(reflect.ValueOf(sample))
+	}
+	// Whether strings match UUID branches, and strings and ints enum branches, depends on their
+	// values rather than their type.
+	valueBranches := false
+	for _, t := range s.Types {
+		valueBranches = valueBranches || isUUID(t) || resolveSchema(t).Type() == Enum
+	}
+	nullBranch := -1
+	for i := len(s.Types) - 1; i >= 0; i-- {
+		if s.Types[i].Type() == Null {
+			nullBranch = i
+		}
+	}
+
+	return func(v interface{}, enc Encoder) error {
+		var index int
+		if v == nil {
+			index = nullBranch
+		} else if sample := unionBranchSample(v); sample >= 0 && !valueBranches {
+			index = sampleBranches[sample]
+		} else {
+			index = s.GetType(reflect.ValueOf(v))
+		}
+		if index == -1 {
+			return fmt.Errorf("Could not write %v as %s", v, s)
+		}
+		enc.WriteInt(int32(index))
+		return writeBranches[index](v, enc)
+	}
+}
+
+func buildGenericFixed(s *FixedSchema) genericWriteFunc {
+	decimal := isDecimal(s)
+	return func(v interface{}, enc Encoder) error {
+		if decimal && isDecimalType(reflect.TypeOf(v)) {
+			b, err := decimalBytes(s, reflect.ValueOf(v))
+			if err != nil {
+				return err
+			}
+			enc.WriteRaw(b)
+			return nil
+		}
+		if !s.Validate(reflect.ValueOf(v)) {
+			return fmt.Errorf("Invalid fixed value: %v", v)
+		}
+		// Write the raw bytes. The length is known by the schema
+		enc.WriteRaw(v.([]byte))
+		return nil
+	}
+}
+
+func (b *genericWriteFuncBuilder) buildRecord(s Schema) genericWriteFunc {
+	if fn, ok := b.records[s]; ok {
+		// Recursive reference to a record still being built.
+		return func(v interface{}, enc Encoder) error {
+			return (*fn)(v, enc)
+		}
+	}
+	fn := new(genericWriteFunc)
+	b.records[s] = fn
+
+	rs := assertRecordSchema(s)
+	layout := ensureRecordLayout(s)
+	writeFields := make([]genericWriteFunc, len(rs.Fields))
+	for i, field := range rs.Fields {
+		writeFields[i] = b.build(field.Type)
+		if len(b.middleware) > 0 {
+			codec := wrapFieldCodec(field, &genericFieldCodec{&GenericDatumReader{}, field, 0, writeFields[i]}, b.middleware)
+			writeFields[i] = codec.Write
+		}
+	}
+	specific := &SpecificDatumWriter{schema: rs}
+	*fn = func(v interface{}, enc Encoder) error {
+		record, ok := v.(*GenericRecord)
+		if !ok {
+			// Structs set in GenericRecords are written as SpecificDatumWriter does.
+			if isSpecificRecord(v) {
+				return specific.Write(v, enc)
+			}
+			return fmt.Errorf("%v is not a *GenericRecord", v)
+		}
+		// Positional access is only safe when the record was laid out from this very schema.
+		byIndex := record.layout != nil && record.layout == layout
+		for i, field := range rs.Fields {
+			var value interface{}
+			if byIndex {
+				value = record.GetByIndex(i)
+			} else {
+				value = record.Get(field.Name)
+			}
+			if value == nil {
+				value = field.Default
+			}
+			if err := writeFields[i](value, enc); err != nil || encodedSizeExceeded(enc) {
+				return encodedSizeErrorAt(enc, err, field.Name)
+			}
+		}
+		return nil
+	}
+	return *fn
+}
@@ -0,0 +1,192 @@
+package avro
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// JSONWriter builds compact JSON documents value by value, without the reflection and intermediate
+// values encoding/json needs. Commas and colons are inserted as needed, so writing an object is a
+// matter of calling BeginObject, then Field and a value for every field, then EndObject.
+//
+// Strings are escaped the same way encoding/json does, so both produce the same output.
+// The zero value is an empty JSONWriter ready to use.
+type JSONWriter struct {
+	buf []byte
+	// whether a value was written in the current object or array, so that the next one needs a comma.
+	comma bool
+}
+
+// NewJSONWriter creates a new JSONWriter.
+func NewJSONWriter() *JSONWriter {
+	return &JSONWriter{}
+}
+
+// Bytes returns the JSON written so far, valid until the next write.
+func (w *JSONWriter) Bytes() []byte {
+	return w.buf
+}
+
+// Reset discards everything written, keeping the buffer for reuse.
+func (w *JSONWriter) Reset() {
+	w.buf = w.buf[:0]
+	w.comma = false
+}
+
+func (w *JSONWriter) value() {
+	if w.comma {
+		w.buf = append(w.buf, ',')
+	}
+	w.comma = true
+}
+
+// BeginObject starts an object.
+func (w *JSONWriter) BeginObject() *JSONWriter {
+	w.value()
+	w.buf = append(w.buf, '{')
+	w.comma = false
+	return w
+}
+
+// EndObject ends the current object.
+func (w *JSONWriter) EndObject() *JSONWriter {
+	w.buf = append(w.buf, '}')
+	w.comma = true
+	return w
+}
+
+// BeginArray starts an array.
+func (w *JSONWriter) BeginArray() *JSONWriter {
+	w.value()
+	w.buf = append(w.buf, '[')
+	w.comma = false
+	return w
+}
+
+// EndArray ends the current array.
+func (w *JSONWriter) EndArray() *JSONWriter {
+	w.buf = append(w.buf, ']')
+	w.comma = true
+	return w
+}
+
+// Field writes the name of an object field, which must be followed by its value.
+func (w *JSONWriter) Field(name string) *JSONWriter {
+	w.String(name)
+	w.buf = append(w.buf, ':')
+	w.comma = false
+	return w
+}
+
+// String writes a string value.
+func (w *JSONWriter) String(s string) *JSONWriter {
+	w.value()
+	w.buf = appendJSONString(w.buf, s)
+	return w
+}
+
+// Int writes an integer value.
+func (w *JSONWriter) Int(n int64) *JSONWriter {
+	w.value()
+	w.buf = strconv.AppendInt(w.buf, n, 10)
+	return w
+}
+
+// Float writes a number the way encoding/json does. NaN and infinities, which JSON can't
+// represent, are written as null.
+func (w *JSONWriter) Float(f float64) *JSONWriter {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return w.Null()
+	}
+	w.value()
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	w.buf = strconv.AppendFloat(w.buf, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		if n := len(w.buf); n >= 4 && w.buf[n-4] == 'e' && w.buf[n-3] == '-' && w.buf[n-2] == '0' {
+			w.buf[n-2] = w.buf[n-1]
+			w.buf = w.buf[:n-1]
+		}
+	}
+	return w
+}
+
+// Bool writes a boolean value.
+func (w *JSONWriter) Bool(b bool) *JSONWriter {
+	w.value()
+	w.buf = strconv.AppendBool(w.buf, b)
+	return w
+}
+
+// Null writes a null value.
+func (w *JSONWriter) Null() *JSONWriter {
+	w.value()
+	w.buf = append(w.buf, "null"...)
+	return w
+}
+
+// Raw writes a value already encoded as JSON as is.
+func (w *JSONWriter) Raw(json []byte) *JSONWriter {
+	w.value()
+	w.buf = append(w.buf, json...)
+	return w
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s quoted and escaped as encoding/json does with HTML escaping: invalid
+// UTF-8 becomes U+FFFD and <, >, &, U+2028 and U+2029 are escaped too.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
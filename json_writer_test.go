@@ -0,0 +1,44 @@
+package avro
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestJSONWriter(t *testing.T) {
+	w := NewJSONWriter()
+	w.BeginObject().Field("name").String("a\"b").Field("values").BeginArray().Int(-1).Float(0.5).Bool(true).Null().EndArray()
+	w.Field("empty").BeginObject().EndObject().Field("raw").Raw([]byte(`{"x":1}`)).Field("nan").Float(math.NaN()).EndObject()
+	assert(t, string(w.Bytes()), `{"name":"a\"b","values":[-1,0.5,true,null],"empty":{},"raw":{"x":1},"nan":null}`)
+
+	w.Reset()
+	w.BeginArray().EndArray().String("next")
+	assert(t, string(w.Bytes()), `[],"next"`)
+}
+
+func TestJSONWriterMatchesEncodingJSON(t *testing.T) {
+	for _, s := range []string{"", "plain", "tab\tnew\nline\r", "\b\f\x01\x1f", `<a href="x">&</a>`, "\\", "ünïcödé ✓", "  ", "bad \xff utf-8"} {
+		expected, _ := json.Marshal(s)
+		assert(t, string(new(JSONWriter).String(s).Bytes()), string(expected))
+	}
+	for _, f := range []float64{0, -0.0, 1, -1.5, 1e20, 1e21, 1e-6, 1e-7, 123456789.125, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+		expected, _ := json.Marshal(f)
+		assert(t, string(new(JSONWriter).Float(f).Bytes()), string(expected))
+	}
+}
+
+func BenchmarkCanonicalForm(b *testing.B) {
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "namespace": "example.avro", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["CREATED", "UPDATED", "DELETED"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 16}},
+		{"name": "tags", "type": {"type": "map", "values": "string"}},
+		{"name": "parent", "type": ["null", "Event"]}
+	]}`)
+	for i := 0; i < b.N; i++ {
+		if _, err := CanonicalForm(schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
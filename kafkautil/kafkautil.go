@@ -0,0 +1,316 @@
+// Package kafkautil packages the usual loop of a Kafka consumer decoding Avro messages.
+//
+// It doesn't depend on any particular Kafka client: feed the raw message values into a Consumer
+// and read decoded records from the channel it returns.
+//
+//	consumer := kafkautil.NewConsumer(kafkautil.NewRegistryDeserializer(lookup), func() interface{} {
+//		return new(MyRecord)
+//	}).OnError(func(err *kafkautil.DecodeError) {
+//		log.Printf("skipping bad message: %s", err)
+//	})
+//	for record := range consumer.Run(messages) {
+//		handle(record.(*MyRecord))
+//	}
+//
+// To resume after restarts without handling messages twice, feed messages along with their
+// partitions and offsets into RunFrom and persist a Checkpoint as records are handled:
+//
+//	for record := range consumer.RunFrom(messages, checkpoint) {
+//		handle(record.Value.(*MyRecord))
+//		checkpoint.Mark(record.Partition, record.Offset)
+//		token, _ := checkpoint.MarshalBinary()
+//		save(token)
+//	}
+package kafkautil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"gopkg.in/avro.v0"
+)
+
+// ErrInvalidFraming happens when a message doesn't start with the magic byte and schema ID.
+var ErrInvalidFraming = errors.New("Message is not framed with a magic byte and schema ID")
+
+// magicByte starts every message framed with a schema ID.
+const magicByte = 0
+
+// Deserializer decodes a raw message into the given value, which can be anything a
+// avro.DatumReader accepts.
+type Deserializer interface {
+	Deserialize(data []byte, v interface{}) error
+}
+
+// SchemaLookup resolves the schema ID found in a framed message to the schema it was written with,
+// typically by asking a schema registry.
+type SchemaLookup func(id uint32) (avro.Schema, error)
+
+// CachedLookup wraps lookup to consult the given SchemaCache first, and store whatever lookup
+// returns in it, so that schemas seen once remain available even if lookup starts failing.
+func CachedLookup(lookup SchemaLookup, cache *avro.SchemaCache) SchemaLookup {
+	return func(id uint32) (avro.Schema, error) {
+		ref := "id/" + strconv.FormatUint(uint64(id), 10)
+		if schema, ok := cache.GetRef(ref); ok {
+			return schema, nil
+		}
+		schema, err := lookup(id)
+		if err != nil {
+			return nil, err
+		}
+		// Failing to cache the schema mustn't fail decoding the message.
+		cache.PutRef(ref, schema)
+		return schema, nil
+	}
+}
+
+// NewDeserializer creates a Deserializer for messages carrying nothing but the datum encoded with
+// the given schema.
+func NewDeserializer(schema avro.Schema) Deserializer {
+	return &schemaDeserializer{reader: avro.NewDatumReader(schema)}
+}
+
+type schemaDeserializer struct {
+	reader avro.DatumReader
+}
+
+func (d *schemaDeserializer) Deserialize(data []byte, v interface{}) error {
+	return d.reader.Read(v, avro.NewBinaryDecoder(data))
+}
+
+// NewRegistryDeserializer creates a Deserializer for messages framed with a zero magic byte and a
+// big-endian 4 byte schema ID, as produced by registry-aware Kafka serializers. Each ID is looked
+// up only once, the resulting DatumReader is cached.
+func NewRegistryDeserializer(lookup SchemaLookup) Deserializer {
+	return &registryDeserializer{lookup: lookup, readers: make(map[uint32]avro.DatumReader)}
+}
+
+type registryDeserializer struct {
+	lookup  SchemaLookup
+	mu      sync.RWMutex
+	readers map[uint32]avro.DatumReader
+}
+
+func (d *registryDeserializer) Deserialize(data []byte, v interface{}) error {
+	if len(data) < 5 || data[0] != magicByte {
+		return ErrInvalidFraming
+	}
+	reader, err := d.readerFor(binary.BigEndian.Uint32(data[1:5]))
+	if err != nil {
+		return err
+	}
+	return reader.Read(v, avro.NewBinaryDecoder(data[5:]))
+}
+
+func (d *registryDeserializer) readerFor(id uint32) (avro.DatumReader, error) {
+	d.mu.RLock()
+	reader, ok := d.readers[id]
+	d.mu.RUnlock()
+	if ok {
+		return reader, nil
+	}
+
+	schema, err := d.lookup(id)
+	if err != nil {
+		return nil, fmt.Errorf("Looking up schema %d: %s", id, err)
+	}
+	reader = avro.NewDatumReader(schema)
+	d.mu.Lock()
+	d.readers[id] = reader
+	d.mu.Unlock()
+	return reader, nil
+}
+
+// DecodeError describes a message that couldn't be decoded.
+type DecodeError struct {
+	Message []byte
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Decoding %d byte message: %s", len(e.Message), e.Err)
+}
+
+// Consumer decodes a stream of raw messages into records.
+type Consumer struct {
+	deserializer Deserializer
+	newRecord    func() interface{}
+	onError      func(*DecodeError)
+	done         <-chan struct{}
+}
+
+// NewConsumer creates a Consumer decoding messages with the given Deserializer into values
+// allocated by newRecord, e.g. func() interface{} { return new(MyRecord) }.
+func NewConsumer(deserializer Deserializer, newRecord func() interface{}) *Consumer {
+	return &Consumer{deserializer: deserializer, newRecord: newRecord}
+}
+
+// OnError sets a function which is called with every message that fails to decode. Such messages
+// are dropped silently if it isn't set.
+func (c *Consumer) OnError(f func(*DecodeError)) *Consumer {
+	c.onError = f
+	return c
+}
+
+// Until makes Run and RunFrom stop decoding messages and close the channel they return once done is
+// closed, so that their goroutine doesn't leak when records stop being received before in is
+// closed.
+func (c *Consumer) Until(done <-chan struct{}) *Consumer {
+	c.done = done
+	return c
+}
+
+// Run starts decoding messages received from in, in order, and returns a channel of the decoded
+// records. The returned channel is closed once in is closed and all its messages are processed,
+// or once the channel given to Until is.
+func (c *Consumer) Run(in <-chan []byte) <-chan interface{} {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for {
+			var message []byte
+			var ok bool
+			select {
+			case message, ok = <-in:
+			case <-c.done:
+				return
+			}
+			if !ok {
+				return
+			}
+			record := c.newRecord()
+			if err := c.deserializer.Deserialize(message, record); err != nil {
+				if c.onError != nil {
+					c.onError(&DecodeError{Message: message, Err: err})
+				}
+				continue
+			}
+			select {
+			case out <- record:
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Message is a raw message along with its position in its topic.
+type Message struct {
+	Partition int32
+	Offset    int64
+	Value     []byte
+}
+
+// Record is a record decoded from a Message.
+type Record struct {
+	Value     interface{}
+	Partition int32
+	Offset    int64
+}
+
+// RunFrom is like Run, but decodes messages into Records telling where they come from, and drops
+// the messages the given Checkpoint covers already, such as those delivered again after a restart.
+// A nil Checkpoint covers nothing. The Checkpoint is copied, so it can be marked while records are
+// received.
+func (c *Consumer) RunFrom(in <-chan Message, from Checkpoint) <-chan Record {
+	from = from.copy()
+	out := make(chan Record)
+	go func() {
+		defer close(out)
+		for {
+			var message Message
+			var ok bool
+			select {
+			case message, ok = <-in:
+			case <-c.done:
+				return
+			}
+			if !ok {
+				return
+			}
+			if from.Covers(message.Partition, message.Offset) {
+				continue
+			}
+			record := c.newRecord()
+			if err := c.deserializer.Deserialize(message.Value, record); err != nil {
+				if c.onError != nil {
+					c.onError(&DecodeError{Message: message.Value, Err: err})
+				}
+				continue
+			}
+			select {
+			case out <- Record{Value: record, Partition: message.Partition, Offset: message.Offset}:
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Checkpoint is how far a stream of messages was processed: the offset of the next message to
+// process in every partition seen. It can be persisted with MarshalBinary and restored with
+// UnmarshalBinary to resume processing. Create it with make before marking messages in it.
+type Checkpoint map[int32]int64
+
+var checkpointSchema = avro.MustParseSchema(`{"type": "map", "values": "long"}`)
+
+// Mark records that the message at the given offset of a partition, and all messages before it,
+// are processed.
+func (c Checkpoint) Mark(partition int32, offset int64) {
+	if next, ok := c[partition]; !ok || offset >= next {
+		c[partition] = offset + 1
+	}
+}
+
+func (c Checkpoint) copy() Checkpoint {
+	copied := make(Checkpoint, len(c))
+	for partition, next := range c {
+		copied[partition] = next
+	}
+	return copied
+}
+
+// Covers tells whether the message at the given offset of a partition is processed already.
+func (c Checkpoint) Covers(partition int32, offset int64) bool {
+	next, ok := c[partition]
+	return ok && offset < next
+}
+
+// MarshalBinary encodes the Checkpoint as an Avro map of offsets by partition, the same for equal
+// Checkpoints. It implements encoding.BinaryMarshaler.
+func (c Checkpoint) MarshalBinary() ([]byte, error) {
+	offsets := make(map[string]int64, len(c))
+	for partition, offset := range c {
+		offsets[strconv.FormatInt(int64(partition), 10)] = offset
+	}
+	buf := &bytes.Buffer{}
+	if err := avro.NewDatumWriter(checkpointSchema).Write(offsets, avro.NewBinaryEncoderCanonical(buf)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the Checkpoint with one encoded by MarshalBinary. It implements
+// encoding.BinaryUnmarshaler.
+func (c *Checkpoint) UnmarshalBinary(data []byte) error {
+	var offsets map[string]interface{}
+	if err := avro.NewGenericDatumReader().SetSchema(checkpointSchema).Read(&offsets, avro.NewBinaryDecoder(data)); err != nil {
+		return fmt.Errorf("Invalid checkpoint: %s", err)
+	}
+	checkpoint := make(Checkpoint, len(offsets))
+	for key, offset := range offsets {
+		partition, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			return fmt.Errorf("Invalid checkpoint partition %q", key)
+		}
+		checkpoint[int32(partition)] = offset.(int64)
+	}
+	*c = checkpoint
+	return nil
+}
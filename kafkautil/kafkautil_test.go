@@ -0,0 +1,171 @@
+package kafkautil
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gopkg.in/avro.v0"
+)
+
+type event struct {
+	ID   int64 `avro:"id"`
+	Name string
+}
+
+var eventSchema = avro.MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+	{"name": "id", "type": "long"},
+	{"name": "name", "type": "string"}
+]}`)
+
+func encode(t *testing.T, prefix []byte, e *event) []byte {
+	buf := bytes.NewBuffer(prefix)
+	if err := avro.NewDatumWriter(eventSchema).Write(e, avro.NewBinaryEncoder(buf)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestConsumer(t *testing.T) {
+	lookups := 0
+	lookup := func(id uint32) (avro.Schema, error) {
+		lookups++
+		if id != 7 {
+			return nil, errors.New("not found")
+		}
+		return eventSchema, nil
+	}
+
+	in := make(chan []byte, 4)
+	in <- encode(t, []byte{0, 0, 0, 0, 7}, &event{ID: 1, Name: "first"})
+	in <- encode(t, []byte{0, 0, 0, 0, 8}, &event{ID: 2, Name: "unknown"})
+	in <- []byte{1, 2}
+	in <- encode(t, []byte{0, 0, 0, 0, 7}, &event{ID: 3, Name: "third"})
+	close(in)
+
+	var failed []error
+	consumer := NewConsumer(NewRegistryDeserializer(lookup), func() interface{} {
+		return new(event)
+	}).OnError(func(err *DecodeError) {
+		failed = append(failed, err.Err)
+	})
+
+	var ids []int64
+	for record := range consumer.Run(in) {
+		ids = append(ids, record.(*event).ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Fatalf("unexpected records %v", ids)
+	}
+	if len(failed) != 2 || failed[1] != ErrInvalidFraming {
+		t.Fatalf("unexpected errors %v", failed)
+	}
+	if lookups != 2 {
+		t.Fatalf("expected 2 lookups, got %d", lookups)
+	}
+}
+
+func TestDeserializer(t *testing.T) {
+	var e event
+	if err := NewDeserializer(eventSchema).Deserialize(encode(t, nil, &event{ID: 5, Name: "plain"}), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != 5 || e.Name != "plain" {
+		t.Fatalf("unexpected record %+v", e)
+	}
+}
+
+func TestCachedLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kafkautil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := avro.NewSchemaCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	available := true
+	lookup := CachedLookup(func(id uint32) (avro.Schema, error) {
+		if !available {
+			return nil, errors.New("registry down")
+		}
+		return eventSchema, nil
+	}, cache)
+	if _, err := lookup(7); err != nil {
+		t.Fatal(err)
+	}
+
+	available = false
+	schema, err := lookup(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.FullName() != "Event" {
+		t.Fatalf("unexpected schema %s", schema)
+	}
+	if _, err := lookup(8); err == nil {
+		t.Fatal("expected lookup of an uncached schema to fail")
+	}
+}
+
+func TestConsumerCheckpoint(t *testing.T) {
+	messages := func() <-chan Message {
+		in := make(chan Message, 4)
+		in <- Message{Partition: 0, Offset: 10, Value: encode(t, nil, &event{ID: 1})}
+		in <- Message{Partition: 1, Offset: 3, Value: encode(t, nil, &event{ID: 2})}
+		in <- Message{Partition: 0, Offset: 11, Value: encode(t, nil, &event{ID: 3})}
+		in <- Message{Partition: 1, Offset: 4, Value: encode(t, nil, &event{ID: 4})}
+		close(in)
+		return in
+	}
+	consumer := NewConsumer(NewDeserializer(eventSchema), func() interface{} {
+		return new(event)
+	})
+
+	checkpoint := make(Checkpoint)
+	var token []byte
+	done := make(chan struct{})
+	records := NewConsumer(NewDeserializer(eventSchema), func() interface{} {
+		return new(event)
+	}).Until(done).RunFrom(messages(), checkpoint)
+	for record := range records {
+		if record.Value.(*event).ID == 3 {
+			break
+		}
+		checkpoint.Mark(record.Partition, record.Offset)
+		var err error
+		if token, err = checkpoint.MarshalBinary(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Stopping closes the channel of records instead of leaving the goroutine blocked on it.
+	close(done)
+	for range records {
+	}
+	if len(checkpoint) != 2 || checkpoint[0] != 11 || checkpoint[1] != 4 {
+		t.Fatalf("unexpected checkpoint %v", checkpoint)
+	}
+
+	var resumed Checkpoint
+	if err := resumed.UnmarshalBinary(token); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for record := range consumer.RunFrom(messages(), resumed) {
+		ids = append(ids, record.Value.(*event).ID)
+		resumed.Mark(record.Partition, record.Offset)
+	}
+	if len(ids) != 2 || ids[0] != 3 || ids[1] != 4 {
+		t.Fatalf("unexpected records %v", ids)
+	}
+	if resumed[0] != 12 || resumed[1] != 5 {
+		t.Fatalf("unexpected checkpoint %v", resumed)
+	}
+	if err := resumed.UnmarshalBinary([]byte{3}); err == nil {
+		t.Fatal("expected an invalid checkpoint to fail")
+	}
+}
@@ -0,0 +1,160 @@
+package avro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeyExtractor pulls the values of a few fields out of encoded records without decoding them, e.g.
+// for partitioners and indexers. The fields are found by skipping over the others, so extracting
+// keys is much cheaper than decoding whole records.
+//
+// A KeyExtractor is safe for concurrent use.
+type KeyExtractor struct {
+	root  *keyNode
+	types []Schema
+	key   *RecordSchema
+}
+
+// keyNode lists the fields of a record which keys are extracted from, in order.
+type keyNode struct {
+	record *RecordSchema
+	fields []*keyField
+}
+
+type keyField struct {
+	index int
+	keys  []int    // indexes of the keys which are this field
+	child *keyNode // for keys within this field, which is a record then
+}
+
+// NewKeyExtractor creates a KeyExtractor for records of the given schema, extracting the fields at
+// the given paths. A path is a field name or alias, or a dotted path to a field of a nested record
+// such as "user.id". Paths can't go through unions, arrays or maps.
+func NewKeyExtractor(schema Schema, paths []string) (*KeyExtractor, error) {
+	record, ok := resolveSchema(schema).(*RecordSchema)
+	if !ok {
+		return nil, fmt.Errorf("Can only extract keys from records, not %s", schema.GetName())
+	} else if len(paths) == 0 {
+		return nil, fmt.Errorf("No fields to extract")
+	}
+
+	e := &KeyExtractor{root: &keyNode{record: record}}
+	e.key = &RecordSchema{Name: record.Name + "Key", Namespace: record.Namespace}
+	for k, path := range paths {
+		fields, indexes, err := record.fieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		node := e.root
+		for i, field := range fields {
+			kf := node.field(indexes[i])
+			if i == len(fields)-1 {
+				kf.keys = append(kf.keys, k)
+				e.types = append(e.types, field.Type)
+				e.key.Fields = append(e.key.Fields, &SchemaField{Name: strings.Replace(path, ".", "_", -1), Type: field.Type, Order: field.Order})
+				break
+			}
+			if kf.child == nil {
+				kf.child = &keyNode{record: resolveSchema(field.Type).(*RecordSchema)}
+			}
+			node = kf.child
+		}
+	}
+	if err := checkRecordFields(e.key.FullName(), e.key.Fields); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// field returns the keyField for the field with the given index, adding it if needed.
+func (node *keyNode) field(index int) *keyField {
+	i := sort.Search(len(node.fields), func(i int) bool { return node.fields[i].index >= index })
+	if i < len(node.fields) && node.fields[i].index == index {
+		return node.fields[i]
+	}
+	kf := &keyField{index: index}
+	node.fields = append(node.fields, nil)
+	copy(node.fields[i+1:], node.fields[i:])
+	node.fields[i] = kf
+	return kf
+}
+
+// KeySchema returns the schema of the keys returned by ExtractEncoded, a record with a field for every
+// path, named after it with dots replaced by underscores and with the order of the field it is at.
+func (e *KeyExtractor) KeySchema() *RecordSchema {
+	return e.key
+}
+
+// ExtractEncoded returns the encoded values of the fields of the encoded record data, one after the
+// other in the order of the paths, which makes a record of the KeySchema.
+func (e *KeyExtractor) ExtractEncoded(data []byte) ([]byte, error) {
+	parts, err := e.extract(data)
+	if err != nil {
+		return nil, err
+	}
+	var key []byte
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key, nil
+}
+
+// Extract returns the values of the fields of the encoded record data in the order of the paths,
+// decoded the way a GenericDatumReader would.
+func (e *KeyExtractor) Extract(data []byte) ([]interface{}, error) {
+	parts, err := e.extract(data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(parts))
+	for k, part := range parts {
+		datum := &GenericDatumReader{schema: e.types[k]}
+		value, err := datum.readValue(e.types[k], NewBinaryDecoder(part))
+		if err != nil {
+			return nil, err
+		}
+		values[k] = fieldValue(value)
+	}
+	return values, nil
+}
+
+// extract returns the encoded values of the fields at every path.
+func (e *KeyExtractor) extract(data []byte) ([][]byte, error) {
+	parts := make([][]byte, len(e.types))
+	bd := &binaryDecoder{buf: data}
+	if err := e.root.extract(bd, parts, false); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// extract moves bd past a record, or only up to the end of its last key field unless whole is set,
+// keeping the encoded values of the key fields in parts.
+func (node *keyNode) extract(bd *binaryDecoder, parts [][]byte, whole bool) error {
+	next := 0
+	for i, field := range node.record.Fields {
+		if next == len(node.fields) && !whole {
+			return nil
+		}
+		start := bd.pos
+		if next < len(node.fields) && node.fields[next].index == i {
+			kf := node.fields[next]
+			next++
+			if kf.child != nil {
+				if err := kf.child.extract(bd, parts, true); err != nil {
+					return err
+				}
+			} else if err := bd.skip(field.Type, nil, DefaultMaxDepth); err != nil {
+				return err
+			}
+			for _, k := range kf.keys {
+				parts[k] = bd.buf[start:bd.pos]
+			}
+		} else if err := bd.skip(field.Type, nil, DefaultMaxDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
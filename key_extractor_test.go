@@ -0,0 +1,56 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyExtractor(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "namespace": "com.example", "fields": [
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "id", "type": "long"},
+			{"name": "extra", "type": {"type": "map", "values": "int"}}
+		]}},
+		{"name": "kind", "type": "string"},
+		{"name": "payload", "type": "bytes"}
+	]}`)
+	user := NewGenericRecord(schema.(*RecordSchema).Fields[1].Type)
+	user.Set("name", "ann")
+	user.Set("id", int64(42))
+	user.Set("extra", map[string]interface{}{"a": int32(1)})
+	event := NewGenericRecord(schema)
+	event.Set("tags", []interface{}{"x", "y"})
+	event.Set("user", user)
+	event.Set("kind", "click")
+	event.Set("payload", []byte{1, 2, 3})
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write(event, NewBinaryEncoder(&buf)), nil)
+
+	e, err := NewKeyExtractor(schema, []string{"kind", "user.id", "user"})
+	assert(t, err, nil)
+	values, err := e.Extract(buf.Bytes())
+	assert(t, err, nil)
+	assert(t, values[:2], []interface{}{"click", int64(42)})
+	assert(t, values[2].(*GenericRecord).Get("name"), "ann")
+
+	key, err := e.ExtractEncoded(buf.Bytes())
+	assert(t, err, nil)
+	keySchema := e.KeySchema()
+	assert(t, keySchema.FullName(), "com.example.EventKey")
+	assert(t, keySchema.Fields[1].Name, "user_id")
+	decoded := NewGenericRecord(keySchema)
+	assert(t, NewDatumReader(keySchema).Read(decoded, NewBinaryDecoder(key)), nil)
+	assert(t, decoded.Get("kind"), "click")
+	assert(t, decoded.Get("user_id"), int64(42))
+
+	_, err = NewKeyExtractor(schema, []string{"user.missing"})
+	assert(t, err.Error(), "Record com.example.User has no field missing")
+	_, err = NewKeyExtractor(schema, []string{"kind.x"})
+	assert(t, err.Error(), "Field kind of kind.x is not a record")
+	_, err = NewKeyExtractor(schema, []string{"kind", "kind"})
+	assert(t, err.Error(), "Record com.example.EventKey has fields kind and kind both named kind")
+	_, err = e.Extract(buf.Bytes()[:10])
+	assert(t, err != nil, true)
+}
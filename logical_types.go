@@ -0,0 +1,510 @@
+package avro
+
+import (
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Logical types annotating long schemas with the number of milliseconds or microseconds from
+// 1970-01-01T00:00:00 UTC. They are read as time.Time values in UTC, and written from time.Time
+// values in any location by the instant they stand for. GenericDatumReaders, and SpecificDatumReaders
+// into interface{} fields, read them as int64 values unless SetLogicalTypes is called.
+const (
+	LogicalTypeTimestampMillis = "timestamp-millis"
+	LogicalTypeTimestampMicros = "timestamp-micros"
+)
+
+// Logical types annotating long schemas with the number of milliseconds or microseconds from
+// 1970-01-01T00:00:00 in an unspecified local time zone. Unlike UTC timestamps, they are
+// read and written as time.Time values by their wall clock, without shifting time zones.
+const (
+	LogicalTypeLocalTimestampMillis = "local-timestamp-millis"
+	LogicalTypeLocalTimestampMicros = "local-timestamp-micros"
+)
+
+// LogicalTypeDate annotates int schemas with the number of days from 1970-01-01, without a time of
+// day or time zone. Dates are read as time.Time values at midnight UTC, and written from time.Time
+// values by their date in their own location. GenericDatumReaders, and SpecificDatumReaders into
+// interface{} fields, read them as int32 values unless SetLogicalTypes is called.
+const LogicalTypeDate = "date"
+
+// LogicalTypeUUID annotates string schemas with UUIDs in the canonical 8-4-4-4-12 hexadecimal
+// format. Besides strings, SpecificDatumReader and SpecificDatumWriter map them to 16 byte arrays,
+// e.g. uuid.UUID, and to Go types implementing encoding.TextMarshaler and encoding.TextUnmarshaler.
+const LogicalTypeUUID = "uuid"
+
+// LogicalTypeDecimal annotates bytes and fixed schemas with decimal numbers of their Precision and
+// Scale, stored as the big-endian two's-complement integer of the number times 10 to the power of
+// the scale. Fixed decimals are sign-extended to the size of the schema. Decimals are read as
+// *big.Rat values, and written from *big.Rat and big.Rat values with no more digits than allowed.
+// GenericDatumReaders, and SpecificDatumReaders into interface{} fields, read them as []byte values
+// unless SetLogicalTypes is called.
+// As the specification requires, decimals with an invalid precision or scale, or a precision too
+// high for their fixed size, are read and written as plain bytes.
+const LogicalTypeDecimal = "decimal"
+
+const (
+	schemaLogicalTypeField = "logicalType"
+	schemaPrecisionField   = "precision"
+	schemaScaleField       = "scale"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	ratType             = reflect.TypeOf(big.Rat{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// SetLogicalTypes makes Read decode the values of logical types as the Go values they stand for,
+// time.Time for dates and timestamps and *big.Rat for decimals, rather than as the values of their underlying types.
+// Must be called before calling Read.
+func (reader *GenericDatumReader) SetLogicalTypes(logical bool) *GenericDatumReader {
+	reader.logicalTypes = logical
+	return reader
+}
+
+// SetLogicalTypes makes Read decode the values of logical types into interface{} fields as the Go
+// values they stand for, as a GenericDatumReader with SetLogicalTypes does, rather than as the
+// values of their underlying types. Fields of type time.Time or big.Rat get them either way.
+// Must be called before calling Read.
+func (reader *SpecificDatumReader) SetLogicalTypes(logical bool) *SpecificDatumReader {
+	reader.logicalTypes = logical
+	return reader
+}
+
+// SetProjectorLogicalTypes makes p, a DatumProjector created by NewDatumProjector, project values
+// to the Go values the logical types of the reader schema stand for, as a GenericDatumReader with
+// SetLogicalTypes does, rather than to the values of their underlying types.
+// Must be called before calling Project. May return an error if p is another kind of DatumProjector.
+func SetProjectorLogicalTypes(p DatumProjector, logical bool) error {
+	projector, ok := p.(*datumProjector)
+	if !ok {
+		return fmt.Errorf("DatumProjector %T doesn't support logical types", p)
+	}
+	projector.logicalTypes = logical
+	return nil
+}
+
+// timestampUnit returns the unit of the timestamp or local timestamp logical type of the given
+// schema, or 0 if it has none.
+func timestampUnit(s Schema) time.Duration {
+	if long, ok := s.(*LongSchema); ok {
+		switch long.LogicalType {
+		case LogicalTypeTimestampMillis, LogicalTypeLocalTimestampMillis:
+			return time.Millisecond
+		case LogicalTypeTimestampMicros, LogicalTypeLocalTimestampMicros:
+			return time.Microsecond
+		}
+	}
+	return 0
+}
+
+// isDate returns whether the given schema has the date logical type.
+func isDate(s Schema) bool {
+	i, ok := s.(*IntSchema)
+	return ok && i.LogicalType == LogicalTypeDate
+}
+
+// date converts a number of days from 1970-01-01 to a time.Time at midnight UTC.
+func date(days int32) time.Time {
+	return time.Date(1970, 1, 1+int(days), 0, 0, 0, 0, time.UTC)
+}
+
+// dateValue returns the number of days from 1970-01-01 to the date of t in its own location.
+func dateValue(t time.Time) int32 {
+	return int32(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / (24 * 3600))
+}
+
+// convertDate converts an int read with the writer schema to the representation of the reader
+// schema: time.Time for dates if logical and int32 otherwise.
+func convertDate(writer, reader Schema, v interface{}, logical bool) interface{} {
+	switch value := v.(type) {
+	case int32:
+		if logical && isDate(reader) {
+			return date(value)
+		}
+	case time.Time:
+		if isDate(writer) && !(logical && isDate(reader)) {
+			return dateValue(value)
+		}
+	}
+	return v
+}
+
+// timestamp converts a timestamp in the given unit to a time.Time in UTC, which for local timestamps
+// has the same wall clock.
+func timestamp(value int64, unit time.Duration) time.Time {
+	perSecond := int64(time.Second / unit)
+	seconds, rest := value/perSecond, value%perSecond
+	if rest < 0 {
+		seconds--
+		rest += perSecond
+	}
+	return time.Unix(seconds, rest*int64(unit)).UTC()
+}
+
+// timestampValue converts t to a timestamp in the unit of the timestamp schema s, truncating any finer
+// precision. Timestamps hold the instant of t, local timestamps its wall clock in its own location.
+func timestampValue(s Schema, t time.Time) int64 {
+	switch s.(*LongSchema).LogicalType {
+	case LogicalTypeLocalTimestampMillis, LogicalTypeLocalTimestampMicros:
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	unit := timestampUnit(s)
+	return t.Unix()*int64(time.Second/unit) + int64(t.Nanosecond())/int64(unit)
+}
+
+// convertTimestamp converts a long read with the writer schema to the representation of the reader
+// schema: time.Time for timestamps if logical and int64 otherwise.
+func convertTimestamp(writer, reader Schema, v interface{}, logical bool) interface{} {
+	switch value := v.(type) {
+	case int64:
+		if unit := timestampUnit(reader); logical && unit != 0 {
+			return timestamp(value, unit)
+		}
+	case time.Time:
+		if timestampUnit(writer) != 0 && !(logical && timestampUnit(reader) != 0) {
+			return timestampValue(writer, value)
+		}
+	}
+	return v
+}
+
+// isUUID returns whether the given schema has the UUID logical type.
+func isUUID(s Schema) bool {
+	str, ok := s.(*StringSchema)
+	return ok && str.LogicalType == LogicalTypeUUID
+}
+
+// isUUIDArray returns whether t is a 16 byte array.
+func isUUIDArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8
+}
+
+// isUUIDType returns whether values of t, or of the type it points to, can be written as UUIDs.
+func isUUIDType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String || isUUIDArray(t) || t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// validUUID returns whether s is a UUID in the canonical format.
+func validUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if s[i] != '-' {
+				return false
+			}
+		case '0' <= s[i] && s[i] <= '9', 'a' <= s[i] && s[i] <= 'f', 'A' <= s[i] && s[i] <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// uuidString returns the UUID held by v in the canonical format. v may be a string, which must be
+// in the canonical format already, a 16 byte array or implement encoding.TextMarshaler.
+func uuidString(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "", fmt.Errorf("Invalid uuid value: %v", v)
+	}
+	var s string
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("Invalid uuid value: %s", err)
+		}
+		s = string(text)
+	} else if v = dereference(v); v.Kind() == reflect.String {
+		s = v.String()
+	} else if isUUIDArray(v.Type()) {
+		var uuid [16]byte
+		reflect.Copy(reflect.ValueOf(uuid[:]), v)
+		s = fmt.Sprintf("%x-%x-%x-%x-%x", uuid[:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+	} else {
+		return "", fmt.Errorf("Invalid uuid value: %v", v)
+	}
+	if !validUUID(s) {
+		return "", fmt.Errorf("Invalid uuid value: %q", s)
+	}
+	return s, nil
+}
+
+// uuidValue converts a UUID read as a string to a value of t, a 16 byte array, a type implementing
+// encoding.TextUnmarshaler or a pointer to either.
+func uuidValue(s string, t reflect.Type) (reflect.Value, error) {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		v := reflect.New(t)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return reflect.Value{}, fmt.Errorf("Invalid uuid value %q: %s", s, err)
+		}
+		return v.Elem(), nil
+	}
+	switch {
+	case t.Kind() == reflect.Ptr:
+		elem, err := uuidValue(s, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.New(t.Elem())
+		v.Elem().Set(elem)
+		return v, nil
+	case isUUIDArray(t):
+		if !validUUID(s) {
+			return reflect.Value{}, fmt.Errorf("Invalid uuid value: %q", s)
+		}
+		var uuid [16]byte
+		hex.Decode(uuid[:], []byte(s[:8]+s[9:13]+s[14:18]+s[19:23]+s[24:]))
+		v := reflect.New(t).Elem()
+		reflect.Copy(v, reflect.ValueOf(uuid[:]))
+		return v, nil
+	}
+	return reflect.Value{}, fmt.Errorf("Can't read uuid into Go type %s", t)
+}
+
+// decimalAttributes returns the precision and scale in the JSON object of a schema, 0 if missing.
+func decimalAttributes(v map[string]interface{}) (precision, scale int) {
+	p, _ := v[schemaPrecisionField].(float64)
+	sc, _ := v[schemaScaleField].(float64)
+	return int(p), int(sc)
+}
+
+// logicalAttributes points at the fields a primitive or fixed schema keeps its logical type in, and
+// the precision and scale of decimals for those that can be, so that Prop, SetProp and DeleteProp
+// get and set them there rather than in its custom properties.
+type logicalAttributes struct {
+	props            *map[string]interface{}
+	logicalType      *string
+	precision, scale *int // nil unless the schema can be a decimal
+}
+
+func (a logicalAttributes) decimal() bool {
+	return a.precision != nil && *a.logicalType == LogicalTypeDecimal
+}
+
+func (a logicalAttributes) prop(key string) (interface{}, bool) {
+	switch {
+	case key == schemaLogicalTypeField && *a.logicalType != "":
+		return *a.logicalType, true
+	case key == schemaPrecisionField && a.decimal():
+		return *a.precision, true
+	case key == schemaScaleField && a.decimal():
+		return *a.scale, true
+	}
+	prop, ok := (*a.props)[key]
+	return prop, ok
+}
+
+func (a logicalAttributes) setProp(key string, value interface{}) error {
+	switch {
+	case key == schemaLogicalTypeField:
+		logicalType, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("Logical type must be a string, not %T", value)
+		}
+		a.setLogicalType(logicalType)
+		return nil
+	case (key == schemaPrecisionField || key == schemaScaleField) && a.decimal():
+		n, ok := intProp(value)
+		if !ok {
+			return fmt.Errorf("Decimal %s must be an integer, not %v", key, value)
+		}
+		if key == schemaPrecisionField {
+			*a.precision = n
+		} else {
+			*a.scale = n
+		}
+		return nil
+	}
+	return setProp(a.props, key, value)
+}
+
+func (a logicalAttributes) deleteProp(key string) {
+	switch {
+	case key == schemaLogicalTypeField && *a.logicalType != "":
+		a.setLogicalType("")
+	case key == schemaPrecisionField && a.decimal():
+		*a.precision = 0
+	case key == schemaScaleField && a.decimal():
+		*a.scale = 0
+	default:
+		delete(*a.props, key)
+	}
+}
+
+// setLogicalType changes the logical type, moving the precision and scale between the fields of
+// decimals and custom properties as parsing would have put them.
+func (a logicalAttributes) setLogicalType(logicalType string) {
+	wasDecimal := a.decimal()
+	*a.logicalType = logicalType
+	delete(*a.props, schemaLogicalTypeField)
+	switch {
+	case a.decimal() && !wasDecimal:
+		*a.precision, _ = intProp((*a.props)[schemaPrecisionField])
+		*a.scale, _ = intProp((*a.props)[schemaScaleField])
+		delete(*a.props, schemaPrecisionField)
+		delete(*a.props, schemaScaleField)
+	case wasDecimal && !a.decimal():
+		if *a.precision != 0 {
+			setProp(a.props, schemaPrecisionField, *a.precision)
+		}
+		if *a.scale != 0 {
+			setProp(a.props, schemaScaleField, *a.scale)
+		}
+		*a.precision, *a.scale = 0, 0
+	}
+}
+
+// intProp returns the value of an integer property, parsed from JSON or set as any Go integer.
+func intProp(value interface{}) (int, bool) {
+	switch n := value.(type) {
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), float64(int(n)) == n
+	}
+	return 0, false
+}
+
+// decimalParams returns the precision and scale of the decimal logical type of the given schema,
+// and whether it has a valid one.
+func decimalParams(s Schema) (precision, scale int, ok bool) {
+	switch s := s.(type) {
+	case *BytesSchema:
+		if s.LogicalType != LogicalTypeDecimal {
+			return 0, 0, false
+		}
+		precision, scale = s.Precision, s.Scale
+	case *FixedSchema:
+		if s.LogicalType != LogicalTypeDecimal || s.Precision > maxFixedDecimalPrecision(s.Size) {
+			return 0, 0, false
+		}
+		precision, scale = s.Precision, s.Scale
+	default:
+		return 0, 0, false
+	}
+	return precision, scale, precision > 0 && scale >= 0 && scale <= precision
+}
+
+// isDecimal returns whether the given schema has a valid decimal logical type.
+func isDecimal(s Schema) bool {
+	_, _, ok := decimalParams(s)
+	return ok
+}
+
+// isDecimalType returns whether t, or the type it points to, is big.Rat.
+func isDecimalType(t reflect.Type) bool {
+	return t == ratType || t.Kind() == reflect.Ptr && t.Elem() == ratType
+}
+
+// maxFixedDecimalPrecision returns the number of digits always fitting in size bytes of two's
+// complement, i.e. floor(log10(2^(8*size-1) - 1)).
+func maxFixedDecimalPrecision(size int) int {
+	if size <= 0 {
+		return 0
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), uint(8*size-1))
+	return len(max.Sub(max, big.NewInt(1)).String()) - 1
+}
+
+// decimal converts the two's-complement bytes of a decimal of the given schema to its value.
+func decimal(s Schema, b []byte) *big.Rat {
+	_, scale, _ := decimalParams(s)
+	unscaled := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+}
+
+// decimalBytes returns the two's-complement bytes of the decimal held by v, a big.Rat or a pointer to
+// one, for the decimal schema s. Values with more digits after the point than the scale, or more in
+// all than the precision, are errors, as are values too large for fixed schemas.
+func decimalBytes(s Schema, v reflect.Value) ([]byte, error) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || !isDecimalType(v.Type()) || v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, fmt.Errorf("Invalid decimal value: %v", v)
+	}
+	value := dereference(v).Interface().(big.Rat)
+
+	precision, scale, _ := decimalParams(s)
+	scaled := new(big.Rat).Mul(&value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("Invalid decimal value %s: more than %d digits after the point", value.RatString(), scale)
+	}
+	unscaled := scaled.Num()
+	if digits := len(new(big.Int).Abs(unscaled).String()); unscaled.Sign() != 0 && digits > precision {
+		return nil, fmt.Errorf("Invalid decimal value %s: more than %d digits", value.FloatString(scale), precision)
+	}
+
+	var b []byte
+	if unscaled.Sign() >= 0 {
+		b = unscaled.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+	} else {
+		// The smallest number of bytes n holding the value is such that value >= -2^(8n-1).
+		n := new(big.Int).Not(unscaled).BitLen()/8 + 1
+		b = new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(8*n)), unscaled).Bytes()
+	}
+
+	if fixed, ok := s.(*FixedSchema); ok {
+		if len(b) > fixed.Size {
+			return nil, fmt.Errorf("Invalid decimal value %s: doesn't fit in %d bytes", value.FloatString(scale), fixed.Size)
+		}
+		padded := make([]byte, fixed.Size)
+		if unscaled.Sign() < 0 {
+			for i := range padded {
+				padded[i] = 0xff
+			}
+		}
+		copy(padded[fixed.Size-len(b):], b)
+		b = padded
+	}
+	return b, nil
+}
+
+// convertDecimal converts bytes or fixed read with the writer schema to the representation of the
+// reader schema: *big.Rat for decimals if logical and []byte otherwise.
+func convertDecimal(writer, reader Schema, v interface{}, logical bool) (interface{}, error) {
+	switch value := v.(type) {
+	case []byte:
+		if logical && isDecimal(reader) {
+			return decimal(reader, value), nil
+		}
+	case *big.Rat:
+		if isDecimal(writer) && !(logical && isDecimal(reader)) {
+			return decimalBytes(writer, reflect.ValueOf(value))
+		}
+	}
+	return v, nil
+}
+
+// decimalValue returns r as a value of t, big.Rat or a pointer or interface holding a *big.Rat.
+func decimalValue(r *big.Rat, t reflect.Type) reflect.Value {
+	if t == ratType {
+		return reflect.ValueOf(*r)
+	}
+	return reflect.ValueOf(r)
+}
@@ -0,0 +1,560 @@
+package avro
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var localTimestampSchema = MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+	{"name": "millis", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+	{"name": "micros", "type": {"type": "long", "logicalType": "local-timestamp-micros"}},
+	{"name": "raw", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+	{"name": "optional", "type": ["null", {"type": "long", "logicalType": "local-timestamp-micros"}]}
+]}`)
+
+type localTimestampEvent struct {
+	Millis   time.Time   `avro:"millis"`
+	Micros   time.Time   `avro:"micros"`
+	Raw      int64       `avro:"raw"`
+	Optional interface{} `avro:"optional"`
+}
+
+func TestLocalTimestampSchema(t *testing.T) {
+	field := localTimestampSchema.(*RecordSchema).Fields[0].Type
+	assert(t, field.(*LongSchema).LogicalType, LogicalTypeLocalTimestampMillis)
+	assert(t, field.String(), `{"type":"long","logicalType":"local-timestamp-millis"}`)
+	json, err := field.(*LongSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"long","logicalType":"local-timestamp-millis"}`)
+
+	reparsed, err := ParseSchema(localTimestampSchema.String())
+	assert(t, err, nil)
+	assert(t, reparsed.(*RecordSchema).Fields[1].Type.(*LongSchema).LogicalType, LogicalTypeLocalTimestampMicros)
+
+	canonical, err := CanonicalForm(localTimestampSchema.(*RecordSchema).Fields[0].Type)
+	assert(t, err, nil)
+	assert(t, canonical, `"long"`)
+	assert(t, MustParseSchema(`{"type": "long"}`).(*LongSchema).LogicalType, "")
+}
+
+func TestLocalTimestampDatum(t *testing.T) {
+	// The wall clock is kept as is, regardless of the location.
+	wall := time.Date(2021, 3, 4, 5, 6, 7, 891234567, time.FixedZone("UTC+5", 5*3600))
+	millis := time.Date(2021, 3, 4, 5, 6, 7, 891000000, time.UTC)
+	micros := time.Date(2021, 3, 4, 5, 6, 7, 891234000, time.UTC)
+
+	event := &localTimestampEvent{Millis: wall, Micros: wall, Raw: millis.UnixNano() / 1e6, Optional: wall}
+	encoded := testEncodeBytes(localTimestampSchema, event)
+	dec := NewBinaryDecoder(encoded)
+	value, err := dec.ReadLong()
+	assert(t, err, nil)
+	assert(t, value, millis.UnixNano()/1e6)
+
+	decoded := &localTimestampEvent{}
+	reader := NewSpecificDatumReader().SetLogicalTypes(true).SetSchema(localTimestampSchema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded, &localTimestampEvent{Millis: millis, Micros: micros, Raw: event.Raw, Optional: micros})
+
+	record := NewGenericRecord(localTimestampSchema)
+	assert(t, NewGenericDatumReader().SetSchema(localTimestampSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("millis"), event.Raw)
+	assert(t, NewGenericDatumReader().SetLogicalTypes(true).SetSchema(localTimestampSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("millis"), millis)
+	assert(t, record.Get("micros"), micros)
+	assert(t, record.Get("raw"), millis)
+	assert(t, record.Get("optional"), micros)
+
+	var buf bytes.Buffer
+	record.Set("raw", event.Raw)
+	assert(t, NewGenericDatumWriter().SetSchema(localTimestampSchema).Write(record, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), encoded)
+
+	assert(t, ValidateSpecific(localTimestampSchema, event), nil)
+	assert(t, timestamp(-1, time.Microsecond), time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC))
+	assert(t, timestampValue(&LongSchema{LogicalType: LogicalTypeLocalTimestampMicros}, time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC)), int64(-1))
+}
+
+func TestLocalTimestampProjection(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "millis", "type": "long"},
+		{"name": "micros", "type": {"type": "long", "logicalType": "local-timestamp-micros"}}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "millis", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+		{"name": "micros", "type": "long"},
+		{"name": "added", "type": {"type": "long", "logicalType": "local-timestamp-millis"}, "default": 1000}
+	]}`)
+	projector, err := NewDatumProjector(writer, reader)
+	assert(t, err, nil)
+
+	record := NewGenericRecord(writer)
+	record.Set("millis", int64(1500))
+	record.Set("micros", time.Date(1970, 1, 1, 0, 0, 2, 0, time.UTC))
+	value, err := projector.Project(record)
+	assert(t, err, nil)
+	projected := value.(*GenericRecord)
+	assert(t, projected.Get("millis"), int64(1500))
+	assert(t, projected.Get("micros"), int64(2000000))
+	assert(t, projected.Get("added"), int64(1000))
+
+	assert(t, SetProjectorLogicalTypes(projector, true), nil)
+	value, err = projector.Project(record)
+	assert(t, err, nil)
+	projected = value.(*GenericRecord)
+	assert(t, projected.Get("millis"), time.Date(1970, 1, 1, 0, 0, 1, 500000000, time.UTC))
+	assert(t, projected.Get("micros"), int64(2000000))
+	assert(t, projected.Get("added"), time.Date(1970, 1, 1, 0, 0, 1, 0, time.UTC))
+}
+
+var uuidSchema = MustParseSchema(`{"type": "record", "name": "Entity", "fields": [
+	{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+	{"name": "text", "type": {"type": "string", "logicalType": "uuid"}},
+	{"name": "raw", "type": {"type": "string", "logicalType": "uuid"}},
+	{"name": "parent", "type": ["null", {"type": "string", "logicalType": "uuid"}]}
+]}`)
+
+type testUUID [16]byte
+
+// textUUID holds a UUID as text, standing in for UUID types that aren't byte arrays.
+type textUUID struct {
+	text string
+}
+
+func (u textUUID) MarshalText() ([]byte, error) {
+	return []byte(u.text), nil
+}
+
+func (u *textUUID) UnmarshalText(text []byte) error {
+	u.text = string(text)
+	return nil
+}
+
+type uuidEntity struct {
+	ID     testUUID  `avro:"id"`
+	Text   textUUID  `avro:"text"`
+	Raw    string    `avro:"raw"`
+	Parent *testUUID `avro:"parent"`
+}
+
+func TestUUIDSchema(t *testing.T) {
+	field := uuidSchema.(*RecordSchema).Fields[0].Type
+	assert(t, field.(*StringSchema).LogicalType, LogicalTypeUUID)
+	assert(t, field.String(), `{"type":"string","logicalType":"uuid"}`)
+	json, err := field.(*StringSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"string","logicalType":"uuid"}`)
+
+	reparsed, err := ParseSchema(uuidSchema.String())
+	assert(t, err, nil)
+	assert(t, reparsed.(*RecordSchema).Fields[1].Type.(*StringSchema).LogicalType, LogicalTypeUUID)
+
+	canonical, err := CanonicalForm(field)
+	assert(t, err, nil)
+	assert(t, canonical, `"string"`)
+	assert(t, MustParseSchema(`{"type": "string"}`).(*StringSchema).LogicalType, "")
+}
+
+func TestUUIDDatum(t *testing.T) {
+	id := testUUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	entity := &uuidEntity{
+		ID:     id,
+		Text:   textUUID{"00000000-0000-0000-0000-00000000000A"},
+		Raw:    "f81d4fae-7dec-11d0-a765-00a0c91e6bf6",
+		Parent: &id,
+	}
+	encoded := testEncodeBytes(uuidSchema, entity)
+	dec := NewBinaryDecoder(encoded)
+	value, err := dec.ReadString()
+	assert(t, err, nil)
+	assert(t, value, "123e4567-e89b-12d3-a456-426614174000")
+
+	decoded := &uuidEntity{}
+	reader := NewSpecificDatumReader().SetSchema(uuidSchema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded, entity)
+
+	record := NewGenericRecord(uuidSchema)
+	assert(t, NewGenericDatumReader().SetSchema(uuidSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("id"), "123e4567-e89b-12d3-a456-426614174000")
+	assert(t, record.Get("parent"), "123e4567-e89b-12d3-a456-426614174000")
+
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(uuidSchema).Write(record, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), encoded)
+	record.Set("raw", "f81d4fae7dec11d0a76500a0c91e6bf6")
+	assert(t, NewGenericDatumWriter().SetSchema(uuidSchema).Write(record, NewBinaryEncoder(&buf)).Error(), `Invalid uuid value: "f81d4fae7dec11d0a76500a0c91e6bf6"`)
+
+	assert(t, ValidateSpecific(uuidSchema, entity), nil)
+	writer := NewSpecificDatumWriter().SetSchema(uuidSchema)
+	entity.Parent = nil
+	assert(t, writer.Write(entity, NewBinaryEncoder(&buf)), nil)
+	entity.Raw = "f81d4fae-7dec-11d0-a765-00a0c91e6bfg"
+	assert(t, writer.Write(entity, NewBinaryEncoder(&buf)).Error(), `Invalid uuid value: "f81d4fae-7dec-11d0-a765-00a0c91e6bfg"`)
+	entity.Raw, entity.Text = "f81d4fae-7dec-11d0-a765-00a0c91e6bf6", textUUID{"nope"}
+	assert(t, writer.Write(entity, NewBinaryEncoder(&buf)).Error(), `Invalid uuid value: "nope"`)
+}
+
+var dateSchema = MustParseSchema(`{"type": "record", "name": "Person", "fields": [
+	{"name": "born", "type": {"type": "int", "logicalType": "date"}},
+	{"name": "raw", "type": {"type": "int", "logicalType": "date"}},
+	{"name": "died", "type": ["null", {"type": "int", "logicalType": "date"}]}
+]}`)
+
+type datePerson struct {
+	Born time.Time   `avro:"born"`
+	Raw  int32       `avro:"raw"`
+	Died interface{} `avro:"died"`
+}
+
+func TestDateSchema(t *testing.T) {
+	field := dateSchema.(*RecordSchema).Fields[0].Type
+	assert(t, field.(*IntSchema).LogicalType, LogicalTypeDate)
+	assert(t, field.String(), `{"type":"int","logicalType":"date"}`)
+	json, err := field.(*IntSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"int","logicalType":"date"}`)
+
+	reparsed, err := ParseSchema(dateSchema.String())
+	assert(t, err, nil)
+	assert(t, reparsed.(*RecordSchema).Fields[1].Type.(*IntSchema).LogicalType, LogicalTypeDate)
+
+	canonical, err := CanonicalForm(field)
+	assert(t, err, nil)
+	assert(t, canonical, `"int"`)
+	assert(t, MustParseSchema(`{"type": "int"}`).(*IntSchema).LogicalType, "")
+}
+
+func TestDateDatum(t *testing.T) {
+	// The date is taken in the location of the time, late evenings don't shift to the next day.
+	born := time.Date(1969, 7, 20, 22, 56, 0, 0, time.FixedZone("UTC-5", -5*3600))
+	died := time.Date(2012, 8, 25, 0, 0, 0, 0, time.UTC)
+
+	person := &datePerson{Born: born, Raw: 15577, Died: died}
+	encoded := testEncodeBytes(dateSchema, person)
+	dec := NewBinaryDecoder(encoded)
+	value, err := dec.ReadInt()
+	assert(t, err, nil)
+	assert(t, value, int32(-165))
+
+	decoded := &datePerson{}
+	reader := NewSpecificDatumReader().SetSchema(dateSchema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded, &datePerson{Born: time.Date(1969, 7, 20, 0, 0, 0, 0, time.UTC), Raw: 15577, Died: int32(15577)})
+	reader = NewSpecificDatumReader().SetLogicalTypes(true).SetSchema(dateSchema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded, &datePerson{Born: time.Date(1969, 7, 20, 0, 0, 0, 0, time.UTC), Raw: 15577, Died: died})
+
+	// Generic readers only read dates as times when asked to.
+	record := NewGenericRecord(dateSchema)
+	assert(t, NewGenericDatumReader().SetSchema(dateSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("born"), int32(-165))
+	assert(t, record.Get("died"), int32(15577))
+	assert(t, NewGenericDatumReader().SetLogicalTypes(true).SetSchema(dateSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("born"), time.Date(1969, 7, 20, 0, 0, 0, 0, time.UTC))
+	assert(t, record.Get("raw"), died)
+	assert(t, record.Get("died"), died)
+
+	var buf bytes.Buffer
+	record.Set("raw", int32(15577))
+	assert(t, NewGenericDatumWriter().SetSchema(dateSchema).Write(record, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), encoded)
+
+	assert(t, ValidateSpecific(dateSchema, person), nil)
+	assert(t, date(dateValue(died)), died)
+}
+
+func TestDateProjection(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Person", "fields": [
+		{"name": "born", "type": "int"},
+		{"name": "died", "type": {"type": "int", "logicalType": "date"}}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Person", "fields": [
+		{"name": "born", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "died", "type": "long"},
+		{"name": "added", "type": {"type": "int", "logicalType": "date"}, "default": 1}
+	]}`)
+	projector, err := NewDatumProjector(writer, reader)
+	assert(t, err, nil)
+
+	record := NewGenericRecord(writer)
+	record.Set("born", int32(-1))
+	record.Set("died", time.Date(1970, 1, 3, 0, 0, 0, 0, time.UTC))
+	value, err := projector.Project(record)
+	assert(t, err, nil)
+	projected := value.(*GenericRecord)
+	assert(t, projected.Get("born"), int32(-1))
+	assert(t, projected.Get("died"), int64(2))
+	assert(t, projected.Get("added"), int32(1))
+
+	assert(t, SetProjectorLogicalTypes(projector, true), nil)
+	value, err = projector.Project(record)
+	assert(t, err, nil)
+	projected = value.(*GenericRecord)
+	assert(t, projected.Get("born"), time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert(t, projected.Get("died"), int64(2))
+	assert(t, projected.Get("added"), time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC))
+}
+
+var timestampSchema = MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+	{"name": "millis", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+	{"name": "micros", "type": ["null", {"type": "long", "logicalType": "timestamp-micros"}]},
+	{"name": "raw", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+]}`)
+
+type timestampEvent struct {
+	Millis time.Time   `avro:"millis"`
+	Micros interface{} `avro:"micros"`
+	Raw    int64       `avro:"raw"`
+}
+
+func TestTimestampDatum(t *testing.T) {
+	// Unlike local timestamps, timestamps stand for the instant whatever the location.
+	at := time.Date(2021, 3, 4, 5, 6, 7, 891234567, time.FixedZone("UTC+5", 5*3600))
+	millis := time.Date(2021, 3, 4, 0, 6, 7, 891000000, time.UTC)
+	micros := time.Date(2021, 3, 4, 0, 6, 7, 891234000, time.UTC)
+
+	event := &timestampEvent{Millis: at, Micros: at, Raw: millis.UnixNano() / 1e6}
+	encoded := testEncodeBytes(timestampSchema, event)
+	dec := NewBinaryDecoder(encoded)
+	value, err := dec.ReadLong()
+	assert(t, err, nil)
+	assert(t, value, millis.UnixNano()/1e6)
+
+	decoded := &timestampEvent{}
+	reader := NewSpecificDatumReader().SetSchema(timestampSchema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded, &timestampEvent{Millis: millis, Micros: micros.UnixNano() / 1e3, Raw: event.Raw})
+	reader = NewSpecificDatumReader().SetLogicalTypes(true).SetSchema(timestampSchema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded, &timestampEvent{Millis: millis, Micros: micros, Raw: event.Raw})
+
+	record := NewGenericRecord(timestampSchema)
+	assert(t, NewGenericDatumReader().SetSchema(timestampSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("millis"), event.Raw)
+	assert(t, record.Get("micros"), micros.UnixNano()/1e3)
+	assert(t, NewGenericDatumReader().SetLogicalTypes(true).SetSchema(timestampSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("millis"), millis)
+	assert(t, record.Get("micros"), micros)
+
+	var buf bytes.Buffer
+	record.Set("raw", event.Raw)
+	assert(t, NewGenericDatumWriter().SetSchema(timestampSchema).Write(record, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), encoded)
+
+	assert(t, ValidateSpecific(timestampSchema, event), nil)
+	assert(t, timestampValue(&LongSchema{LogicalType: LogicalTypeTimestampMicros}, at), micros.UnixNano()/1e3)
+}
+
+func TestLocalTimestampVersusTimestamp(t *testing.T) {
+	local := MustParseSchema(`{"type": "long", "logicalType": "local-timestamp-millis"}`)
+	utc := MustParseSchema(`{"type": "long", "logicalType": "timestamp-millis"}`)
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("UTC-8", -8*3600))
+
+	// The same time is written by its wall clock or by its instant.
+	var localBuf, utcBuf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(local).Write(at, NewBinaryEncoder(&localBuf)), nil)
+	assert(t, NewGenericDatumWriter().SetSchema(utc).Write(at, NewBinaryEncoder(&utcBuf)), nil)
+	localValue, err := NewBinaryDecoder(localBuf.Bytes()).ReadLong()
+	assert(t, err, nil)
+	utcValue, err := NewBinaryDecoder(utcBuf.Bytes()).ReadLong()
+	assert(t, err, nil)
+	assert(t, utcValue-localValue, int64(8*3600*1000))
+
+	// Both read back in UTC, local timestamps with the wall clock they were written with.
+	specific := struct {
+		Value time.Time
+	}{}
+	assert(t, NewSpecificDatumReader().SetSchema(MustParseSchema(`{"type": "record", "name": "R", "fields": [
+		{"name": "Value", "type": {"type": "long", "logicalType": "local-timestamp-millis"}}
+	]}`)).Read(&specific, NewBinaryDecoder(localBuf.Bytes())), nil)
+	assert(t, specific.Value, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC))
+	var generic interface{}
+	assert(t, NewGenericDatumReader().SetLogicalTypes(true).SetSchema(utc).Read(&generic, NewBinaryDecoder(utcBuf.Bytes())), nil)
+	assert(t, generic, time.Date(2021, 3, 4, 13, 6, 7, 0, time.UTC))
+	assert(t, generic.(time.Time).Equal(at), true)
+
+	// Projecting between them keeps the long as written.
+	projector, err := NewDatumProjector(local, utc)
+	assert(t, err, nil)
+	projected, err := projector.Project(specific.Value)
+	assert(t, err, nil)
+	assert(t, projected, localValue)
+	assert(t, SetProjectorLogicalTypes(projector, true), nil)
+	projected, err = projector.Project(specific.Value)
+	assert(t, err, nil)
+	assert(t, timestampValue(utc, projected.(time.Time)), localValue)
+}
+
+var decimalSchema = MustParseSchema(`{"type": "record", "name": "Payment", "fields": [
+	{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+	{"name": "fee", "type": {"type": "fixed", "name": "Fee", "size": 4, "logicalType": "decimal", "precision": 9, "scale": 2}},
+	{"name": "rate", "type": ["null", {"type": "fixed", "name": "Rate", "size": 2, "logicalType": "decimal", "precision": 4, "scale": 3}]},
+	{"name": "raw", "type": {"type": "fixed", "name": "Raw", "size": 2, "logicalType": "decimal", "precision": 4, "scale": 3}}
+]}`)
+
+type decimalPayment struct {
+	Amount big.Rat     `avro:"amount"`
+	Fee    *big.Rat    `avro:"fee"`
+	Rate   interface{} `avro:"rate"`
+	Raw    []byte      `avro:"raw"`
+}
+
+func TestDecimalSchema(t *testing.T) {
+	amount := decimalSchema.(*RecordSchema).Fields[0].Type.(*BytesSchema)
+	assert(t, amount.LogicalType, LogicalTypeDecimal)
+	assert(t, amount.Precision, 9)
+	assert(t, amount.Scale, 2)
+	assert(t, amount.String(), `{"type":"bytes","logicalType":"decimal","precision":9,"scale":2}`)
+	json, err := amount.MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"bytes","logicalType":"decimal","precision":9,"scale":2}`)
+
+	fee := decimalSchema.(*RecordSchema).Fields[1].Type.(*FixedSchema)
+	assert(t, fee.LogicalType, LogicalTypeDecimal)
+	assert(t, fee.Precision, 9)
+	assert(t, fee.Scale, 2)
+	assert(t, len(fee.Properties), 0)
+	json, err = fee.MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"fixed","size":4,"name":"Fee","logicalType":"decimal","precision":9,"scale":2}`)
+
+	reparsed, err := ParseSchema(decimalSchema.String())
+	assert(t, err, nil)
+	assert(t, reparsed.(*RecordSchema).Fields[0].Type.(*BytesSchema).Precision, 9)
+	assert(t, reparsed.(*RecordSchema).Fields[1].Type.(*FixedSchema).Scale, 2)
+
+	canonical, err := CanonicalForm(fee)
+	assert(t, err, nil)
+	assert(t, canonical, `{"name":"Fee","type":"fixed","size":4}`)
+
+	// Invalid decimals are plain bytes and fixed.
+	assert(t, isDecimal(fee), true)
+	assert(t, isDecimal(MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 2, "scale": 3}`)), false)
+	assert(t, isDecimal(MustParseSchema(`{"type": "bytes", "logicalType": "decimal"}`)), false)
+	assert(t, isDecimal(MustParseSchema(`{"type": "fixed", "name": "F", "size": 4, "logicalType": "decimal", "precision": 10}`)), false)
+	assert(t, maxFixedDecimalPrecision(1), 2)
+	assert(t, maxFixedDecimalPrecision(4), 9)
+	assert(t, maxFixedDecimalPrecision(16), 38)
+}
+
+func TestDecimalDatum(t *testing.T) {
+	payment := &decimalPayment{Fee: big.NewRat(-1, 100), Rate: big.NewRat(1, 8), Raw: []byte{0x01, 0x02}}
+	payment.Amount.SetFrac64(12345, 100)
+	encoded := testEncodeBytes(decimalSchema, payment)
+	assert(t, encoded, []byte{
+		0x04, 0x30, 0x39, // amount 12345, with as few bytes as possible
+		0xff, 0xff, 0xff, 0xff, // fee -1, sign-extended to the size
+		0x02, 0x00, 0x7d, // rate 125
+		0x01, 0x02, // raw
+	})
+
+	decoded := &decimalPayment{}
+	assert(t, NewSpecificDatumReader().SetSchema(decimalSchema).Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded.Amount.RatString(), "2469/20")
+	assert(t, decoded.Fee.RatString(), "-1/100")
+	assert(t, decoded.Rate, []byte{0x00, 0x7d})
+	assert(t, decoded.Raw, []byte{0x01, 0x02})
+	assert(t, NewSpecificDatumReader().SetLogicalTypes(true).SetSchema(decimalSchema).Read(decoded, NewBinaryDecoder(encoded)), nil)
+	assert(t, decoded.Rate.(*big.Rat).RatString(), "1/8")
+
+	record := NewGenericRecord(decimalSchema)
+	assert(t, NewGenericDatumReader().SetSchema(decimalSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("amount"), []byte{0x30, 0x39})
+	assert(t, record.Get("fee"), []byte{0xff, 0xff, 0xff, 0xff})
+	assert(t, NewGenericDatumReader().SetLogicalTypes(true).SetSchema(decimalSchema).Read(record, NewBinaryDecoder(encoded)), nil)
+	assert(t, record.Get("amount").(*big.Rat).FloatString(2), "123.45")
+	assert(t, record.Get("fee").(*big.Rat).FloatString(2), "-0.01")
+	assert(t, record.Get("raw").(*big.Rat).FloatString(3), "0.258")
+
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(decimalSchema).Write(record, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), encoded)
+	assert(t, ValidateSpecific(decimalSchema, payment), nil)
+
+	copied, err := DeepCopy(decimalSchema, record)
+	assert(t, err, nil)
+	record.Get("amount").(*big.Rat).SetInt64(0)
+	assert(t, copied.(*GenericRecord).Get("amount").(*big.Rat).FloatString(2), "123.45")
+}
+
+func TestDecimalBytes(t *testing.T) {
+	bytesSchema := decimalSchema.(*RecordSchema).Fields[0].Type
+	fixedSchema := decimalSchema.(*RecordSchema).Fields[1].Type
+	for _, test := range []struct {
+		value *big.Rat
+		bytes []byte
+		fixed []byte
+	}{
+		{big.NewRat(0, 1), []byte{0x00}, []byte{0x00, 0x00, 0x00, 0x00}},
+		{big.NewRat(127, 100), []byte{0x7f}, []byte{0x00, 0x00, 0x00, 0x7f}},
+		{big.NewRat(128, 100), []byte{0x00, 0x80}, []byte{0x00, 0x00, 0x00, 0x80}},
+		{big.NewRat(-128, 100), []byte{0x80}, []byte{0xff, 0xff, 0xff, 0x80}},
+		{big.NewRat(-129, 100), []byte{0xff, 0x7f}, []byte{0xff, 0xff, 0xff, 0x7f}},
+		{big.NewRat(-999999999, 100), []byte{0xc4, 0x65, 0x36, 0x01}, []byte{0xc4, 0x65, 0x36, 0x01}},
+	} {
+		b, err := decimalBytes(bytesSchema, reflect.ValueOf(test.value))
+		assert(t, err, nil)
+		assert(t, b, test.bytes)
+		assert(t, decimal(bytesSchema, b).Cmp(test.value), 0)
+		b, err = decimalBytes(fixedSchema, reflect.ValueOf(test.value))
+		assert(t, err, nil)
+		assert(t, b, test.fixed)
+		assert(t, decimal(fixedSchema, b).Cmp(test.value), 0)
+	}
+
+	_, err := decimalBytes(fixedSchema, reflect.ValueOf(big.NewRat(1, 1000)))
+	assert(t, err.Error(), "Invalid decimal value 1/1000: more than 2 digits after the point")
+	_, err = decimalBytes(fixedSchema, reflect.ValueOf(big.NewRat(10000000, 1)))
+	assert(t, err.Error(), "Invalid decimal value 10000000.00: more than 9 digits")
+	_, err = decimalBytes(fixedSchema, reflect.ValueOf("1.5"))
+	assert(t, err.Error(), "Invalid decimal value: 1.5")
+	assert(t, fixedSchema.Validate(reflect.ValueOf(big.NewRat(1, 3))), false)
+	assert(t, fixedSchema.Validate(reflect.ValueOf(big.NewRat(1, 4))), true)
+
+	// The largest values of the precision fit in the size.
+	tight := &FixedSchema{Name: "Tight", Size: 1, LogicalType: LogicalTypeDecimal, Precision: 2}
+	b, err := decimalBytes(tight, reflect.ValueOf(big.NewRat(-99, 1)))
+	assert(t, err, nil)
+	assert(t, b, []byte{0x9d})
+	tight.Precision = 3
+	assert(t, isDecimal(tight), false)
+
+	payment := &decimalPayment{Fee: big.NewRat(1, 1000)}
+	err = NewSpecificDatumWriter().SetSchema(decimalSchema).Write(payment, NewBinaryEncoder(new(bytes.Buffer)))
+	assert(t, err.Error(), "Invalid decimal value 1/1000: more than 2 digits after the point")
+}
+
+func TestDecimalProjection(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Payment", "fields": [
+		{"name": "amount", "type": "bytes"},
+		{"name": "fee", "type": {"type": "fixed", "name": "Fee", "size": 4, "logicalType": "decimal", "precision": 9, "scale": 2}}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Payment", "fields": [
+		{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+		{"name": "fee", "type": {"type": "fixed", "name": "Fee", "size": 4}},
+		{"name": "tax", "type": {"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 1}, "default": "\u0001"}
+	]}`)
+	projector, err := NewDatumProjector(writer, reader)
+	assert(t, err, nil)
+
+	record := NewGenericRecord(writer)
+	record.Set("amount", []byte{0x01, 0x00})
+	record.Set("fee", big.NewRat(5, 100))
+	value, err := projector.Project(record)
+	assert(t, err, nil)
+	projected := value.(*GenericRecord)
+	assert(t, projected.Get("amount"), []byte{0x01, 0x00})
+	assert(t, projected.Get("fee"), []byte{0x00, 0x00, 0x00, 0x05})
+	assert(t, projected.Get("tax"), []byte{0x01})
+
+	assert(t, SetProjectorLogicalTypes(projector, true), nil)
+	value, err = projector.Project(record)
+	assert(t, err, nil)
+	projected = value.(*GenericRecord)
+	assert(t, projected.Get("amount").(*big.Rat).FloatString(2), "2.56")
+	assert(t, projected.Get("fee"), []byte{0x00, 0x00, 0x00, 0x05})
+	assert(t, projected.Get("tax").(*big.Rat).FloatString(1), "0.1")
+}
@@ -0,0 +1,117 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// MessageCatalog routes single-object encoded messages of several record types exchanged over one
+// channel, e.g. by services sharing a queue. Every type of message has a logical name, the writer
+// schema it's encoded with and the reader schema it's decoded to. Messages are routed by the
+// fingerprint of their writer schema, so every writer schema can belong to a single message name.
+//
+// A MessageCatalog is safe for concurrent use.
+type MessageCatalog struct {
+	lock          sync.RWMutex
+	byName        map[string]*catalogMessage
+	byFingerprint map[uint64]*catalogWriter
+}
+
+type catalogMessage struct {
+	name   string
+	reader Schema
+	writer *catalogWriter // to encode messages with
+}
+
+type catalogWriter struct {
+	message   *catalogMessage
+	schema    Schema
+	header    []byte
+	projector DatumProjector
+}
+
+// NewMessageCatalog creates an empty MessageCatalog.
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{byName: make(map[string]*catalogMessage), byFingerprint: make(map[uint64]*catalogWriter)}
+}
+
+// Add adds a writer and reader schema pair for messages of the given name. Adding other writer
+// schemas for the same name and reader schema, such as older versions, makes messages written with
+// them decodable too, while messages are encoded with the one added last.
+// May return an error if the writer schema can't be projected to the reader schema, if the name was
+// added with another reader schema, or if the writer schema belongs to another name already.
+func (c *MessageCatalog) Add(name string, writer, reader Schema) error {
+	header, err := singleObjectPrefix(writer)
+	if err != nil {
+		return err
+	}
+	projector, err := NewDatumProjector(writer, reader)
+	if err != nil {
+		return fmt.Errorf("Message %s: %s", name, err)
+	}
+	fingerprint := binary.LittleEndian.Uint64(header[2:])
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	message := c.byName[name]
+	if message == nil {
+		message = &catalogMessage{name: name, reader: reader}
+	} else if message.reader.String() != reader.String() {
+		return fmt.Errorf("Message %s has another reader schema already", name)
+	}
+	if existing := c.byFingerprint[fingerprint]; existing != nil && existing.message != message {
+		return fmt.Errorf("Writer schema of message %s belongs to message %s already", name, existing.message.name)
+	}
+	w := &catalogWriter{message: message, schema: writer, header: header, projector: projector}
+	message.writer = w
+	c.byName[name] = message
+	c.byFingerprint[fingerprint] = w
+	return nil
+}
+
+// Encode encodes v as a message of the given name in the single-object encoding, with the writer
+// schema added last for it. v is written the way a DatumWriter returned by NewDatumWriter would.
+func (c *MessageCatalog) Encode(name string, v interface{}) ([]byte, error) {
+	c.lock.RLock()
+	var w *catalogWriter
+	if message := c.byName[name]; message != nil {
+		w = message.writer
+	}
+	c.lock.RUnlock()
+	if w == nil {
+		return nil, fmt.Errorf("Unknown message %s", name)
+	}
+	buf := bytes.NewBuffer(append([]byte{}, w.header...))
+	if err := NewDatumWriter(w.schema).Write(v, newBinaryEncoder(buf)); err != nil {
+		return nil, fmt.Errorf("Message %s: %s", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a message in the single-object encoding, returning the name of the message its
+// writer schema belongs to and its value projected to the reader schema of that name, in the same
+// shape a GenericDatumReader would produce.
+func (c *MessageCatalog) Decode(data []byte) (name string, value interface{}, err error) {
+	if !isSingleObject(data) {
+		return "", nil, ErrUnknownMessageFormat
+	}
+	fingerprint := binary.LittleEndian.Uint64(data[2:10])
+	c.lock.RLock()
+	w := c.byFingerprint[fingerprint]
+	c.lock.RUnlock()
+	if w == nil {
+		return "", nil, fmt.Errorf("Unknown writer schema fingerprint %016x", fingerprint)
+	}
+
+	name = w.message.name
+	value, err = (&GenericDatumReader{schema: w.schema}).readValue(w.schema, NewBinaryDecoder(data[10:]))
+	if err == nil {
+		value, err = w.projector.Project(value)
+	}
+	if err != nil {
+		return name, nil, fmt.Errorf("Message %s: %s", name, err)
+	}
+	return name, value, nil
+}
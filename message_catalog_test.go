@@ -0,0 +1,76 @@
+package avro
+
+import "testing"
+
+func TestMessageCatalog(t *testing.T) {
+	orderV1 := MustParseSchema(`{"type": "record", "name": "Order", "fields": [
+		{"name": "id", "type": "int"}
+	]}`)
+	orderV2 := MustParseSchema(`{"type": "record", "name": "Order", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "total", "type": "double", "default": 0}
+	]}`)
+	refund := MustParseSchema(`{"type": "record", "name": "Refund", "fields": [
+		{"name": "order", "type": "long"}
+	]}`)
+	type order struct {
+		ID int32 `avro:"id"`
+	}
+
+	catalog := NewMessageCatalog()
+	assert(t, catalog.Add("order", orderV1, orderV2), nil)
+	assert(t, catalog.Add("order", orderV2, orderV2), nil)
+	assert(t, catalog.Add("refund", refund, refund), nil)
+
+	old := NewGenericRecord(orderV1)
+	old.Set("id", int32(1))
+	message, err := encodeMessageWith(orderV1, old)
+	assert(t, err, nil)
+	name, value, err := catalog.Decode(message)
+	assert(t, err, nil)
+	assert(t, name, "order")
+	assert(t, value.(*GenericRecord).Get("id"), int64(1))
+	assert(t, value.(*GenericRecord).Get("total"), float64(0))
+
+	current := NewGenericRecord(orderV2)
+	current.Set("id", int64(2))
+	current.Set("total", 9.5)
+	message, err = catalog.Encode("order", current)
+	assert(t, err, nil)
+	assert(t, message[:10], singleObjectHeader(t, orderV2))
+	name, value, err = catalog.Decode(message)
+	assert(t, err, nil)
+	assert(t, name, "order")
+	assert(t, value.(*GenericRecord).Get("total"), 9.5)
+
+	refunded := NewGenericRecord(refund)
+	refunded.Set("order", int64(2))
+	message, err = catalog.Encode("refund", refunded)
+	assert(t, err, nil)
+	name, value, err = catalog.Decode(message)
+	assert(t, err, nil)
+	assert(t, name, "refund")
+	assert(t, value.(*GenericRecord).Get("order"), int64(2))
+
+	_, err = catalog.Encode("shipment", refunded)
+	assert(t, err.Error(), "Unknown message shipment")
+	_, _, err = catalog.Decode([]byte{1, 2, 3})
+	assert(t, err, ErrUnknownMessageFormat)
+	_, _, err = catalog.Decode(message[:9])
+	assert(t, err, ErrUnknownMessageFormat)
+	_, _, err = catalog.Decode(message[:10])
+	assert(t, err.Error(), "Message refund: Invalid long value")
+
+	assert(t, catalog.Add("order", orderV1, refund) != nil, true)
+	assert(t, catalog.Add("order", refund, orderV2) != nil, true)
+	err = catalog.Add("legacy", orderV1, orderV2)
+	assert(t, err.Error(), "Writer schema of message legacy belongs to message order already")
+}
+
+func encodeMessageWith(schema Schema, v interface{}) ([]byte, error) {
+	catalog := NewMessageCatalog()
+	if err := catalog.Add("message", schema, schema); err != nil {
+		return nil, err
+	}
+	return catalog.Encode("message", v)
+}
@@ -0,0 +1,197 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// singleObjectMagic starts messages in the Avro single-object encoding, followed by the
+// little-endian CRC-64-AVRO fingerprint of the writer schema.
+var singleObjectMagic = [2]byte{0xc3, 0x01}
+
+// singleObjectPrefix returns the single-object encoding header of messages written with schema.
+func singleObjectPrefix(schema Schema) ([]byte, error) {
+	canonical, err := CanonicalForm(schema)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 10)
+	copy(header, singleObjectMagic[:])
+	binary.LittleEndian.PutUint64(header[2:], fingerprintCRC64([]byte(canonical)))
+	return header, nil
+}
+
+// isSingleObject tells whether message starts with a single-object encoding header.
+func isSingleObject(message []byte) bool {
+	return len(message) >= 10 && message[0] == singleObjectMagic[0] && message[1] == singleObjectMagic[1]
+}
+
+// registryMagic starts messages framed with a big-endian 4 byte schema registry ID.
+const registryMagic = 0x00
+
+// MessageReader decodes messages from streams mixing data written with different schema versions,
+// as long as every message identifies its writer schema with either of:
+//
+//   - the single-object encoding header: 0xC3 0x01 and the 8 byte little-endian CRC-64-AVRO fingerprint
+//     of the schema's Parsing Canonical Form,
+//   - a schema registry header: a zero byte and the 4 byte big-endian ID of the schema in the registry.
+//
+// Every message is decoded with its writer schema, as a GenericDatumReader would, and projected to
+// the reader schema. Writer schemas are resolved once and then cached along with their projection,
+// and also in a SchemaCache if set. A MessageReader is safe for concurrent use.
+type MessageReader struct {
+	reader            Schema
+	fingerprintLookup func(fingerprint uint64) (Schema, error)
+	idLookup          func(id uint32) (Schema, error)
+	cache             *SchemaCache
+	budget            int64
+	redaction         *Redaction
+
+	lock     sync.RWMutex
+	decoders map[messageSchemaKey]*messageDecoder
+}
+
+type messageSchemaKey struct {
+	registryID  bool
+	fingerprint uint64 // or registry ID
+}
+
+type messageDecoder struct {
+	writer    Schema
+	projector DatumProjector
+}
+
+// NewMessageReader creates a MessageReader producing values of the given reader schema, in the
+// same shape a GenericDatumReader would.
+func NewMessageReader(reader Schema) *MessageReader {
+	return &MessageReader{reader: reader, decoders: make(map[messageSchemaKey]*messageDecoder)}
+}
+
+// AddSchema makes single-object encoded messages written with the given schema readable.
+// May return an error if it can't be projected to the reader schema.
+func (r *MessageReader) AddSchema(writer Schema) error {
+	canonical, err := CanonicalForm(writer)
+	if err != nil {
+		return err
+	}
+	_, err = r.addDecoder(messageSchemaKey{fingerprint: fingerprintCRC64([]byte(canonical))}, writer)
+	return err
+}
+
+// SetFingerprintLookup sets a function to resolve the CRC-64-AVRO fingerprints of single-object
+// encoded messages written with schemas that weren't added with AddSchema.
+func (r *MessageReader) SetFingerprintLookup(lookup func(fingerprint uint64) (Schema, error)) *MessageReader {
+	r.fingerprintLookup = lookup
+	return r
+}
+
+// SetIDLookup sets a function to resolve the schema registry IDs of messages, without which they
+// can't be read.
+func (r *MessageReader) SetIDLookup(lookup func(id uint32) (Schema, error)) *MessageReader {
+	r.idLookup = lookup
+	return r
+}
+
+// SetSchemaCache makes the writer schemas of messages that weren't added with AddSchema be looked up
+// in cache first, and stored in it once resolved, under the ref "id/<ID>" for schema registry IDs
+// and "fingerprint/<fingerprint>" for single-object encoded messages, with the fingerprint in hex.
+// Must be called before calling Read.
+func (r *MessageReader) SetSchemaCache(cache *SchemaCache) *MessageReader {
+	r.cache = cache
+	return r
+}
+
+// SetAllocationBudget makes Read fail with ErrAllocationBudgetExceeded for messages that would take
+// more than budget bytes to decode, as described for NewBinaryDecoderBudget, or not limit them if 0.
+// Must be called before calling Read.
+func (r *MessageReader) SetAllocationBudget(budget int64) *MessageReader {
+	r.budget = budget
+	return r
+}
+
+// SetRedaction makes Read redact fields as GenericDatumReader.SetRedaction does, before projecting
+// messages to the reader schema. Must be called before calling Read.
+func (r *MessageReader) SetRedaction(redaction *Redaction) *MessageReader {
+	r.redaction = redaction
+	return r
+}
+
+// Read decodes a message and returns its value projected to the reader schema.
+func (r *MessageReader) Read(message []byte) (interface{}, error) {
+	var key messageSchemaKey
+	var payload []byte
+	switch {
+	case isSingleObject(message):
+		key.fingerprint = binary.LittleEndian.Uint64(message[2:10])
+		payload = message[10:]
+	case len(message) >= 5 && message[0] == registryMagic:
+		key = messageSchemaKey{registryID: true, fingerprint: uint64(binary.BigEndian.Uint32(message[1:5]))}
+		payload = message[5:]
+	default:
+		return nil, ErrUnknownMessageFormat
+	}
+
+	decoder, err := r.decoder(key)
+	if err != nil {
+		return nil, err
+	}
+	reader := &GenericDatumReader{schema: decoder.writer, redaction: r.redaction}
+	value, err := reader.readDatum(NewBinaryDecoderBudget(payload, r.budget))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.projector.Project(value)
+}
+
+func (r *MessageReader) decoder(key messageSchemaKey) (*messageDecoder, error) {
+	r.lock.RLock()
+	decoder := r.decoders[key]
+	r.lock.RUnlock()
+	if decoder != nil {
+		return decoder, nil
+	}
+
+	ref := fmt.Sprintf("fingerprint/%016x", key.fingerprint)
+	if key.registryID {
+		ref = fmt.Sprintf("id/%d", key.fingerprint)
+	}
+	if r.cache != nil {
+		if writer, ok := r.cache.GetRef(ref); ok {
+			return r.addDecoder(key, writer)
+		}
+	}
+
+	var writer Schema
+	var err error
+	switch {
+	case key.registryID && r.idLookup != nil:
+		writer, err = r.idLookup(uint32(key.fingerprint))
+	case !key.registryID && r.fingerprintLookup != nil:
+		writer, err = r.fingerprintLookup(key.fingerprint)
+	case key.registryID:
+		return nil, fmt.Errorf("Unknown writer schema ID %d", key.fingerprint)
+	default:
+		return nil, fmt.Errorf("Unknown writer schema fingerprint %016x", key.fingerprint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.cache != nil {
+		// Failing to cache the schema mustn't fail reading the message.
+		r.cache.PutRef(ref, writer)
+	}
+	return r.addDecoder(key, writer)
+}
+
+func (r *MessageReader) addDecoder(key messageSchemaKey, writer Schema) (*messageDecoder, error) {
+	projector, err := NewDatumProjector(writer, r.reader)
+	if err != nil {
+		return nil, err
+	}
+	decoder := &messageDecoder{writer: writer, projector: projector}
+	r.lock.Lock()
+	r.decoders[key] = decoder
+	r.lock.Unlock()
+	return decoder, nil
+}
@@ -0,0 +1,98 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func encodeMessage(t *testing.T, header []byte, schema Schema, v interface{}) []byte {
+	buf := bytes.NewBuffer(header)
+	if err := NewDatumWriter(schema).Write(v, NewBinaryEncoder(buf)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func singleObjectHeader(t *testing.T, schema Schema) []byte {
+	canonical, err := CanonicalForm(schema)
+	assert(t, err, nil)
+	header := []byte{0xc3, 0x01, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(header[2:], fingerprintCRC64([]byte(canonical)))
+	return header
+}
+
+func TestMessageReader(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "int"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "source", "type": "string", "default": "unknown"}
+	]}`)
+
+	lookups := 0
+	reader := NewMessageReader(v2).SetIDLookup(func(id uint32) (Schema, error) {
+		lookups++
+		return v2, nil
+	})
+	assert(t, reader.AddSchema(v1), nil)
+
+	old := NewGenericRecord(v1)
+	old.Set("id", int32(1))
+	current := NewGenericRecord(v2)
+	current.Set("id", int64(2))
+	current.Set("source", "registry")
+
+	value, err := reader.Read(encodeMessage(t, singleObjectHeader(t, v1), v1, old))
+	assert(t, err, nil)
+	assert(t, value.(*GenericRecord).Get("id"), int64(1))
+	assert(t, value.(*GenericRecord).Get("source"), "unknown")
+
+	for i := 0; i < 2; i++ {
+		value, err = reader.Read(encodeMessage(t, []byte{0, 0, 0, 0, 42}, v2, current))
+		assert(t, err, nil)
+		assert(t, value.(*GenericRecord).Get("source"), "registry")
+	}
+	assert(t, lookups, 1)
+
+	_, err = reader.Read(encodeMessage(t, singleObjectHeader(t, v2), v2, current))
+	assert(t, err.Error(), fmt.Sprintf("Unknown writer schema fingerprint %016x", binary.LittleEndian.Uint64(singleObjectHeader(t, v2)[2:])))
+	_, err = reader.Read([]byte{0xff})
+	assert(t, err, ErrUnknownMessageFormat)
+}
+
+func TestMessageReaderDecoding(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "email", "type": "string", "pii": true}
+	]}`)
+	user := NewGenericRecord(schema)
+	user.Set("name", "jane")
+	user.Set("email", "jane@example.com")
+	message := encodeMessage(t, []byte{0, 0, 0, 0, 7}, schema, user)
+
+	dir, err := ioutil.TempDir("", "avro-message-reader")
+	assert(t, err, nil)
+	defer os.RemoveAll(dir)
+	cache, err := NewSchemaCache(dir)
+	assert(t, err, nil)
+	reader := NewMessageReader(schema).SetSchemaCache(cache).SetRedaction(&Redaction{Property: "pii", Mask: "***"})
+	reader.SetIDLookup(func(id uint32) (Schema, error) { return schema, nil })
+	value, err := reader.Read(message)
+	assert(t, err, nil)
+	assert(t, value.(*GenericRecord).Get("name"), "jane")
+	assert(t, value.(*GenericRecord).Get("email"), "***")
+
+	// Schemas resolved once are found in the cache when lookups fail.
+	reader = NewMessageReader(schema).SetSchemaCache(cache).SetAllocationBudget(8)
+	reader.SetIDLookup(func(id uint32) (Schema, error) { return nil, fmt.Errorf("Registry unavailable") })
+	_, err = reader.Read(message)
+	assert(t, err, ErrAllocationBudgetExceeded)
+	value, err = reader.SetAllocationBudget(0).Read(message)
+	assert(t, err, nil)
+	assert(t, value.(*GenericRecord).Get("email"), "jane@example.com")
+}
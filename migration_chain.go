@@ -0,0 +1,96 @@
+package avro
+
+import "fmt"
+
+// MigrationFunc fixes up a value right after it was projected into a schema version of a
+// MigrationChain, e.g. to compute a new field from old ones. It returns the fixed value.
+type MigrationFunc func(v interface{}) (interface{}, error)
+
+// MigrationChain projects values written with any of a sequence of schema versions to the latest
+// one, hop by hop through every intermediate version, so that each hop can apply its own fix-ups.
+type MigrationChain struct {
+	versions   []Schema
+	projectors []DatumProjector      // projectors[i] projects from versions[i] to versions[i+1]
+	fixUps     map[int]MigrationFunc // by version index
+}
+
+// NewMigrationChain creates a MigrationChain through the given schema versions, oldest first.
+// May return an error if any version can't be projected to the next one.
+func NewMigrationChain(versions ...Schema) (*MigrationChain, error) {
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("MigrationChain needs at least one schema version")
+	}
+	chain := &MigrationChain{versions: versions, fixUps: make(map[int]MigrationFunc)}
+	for i := 0; i+1 < len(versions); i++ {
+		projector, err := NewDatumProjector(versions[i], versions[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("Version %d to %d: %s", i, i+1, err)
+		}
+		chain.projectors = append(chain.projectors, projector)
+	}
+	return chain, nil
+}
+
+// FixUp sets a MigrationFunc to run on values after they're projected into the version with the
+// given index. Values written with that version already don't go through it.
+func (c *MigrationChain) FixUp(version int, f MigrationFunc) *MigrationChain {
+	c.fixUps[version] = f
+	return c
+}
+
+// Latest returns the schema version values are migrated to.
+func (c *MigrationChain) Latest() Schema {
+	return c.versions[len(c.versions)-1]
+}
+
+// Migrate projects v, a value written with the version with the given index, to the latest version.
+func (c *MigrationChain) Migrate(version int, v interface{}) (interface{}, error) {
+	if version < 0 || version >= len(c.versions) {
+		return nil, fmt.Errorf("Unknown schema version %d", version)
+	}
+	var err error
+	for i := version; i < len(c.projectors); i++ {
+		if v, err = c.projectors[i].Project(v); err != nil {
+			return nil, fmt.Errorf("Version %d to %d: %s", i, i+1, err)
+		}
+		if fixUp := c.fixUps[i+1]; fixUp != nil {
+			if v, err = fixUp(v); err != nil {
+				return nil, fmt.Errorf("Fixing up version %d: %s", i+1, err)
+			}
+		}
+	}
+	return v, nil
+}
+
+// Projector returns a DatumProjector migrating values from the version with the given index to the
+// latest one through this chain.
+func (c *MigrationChain) Projector(version int) DatumProjector {
+	return &chainProjector{chain: c, version: version}
+}
+
+type chainProjector struct {
+	chain   *MigrationChain
+	version int
+}
+
+func (p *chainProjector) Project(v interface{}) (interface{}, error) {
+	return p.chain.Migrate(p.version, v)
+}
+
+func (p *chainProjector) WriterSchema() Schema {
+	return p.chain.versions[p.version]
+}
+
+func (p *chainProjector) ReaderSchema() Schema {
+	return p.chain.Latest()
+}
+
+// Explain describes every hop of the chain in turn, from the writer version on. Fix-ups aren't
+// described.
+func (p *chainProjector) Explain() *ProjectionExplanation {
+	e := &ProjectionExplanation{Fields: []FieldProjection{}}
+	for _, projector := range p.chain.projectors[p.version:] {
+		e.Fields = append(e.Fields, projector.Explain().Fields...)
+	}
+	return e
+}
@@ -0,0 +1,130 @@
+package avro
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Opener opens files by name for ParseSchemaFile, LoadSchemas and NewDataFileReader, allowing them
+// to read from places other than the local filesystem. Register one with RegisterOpener, e.g. the one
+// NewHTTPOpener returns to read http:// and https:// URLs.
+type Opener interface {
+	// Open opens the file with the given name, which is a full URL including the scheme.
+	Open(name string) (io.ReadCloser, error)
+}
+
+// Lister can be implemented by an Opener that can also enumerate files, which LoadSchemas needs to
+// load all schemas under a directory.
+type Lister interface {
+	// List returns the full names of all files under the directory dir, including subdirectories.
+	List(dir string) ([]string, error)
+}
+
+var (
+	openers     = make(map[string]Opener)
+	openersLock sync.RWMutex
+)
+
+// RegisterOpener makes names starting with scheme:// open using the given Opener, e.g. to read
+// files from cloud storage with RegisterOpener("s3", myS3Opener), or stop opening them if nil.
+// No Opener is registered by default, so that schema and data file names can't make requests unless
+// asked to. Names without a scheme, or with the file:// one, always refer to the local filesystem.
+func RegisterOpener(scheme string, opener Opener) {
+	openersLock.Lock()
+	defer openersLock.Unlock()
+	openers[scheme] = opener
+}
+
+// schemeOf returns the scheme of a name like scheme://path, or "" if it has none.
+func schemeOf(name string) string {
+	if i := strings.Index(name, "://"); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// localPath returns the local filesystem path for name, or false if it doesn't refer to a local file.
+func localPath(name string) (string, bool) {
+	switch schemeOf(name) {
+	case "":
+		return name, true
+	case "file":
+		return strings.TrimPrefix(name, "file://"), true
+	}
+	return "", false
+}
+
+func openFile(name string) (io.ReadCloser, error) {
+	if path, ok := localPath(name); ok {
+		return os.Open(path)
+	}
+	scheme := schemeOf(name)
+	openersLock.RLock()
+	opener := openers[scheme]
+	openersLock.RUnlock()
+	if opener == nil {
+		return nil, fmt.Errorf("No Opener registered for scheme %s", scheme)
+	}
+	return opener.Open(name)
+}
+
+func readFile(name string) ([]byte, error) {
+	if path, ok := localPath(name); ok {
+		return ioutil.ReadFile(path)
+	}
+	f, err := openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// listFiles returns all files under the remote directory dir if its Opener is a Lister.
+func listFiles(dir string) ([]string, error) {
+	scheme := schemeOf(dir)
+	openersLock.RLock()
+	opener := openers[scheme]
+	openersLock.RUnlock()
+	lister, ok := opener.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("Can't list files for scheme %s", scheme)
+	}
+	return lister.List(dir)
+}
+
+// defaultHTTPClient makes the requests of Openers and ParseSchemaURL not given a client, with a
+// timeout so that unresponsive servers can't block them forever.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// NewHTTPOpener creates an Opener fetching URLs with GET requests made with the given client, or
+// one timing out after 30 seconds if nil. Register it for the schemes to open, e.g.
+// RegisterOpener("https", NewHTTPOpener(nil)).
+func NewHTTPOpener(client *http.Client) Opener {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	return &httpOpener{client}
+}
+
+type httpOpener struct {
+	client *http.Client
+}
+
+func (o *httpOpener) Open(name string) (io.ReadCloser, error) {
+	resp, err := o.client.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Fetching %s: %s", name, resp.Status)
+	}
+	return resp.Body, nil
+}
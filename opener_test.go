@@ -0,0 +1,95 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type memOpener map[string][]byte
+
+func (m memOpener) Open(name string) (io.ReadCloser, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m memOpener) List(dir string) ([]string, error) {
+	var names []string
+	for name := range m {
+		if strings.HasPrefix(name, dir) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func TestOpener(t *testing.T) {
+	files := memOpener{
+		"mem://schemas/a/Rec.avsc":  []byte(`{"type": "record", "name": "Rec", "namespace": "a", "fields": [{"name": "id", "type": "long"}]}`),
+		"mem://schemas/b/Enum.avsc": []byte(`{"type": "enum", "name": "Enum", "namespace": "b", "symbols": ["X"]}`),
+		"mem://schemas/README":      []byte(`not a schema`),
+		"mem://data/p.avro":         testDataFile(t, 2),
+	}
+	RegisterOpener("mem", files)
+	defer RegisterOpener("mem", nil)
+
+	schema, err := ParseSchemaFile("mem://schemas/b/Enum.avsc")
+	assert(t, err, nil)
+	assert(t, schema.FullName(), "b.Enum")
+
+	schemas := LoadSchemas("mem://schemas/")
+	assert(t, len(schemas), 2)
+	assert(t, schemas["a.Rec"].FullName(), "a.Rec")
+
+	reader, err := NewDataFileReader("mem://data/p.avro")
+	assert(t, err, nil)
+	records := 0
+	for reader.HasNext() {
+		var p primitive
+		assert(t, reader.Next(&p), nil)
+		records++
+	}
+	assert(t, reader.Err(), nil)
+	assert(t, reader.Close(), nil)
+	assert(t, records, 2)
+
+	_, err = ParseSchemaFile("unknown://x.avsc")
+	assert(t, err.Error(), "No Opener registered for scheme unknown")
+
+	schema, err = ParseSchemaFile("file://test/schemas/test_record.avsc")
+	assert(t, err, nil)
+	assert(t, schema.Type(), Record)
+}
+
+func TestHTTPOpener(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Hash.avsc" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"type": "fixed", "name": "Hash", "size": 16}`))
+	}))
+	defer server.Close()
+
+	// URLs are only fetched once asked to.
+	_, err := ParseSchemaFile(server.URL + "/Hash.avsc")
+	assert(t, err.Error(), "No Opener registered for scheme http")
+
+	RegisterOpener("http", NewHTTPOpener(nil))
+	defer RegisterOpener("http", nil)
+	schema, err := ParseSchemaFile(server.URL + "/Hash.avsc")
+	assert(t, err, nil)
+	assert(t, schema.FullName(), "Hash")
+	_, err = ParseSchemaFile(server.URL + "/Missing.avsc")
+	assert(t, err.Error(), "Fetching "+server.URL+"/Missing.avsc: 404 Not Found")
+}
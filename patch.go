@@ -0,0 +1,108 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PatchRecord returns a copy of the encoded record data with the fields at the paths of updates set
+// to their values, e.g. for enrichment services setting a field or two of every message they relay.
+// Only the updated fields are encoded, the bytes of the others are copied by skipping over them,
+// which makes patching much cheaper than decoding and re-encoding whole records.
+//
+// A path is a field name, or a dotted path to a field of a nested record such as "user.id". Paths
+// can't go through unions, arrays or maps. Values are encoded the way EncodeField encodes them.
+func PatchRecord(schema Schema, encoded []byte, updates map[string]interface{}) ([]byte, error) {
+	record, ok := resolveSchema(schema).(*RecordSchema)
+	if !ok {
+		return nil, fmt.Errorf("Can only patch records, not %s", schema.GetName())
+	}
+	root := &patchNode{record: record, fields: make(map[int]*patchField)}
+	for path, value := range updates {
+		fields, indexes, err := record.fieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = root.add(fields, indexes, value); err != nil {
+			return nil, err
+		}
+	}
+
+	bd := &binaryDecoder{buf: encoded}
+	patched, err := root.patch(bd, make([]byte, 0, len(encoded)))
+	if err != nil {
+		return nil, err
+	}
+	if bd.pos != int64(len(encoded)) {
+		return nil, fmt.Errorf("Record is followed by %d unread bytes", int64(len(encoded))-bd.pos)
+	}
+	return patched, nil
+}
+
+// patchNode holds the updates of the fields of a record, by field index.
+type patchNode struct {
+	record *RecordSchema
+	path   string // of the record, empty for the top-level one
+	fields map[int]*patchField
+}
+
+type patchField struct {
+	encoded []byte     // new value of the field, or nil if it has updates within
+	child   *patchNode // updates of fields within this field, which is a record then
+}
+
+// add adds the update of the field at the end of fields, as resolved from the record of node with
+// their indexes.
+func (node *patchNode) add(fields []*SchemaField, indexes []int, value interface{}) error {
+	field, index := fields[0], indexes[0]
+	fieldPath := strings.TrimPrefix(node.path+"."+field.Name, ".")
+	pf := node.fields[index]
+	if len(fields) == 1 {
+		if pf != nil {
+			return fmt.Errorf("Conflicting updates of %s", fieldPath)
+		}
+		encoded, err := EncodeField(node.record, field.Name, value)
+		if err != nil {
+			return fmt.Errorf("Field %s: %s", fieldPath, err)
+		}
+		node.fields[index] = &patchField{encoded: encoded}
+		return nil
+	}
+
+	if pf == nil {
+		nested := resolveSchema(field.Type).(*RecordSchema)
+		pf = &patchField{child: &patchNode{record: nested, path: fieldPath, fields: make(map[int]*patchField)}}
+		node.fields[index] = pf
+	} else if pf.child == nil {
+		return fmt.Errorf("Conflicting updates of %s", fieldPath)
+	}
+	return pf.child.add(fields[1:], indexes[1:], value)
+}
+
+// patch appends the record bd is at to out, with the updates of node.
+func (node *patchNode) patch(bd *binaryDecoder, out []byte) ([]byte, error) {
+	unchanged := bd.pos // start of the bytes to copy as is
+	for i, field := range node.record.Fields {
+		pf := node.fields[i]
+		if pf == nil {
+			if err := bd.skip(field.Type, nil, DefaultMaxDepth); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		out = append(out, bd.buf[unchanged:bd.pos]...)
+		if pf.child != nil {
+			var err error
+			if out, err = pf.child.patch(bd, out); err != nil {
+				return nil, err
+			}
+		} else {
+			if err := bd.skip(field.Type, nil, DefaultMaxDepth); err != nil {
+				return nil, err
+			}
+			out = append(out, pf.encoded...)
+		}
+		unchanged = bd.pos
+	}
+	return append(out, bd.buf[unchanged:bd.pos]...), nil
+}
@@ -0,0 +1,54 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPatchRecord(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "user", "type": {"type": "record", "name": "User", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "country", "type": ["null", "string"]}
+		]}},
+		{"name": "score", "type": "double"}
+	]}`)
+	user := NewGenericRecord(schema.(*RecordSchema).Fields[2].Type)
+	user.Set("name", "ann")
+	record := NewGenericRecord(schema)
+	record.Set("id", int64(7))
+	record.Set("tags", []interface{}{"a", "b"})
+	record.Set("user", user)
+	record.Set("score", 1.5)
+	encode := func() []byte {
+		var buf bytes.Buffer
+		assert(t, NewGenericDatumWriter().SetSchema(schema).Write(record, NewBinaryEncoder(&buf)), nil)
+		return buf.Bytes()
+	}
+	encoded := encode()
+
+	patched, err := PatchRecord(schema, encoded, map[string]interface{}{"user.country": "NZ", "score": 2.5})
+	assert(t, err, nil)
+	user.Set("country", "NZ")
+	record.Set("score", 2.5)
+	assert(t, patched, encode())
+
+	patched, err = PatchRecord(schema, encoded, nil)
+	assert(t, err, nil)
+	assert(t, patched, encoded)
+
+	_, err = PatchRecord(schema, encoded, map[string]interface{}{"user": user, "user.name": "bob"})
+	assert(t, err != nil, true)
+	_, err = PatchRecord(schema, encoded, map[string]interface{}{"user.age": 3})
+	assert(t, err.Error(), "Record User has no field age")
+	_, err = PatchRecord(schema, encoded, map[string]interface{}{"tags.x": 3})
+	assert(t, err.Error(), "Field tags of tags.x is not a record")
+	_, err = PatchRecord(schema, encoded, map[string]interface{}{"id": "seven"})
+	assert(t, err.Error(), "Field id: seven is not an int64")
+	_, err = PatchRecord(schema, append(encoded, 0), map[string]interface{}{"id": int64(8)})
+	assert(t, err.Error(), "Record is followed by 1 unread bytes")
+	_, err = PatchRecord(schema, encoded[:len(encoded)-1], map[string]interface{}{"id": int64(8)})
+	assert(t, err, ErrUnexpectedEOF)
+}
@@ -0,0 +1,495 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DatumProjector converts values decoded by a GenericDatumReader with a writer schema into values
+// of a reader schema, following the Avro schema resolution rules: record fields are matched by
+// name, fields missing from the writer get their default value, numbers are promoted and strings
+// and bytes are interchangeable, enum symbols are matched by name and unions are resolved to the
+// first matching branch.
+type DatumProjector interface {
+	// Project converts v, a value of the writer schema, into a value of the reader schema.
+	Project(v interface{}) (interface{}, error)
+
+	// WriterSchema returns the schema of the values this DatumProjector accepts.
+	WriterSchema() Schema
+
+	// ReaderSchema returns the schema of the values this DatumProjector produces.
+	ReaderSchema() Schema
+
+	// Explain describes how every field of the reader records is resolved, e.g. to check how data
+	// of the writer schema will be read before deploying consumers with the reader schema.
+	Explain() *ProjectionExplanation
+}
+
+// NewDatumProjector creates a DatumProjector from the writer to the reader schema.
+// May return an error if data written with the writer schema can never be read with the reader one,
+// which is the first of the problems CanProject would report.
+// Named types are resolved when the schemas are parsed, with ParseSchemaUsing to resolve them in a
+// given Registry, so projecting doesn't need one.
+// Values nesting more records than SetProjectorMaxDepth allows fail to project with
+// ErrMaxDepthExceeded.
+func NewDatumProjector(writer, reader Schema) (DatumProjector, error) {
+	if problems := checkResolvable(writer, reader); len(problems) > 0 {
+		return nil, errors.New(problems[0])
+	}
+	return &datumProjector{writer: writer, reader: reader}, nil
+}
+
+// SetProjectorMaxDepth sets the maximum number of records values projected by p, a DatumProjector
+// created by NewDatumProjector, may nest, DefaultMaxDepth if not set or zero. Deeper values fail to
+// project with ErrMaxDepthExceeded.
+// Must be called before calling Project. May return an error if p is another kind of DatumProjector.
+func SetProjectorMaxDepth(p DatumProjector, depth int) error {
+	projector, ok := p.(*datumProjector)
+	if !ok {
+		return fmt.Errorf("DatumProjector %T doesn't support setting the maximum depth", p)
+	}
+	projector.maxDepth = depth
+	return nil
+}
+
+type datumProjector struct {
+	writer       Schema
+	reader       Schema
+	unknownEnum  UnknownEnum
+	logicalTypes bool
+	maxDepth     int
+}
+
+func (p *datumProjector) Project(v interface{}) (interface{}, error) {
+	return p.project(p.writer, p.reader, v, 0)
+}
+
+func (p *datumProjector) WriterSchema() Schema {
+	return p.writer
+}
+
+func (p *datumProjector) ReaderSchema() Schema {
+	return p.reader
+}
+
+// resolveSchema returns the actual definition of schema references and prepared records.
+func resolveSchema(schema Schema) Schema {
+	for {
+		switch s := schema.(type) {
+		case *RecursiveSchema:
+			schema = s.Actual
+		case *preparedRecordSchema:
+			return &s.RecordSchema
+		default:
+			return schema
+		}
+	}
+}
+
+// shortName returns the unqualified name of a named schema, which is what resolution matches on.
+func shortName(schema Schema) string {
+	name := schema.FullName()
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+func isPromotable(writer, reader int) bool {
+	switch writer {
+	case Int:
+		return reader == Long || reader == Float || reader == Double
+	case Long:
+		return reader == Float || reader == Double
+	case Float:
+		return reader == Double
+	case String:
+		return reader == Bytes
+	case Bytes:
+		return reader == String
+	}
+	return false
+}
+
+// matches reports whether writer and reader are of the same kind, disregarding what they contain.
+func matches(writer, reader Schema) bool {
+	if writer.Type() != reader.Type() {
+		return false
+	}
+	switch writer.Type() {
+	case Record, Enum, Fixed:
+		return shortName(writer) == shortName(reader)
+	}
+	return true
+}
+
+// readerBranch returns the index of the branch of the reader union that a non-union writer schema
+// resolves to, or -1 if there is none.
+func readerBranch(writer Schema, reader *UnionSchema) int {
+	for i, t := range reader.Types {
+		if matches(writer, resolveSchema(t)) {
+			return i
+		}
+	}
+	for i, t := range reader.Types {
+		if isPromotable(writer.Type(), t.Type()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// writerBranch returns the index of the branch of the writer union that the value v was written with.
+func writerBranch(writer *UnionSchema, v interface{}) int {
+	for i, t := range writer.Types {
+		t = resolveSchema(t)
+		switch value := v.(type) {
+		case nil:
+			if t.Type() == Null {
+				return i
+			}
+		case *GenericRecord:
+			if t.Type() == Record && (value.Schema() == nil || value.Schema().FullName() == t.FullName()) {
+				return i
+			}
+		case *GenericEnum:
+			if t.Type() == Enum && reflect.DeepEqual(value.Symbols, t.(*EnumSchema).Symbols) {
+				return i
+			}
+		case []byte:
+			if t.Type() == Bytes || t.Type() == Fixed && t.(*FixedSchema).Size == len(value) {
+				return i
+			}
+		}
+	}
+	if v == nil {
+		return -1
+	}
+	return writer.GetType(reflect.ValueOf(v))
+}
+
+// findWriterField finds the writer field a reader field is read from, by name or by one of the
+// reader field's aliases.
+func findWriterField(writer *RecordSchema, field *SchemaField) (*SchemaField, int, bool) {
+	if writerField, i, ok := writer.Field(field.Name); ok {
+		return writerField, i, ok
+	}
+	for _, alias := range field.Aliases {
+		if writerField, i, ok := writer.Field(alias); ok {
+			return writerField, i, ok
+		}
+	}
+	return nil, -1, false
+}
+
+// hasDefault reports whether a field has a default, which for a nil Default is only the case if
+// null is a valid default for it.
+func hasDefault(field *SchemaField) bool {
+	if field.Default != nil {
+		return true
+	}
+	switch t := resolveSchema(field.Type); t.Type() {
+	case Null:
+		return true
+	case Union:
+		return len(t.(*UnionSchema).Types) > 0 && t.(*UnionSchema).Types[0].Type() == Null
+	}
+	return false
+}
+
+// project converts v, nested in depth records, from the writer to the reader schema.
+func (p *datumProjector) project(writer, reader Schema, v interface{}, depth int) (interface{}, error) {
+	writer, reader = resolveSchema(writer), resolveSchema(reader)
+	if writer.Type() == Union {
+		i := writerBranch(writer.(*UnionSchema), v)
+		if i < 0 {
+			return nil, fmt.Errorf("Value %v doesn't match any branch of writer union", v)
+		}
+		return p.project(writer.(*UnionSchema).Types[i], reader, v, depth)
+	}
+	if reader.Type() == Union {
+		i := readerBranch(writer, reader.(*UnionSchema))
+		if i < 0 {
+			return nil, fmt.Errorf("Writer schema %s doesn't resolve to any branch of reader union", writer.FullName())
+		}
+		branch := resolveSchema(reader.(*UnionSchema).Types[i])
+		if p.unknownEnum == UnknownEnumNull && branch.Type() == Enum && unknownSymbol(branch.(*EnumSchema), v) &&
+			hasNullBranch(reader.(*UnionSchema)) {
+			return nil, nil
+		}
+		return p.project(writer, branch, v, depth)
+	}
+	switch writer.Type() {
+	case Int:
+		v = convertDate(writer, reader, v, p.logicalTypes)
+	case Long:
+		v = convertTimestamp(writer, reader, v, p.logicalTypes)
+	case Bytes, Fixed:
+		var err error
+		if v, err = convertDecimal(writer, reader, v, p.logicalTypes); err != nil {
+			return nil, err
+		}
+	}
+
+	switch reader.Type() {
+	case Null, Boolean, Int, Fixed:
+		return v, nil
+	case Long:
+		if i, ok := v.(int32); ok {
+			return int64(i), nil
+		}
+		return v, nil
+	case Float:
+		switch n := v.(type) {
+		case int32:
+			return float32(n), nil
+		case int64:
+			return float32(n), nil
+		}
+		return v, nil
+	case Double:
+		switch n := v.(type) {
+		case int32:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case float32:
+			return float64(n), nil
+		}
+		return v, nil
+	case String:
+		if b, ok := v.([]byte); ok {
+			return string(b), nil
+		}
+		return v, nil
+	case Bytes:
+		if s, ok := v.(string); ok {
+			return []byte(s), nil
+		}
+		return v, nil
+	case Enum:
+		// Enums are symbols within records and GenericEnums anywhere else.
+		switch enum := v.(type) {
+		case string:
+			if p.unknownEnum == UnknownEnumSymbol && unknownSymbol(reader.(*EnumSchema), enum) {
+				return UnknownSymbol, nil
+			}
+			if _, err := newGenericEnumSymbol(reader.(*EnumSchema), enum); err != nil {
+				return nil, err
+			}
+			return enum, nil
+		case *GenericEnum:
+			if p.unknownEnum == UnknownEnumSymbol && unknownSymbol(reader.(*EnumSchema), enum) {
+				return unknownEnumValue(reader.(*EnumSchema)), nil
+			}
+			return newGenericEnumSymbol(reader.(*EnumSchema), enum.Get())
+		}
+		return nil, fmt.Errorf("Expected enum symbol for %s, got %T", writer.FullName(), v)
+	case Array:
+		items, _ := v.([]interface{})
+		projected := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if projected[i], err = p.project(writer.(*ArraySchema).Items, reader.(*ArraySchema).Items, item, depth); err != nil {
+				return nil, err
+			}
+		}
+		return projected, nil
+	case Map:
+		values, _ := v.(map[string]interface{})
+		projected := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			var err error
+			if projected[key], err = p.project(writer.(*MapSchema).Values, reader.(*MapSchema).Values, value, depth); err != nil {
+				return nil, err
+			}
+		}
+		return projected, nil
+	case Record:
+		if depth >= maxDepth(p.maxDepth) {
+			return nil, ErrMaxDepthExceeded
+		}
+		return p.projectRecord(writer.(*RecordSchema), reader.(*RecordSchema), v, depth+1)
+	}
+
+	return nil, fmt.Errorf("Unknown field type: %d", reader.Type())
+}
+
+func (p *datumProjector) projectRecord(writer, reader *RecordSchema, v interface{}, depth int) (interface{}, error) {
+	record, ok := v.(*GenericRecord)
+	if !ok {
+		return nil, fmt.Errorf("Expected *GenericRecord for %s, got %T", writer.FullName(), v)
+	}
+	// Positional access is only safe when the record was laid out from the writer schema.
+	byIndex := record.layout != nil && record.layout == ensureRecordLayout(writer)
+	projected := NewGenericRecord(reader)
+	for i, field := range reader.Fields {
+		var value interface{}
+		var err error
+		presence := FieldFromDefault
+		if writerField, j, ok := findWriterField(writer, field); ok {
+			var written interface{}
+			if byIndex {
+				written = record.GetByIndex(j)
+			} else {
+				written = record.Get(writerField.Name)
+			}
+			value, err = p.project(writerField.Type, field.Type, written, depth)
+			presence = record.Presence(writerField.Name)
+		} else {
+			value, err = defaultValue(field.Type, field.Default, p.logicalTypes)
+		}
+		if err == ErrMaxDepthExceeded {
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("Field %s.%s: %s", reader.FullName(), field.Name, err)
+		}
+		projected.setByIndex(i, fieldValue(value), presence)
+	}
+	return projected, nil
+}
+
+// fieldValue returns the representation of v GenericDatumReader uses in record fields.
+func fieldValue(v interface{}) interface{} {
+	if enum, ok := v.(*GenericEnum); ok {
+		return enum.Get()
+	}
+	return v
+}
+
+// unknownSymbol tells whether v is an enum symbol or GenericEnum that isn't one of the symbols of
+// schema. Other values aren't, and fail to project as enums.
+func unknownSymbol(schema *EnumSchema, v interface{}) bool {
+	var symbol string
+	switch enum := v.(type) {
+	case string:
+		symbol = enum
+	case *GenericEnum:
+		symbol = enum.String()
+	default:
+		return false
+	}
+	for _, s := range schema.Symbols {
+		if s == symbol {
+			return false
+		}
+	}
+	return true
+}
+
+func newGenericEnumSymbol(schema *EnumSchema, symbol string) (*GenericEnum, error) {
+	enum := NewGenericEnum(schema.Symbols)
+	index, ok := enum.symbolsToIndex[symbol]
+	if !ok {
+		return nil, fmt.Errorf("Symbol %s is not defined in enum %s", symbol, schema.FullName())
+	}
+	enum.SetIndex(index)
+	return enum, nil
+}
+
+// defaultValue converts a default value as parsed from the schema JSON to the value a
+// GenericDatumReader would produce for schema, with logical types if logical.
+func defaultValue(schema Schema, def interface{}, logical bool) (interface{}, error) {
+	schema = resolveSchema(schema)
+	invalid := fmt.Errorf("Invalid default %v for %s", def, schema.FullName())
+	switch schema.Type() {
+	case Null:
+		return nil, nil
+	case Boolean:
+		if b, ok := def.(bool); ok {
+			return b, nil
+		}
+	case Int, Long, Float, Double:
+		var n float64
+		switch d := def.(type) {
+		case int32:
+			n = float64(d)
+		case int64:
+			n = float64(d)
+		case float32:
+			n = float64(d)
+		case float64:
+			n = d
+		default:
+			return nil, invalid
+		}
+		switch schema.Type() {
+		case Int:
+			if logical && isDate(schema) {
+				return date(int32(n)), nil
+			}
+			return int32(n), nil
+		case Long:
+			if unit := timestampUnit(schema); logical && unit != 0 {
+				return timestamp(int64(n), unit), nil
+			}
+			return int64(n), nil
+		case Float:
+			return float32(n), nil
+		}
+		return n, nil
+	case String:
+		if s, ok := def.(string); ok {
+			return s, nil
+		}
+	case Bytes, Fixed:
+		// Bytes defaults are strings with a code point per byte.
+		if s, ok := def.(string); ok {
+			b := make([]byte, 0, len(s))
+			for _, r := range s {
+				b = append(b, byte(r))
+			}
+			if logical && isDecimal(schema) {
+				return decimal(schema, b), nil
+			}
+			return b, nil
+		}
+	case Enum:
+		if s, ok := def.(string); ok {
+			return newGenericEnumSymbol(schema.(*EnumSchema), s)
+		}
+	case Array:
+		if items, ok := def.([]interface{}); ok {
+			values := make([]interface{}, len(items))
+			for i, item := range items {
+				var err error
+				if values[i], err = defaultValue(schema.(*ArraySchema).Items, item, logical); err != nil {
+					return nil, err
+				}
+			}
+			return values, nil
+		}
+	case Map:
+		if items, ok := def.(map[string]interface{}); ok {
+			values := make(map[string]interface{}, len(items))
+			for key, item := range items {
+				var err error
+				if values[key], err = defaultValue(schema.(*MapSchema).Values, item, logical); err != nil {
+					return nil, err
+				}
+			}
+			return values, nil
+		}
+	case Union:
+		// Union defaults are of the first branch.
+		if types := schema.(*UnionSchema).Types; len(types) > 0 {
+			return defaultValue(types[0], def, logical)
+		}
+	case Record:
+		if fields, ok := def.(map[string]interface{}); ok {
+			record := NewGenericRecord(schema)
+			for i, field := range schema.(*RecordSchema).Fields {
+				fieldDef, exists := fields[field.Name]
+				if !exists {
+					fieldDef = field.Default
+				}
+				value, err := defaultValue(field.Type, fieldDef, logical)
+				if err != nil {
+					return nil, err
+				}
+				record.setByIndex(i, fieldValue(value), FieldFromDefault)
+			}
+			return record, nil
+		}
+	}
+	return nil, invalid
+}
@@ -0,0 +1,116 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProjectionError is returned by CanProject with every problem preventing a projection.
+type ProjectionError struct {
+	// Every problem, starting with the reader record field it's about if any, e.g.
+	// "Field shop.Order.id: ...".
+	Problems []string
+}
+
+// Error returns all problems found.
+func (e *ProjectionError) Error() string {
+	return fmt.Sprintf("Schemas can't be projected: %s", strings.Join(e.Problems, "; "))
+}
+
+// CanProject checks that data written with the writer schema can be read with the reader schema,
+// including that the defaults of reader fields missing from the writer are valid, so that no value
+// fails to project because of the schemas. Unlike NewDatumProjector, which fails with the first
+// problem, it returns a *ProjectionError describing all of them.
+func CanProject(reader, writer Schema) error {
+	if problems := checkResolvable(writer, reader); len(problems) > 0 {
+		return &ProjectionError{Problems: problems}
+	}
+	return nil
+}
+
+// checkResolvable returns every problem resolving writer to reader.
+func checkResolvable(writer, reader Schema) []string {
+	c := &projectionChecker{seen: make(map[[2]Schema]bool)}
+	c.check("", writer, reader)
+	return c.problems
+}
+
+type projectionChecker struct {
+	seen     map[[2]Schema]bool // pairs being or already checked
+	problems []string
+}
+
+func (c *projectionChecker) problem(prefix string, format string, args ...interface{}) {
+	c.problems = append(c.problems, prefix+fmt.Sprintf(format, args...))
+}
+
+// resolves tells whether writer resolves to reader without adding problems, taking the pairs already
+// being checked as resolving, which ends the recursion of recursive schemas.
+func (c *projectionChecker) resolves(writer, reader Schema) bool {
+	trial := &projectionChecker{seen: make(map[[2]Schema]bool, len(c.seen))}
+	for key := range c.seen {
+		trial.seen[key] = true
+	}
+	trial.check("", writer, reader)
+	return len(trial.problems) == 0
+}
+
+// check adds the problems resolving writer to reader, prefixing them with prefix.
+func (c *projectionChecker) check(prefix string, writer, reader Schema) {
+	writer, reader = resolveSchema(writer), resolveSchema(reader)
+	key := [2]Schema{writer, reader}
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+
+	if writer.Type() == Union {
+		// Only the branches that actually get written need to resolve, so one is enough.
+		resolved := false
+		for _, t := range writer.(*UnionSchema).Types {
+			if c.resolves(t, reader) {
+				resolved = true
+			}
+		}
+		if !resolved {
+			c.problem(prefix, "No branch of writer union resolves to reader schema %s", reader.FullName())
+		}
+		return
+	}
+	if reader.Type() == Union {
+		i := readerBranch(writer, reader.(*UnionSchema))
+		if i < 0 {
+			c.problem(prefix, "Writer schema %s doesn't resolve to any branch of reader union", writer.FullName())
+			return
+		}
+		c.check(prefix, writer, reader.(*UnionSchema).Types[i])
+		return
+	}
+
+	if !matches(writer, reader) && !isPromotable(writer.Type(), reader.Type()) {
+		c.problem(prefix, "Writer schema %s doesn't resolve to reader schema %s", writer.FullName(), reader.FullName())
+		return
+	}
+	switch reader.Type() {
+	case Array:
+		c.check(prefix, writer.(*ArraySchema).Items, reader.(*ArraySchema).Items)
+	case Map:
+		c.check(prefix, writer.(*MapSchema).Values, reader.(*MapSchema).Values)
+	case Fixed:
+		if writer.(*FixedSchema).Size != reader.(*FixedSchema).Size {
+			c.problem(prefix, "Fixed %s has size %d in writer and %d in reader schema", reader.FullName(),
+				writer.(*FixedSchema).Size, reader.(*FixedSchema).Size)
+		}
+	case Record:
+		for _, field := range reader.(*RecordSchema).Fields {
+			fieldPrefix := fmt.Sprintf("Field %s.%s: ", reader.FullName(), field.Name)
+			if writerField, _, ok := findWriterField(writer.(*RecordSchema), field); ok {
+				c.check(fieldPrefix, writerField.Type, field.Type)
+			} else if !hasDefault(field) {
+				c.problem("", "Field %s.%s is missing from writer schema and has no default", reader.FullName(), field.Name)
+			} else if _, err := defaultValue(field.Type, field.Default, false); err != nil {
+				c.problem(fieldPrefix, "%s", err)
+			}
+		}
+	}
+}
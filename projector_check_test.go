@@ -0,0 +1,48 @@
+package avro
+
+import "testing"
+
+func TestCanProject(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Order", "namespace": "shop", "fields": [
+		{"name": "id", "type": "string"},
+		{"name": "sku", "type": {"type": "fixed", "name": "SKU", "size": 8}},
+		{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+			{"name": "qty", "type": "long"}
+		]}}}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Order", "namespace": "shop", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "sku", "type": {"type": "fixed", "name": "SKU", "size": 16}},
+		{"name": "status", "type": "string"},
+		{"name": "priority", "type": "int", "default": "high"},
+		{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+			{"name": "qty", "type": ["null", "int"]}
+		]}}}
+	]}`)
+	err := CanProject(reader, writer)
+	assert(t, err.(*ProjectionError).Problems, []string{
+		"Field shop.Order.id: Writer schema string doesn't resolve to reader schema long",
+		"Field shop.Order.sku: Fixed shop.SKU has size 8 in writer and 16 in reader schema",
+		"Field shop.Order.status is missing from writer schema and has no default",
+		"Field shop.Order.priority: Invalid default high for int",
+		"Field shop.Line.qty: Writer schema long doesn't resolve to any branch of reader union",
+	})
+	_, err = NewDatumProjector(writer, reader)
+	assert(t, err.Error(), "Field shop.Order.id: Writer schema string doesn't resolve to reader schema long")
+
+	// Invalid defaults fail NewDatumProjector too.
+	_, err = NewDatumProjector(writer, MustParseSchema(`{"type": "record", "name": "Order", "namespace": "shop", "fields": [
+		{"name": "priority", "type": "int", "default": "high"}
+	]}`))
+	assert(t, err.Error(), "Field shop.Order.priority: Invalid default high for int")
+
+	assert(t, CanProject(writer, writer), nil)
+	assert(t, CanProject(MustParseSchema(`["null", "long"]`), MustParseSchema(`["int", "string"]`)), nil)
+	assert(t, CanProject(MustParseSchema(`"long"`), MustParseSchema(`["string", "bytes"]`)).Error(),
+		"Schemas can't be projected: No branch of writer union resolves to reader schema long")
+
+	recursive := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "next", "type": ["null", "Node"]}
+	]}`)
+	assert(t, CanProject(recursive, recursive), nil)
+}
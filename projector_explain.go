@@ -0,0 +1,158 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FieldResolution tells how the value of a reader field is obtained when projecting a record.
+type FieldResolution string
+
+// The ways reader fields are resolved, and writer fields skipped.
+const (
+	FieldFromWriter FieldResolution = "writer"  // read from the writer field of the same name
+	FieldFromAlias  FieldResolution = "alias"   // read from a writer field named by an alias
+	FieldDefault    FieldResolution = "default" // missing from the writer, set to its default
+	FieldSkipped    FieldResolution = "skipped" // writer field the reader doesn't have
+)
+
+// FieldProjection describes how a field of a record is resolved by a DatumProjector.
+type FieldProjection struct {
+	Record      string          `json:"record"`                // full name of the reader record
+	Field       string          `json:"field,omitempty"`       // reader field, empty if skipped
+	WriterField string          `json:"writerField,omitempty"` // empty if defaulted
+	Resolution  FieldResolution `json:"resolution"`
+
+	// Promotion describes how values of the writer field are converted, e.g. "int to long", if they
+	// are. Default is the value of defaulted fields.
+	Promotion string      `json:"promotion,omitempty"`
+	Default   interface{} `json:"default,omitempty"`
+}
+
+// String describes the resolution of the field on a single line.
+func (f FieldProjection) String() string {
+	switch f.Resolution {
+	case FieldSkipped:
+		return fmt.Sprintf("%s.%s: writer field skipped", f.Record, f.WriterField)
+	case FieldDefault:
+		def, err := json.Marshal(f.Default)
+		if err != nil {
+			def = []byte(fmt.Sprint(f.Default))
+		}
+		return fmt.Sprintf("%s.%s: default %s", f.Record, f.Field, def)
+	}
+	line := fmt.Sprintf("%s.%s: writer field %s", f.Record, f.Field, f.WriterField)
+	if f.Resolution == FieldFromAlias {
+		line += " by alias"
+	}
+	if f.Promotion != "" {
+		line += ", promoted from " + f.Promotion
+	}
+	return line
+}
+
+// ProjectionExplanation describes how a DatumProjector resolves the records of the writer schema to
+// those of the reader schema, as returned by Explain.
+type ProjectionExplanation struct {
+	// Fields are the fields of every pair of writer and reader records the projection goes
+	// through, starting with the outermost ones. The fields of a reader record come in order,
+	// followed by the writer fields it skips.
+	Fields []FieldProjection `json:"fields"`
+}
+
+// String describes the projection with a line per field.
+func (e *ProjectionExplanation) String() string {
+	lines := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		lines[i] = field.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *datumProjector) Explain() *ProjectionExplanation {
+	e := &ProjectionExplanation{Fields: []FieldProjection{}}
+	e.explain(p.writer, p.reader, make(map[[2]Schema]bool))
+	return e
+}
+
+func (e *ProjectionExplanation) explain(writer, reader Schema, seen map[[2]Schema]bool) {
+	writer, reader = resolveSchema(writer), resolveSchema(reader)
+	key := [2]Schema{writer, reader}
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+
+	if writer.Type() == Union {
+		// Every branch that resolves may get written.
+		for _, t := range writer.(*UnionSchema).Types {
+			if len(checkResolvable(t, reader)) == 0 {
+				e.explain(t, reader, seen)
+			}
+		}
+		return
+	}
+	if reader.Type() == Union {
+		if i := readerBranch(writer, reader.(*UnionSchema)); i >= 0 {
+			e.explain(writer, reader.(*UnionSchema).Types[i], seen)
+		}
+		return
+	}
+	switch {
+	case reader.Type() == Array && writer.Type() == Array:
+		e.explain(writer.(*ArraySchema).Items, reader.(*ArraySchema).Items, seen)
+	case reader.Type() == Map && writer.Type() == Map:
+		e.explain(writer.(*MapSchema).Values, reader.(*MapSchema).Values, seen)
+	case reader.Type() == Record && writer.Type() == Record:
+		e.explainRecord(writer.(*RecordSchema), reader.(*RecordSchema), seen)
+	}
+}
+
+func (e *ProjectionExplanation) explainRecord(writer, reader *RecordSchema, seen map[[2]Schema]bool) {
+	var nested [][2]Schema
+	read := make([]bool, len(writer.Fields))
+	for _, field := range reader.Fields {
+		f := FieldProjection{Record: reader.FullName(), Field: field.Name}
+		if writerField, i, ok := findWriterField(writer, field); ok {
+			read[i] = true
+			f.WriterField, f.Resolution = writerField.Name, FieldFromWriter
+			if writerField.Name != field.Name {
+				f.Resolution = FieldFromAlias
+			}
+			f.Promotion = promotion(writerField.Type, field.Type)
+			nested = append(nested, [2]Schema{writerField.Type, field.Type})
+		} else {
+			f.Resolution, f.Default = FieldDefault, field.Default
+		}
+		e.Fields = append(e.Fields, f)
+	}
+	for i, field := range writer.Fields {
+		if !read[i] {
+			e.Fields = append(e.Fields, FieldProjection{Record: reader.FullName(), WriterField: field.Name, Resolution: FieldSkipped})
+		}
+	}
+	for _, pair := range nested {
+		e.explain(pair[0], pair[1], seen)
+	}
+}
+
+// promotion describes how values of a non-union writer schema are promoted to the reader schema,
+// e.g. "int to long", or returns an empty string if they aren't.
+func promotion(writer, reader Schema) string {
+	writer, reader = resolveSchema(writer), resolveSchema(reader)
+	if writer.Type() == Union {
+		return ""
+	}
+	if union, ok := reader.(*UnionSchema); ok {
+		i := readerBranch(writer, union)
+		if i < 0 {
+			return ""
+		}
+		reader = resolveSchema(union.Types[i])
+	}
+	if writer.Type() == reader.Type() || !isPromotable(writer.Type(), reader.Type()) {
+		return ""
+	}
+	return writer.GetName() + " to " + reader.GetName()
+}
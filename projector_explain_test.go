@@ -0,0 +1,53 @@
+package avro
+
+import "testing"
+
+func TestDatumProjectorExplain(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Order", "namespace": "shop", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "customer", "type": "string"},
+		{"name": "legacy", "type": "boolean"},
+		{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+			{"name": "qty", "type": "int"}
+		]}}}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Order", "namespace": "shop", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "buyer", "type": ["null", "bytes"], "aliases": ["customer"]},
+		{"name": "status", "type": "string", "default": "OPEN"},
+		{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+			{"name": "qty", "type": "int"},
+			{"name": "note", "type": ["null", "string"], "default": null}
+		]}}}
+	]}`)
+	projector, err := NewDatumProjector(writer, reader)
+	assert(t, err, nil)
+
+	e := projector.Explain()
+	assert(t, e.Fields, []FieldProjection{
+		{Record: "shop.Order", Field: "id", WriterField: "id", Resolution: FieldFromWriter, Promotion: "int to long"},
+		{Record: "shop.Order", Field: "buyer", WriterField: "customer", Resolution: FieldFromAlias, Promotion: "string to bytes"},
+		{Record: "shop.Order", Field: "status", Resolution: FieldDefault, Default: "OPEN"},
+		{Record: "shop.Order", Field: "lines", WriterField: "lines", Resolution: FieldFromWriter},
+		{Record: "shop.Order", WriterField: "legacy", Resolution: FieldSkipped},
+		{Record: "shop.Line", Field: "qty", WriterField: "qty", Resolution: FieldFromWriter},
+		{Record: "shop.Line", Field: "note", Resolution: FieldDefault},
+	})
+	assert(t, e.String(), `shop.Order.id: writer field id, promoted from int to long
+shop.Order.buyer: writer field customer by alias, promoted from string to bytes
+shop.Order.status: default "OPEN"
+shop.Order.lines: writer field lines
+shop.Order.legacy: writer field skipped
+shop.Line.qty: writer field qty
+shop.Line.note: default null`)
+
+	chain, err := NewMigrationChain(writer, reader, reader)
+	assert(t, err, nil)
+	explained := chain.Projector(0).Explain()
+	assert(t, len(explained.Fields), len(e.Fields)+6)
+	assert(t, explained.Fields[len(e.Fields)], FieldProjection{Record: "shop.Order", Field: "id", WriterField: "id", Resolution: FieldFromWriter})
+
+	projector, err = NewDatumProjector(MustParseSchema(`"int"`), MustParseSchema(`"long"`))
+	assert(t, err, nil)
+	assert(t, len(projector.Explain().Fields), 0)
+}
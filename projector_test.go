@@ -0,0 +1,163 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func decodeGeneric(t *testing.T, schema Schema, v interface{}) interface{} {
+	buf := &bytes.Buffer{}
+	if err := NewDatumWriter(schema).Write(v, NewBinaryEncoder(buf)); err != nil {
+		t.Fatal(err)
+	}
+	value, err := (&GenericDatumReader{schema: schema}).readValue(schema, NewBinaryDecoder(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestDatumProjector(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Rec", "namespace": "v1", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "name", "type": "bytes"},
+		{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+		{"name": "sizes", "type": {"type": "array", "items": "int"}},
+		{"name": "dropped", "type": "string"},
+		{"name": "next", "type": ["null", "Rec"]}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Rec", "namespace": "v2", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": "string"},
+		{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["BLUE", "GREEN", "RED"]}},
+		{"name": "sizes", "type": {"type": "array", "items": "double"}},
+		{"name": "added", "type": "int", "default": 7},
+		{"name": "nested", "type": {"type": "record", "name": "Nested", "fields": [{"name": "x", "type": "string"}]},
+			"default": {"x": "y"}},
+		{"name": "next", "type": ["null", "Rec"]}
+	]}`)
+
+	inner := NewGenericRecord(writer)
+	inner.Set("id", int32(2))
+	inner.Set("name", []byte("inner"))
+	inner.Set("color", "RED")
+	inner.Set("sizes", []int32{})
+	inner.Set("dropped", "")
+	inner.Set("next", nil)
+	record := NewGenericRecord(writer)
+	record.Set("id", int32(1))
+	record.Set("name", []byte("outer"))
+	record.Set("color", "GREEN")
+	record.Set("sizes", []int32{3})
+	record.Set("dropped", "gone")
+	record.Set("next", inner)
+
+	projector, err := NewDatumProjector(writer, reader)
+	assert(t, err, nil)
+	value, err := projector.Project(decodeGeneric(t, writer, record))
+	assert(t, err, nil)
+	projected := value.(*GenericRecord)
+	assert(t, projected.Schema(), reader)
+	assert(t, projected.Get("id"), int64(1))
+	assert(t, projected.Get("name"), "outer")
+	assert(t, projected.Get("color"), "GREEN")
+	assert(t, projected.Get("sizes"), []interface{}{float64(3)})
+	assert(t, projected.Get("dropped"), nil)
+	assert(t, projected.Get("added"), int32(7))
+	assert(t, projected.Get("nested").(*GenericRecord).Get("x"), "y")
+	next := projected.Get("next").(*GenericRecord)
+	assert(t, next.Get("id"), int64(2))
+	assert(t, next.Get("color"), "RED")
+	assert(t, next.Get("next"), nil)
+
+	_, err = NewDatumProjector(reader, writer)
+	assert(t, err.Error(), "Field v1.Rec.id: Writer schema long doesn't resolve to reader schema int")
+	_, err = NewDatumProjector(writer, MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "required", "type": "string"}
+	]}`))
+	assert(t, err.Error(), "Field Rec.required is missing from writer schema and has no default")
+}
+
+func TestDatumProjectorRecordLayout(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": "string"},
+		{"name": "b", "type": "int"}
+	]}`)
+	// Records of a same-named schema with the fields in another order are projected by name.
+	record := NewGenericRecord(MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "b", "type": "int"},
+		{"name": "a", "type": "string"}
+	]}`))
+	record.Set("a", "x")
+	record.Set("b", int32(1))
+	projector, err := NewDatumProjector(writer, writer)
+	assert(t, err, nil)
+	projected, err := projector.Project(record)
+	assert(t, err, nil)
+	assert(t, projected.(*GenericRecord).Get("a"), "x")
+	assert(t, projected.(*GenericRecord).Get("b"), int32(1))
+
+	projected, err = projector.Project(&GenericRecord{})
+	assert(t, err, nil)
+	assert(t, projected.(*GenericRecord).Get("a"), nil)
+}
+
+func TestMigrationChain(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "email", "type": ["null", "string"]}
+	]}`)
+	v3 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "email", "type": ["null", "string"]},
+		{"name": "active", "type": "boolean", "default": true}
+	]}`)
+
+	chain, err := NewMigrationChain(v1, v2, v3)
+	assert(t, err, nil)
+	chain.FixUp(1, func(v interface{}) (interface{}, error) {
+		record := v.(*GenericRecord)
+		record.Set("email", record.Get("name").(string)+"@example.com")
+		return record, nil
+	})
+
+	user := NewGenericRecord(v1)
+	user.Set("name", "alice")
+	migrated, err := chain.Migrate(0, decodeGeneric(t, v1, user))
+	assert(t, err, nil)
+	assert(t, migrated.(*GenericRecord).Get("email"), "alice@example.com")
+	assert(t, migrated.(*GenericRecord).Get("active"), true)
+
+	user = NewGenericRecord(v2)
+	user.Set("name", "bob")
+	user.Set("email", nil)
+	projector := chain.Projector(1)
+	assert(t, projector.WriterSchema(), v2)
+	assert(t, projector.ReaderSchema(), v3)
+	migrated, err = projector.Project(decodeGeneric(t, v2, user))
+	assert(t, err, nil)
+	assert(t, migrated.(*GenericRecord).Get("email"), nil)
+
+	_, err = NewMigrationChain(v3, v1)
+	assert(t, err.Error(), "Version 0 to 1: Field User.name is missing from writer schema and has no default")
+}
+
+func TestDatumProjectorFieldAliases(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "mail", "type": "string"}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "email", "aliases": ["mail"], "type": "string"}
+	]}`)
+
+	user := NewGenericRecord(writer)
+	user.Set("mail", "a@example.com")
+	projector, err := NewDatumProjector(writer, reader)
+	assert(t, err, nil)
+	value, err := projector.Project(decodeGeneric(t, writer, user))
+	assert(t, err, nil)
+	assert(t, value.(*GenericRecord).Get("email"), "a@example.com")
+	assert(t, value.(*GenericRecord).Presence("email"), FieldFromData)
+}
@@ -0,0 +1,133 @@
+package avro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Custom properties holding the provenance of schemas and their fields, as read and written by
+// GetProvenance and SetProvenance.
+const (
+	SourceProp  = "source"  // system the data comes from
+	OwnerProp   = "owner"   // team or person responsible for the data
+	VersionProp = "version" // version of the schema or field, a string or a number
+	PIIProp     = "pii"     // array of tags of the personal data held, e.g. "email"
+)
+
+// PropertyHolder is anything holding custom properties, such as a Schema or a *SchemaField.
+type PropertyHolder interface {
+	Prop(key string) (interface{}, bool)
+	SetProp(key string, value interface{}) error
+	DeleteProp(key string)
+}
+
+// Provenance is the lineage metadata of a schema or field, kept in its custom properties so that it
+// travels along with schema JSON, e.g. for governance tooling.
+type Provenance struct {
+	Source  string
+	Owner   string
+	Version string
+	PII     []string // tags of the personal data held, empty if none
+}
+
+// GetProvenance reads the provenance properties of a schema or field. Properties it doesn't have
+// are left empty, while properties of unexpected types are errors.
+func GetProvenance(holder PropertyHolder) (Provenance, error) {
+	var p Provenance
+	for _, prop := range []struct {
+		key   string
+		value *string
+	}{{SourceProp, &p.Source}, {OwnerProp, &p.Owner}, {VersionProp, &p.Version}} {
+		switch v, _ := holder.Prop(prop.key); v := v.(type) {
+		case nil:
+		case string:
+			*prop.value = v
+		case float64:
+			// Versions parsed from JSON numbers.
+			*prop.value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return p, fmt.Errorf("Invalid %s property: %v", prop.key, v)
+		}
+	}
+	switch v, _ := holder.Prop(PIIProp); v := v.(type) {
+	case nil:
+	case []string:
+		p.PII = append([]string{}, v...)
+	case []interface{}:
+		for _, tag := range v {
+			s, ok := tag.(string)
+			if !ok {
+				return p, fmt.Errorf("Invalid %s property: %v", PIIProp, v)
+			}
+			p.PII = append(p.PII, s)
+		}
+	default:
+		return p, fmt.Errorf("Invalid %s property: %v", PIIProp, v)
+	}
+	return p, nil
+}
+
+// SetProvenance stamps the provenance properties on a schema or field, replacing any it has
+// already. Empty attributes of p remove their property.
+func SetProvenance(holder PropertyHolder, p Provenance) error {
+	for _, prop := range []struct {
+		key   string
+		value string
+	}{{SourceProp, p.Source}, {OwnerProp, p.Owner}, {VersionProp, p.Version}} {
+		if prop.value == "" {
+			holder.DeleteProp(prop.key)
+		} else if err := holder.SetProp(prop.key, prop.value); err != nil {
+			return err
+		}
+	}
+	if len(p.PII) == 0 {
+		holder.DeleteProp(PIIProp)
+		return nil
+	}
+	return holder.SetProp(PIIProp, append([]string{}, p.PII...))
+}
+
+// PIIFields returns the fields of a record holding personal data according to their PII property,
+// by their dotted paths such as "user.email", along with their tags. Nested records, including
+// those of optional fields, arrays and maps, are looked through. Fields with invalid PII properties
+// are errors.
+func PIIFields(schema *RecordSchema) (map[string][]string, error) {
+	fields := make(map[string][]string)
+	return fields, collectPIIFields(schema, "", fields, make(map[*RecordSchema]bool))
+}
+
+func collectPIIFields(s Schema, path string, fields map[string][]string, seen map[*RecordSchema]bool) error {
+	switch s := resolveSchema(s).(type) {
+	case *RecordSchema:
+		if seen[s] {
+			return nil
+		}
+		seen[s] = true
+		defer delete(seen, s)
+		for _, field := range s.Fields {
+			fieldPath := strings.TrimPrefix(path+"."+field.Name, ".")
+			p, err := GetProvenance(field)
+			if err != nil {
+				return fmt.Errorf("Field %s: %s", fieldPath, err)
+			}
+			if len(p.PII) > 0 {
+				fields[fieldPath] = p.PII
+			}
+			if err := collectPIIFields(field.Type, fieldPath, fields, seen); err != nil {
+				return err
+			}
+		}
+	case *ArraySchema:
+		return collectPIIFields(s.Items, path, fields, seen)
+	case *MapSchema:
+		return collectPIIFields(s.Values, path, fields, seen)
+	case *UnionSchema:
+		for _, t := range s.Types {
+			if err := collectPIIFields(t, path, fields, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package avro
+
+import "testing"
+
+func TestProvenance(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "User", "source": "crm", "version": 3, "fields": [
+		{"name": "email", "type": "string", "pii": ["email"], "default": ""},
+		{"name": "friends", "type": {"type": "array", "items": "User"}},
+		{"name": "address", "type": ["null", {"type": "record", "name": "Address", "fields": [
+			{"name": "street", "type": "string", "pii": ["address", "location"]}
+		]}]}
+	]}`).(*RecordSchema)
+
+	p, err := GetProvenance(schema)
+	assert(t, err, nil)
+	assert(t, p, Provenance{Source: "crm", Version: "3"})
+
+	fields, err := PIIFields(schema)
+	assert(t, err, nil)
+	assert(t, fields, map[string][]string{"email": {"email"}, "address.street": {"address", "location"}})
+
+	assert(t, SetProvenance(schema, Provenance{Source: "crm", Owner: "identity", Version: "4"}), nil)
+	assert(t, SetProvenance(schema.Fields[1], Provenance{PII: []string{"social"}}), nil)
+	p, err = GetProvenance(schema.Fields[1])
+	assert(t, err, nil)
+	assert(t, p, Provenance{PII: []string{"social"}})
+
+	reparsed, err := ParseSchema(schema.String())
+	assert(t, err, nil)
+	p, err = GetProvenance(reparsed)
+	assert(t, err, nil)
+	assert(t, p, Provenance{Source: "crm", Owner: "identity", Version: "4"})
+	fields, err = PIIFields(reparsed.(*RecordSchema))
+	assert(t, err, nil)
+	assert(t, fields, map[string][]string{"email": {"email"}, "friends": {"social"}, "address.street": {"address", "location"}})
+	field, err := schema.Fields[0].MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(field), `{"name":"email","default":"","type":"string","pii":["email"]}`)
+
+	assert(t, SetProvenance(schema, Provenance{}), nil)
+	assert(t, len(schema.Properties), 0)
+
+	schema.Fields[0].Properties[PIIProp] = "email"
+	_, err = PIIFields(schema)
+	assert(t, err.Error(), "Field email: Invalid pii property: email")
+}
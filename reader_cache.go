@@ -0,0 +1,118 @@
+package avro
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// ReaderCache memoizes DatumReaders and DatumProjectors by the JSON of their schemas, so that
+// frameworks decoding requests of a few schemas don't build new ones per request. A ReaderCache is
+// safe for concurrent use, as are the readers and projectors it returns.
+//
+// Schemas are keyed by their whole JSON rather than by their Parsing Canonical Form, since logical
+// types, defaults and aliases, which that leaves out, change how data decodes.
+type ReaderCache struct {
+	lock       sync.RWMutex
+	readers    map[readerCacheKey]DatumReader
+	projectors map[[2]string]DatumProjector
+}
+
+type readerCacheKey struct {
+	schema string
+	target reflect.Type // nil for GenericDatumReaders
+}
+
+// NewReaderCache creates an empty ReaderCache.
+func NewReaderCache() *ReaderCache {
+	return &ReaderCache{readers: make(map[readerCacheKey]DatumReader), projectors: make(map[[2]string]DatumProjector)}
+}
+
+// Reader returns a DatumReader of the given schema for values of the target type: a
+// GenericDatumReader if target is nil or GenericRecord or a pointer to it, and a SpecificDatumReader
+// for any other type, usually a struct or a pointer to one.
+// May return an error if the schema can't be serialized as JSON.
+func (c *ReaderCache) Reader(schema Schema, target reflect.Type) (DatumReader, error) {
+	key, err := readerCacheSchemaKey(schema)
+	if err != nil {
+		return nil, err
+	}
+	for target != nil && target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	if target == genericRecordType.Elem() {
+		target = nil
+	}
+	cacheKey := readerCacheKey{key, target}
+
+	c.lock.RLock()
+	reader, ok := c.readers[cacheKey]
+	c.lock.RUnlock()
+	if ok {
+		return reader, nil
+	}
+	if target == nil {
+		reader = NewGenericDatumReader().SetSchema(schema)
+	} else {
+		reader = NewSpecificDatumReader().SetSchema(schema)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if existing, ok := c.readers[cacheKey]; ok {
+		// Built concurrently by another caller.
+		return existing, nil
+	}
+	c.readers[cacheKey] = reader
+	return reader, nil
+}
+
+// Projector returns a DatumProjector from the writer to the reader schema.
+// May return an error if the schemas can't be serialized as JSON, or as NewDatumProjector does.
+// Such errors aren't cached.
+func (c *ReaderCache) Projector(writer, reader Schema) (DatumProjector, error) {
+	writerKey, err := readerCacheSchemaKey(writer)
+	if err != nil {
+		return nil, err
+	}
+	readerKey, err := readerCacheSchemaKey(reader)
+	if err != nil {
+		return nil, err
+	}
+	key := [2]string{writerKey, readerKey}
+
+	c.lock.RLock()
+	projector, ok := c.projectors[key]
+	c.lock.RUnlock()
+	if ok {
+		return projector, nil
+	}
+	if projector, err = NewDatumProjector(writer, reader); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if existing, ok := c.projectors[key]; ok {
+		return existing, nil
+	}
+	c.projectors[key] = projector
+	return projector, nil
+}
+
+// readerCacheSchemaKey returns the JSON of a schema, which tells apart all the schemas that decode
+// differently.
+func readerCacheSchemaKey(schema Schema) (string, error) {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Len returns the number of readers and projectors cached.
+func (c *ReaderCache) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.readers) + len(c.projectors)
+}
@@ -0,0 +1,87 @@
+package avro
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestReaderCache(t *testing.T) {
+	cache := NewReaderCache()
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [{"name": "id", "type": "long"}]}`)
+	type event struct {
+		ID int64 `avro:"id"`
+	}
+
+	generic, err := cache.Reader(schema, nil)
+	assert(t, err, nil)
+	_, ok := generic.(*GenericDatumReader)
+	assert(t, ok, true)
+	same, err := cache.Reader(MustParseSchema(schema.String()), reflect.TypeOf(&GenericRecord{}))
+	assert(t, err, nil)
+	assert(t, same == generic, true)
+
+	specific, err := cache.Reader(schema, reflect.TypeOf(&event{}))
+	assert(t, err, nil)
+	_, ok = specific.(*SpecificDatumReader)
+	assert(t, ok, true)
+	same, err = cache.Reader(schema, reflect.TypeOf(event{}))
+	assert(t, err, nil)
+	assert(t, same == specific, true)
+
+	decoded := &event{}
+	assert(t, specific.Read(decoded, NewBinaryDecoder([]byte{0x0e})), nil)
+	assert(t, decoded.ID, int64(7))
+
+	reader := MustParseSchema(`{"type": "record", "name": "Event", "fields": [{"name": "id", "type": "double"}]}`)
+	projector, err := cache.Projector(schema, reader)
+	assert(t, err, nil)
+	again, err := cache.Projector(schema, reader)
+	assert(t, err, nil)
+	assert(t, again == projector, true)
+	_, err = cache.Projector(reader, schema)
+	assert(t, err != nil, true)
+	assert(t, cache.Len(), 3)
+}
+
+func TestReaderCacheLogicalTypes(t *testing.T) {
+	cache := NewReaderCache()
+	cents := MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}`)
+	basisPoints := MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 4}`)
+	centsReader, err := cache.Reader(cents, nil)
+	assert(t, err, nil)
+	basisPointsReader, err := cache.Reader(basisPoints, nil)
+	assert(t, err, nil)
+	assert(t, centsReader == basisPointsReader, false)
+
+	// Defaults only matter to projections, which must not be shared either.
+	writer := MustParseSchema(`{"type": "record", "name": "Event", "fields": [{"name": "id", "type": "long"}]}`)
+	zero := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"}, {"name": "n", "type": "int", "default": 0}]}`)
+	one := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"}, {"name": "n", "type": "int", "default": 1}]}`)
+	zeroProjector, err := cache.Projector(writer, zero)
+	assert(t, err, nil)
+	oneProjector, err := cache.Projector(writer, one)
+	assert(t, err, nil)
+	assert(t, zeroProjector == oneProjector, false)
+}
+
+func TestReaderCacheConcurrency(t *testing.T) {
+	cache := NewReaderCache()
+	schema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [{"name": "id", "type": "long"}]}`)
+	readers := make([]DatumReader, 8)
+	var wg sync.WaitGroup
+	for i := range readers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			readers[i], _ = cache.Reader(schema, nil)
+		}(i)
+	}
+	wg.Wait()
+	for _, reader := range readers {
+		assert(t, reader == readers[0], true)
+	}
+	assert(t, cache.Len(), 1)
+}
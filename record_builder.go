@@ -0,0 +1,90 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RecordBuilder builds GenericRecords, making sure every field without a default gets a value.
+//
+//	record, err := avro.NewRecordBuilder(schema).
+//		Set("id", int64(1)).
+//		Set("name", "alice").
+//		Build()
+type RecordBuilder struct {
+	schema  Schema
+	fields  []*SchemaField
+	values  map[string]interface{}
+	unknown []string
+}
+
+// NewRecordBuilder creates a RecordBuilder for the given record schema.
+func NewRecordBuilder(schema Schema) *RecordBuilder {
+	var fields []*SchemaField
+	if s, ok := resolveSchema(schema).(*RecordSchema); ok {
+		fields = s.Fields
+	}
+	return &RecordBuilder{schema: schema, fields: fields, values: make(map[string]interface{})}
+}
+
+// Set sets the value of the field with the given name.
+// Setting a field that doesn't exist in the schema makes Build fail.
+func (b *RecordBuilder) Set(name string, value interface{}) *RecordBuilder {
+	if b.field(name) == nil {
+		b.unknown = append(b.unknown, name)
+	} else {
+		b.values[name] = value
+	}
+	return b
+}
+
+// Has reports whether a value was set for the field with the given name.
+func (b *RecordBuilder) Has(name string) bool {
+	_, ok := b.values[name]
+	return ok
+}
+
+// Clear removes the value set for the field with the given name.
+func (b *RecordBuilder) Clear(name string) *RecordBuilder {
+	delete(b.values, name)
+	return b
+}
+
+// Build creates a GenericRecord with the values set so far, and defaults for the other fields.
+// May return an error listing the fields that have neither, or any unknown fields that were set.
+func (b *RecordBuilder) Build() (*GenericRecord, error) {
+	if b.fields == nil {
+		return nil, fmt.Errorf("Can't build records of non-record schema %s", b.schema.FullName())
+	} else if len(b.unknown) > 0 {
+		return nil, fmt.Errorf("Record %s has no fields %s", b.schema.FullName(), strings.Join(b.unknown, ", "))
+	}
+
+	record := NewGenericRecord(b.schema)
+	var missing []string
+	for i, field := range b.fields {
+		if value, ok := b.values[field.Name]; ok {
+			record.SetByIndex(i, value)
+		} else if hasDefault(field) {
+			value, err := defaultValue(field.Type, field.Default, false)
+			if err != nil {
+				return nil, fmt.Errorf("Field %s.%s: %s", b.schema.FullName(), field.Name, err)
+			}
+			record.setByIndex(i, fieldValue(value), FieldFromDefault)
+		} else {
+			missing = append(missing, field.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("Record %s is missing values for fields %s", b.schema.FullName(), strings.Join(missing, ", "))
+	}
+	return record, nil
+}
+
+func (b *RecordBuilder) field(name string) *SchemaField {
+	for _, field := range b.fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
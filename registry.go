@@ -0,0 +1,201 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds named schemas (records, enums and fixed) by their full names so that they can be
+// referenced by name from other schemas.
+type Registry interface {
+	// Get looks up a schema by its full name and returns a bool representing if it exists.
+	Get(fullName string) (Schema, bool)
+
+	// Add registers a named schema under its full name.
+	// May return an error if the schema can't be added.
+	Add(Schema) error
+
+	// List returns the full names of all registered schemas in sorted order.
+	List() []string
+}
+
+// ErrRegistryReadOnly happens when trying to add a schema to a read-only Registry.
+var ErrRegistryReadOnly = errors.New("Registry is read-only")
+
+// NewRegistry creates a new empty in-memory Registry.
+// It is not safe to add schemas to it from multiple goroutines.
+func NewRegistry() Registry {
+	return make(mapRegistry)
+}
+
+// DefaultRegistry is a process-wide Registry safe for concurrent use, e.g. to load shared schemas into
+// at init with LoadSchemasUsing and have independently parsed schemas reference them by name with
+// ParseSchemaUsing, without threading a registry through every call site. Nothing uses it unless
+// given it explicitly.
+var DefaultRegistry = NewSyncRegistry()
+
+// NewSyncRegistry creates a new empty in-memory Registry which is safe for concurrent use.
+// ParseSchemaUsing adds the named types of a schema to it all at once, and none of them if parsing
+// fails, so other goroutines never see partial definitions.
+func NewSyncRegistry() Registry {
+	return &syncRegistry{schemas: make(mapRegistry)}
+}
+
+type syncRegistry struct {
+	mu      sync.RWMutex
+	schemas mapRegistry
+}
+
+func (r *syncRegistry) Get(fullName string) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schemas.Get(fullName)
+}
+
+func (r *syncRegistry) Add(schema Schema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.schemas.Add(schema)
+}
+
+func (r *syncRegistry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schemas.List()
+}
+
+// parse parses a decoded schema, holding the lock throughout and only keeping the schemas it defines
+// if it succeeds.
+func (r *syncRegistry) parse(schema interface{}) (Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := &pendingRegistry{base: r.schemas, added: make(mapRegistry)}
+	s, err := schemaByType(schema, pending, "")
+	if err != nil {
+		return nil, err
+	}
+	for name, added := range pending.added {
+		r.schemas[name] = added
+	}
+	return s, nil
+}
+
+// pendingRegistry collects the schemas added to it apart from those of its base.
+type pendingRegistry struct {
+	base  mapRegistry
+	added mapRegistry
+}
+
+func (r *pendingRegistry) Get(fullName string) (Schema, bool) {
+	if schema, ok := r.base.Get(fullName); ok {
+		return schema, true
+	}
+	return r.added.Get(fullName)
+}
+
+func (r *pendingRegistry) Add(schema Schema) error {
+	if schema != nil {
+		if _, ok := r.base[schema.FullName()]; ok {
+			return fmt.Errorf("Registry: schema %s already exists", schema.FullName())
+		}
+	}
+	return r.added.Add(schema)
+}
+
+func (r *pendingRegistry) List() []string {
+	names := append(r.base.List(), r.added.List()...)
+	sort.Strings(names)
+	return names
+}
+
+// mapRegistry is a Registry backed by a plain map. It is also used to wrap the maps passed to
+// ParseSchemaWithRegistry and LoadSchemas which predate the Registry interface.
+type mapRegistry map[string]Schema
+
+func (r mapRegistry) Get(fullName string) (Schema, bool) {
+	schema, ok := r[fullName]
+	return schema, ok
+}
+
+func (r mapRegistry) Add(schema Schema) error {
+	if schema == nil {
+		return errors.New("Registry: can't add a nil schema")
+	}
+	name := schema.FullName()
+	if _, ok := r[name]; ok {
+		return fmt.Errorf("Registry: schema %s already exists", name)
+	}
+	if r != nil {
+		r[name] = schema
+	}
+	return nil
+}
+
+func (r mapRegistry) List() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewNamespacedRegistry creates a Registry which resolves names relative to the given namespace before
+// falling back to the parent: looking up "Foo" finds "namespace.Foo" if it exists, otherwise "Foo".
+// Schemas added are stored in the parent and List returns only the names within the namespace.
+func NewNamespacedRegistry(namespace string, parent Registry) Registry {
+	return &namespacedRegistry{
+		namespace: namespace,
+		parent:    parent,
+	}
+}
+
+type namespacedRegistry struct {
+	namespace string
+	parent    Registry
+}
+
+func (r *namespacedRegistry) Get(fullName string) (Schema, bool) {
+	if !strings.ContainsRune(fullName, '.') {
+		if schema, ok := r.parent.Get(getFullName(fullName, r.namespace)); ok {
+			return schema, true
+		}
+	}
+	return r.parent.Get(fullName)
+}
+
+func (r *namespacedRegistry) Add(schema Schema) error {
+	return r.parent.Add(schema)
+}
+
+func (r *namespacedRegistry) List() []string {
+	var names []string
+	prefix := r.namespace + "."
+	for _, name := range r.parent.List() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// NewSnapshotRegistry creates a read-only copy of the given Registry as it is at the time of the call.
+// Adding schemas to the snapshot returns ErrRegistryReadOnly, while the original can keep on changing.
+func NewSnapshotRegistry(registry Registry) Registry {
+	snapshot := make(mapRegistry)
+	for _, name := range registry.List() {
+		snapshot[name], _ = registry.Get(name)
+	}
+	return snapshotRegistry{snapshot}
+}
+
+type snapshotRegistry struct {
+	mapRegistry
+}
+
+func (snapshotRegistry) Add(Schema) error {
+	return ErrRegistryReadOnly
+}
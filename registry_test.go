@@ -0,0 +1,96 @@
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	_, err := ParseSchemaUsing(`{"type": "record", "name": "Outer", "namespace": "com.example", "fields": [
+		{"name": "inner", "type": {"type": "enum", "name": "Inner", "symbols": ["A", "B"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "org.other.Hash", "size": 4}}
+	]}`, registry)
+	assert(t, err, nil)
+	assert(t, registry.List(), []string{"com.example.Inner", "com.example.Outer", "org.other.Hash"})
+
+	inner, ok := registry.Get("com.example.Inner")
+	assert(t, ok, true)
+	assert(t, inner.(*EnumSchema).Namespace, "com.example")
+
+	s, err := ParseSchemaUsing(`{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+		{"name": "outer", "type": "Outer"}
+	]}`, registry)
+	assert(t, err, nil)
+	assert(t, s.(*RecordSchema).Fields[0].Type.Type(), Recursive)
+
+	err = registry.Add(inner)
+	assert(t, err != nil, true)
+}
+
+func TestNamespacedRegistry(t *testing.T) {
+	parent := NewRegistry()
+	_, err := ParseSchemaUsing(`{"type": "fixed", "name": "Hash", "namespace": "com.example", "size": 4}`, parent)
+	assert(t, err, nil)
+	_, err = ParseSchemaUsing(`{"type": "fixed", "name": "Other", "size": 4}`, parent)
+	assert(t, err, nil)
+
+	registry := NewNamespacedRegistry("com.example", parent)
+	hash, ok := registry.Get("Hash")
+	assert(t, ok, true)
+	assert(t, hash.GetName(), "Hash")
+	_, ok = registry.Get("Other")
+	assert(t, ok, true)
+	assert(t, registry.List(), []string{"com.example.Hash"})
+}
+
+func TestSnapshotRegistry(t *testing.T) {
+	registry := NewRegistry()
+	_, err := ParseSchemaUsing(`{"type": "fixed", "name": "Hash", "size": 4}`, registry)
+	assert(t, err, nil)
+
+	snapshot := NewSnapshotRegistry(registry)
+	_, err = ParseSchemaUsing(`{"type": "fixed", "name": "Hash2", "size": 4}`, registry)
+	assert(t, err, nil)
+	assert(t, snapshot.List(), []string{"Hash"})
+
+	// Referencing existing names is fine, defining new ones is not.
+	_, err = ParseSchemaUsing(`{"type": "record", "name": "R", "fields": [{"name": "h", "type": "Hash"}]}`, snapshot)
+	assert(t, err, ErrRegistryReadOnly)
+	_, err = ParseSchemaUsing(`{"type": "array", "items": "Hash"}`, snapshot)
+	assert(t, err, nil)
+}
+
+func TestSyncRegistry(t *testing.T) {
+	registry := NewSyncRegistry()
+	_, err := ParseSchemaUsing(`{"type": "fixed", "name": "Hash", "namespace": "com.example", "size": 4}`, registry)
+	assert(t, err, nil)
+
+	parallelF(10, 10, func(routine, loop int) {
+		s, err := ParseSchemaUsing(fmt.Sprintf(`{"type": "record", "name": "R%d_%d", "fields": [
+			{"name": "h", "type": "com.example.Hash"}
+		]}`, routine, loop), registry)
+		assert(t, err, nil)
+		assert(t, s.(*RecordSchema).Fields[0].Type.Type(), Fixed)
+		_, ok := registry.Get("com.example.Hash")
+		assert(t, ok, true)
+	})
+	assert(t, len(registry.List()), 101)
+
+	// Nothing is kept of schemas failing to parse.
+	_, err = ParseSchemaUsing(`{"type": "record", "name": "Broken", "fields": [
+		{"name": "a", "type": {"type": "enum", "name": "Kind", "symbols": ["A"]}},
+		{"name": "b", "type": "Missing"}
+	]}`, registry)
+	assert(t, err != nil, true)
+	_, ok := registry.Get("Kind")
+	assert(t, ok, false)
+	_, ok = registry.Get("Broken")
+	assert(t, ok, false)
+
+	_, err = ParseSchemaUsing(`{"type": "fixed", "name": "avro.test.DefaultHash", "size": 4}`, DefaultRegistry)
+	assert(t, err, nil)
+	s, err := ParseSchemaUsing(`{"type": "array", "items": "avro.test.DefaultHash"}`, DefaultRegistry)
+	assert(t, err, nil)
+	assert(t, s.(*ArraySchema).Items.Type(), Fixed)
+}
@@ -3,10 +3,11 @@ package avro
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"math"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 // ***********************
@@ -88,6 +89,7 @@ const (
 	schemaItemsField     = "items"
 	schemaNameField      = "name"
 	schemaNamespaceField = "namespace"
+	schemaOrderField     = "order"
 	schemaSizeField      = "size"
 	schemaSymbolsField   = "symbols"
 	schemaTypeField      = "type"
@@ -102,10 +104,21 @@ type Schema interface {
 	// If this is a record, enum or fixed, returns its name, otherwise the name of the primitive type.
 	GetName() string
 
+	// If this is a record, enum or fixed, returns its namespace-qualified name, otherwise the name of the
+	// primitive type.
+	FullName() string
+
 	// Gets a custom non-reserved property from this schema and a bool representing if it exists.
 	Prop(key string) (interface{}, bool)
 
-	// Converts this schema to its JSON representation.
+	// Sets a custom property on this schema. Fails with ErrReservedProperty for reserved attribute names.
+	SetProp(key string, value interface{}) error
+
+	// Removes a custom property from this schema. Does nothing if it doesn't exist.
+	DeleteProp(key string)
+
+	// Converts this schema to its JSON representation. Never fails: schemas that can't be serialized,
+	// e.g. because of custom properties, are converted to their Parsing Canonical Form instead.
 	String() string
 
 	// Checks whether the given value is writeable to this schema.
@@ -113,11 +126,15 @@ type Schema interface {
 }
 
 // StringSchema implements Schema and represents Avro string type.
-type StringSchema struct{}
+type StringSchema struct {
+	// Logical type annotating this schema, e.g. LogicalTypeUUID, empty if none.
+	LogicalType string
+	Properties  map[string]interface{}
+}
 
 // Returns a JSON representation of StringSchema.
-func (*StringSchema) String() string {
-	return `{"type": "string"}`
+func (s *StringSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this StringSchema.
@@ -130,28 +147,61 @@ func (*StringSchema) GetName() string {
 	return typeString
 }
 
-// Prop doesn't return anything valuable for StringSchema.
-func (*StringSchema) Prop(key string) (interface{}, bool) {
-	return nil, false
+// FullName returns a type name for this StringSchema.
+func (*StringSchema) FullName() string {
+	return typeString
 }
 
-// Validate checks whether the given value is writeable to this schema.
-func (*StringSchema) Validate(v reflect.Value) bool {
-	_, ok := dereference(v).Interface().(string)
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *StringSchema) Prop(key string) (interface{}, bool) {
+	return s.logicalAttributes().prop(key)
+}
+
+// SetProp sets a custom non-reserved property on this schema, or its LogicalType for logicalType.
+func (s *StringSchema) SetProp(key string, value interface{}) error {
+	return s.logicalAttributes().setProp(key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *StringSchema) DeleteProp(key string) {
+	s.logicalAttributes().deleteProp(key)
+}
+
+func (s *StringSchema) logicalAttributes() logicalAttributes {
+	return logicalAttributes{&s.Properties, &s.LogicalType, nil, nil}
+}
+
+// Validate checks whether the given value is writeable to this schema. UUIDs accept only strings
+// in the canonical format, and also Go types holding UUIDs.
+func (s *StringSchema) Validate(v reflect.Value) bool {
+	v = dereference(v)
+	if s.LogicalType == LogicalTypeUUID {
+		_, err := uuidString(v)
+		return err == nil
+	}
+	_, ok := v.Interface().(string)
 	return ok
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*StringSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"string"`), nil
+// MarshalJSON serializes the given schema as JSON.
+func (s *StringSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // BytesSchema implements Schema and represents Avro bytes type.
-type BytesSchema struct{}
+type BytesSchema struct {
+	// Logical type annotating this schema, e.g. LogicalTypeDecimal, empty if none.
+	LogicalType string
+
+	// Precision and Scale of decimals.
+	Precision  int
+	Scale      int
+	Properties map[string]interface{}
+}
 
 // String returns a JSON representation of BytesSchema.
-func (*BytesSchema) String() string {
-	return `{"type": "bytes"}`
+func (s *BytesSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this BytesSchema.
@@ -164,29 +214,58 @@ func (*BytesSchema) GetName() string {
 	return typeBytes
 }
 
-// Prop doesn't return anything valuable for BytesSchema.
-func (*BytesSchema) Prop(key string) (interface{}, bool) {
-	return nil, false
+// FullName returns a type name for this BytesSchema.
+func (*BytesSchema) FullName() string {
+	return typeBytes
 }
 
-// Validate checks whether the given value is writeable to this schema.
-func (*BytesSchema) Validate(v reflect.Value) bool {
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *BytesSchema) Prop(key string) (interface{}, bool) {
+	return s.logicalAttributes().prop(key)
+}
+
+// SetProp sets a custom non-reserved property on this schema, or its LogicalType for logicalType,
+// and its Precision and Scale for precision and scale if it is a decimal.
+func (s *BytesSchema) SetProp(key string, value interface{}) error {
+	return s.logicalAttributes().setProp(key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *BytesSchema) DeleteProp(key string) {
+	s.logicalAttributes().deleteProp(key)
+}
+
+func (s *BytesSchema) logicalAttributes() logicalAttributes {
+	return logicalAttributes{&s.Properties, &s.LogicalType, &s.Precision, &s.Scale}
+}
+
+// Validate checks whether the given value is writeable to this schema. Decimals also accept big.Rat
+// values fitting in their precision and scale.
+func (s *BytesSchema) Validate(v reflect.Value) bool {
+	if isDecimal(s) && isDecimalType(reflect.TypeOf(v.Interface())) {
+		_, err := decimalBytes(s, v)
+		return err == nil
+	}
 	v = dereference(v)
 
 	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*BytesSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"bytes"`), nil
+// MarshalJSON serializes the given schema as JSON.
+func (s *BytesSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // IntSchema implements Schema and represents Avro int type.
-type IntSchema struct{}
+type IntSchema struct {
+	// Logical type annotating this schema, e.g. LogicalTypeDate, empty if none.
+	LogicalType string
+	Properties  map[string]interface{}
+}
 
 // String returns a JSON representation of IntSchema.
-func (*IntSchema) String() string {
-	return `{"type": "int"}`
+func (s *IntSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this IntSchema.
@@ -199,27 +278,52 @@ func (*IntSchema) GetName() string {
 	return typeInt
 }
 
-// Prop doesn't return anything valuable for IntSchema.
-func (*IntSchema) Prop(key string) (interface{}, bool) {
-	return nil, false
+// FullName returns a type name for this IntSchema.
+func (*IntSchema) FullName() string {
+	return typeInt
 }
 
-// Validate checks whether the given value is writeable to this schema.
-func (*IntSchema) Validate(v reflect.Value) bool {
-	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int32
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *IntSchema) Prop(key string) (interface{}, bool) {
+	return s.logicalAttributes().prop(key)
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*IntSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"int"`), nil
+// SetProp sets a custom non-reserved property on this schema, or its LogicalType for logicalType.
+func (s *IntSchema) SetProp(key string, value interface{}) error {
+	return s.logicalAttributes().setProp(key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *IntSchema) DeleteProp(key string) {
+	s.logicalAttributes().deleteProp(key)
+}
+
+func (s *IntSchema) logicalAttributes() logicalAttributes {
+	return logicalAttributes{&s.Properties, &s.LogicalType, nil, nil}
+}
+
+// Validate checks whether the given value is writeable to this schema. Dates also accept time.Time
+// values.
+func (s *IntSchema) Validate(v reflect.Value) bool {
+	t := reflect.TypeOf(dereference(v).Interface())
+	return t.Kind() == reflect.Int32 || t == timeType && isDate(s)
+}
+
+// MarshalJSON serializes the given schema as JSON.
+func (s *IntSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // LongSchema implements Schema and represents Avro long type.
-type LongSchema struct{}
+type LongSchema struct {
+	// Logical type annotating this schema, e.g. LogicalTypeLocalTimestampMillis, empty if none.
+	LogicalType string
+	Properties  map[string]interface{}
+}
 
 // Returns a JSON representation of LongSchema.
-func (*LongSchema) String() string {
-	return `{"type": "long"}`
+func (s *LongSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this LongSchema.
@@ -232,27 +336,50 @@ func (*LongSchema) GetName() string {
 	return typeLong
 }
 
-// Prop doesn't return anything valuable for LongSchema.
-func (*LongSchema) Prop(key string) (interface{}, bool) {
-	return nil, false
+// FullName returns a type name for this LongSchema.
+func (*LongSchema) FullName() string {
+	return typeLong
 }
 
-// Validate checks whether the given value is writeable to this schema.
-func (*LongSchema) Validate(v reflect.Value) bool {
-	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int64
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *LongSchema) Prop(key string) (interface{}, bool) {
+	return s.logicalAttributes().prop(key)
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*LongSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"long"`), nil
+// SetProp sets a custom non-reserved property on this schema, or its LogicalType for logicalType.
+func (s *LongSchema) SetProp(key string, value interface{}) error {
+	return s.logicalAttributes().setProp(key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *LongSchema) DeleteProp(key string) {
+	s.logicalAttributes().deleteProp(key)
+}
+
+func (s *LongSchema) logicalAttributes() logicalAttributes {
+	return logicalAttributes{&s.Properties, &s.LogicalType, nil, nil}
+}
+
+// Validate checks whether the given value is writeable to this schema. Timestamps and local
+// timestamps also accept time.Time values.
+func (s *LongSchema) Validate(v reflect.Value) bool {
+	t := reflect.TypeOf(dereference(v).Interface())
+	return t.Kind() == reflect.Int64 || t == timeType && timestampUnit(s) != 0
+}
+
+// MarshalJSON serializes the given schema as JSON.
+func (s *LongSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // FloatSchema implements Schema and represents Avro float type.
-type FloatSchema struct{}
+type FloatSchema struct {
+	Properties map[string]interface{}
+}
 
 // String returns a JSON representation of FloatSchema.
-func (*FloatSchema) String() string {
-	return `{"type": "float"}`
+func (s *FloatSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this FloatSchema.
@@ -265,27 +392,50 @@ func (*FloatSchema) GetName() string {
 	return typeFloat
 }
 
-// Prop doesn't return anything valuable for FloatSchema.
-func (*FloatSchema) Prop(key string) (interface{}, bool) {
+// FullName returns a type name for this FloatSchema.
+func (*FloatSchema) FullName() string {
+	return typeFloat
+}
+
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *FloatSchema) Prop(key string) (interface{}, bool) {
+	if s.Properties != nil {
+		if prop, ok := s.Properties[key]; ok {
+			return prop, true
+		}
+	}
+
 	return nil, false
 }
 
+// SetProp sets a custom non-reserved property on this schema.
+func (s *FloatSchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *FloatSchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
 // Validate checks whether the given value is writeable to this schema.
 func (*FloatSchema) Validate(v reflect.Value) bool {
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Float32
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*FloatSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"float"`), nil
+// MarshalJSON serializes the given schema as JSON.
+func (s *FloatSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // DoubleSchema implements Schema and represents Avro double type.
-type DoubleSchema struct{}
+type DoubleSchema struct {
+	Properties map[string]interface{}
+}
 
 // Returns a JSON representation of DoubleSchema.
-func (*DoubleSchema) String() string {
-	return `{"type": "double"}`
+func (s *DoubleSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this DoubleSchema.
@@ -298,27 +448,50 @@ func (*DoubleSchema) GetName() string {
 	return typeDouble
 }
 
-// Prop doesn't return anything valuable for DoubleSchema.
-func (*DoubleSchema) Prop(key string) (interface{}, bool) {
+// FullName returns a type name for this DoubleSchema.
+func (*DoubleSchema) FullName() string {
+	return typeDouble
+}
+
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *DoubleSchema) Prop(key string) (interface{}, bool) {
+	if s.Properties != nil {
+		if prop, ok := s.Properties[key]; ok {
+			return prop, true
+		}
+	}
+
 	return nil, false
 }
 
+// SetProp sets a custom non-reserved property on this schema.
+func (s *DoubleSchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *DoubleSchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
 // Validate checks whether the given value is writeable to this schema.
 func (*DoubleSchema) Validate(v reflect.Value) bool {
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Float64
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*DoubleSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"double"`), nil
+// MarshalJSON serializes the given schema as JSON.
+func (s *DoubleSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // BooleanSchema implements Schema and represents Avro boolean type.
-type BooleanSchema struct{}
+type BooleanSchema struct {
+	Properties map[string]interface{}
+}
 
 // String returns a JSON representation of BooleanSchema.
-func (*BooleanSchema) String() string {
-	return `{"type": "boolean"}`
+func (s *BooleanSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this BooleanSchema.
@@ -331,27 +504,50 @@ func (*BooleanSchema) GetName() string {
 	return typeBoolean
 }
 
-// Prop doesn't return anything valuable for BooleanSchema.
-func (*BooleanSchema) Prop(key string) (interface{}, bool) {
+// FullName returns a type name for this BooleanSchema.
+func (*BooleanSchema) FullName() string {
+	return typeBoolean
+}
+
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *BooleanSchema) Prop(key string) (interface{}, bool) {
+	if s.Properties != nil {
+		if prop, ok := s.Properties[key]; ok {
+			return prop, true
+		}
+	}
+
 	return nil, false
 }
 
+// SetProp sets a custom non-reserved property on this schema.
+func (s *BooleanSchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *BooleanSchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
 // Validate checks whether the given value is writeable to this schema.
 func (*BooleanSchema) Validate(v reflect.Value) bool {
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Bool
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*BooleanSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"boolean"`), nil
+// MarshalJSON serializes the given schema as JSON.
+func (s *BooleanSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // NullSchema implements Schema and represents Avro null type.
-type NullSchema struct{}
+type NullSchema struct {
+	Properties map[string]interface{}
+}
 
 // String returns a JSON representation of NullSchema.
-func (*NullSchema) String() string {
-	return `{"type": "null"}`
+func (s *NullSchema) String() string {
+	return schemaJSON(s)
 }
 
 // Type returns a type constant for this NullSchema.
@@ -364,11 +560,32 @@ func (*NullSchema) GetName() string {
 	return typeNull
 }
 
-// Prop doesn't return anything valuable for NullSchema.
-func (*NullSchema) Prop(key string) (interface{}, bool) {
+// FullName returns a type name for this NullSchema.
+func (*NullSchema) FullName() string {
+	return typeNull
+}
+
+// Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
+func (s *NullSchema) Prop(key string) (interface{}, bool) {
+	if s.Properties != nil {
+		if prop, ok := s.Properties[key]; ok {
+			return prop, true
+		}
+	}
+
 	return nil, false
 }
 
+// SetProp sets a custom non-reserved property on this schema.
+func (s *NullSchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *NullSchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
 // Validate checks whether the given value is writeable to this schema.
 func (*NullSchema) Validate(v reflect.Value) bool {
 	// Check if the value is something that can be null
@@ -399,9 +616,9 @@ func (*NullSchema) Validate(v reflect.Value) bool {
 	return false
 }
 
-// MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*NullSchema) MarshalJSON() ([]byte, error) {
-	return []byte(`"null"`), nil
+// MarshalJSON serializes the given schema as JSON.
+func (s *NullSchema) MarshalJSON() ([]byte, error) {
+	return s.AppendJSON(nil)
 }
 
 // RecordSchema implements Schema and represents Avro record type.
@@ -412,35 +629,23 @@ type RecordSchema struct {
 	Aliases    []string `json:"aliases,omitempty"`
 	Properties map[string]interface{}
 	Fields     []*SchemaField `json:"fields"`
+
+	fieldIndexOnce sync.Once
+	fieldIndex     map[string]int // positions of fields by name and alias, built by Field
+	layoutOnce     sync.Once
+	layout         *recordLayout // of the GenericRecords of this schema
+	writeFuncOnce  sync.Once
+	writeFunc      genericWriteFunc // shared by the GenericDatumWriters of this schema
 }
 
 // String returns a JSON representation of RecordSchema.
 func (s *RecordSchema) String() string {
-	bytes, err := json.MarshalIndent(s, "", "    ")
-	if err != nil {
-		panic(err)
-	}
-
-	return string(bytes)
+	return indentedSchemaJSON(s)
 }
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *RecordSchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Type      string         `json:"type,omitempty"`
-		Namespace string         `json:"namespace,omitempty"`
-		Name      string         `json:"name,omitempty"`
-		Doc       string         `json:"doc,omitempty"`
-		Aliases   []string       `json:"aliases,omitempty"`
-		Fields    []*SchemaField `json:"fields"`
-	}{
-		Type:      "record",
-		Namespace: s.Namespace,
-		Name:      s.Name,
-		Doc:       s.Doc,
-		Aliases:   s.Aliases,
-		Fields:    s.Fields,
-	})
+	return s.AppendJSON(nil)
 }
 
 // Type returns a type constant for this RecordSchema.
@@ -453,6 +658,16 @@ func (s *RecordSchema) GetName() string {
 	return s.Name
 }
 
+// FullName returns a record name qualified with its namespace for this RecordSchema.
+func (s *RecordSchema) FullName() string {
+	return getFullName(s.Name, s.Namespace)
+}
+
+// GetDoc returns the documentation of this RecordSchema.
+func (s *RecordSchema) GetDoc() string {
+	return s.Doc
+}
+
 // Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
 func (s *RecordSchema) Prop(key string) (interface{}, bool) {
 	if s.Properties != nil {
@@ -464,10 +679,66 @@ func (s *RecordSchema) Prop(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// SetProp sets a custom non-reserved property on this schema.
+func (s *RecordSchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *RecordSchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
+// Field looks up a field of this RecordSchema by name, or by one of its aliases, and returns it
+// along with its position. Lookups use an index built on first use, so Fields must not be modified
+// after calling Field.
+func (s *RecordSchema) Field(name string) (*SchemaField, int, bool) {
+	s.fieldIndexOnce.Do(func() {
+		s.fieldIndex = make(map[string]int, len(s.Fields))
+		for i, field := range s.Fields {
+			s.fieldIndex[field.Name] = i
+		}
+		for i, field := range s.Fields {
+			for _, alias := range field.Aliases {
+				if _, ok := s.fieldIndex[alias]; !ok {
+					s.fieldIndex[alias] = i
+				}
+			}
+		}
+	})
+
+	if i, ok := s.fieldIndex[name]; ok {
+		return s.Fields[i], i, true
+	}
+	return nil, -1, false
+}
+
+// fieldPath resolves a path to a field of this record, which is a field name, or a dotted path to a
+// field of a nested record such as "user.id", to the fields it goes through and their positions.
+// Fields are looked up by name or alias. Paths can't go through unions, arrays or maps.
+func (s *RecordSchema) fieldPath(path string) ([]*SchemaField, []int, error) {
+	names := strings.Split(path, ".")
+	fields, indexes := make([]*SchemaField, len(names)), make([]int, len(names))
+	record := s
+	for i, name := range names {
+		field, index, ok := record.Field(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("Record %s has no field %s", record.FullName(), name)
+		}
+		fields[i], indexes[i] = field, index
+		if i < len(names)-1 {
+			if record, ok = resolveSchema(field.Type).(*RecordSchema); !ok {
+				return nil, nil, fmt.Errorf("Field %s of %s is not a record", name, path)
+			}
+		}
+	}
+	return fields, indexes, nil
+}
+
 // Validate checks whether the given value is writeable to this schema.
 func (s *RecordSchema) Validate(v reflect.Value) bool {
 	v = dereference(v)
-	if v.Kind() != reflect.Struct || !v.CanAddr() || !v.CanInterface() {
+	if v.Kind() != reflect.Struct || !v.CanInterface() {
 		return false
 	}
 	rec, ok := v.Interface().(GenericRecord)
@@ -475,27 +746,34 @@ func (s *RecordSchema) Validate(v reflect.Value) bool {
 		// This is not a generic record and is likely a specific record. Hence
 		// use the basic check.
 		return v.Kind() == reflect.Struct
+	} else if !v.CanAddr() {
+		return false
 	}
 
 	fieldCount := 0
-	for key, val := range rec.fields {
+	recordFields := 0
+	rec.each(func(key string, val interface{}) {
+		if val == nil {
+			// unset fields are written out using their defaults
+			return
+		}
+		recordFields++
 		for idx := range s.Fields {
 			// key.Name must have rs.Fields[idx].Name as a suffix
 			if len(s.Fields[idx].Name) <= len(key) {
 				lhs := key[len(key)-len(s.Fields[idx].Name):]
 				if lhs == s.Fields[idx].Name {
-					if !s.Fields[idx].Type.Validate(reflect.ValueOf(val)) {
-						return false
+					if s.Fields[idx].Type.Validate(reflect.ValueOf(val)) {
+						fieldCount++
 					}
-					fieldCount++
 					break
 				}
 			}
 		}
-	}
+	})
 
 	// All of the fields set must be accounted for in the union.
-	if fieldCount < len(rec.fields) {
+	if fieldCount < recordFields {
 		return false
 	}
 
@@ -528,9 +806,29 @@ func (s *RecursiveSchema) GetName() string {
 	return s.Actual.GetName()
 }
 
-// Prop doesn't return anything valuable for RecursiveSchema.
-func (*RecursiveSchema) Prop(key string) (interface{}, bool) {
-	return nil, false
+// FullName returns a record name qualified with its namespace for enclosed RecordSchema.
+func (s *RecursiveSchema) FullName() string {
+	return s.Actual.FullName()
+}
+
+// GetDoc returns the documentation of the enclosed RecordSchema.
+func (s *RecursiveSchema) GetDoc() string {
+	return s.Actual.Doc
+}
+
+// Prop gets a custom non-reserved property from the enclosed RecordSchema.
+func (s *RecursiveSchema) Prop(key string) (interface{}, bool) {
+	return s.Actual.Prop(key)
+}
+
+// SetProp sets a custom non-reserved property on the enclosed RecordSchema.
+func (s *RecursiveSchema) SetProp(key string, value interface{}) error {
+	return s.Actual.SetProp(key, value)
+}
+
+// DeleteProp removes a custom property from the enclosed RecordSchema.
+func (s *RecursiveSchema) DeleteProp(key string) {
+	s.Actual.DeleteProp(key)
 }
 
 // Validate checks whether the given value is writeable to this schema.
@@ -540,15 +838,17 @@ func (s *RecursiveSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
 func (s *RecursiveSchema) MarshalJSON() ([]byte, error) {
-	return []byte(fmt.Sprintf(`"%s"`, s.Actual.GetName())), nil
+	return s.AppendJSON(nil)
 }
 
 // SchemaField represents a schema field for Avro record.
 type SchemaField struct {
 	Name       string      `json:"name,omitempty"`
 	Doc        string      `json:"doc,omitempty"`
+	Aliases    []string    `json:"aliases,omitempty"`
 	Default    interface{} `json:"default"`
 	Type       Schema      `json:"type,omitempty"`
+	Order      string      `json:"order,omitempty"` // "ascending" (the default if empty), "descending" or "ignore"
 	Properties map[string]interface{}
 }
 
@@ -562,33 +862,27 @@ func (this *SchemaField) Prop(key string) (interface{}, bool) {
 	return nil, false
 }
 
-// MarshalJSON serializes the given schema field as JSON.
-func (s *SchemaField) MarshalJSON() ([]byte, error) {
-	if s.Type.Type() == Null || (s.Type.Type() == Union && s.Type.(*UnionSchema).Types[0].Type() == Null) {
-		return json.Marshal(struct {
-			Name    string      `json:"name,omitempty"`
-			Doc     string      `json:"doc,omitempty"`
-			Default interface{} `json:"default"`
-			Type    Schema      `json:"type,omitempty"`
-		}{
-			Name:    s.Name,
-			Doc:     s.Doc,
-			Default: s.Default,
-			Type:    s.Type,
-		})
+// SetProp sets a custom non-reserved property on this schemafield.
+func (s *SchemaField) SetProp(key string, value interface{}) error {
+	if isReservedFieldProp(key) {
+		return ErrReservedProperty
 	}
+	return setProp(&s.Properties, key, value)
+}
 
-	return json.Marshal(struct {
-		Name    string      `json:"name,omitempty"`
-		Doc     string      `json:"doc,omitempty"`
-		Default interface{} `json:"default,omitempty"`
-		Type    Schema      `json:"type,omitempty"`
-	}{
-		Name:    s.Name,
-		Doc:     s.Doc,
-		Default: s.Default,
-		Type:    s.Type,
-	})
+// DeleteProp removes a custom property from this schemafield.
+func (s *SchemaField) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
+// GetDoc returns the documentation of this SchemaField.
+func (s *SchemaField) GetDoc() string {
+	return s.Doc
+}
+
+// MarshalJSON serializes the given schema field as JSON.
+func (s *SchemaField) MarshalJSON() ([]byte, error) {
+	return appendSchemaJSON(nil, s)
 }
 
 // String returns a JSON representation of SchemaField.
@@ -608,12 +902,7 @@ type EnumSchema struct {
 
 // String returns a JSON representation of EnumSchema.
 func (s *EnumSchema) String() string {
-	bytes, err := json.MarshalIndent(s, "", "    ")
-	if err != nil {
-		panic(err)
-	}
-
-	return string(bytes)
+	return indentedSchemaJSON(s)
 }
 
 // Type returns a type constant for this EnumSchema.
@@ -626,6 +915,16 @@ func (s *EnumSchema) GetName() string {
 	return s.Name
 }
 
+// FullName returns an enum name qualified with its namespace for this EnumSchema.
+func (s *EnumSchema) FullName() string {
+	return getFullName(s.Name, s.Namespace)
+}
+
+// GetDoc returns the documentation of this EnumSchema.
+func (s *EnumSchema) GetDoc() string {
+	return s.Doc
+}
+
 // Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
 func (s *EnumSchema) Prop(key string) (interface{}, bool) {
 	if s.Properties != nil {
@@ -637,27 +936,56 @@ func (s *EnumSchema) Prop(key string) (interface{}, bool) {
 	return nil, false
 }
 
-// Validate checks whether the given value is writeable to this schema.
-func (*EnumSchema) Validate(v reflect.Value) bool {
-	//TODO implement
-	return true
+// SetProp sets a custom non-reserved property on this schema.
+func (s *EnumSchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *EnumSchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
+// Validate checks whether the given value is writeable to this schema: a *GenericEnum with one of
+// its symbols, or without symbols of its own and an index in range, a symbol string or an int32 index.
+func (s *EnumSchema) Validate(v reflect.Value) bool {
+	if !v.IsValid() || !v.CanInterface() {
+		return false
+	}
+	switch value := v.Interface().(type) {
+	case *GenericEnum:
+		return value != nil && s.validEnum(value)
+	case GenericEnum:
+		// Unions dereference values before validating them.
+		return s.validEnum(&value)
+	case string:
+		return s.hasSymbol(value)
+	case int32:
+		return value >= 0 && int(value) < len(s.Symbols)
+	}
+	return false
+}
+
+func (s *EnumSchema) validEnum(enum *GenericEnum) bool {
+	index := enum.GetIndex()
+	if len(enum.Symbols) == 0 {
+		return index >= 0 && int(index) < len(s.Symbols)
+	}
+	return index >= 0 && int(index) < len(enum.Symbols) && s.hasSymbol(enum.Symbols[index])
+}
+
+func (s *EnumSchema) hasSymbol(symbol string) bool {
+	for _, sym := range s.Symbols {
+		if sym == symbol {
+			return true
+		}
+	}
+	return false
 }
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *EnumSchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Type      string   `json:"type,omitempty"`
-		Namespace string   `json:"namespace,omitempty"`
-		Name      string   `json:"name,omitempty"`
-		Doc       string   `json:"doc,omitempty"`
-		Symbols   []string `json:"symbols,omitempty"`
-	}{
-		Type:      "enum",
-		Namespace: s.Namespace,
-		Name:      s.Name,
-		Doc:       s.Doc,
-		Symbols:   s.Symbols,
-	})
+	return s.AppendJSON(nil)
 }
 
 // ArraySchema implements Schema and represents Avro array type.
@@ -668,12 +996,7 @@ type ArraySchema struct {
 
 // String returns a JSON representation of ArraySchema.
 func (s *ArraySchema) String() string {
-	bytes, err := json.MarshalIndent(s, "", "    ")
-	if err != nil {
-		panic(err)
-	}
-
-	return string(bytes)
+	return indentedSchemaJSON(s)
 }
 
 // Type returns a type constant for this ArraySchema.
@@ -686,6 +1009,11 @@ func (*ArraySchema) GetName() string {
 	return typeArray
 }
 
+// FullName returns a type name for this ArraySchema.
+func (*ArraySchema) FullName() string {
+	return typeArray
+}
+
 // Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
 func (s *ArraySchema) Prop(key string) (interface{}, bool) {
 	if s.Properties != nil {
@@ -697,6 +1025,16 @@ func (s *ArraySchema) Prop(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// SetProp sets a custom non-reserved property on this schema.
+func (s *ArraySchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *ArraySchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
 // Validate checks whether the given value is writeable to this schema.
 func (s *ArraySchema) Validate(v reflect.Value) bool {
 	v = dereference(v)
@@ -707,13 +1045,7 @@ func (s *ArraySchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *ArraySchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Type  string `json:"type,omitempty"`
-		Items Schema `json:"items,omitempty"`
-	}{
-		Type:  "array",
-		Items: s.Items,
-	})
+	return s.AppendJSON(nil)
 }
 
 // MapSchema implements Schema and represents Avro map type.
@@ -724,12 +1056,7 @@ type MapSchema struct {
 
 // String returns a JSON representation of MapSchema.
 func (s *MapSchema) String() string {
-	bytes, err := json.MarshalIndent(s, "", "    ")
-	if err != nil {
-		panic(err)
-	}
-
-	return string(bytes)
+	return indentedSchemaJSON(s)
 }
 
 // Type returns a type constant for this MapSchema.
@@ -742,6 +1069,11 @@ func (*MapSchema) GetName() string {
 	return typeMap
 }
 
+// FullName returns a type name for this MapSchema.
+func (*MapSchema) FullName() string {
+	return typeMap
+}
+
 // Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
 func (s *MapSchema) Prop(key string) (interface{}, bool) {
 	if s.Properties != nil {
@@ -752,6 +1084,16 @@ func (s *MapSchema) Prop(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// SetProp sets a custom non-reserved property on this schema.
+func (s *MapSchema) SetProp(key string, value interface{}) error {
+	return setProp(&s.Properties, key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *MapSchema) DeleteProp(key string) {
+	delete(s.Properties, key)
+}
+
 // Validate checks whether the given value is writeable to this schema.
 func (s *MapSchema) Validate(v reflect.Value) bool {
 	v = dereference(v)
@@ -761,13 +1103,7 @@ func (s *MapSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *MapSchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Type   string `json:"type,omitempty"`
-		Values Schema `json:"values,omitempty"`
-	}{
-		Type:   "map",
-		Values: s.Values,
-	})
+	return s.AppendJSON(nil)
 }
 
 // UnionSchema implements Schema and represents Avro union type.
@@ -777,12 +1113,7 @@ type UnionSchema struct {
 
 // String returns a JSON representation of UnionSchema.
 func (s *UnionSchema) String() string {
-	bytes, err := json.MarshalIndent(s, "", "    ")
-	if err != nil {
-		panic(err)
-	}
-
-	return fmt.Sprintf(`{"type": %s}`, string(bytes))
+	return fmt.Sprintf(`{"type": %s}`, indentedSchemaJSON(s))
 }
 
 // Type returns a type constant for this UnionSchema.
@@ -795,11 +1126,56 @@ func (*UnionSchema) GetName() string {
 	return typeUnion
 }
 
+// FullName returns a type name for this UnionSchema.
+func (*UnionSchema) FullName() string {
+	return typeUnion
+}
+
 // Prop doesn't return anything valuable for UnionSchema.
 func (*UnionSchema) Prop(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// SetProp always fails for UnionSchema as unions are JSON arrays and can't carry properties.
+func (*UnionSchema) SetProp(key string, value interface{}) error {
+	return ErrUnionProperty
+}
+
+// DeleteProp does nothing for UnionSchema.
+func (*UnionSchema) DeleteProp(key string) {}
+
+// IsNullable tells whether null is one of the branches of this UnionSchema.
+func (s *UnionSchema) IsNullable() bool {
+	for _, t := range s.Types {
+		if t.Type() == Null {
+			return true
+		}
+	}
+	return false
+}
+
+// NonNullTypes returns the branches of this UnionSchema other than null, in order.
+func (s *UnionSchema) NonNullTypes() []Schema {
+	types := make([]Schema, 0, len(s.Types))
+	for _, t := range s.Types {
+		if t.Type() != Null {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// BranchByName finds the branch of this UnionSchema with the given full name, i.e. the full name
+// of a named type or the name of any other type such as "string" or "map", and returns its index.
+func (s *UnionSchema) BranchByName(fullName string) (int, Schema, bool) {
+	for i, t := range s.Types {
+		if t.FullName() == fullName {
+			return i, t, true
+		}
+	}
+	return -1, nil, false
+}
+
 // GetType gets the index of actual union type for a given value.
 func (s *UnionSchema) GetType(v reflect.Value) int {
 	if s.Types != nil {
@@ -827,25 +1203,28 @@ func (s *UnionSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *UnionSchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s.Types)
+	return s.AppendJSON(nil)
 }
 
 // FixedSchema implements Schema and represents Avro fixed type.
 type FixedSchema struct {
-	Namespace  string
-	Name       string
-	Size       int
-	Properties map[string]interface{}
+	Namespace string
+	Name      string
+	Aliases   []string
+	Doc       string
+	Size      int
+
+	// Logical type annotating this schema, e.g. LogicalTypeDecimal, empty if none, along with the
+	// Precision and Scale of decimals.
+	LogicalType string
+	Precision   int
+	Scale       int
+	Properties  map[string]interface{}
 }
 
 // String returns a JSON representation of FixedSchema.
 func (s *FixedSchema) String() string {
-	bytes, err := json.MarshalIndent(s, "", "    ")
-	if err != nil {
-		panic(err)
-	}
-
-	return string(bytes)
+	return indentedSchemaJSON(s)
 }
 
 // Type returns a type constant for this FixedSchema.
@@ -858,18 +1237,43 @@ func (s *FixedSchema) GetName() string {
 	return s.Name
 }
 
+// FullName returns a fixed name qualified with its namespace for this FixedSchema.
+func (s *FixedSchema) FullName() string {
+	return getFullName(s.Name, s.Namespace)
+}
+
+// GetDoc returns the documentation of this FixedSchema.
+func (s *FixedSchema) GetDoc() string {
+	return s.Doc
+}
+
 // Prop gets a custom non-reserved property from this schema and a bool representing if it exists.
 func (s *FixedSchema) Prop(key string) (interface{}, bool) {
-	if s.Properties != nil {
-		if prop, ok := s.Properties[key]; ok {
-			return prop, true
-		}
-	}
-	return nil, false
+	return s.logicalAttributes().prop(key)
 }
 
-// Validate checks whether the given value is writeable to this schema.
+// SetProp sets a custom non-reserved property on this schema, or its LogicalType for logicalType,
+// and its Precision and Scale for precision and scale if it is a decimal.
+func (s *FixedSchema) SetProp(key string, value interface{}) error {
+	return s.logicalAttributes().setProp(key, value)
+}
+
+// DeleteProp removes a custom property from this schema.
+func (s *FixedSchema) DeleteProp(key string) {
+	s.logicalAttributes().deleteProp(key)
+}
+
+func (s *FixedSchema) logicalAttributes() logicalAttributes {
+	return logicalAttributes{&s.Properties, &s.LogicalType, &s.Precision, &s.Scale}
+}
+
+// Validate checks whether the given value is writeable to this schema. Decimals also accept big.Rat
+// values fitting in their precision, scale and size.
 func (s *FixedSchema) Validate(v reflect.Value) bool {
+	if isDecimal(s) && isDecimalType(reflect.TypeOf(v.Interface())) {
+		_, err := decimalBytes(s, v)
+		return err == nil
+	}
 	v = dereference(v)
 
 	return (v.Kind() == reflect.Array || v.Kind() == reflect.Slice) && v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == s.Size
@@ -877,59 +1281,98 @@ func (s *FixedSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *FixedSchema) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Type string `json:"type,omitempty"`
-		Size int    `json:"size,omitempty"`
-		Name string `json:"name,omitempty"`
-	}{
-		Type: "fixed",
-		Size: s.Size,
-		Name: s.Name,
-	})
+	return s.AppendJSON(nil)
 }
 
 // GetFullName returns a fully-qualified name for a schema if possible. The format is namespace.name.
+//
+// Deprecated: use Schema.FullName instead.
 func GetFullName(schema Schema) string {
-	switch sch := schema.(type) {
-	case *RecordSchema:
-		return getFullName(sch.GetName(), sch.Namespace)
-	case *EnumSchema:
-		return getFullName(sch.GetName(), sch.Namespace)
-	case *FixedSchema:
-		return getFullName(sch.GetName(), sch.Namespace)
-	default:
-		return schema.GetName()
-	}
+	return schema.FullName()
 }
 
-// ParseSchemaFile parses a given file.
+// ParseSchemaFile parses a given file, which may also be a URL handled by a registered Opener.
+// Files with a .yaml or .yml extension are read as YAML, which is converted to the equivalent
+// JSON before parsing it.
 // May return an error if schema is not parsable or file does not exist.
 func ParseSchemaFile(file string) (Schema, error) {
-	fileContents, err := ioutil.ReadFile(file)
+	fileContents, err := readSchemaFile(file)
 	if err != nil {
 		return nil, err
 	}
 
-	return ParseSchema(string(fileContents))
+	return ParseSchema(fileContents)
 }
 
 // ParseSchema parses a given schema without provided schemas to reuse.
-// Equivalent to call ParseSchemaWithResistry(rawSchema, make(map[string]Schema))
+// Equivalent to call ParseSchemaUsing(rawSchema, NewRegistry())
 // May return an error if schema is not parsable or has insufficient information about any type.
 func ParseSchema(rawSchema string) (Schema, error) {
-	return ParseSchemaWithRegistry(rawSchema, make(map[string]Schema))
+	return ParseSchemaUsing(rawSchema, NewRegistry())
 }
 
 // ParseSchemaWithRegistry parses a given schema using the provided registry for type lookup.
 // Registry will be filled up during parsing.
 // May return an error if schema is not parsable or has insufficient information about any type.
 func ParseSchemaWithRegistry(rawSchema string, schemas map[string]Schema) (Schema, error) {
-	var schema interface{}
-	if err := json.Unmarshal([]byte(rawSchema), &schema); err != nil {
+	return ParseSchemaUsing(rawSchema, mapRegistry(schemas))
+}
+
+// ParseSchemaUsing parses a given schema using the provided Registry for type lookup.
+// Named types defined by the schema will be added to the Registry during parsing.
+// May return an error if schema is not parsable, has insufficient information about any type
+// or the Registry refuses a definition.
+func ParseSchemaUsing(rawSchema string, registry Registry) (Schema, error) {
+	schema, err := decodeSchemaJSON(rawSchema)
+	if err != nil {
 		schema = rawSchema
 	}
 
-	return schemaByType(schema, schemas, "")
+	if r, ok := registry.(*syncRegistry); ok {
+		return r.parse(schema)
+	}
+	return schemaByType(schema, registry, "")
+}
+
+// decodeSchemaJSON decodes the JSON of a schema, with numbers as float64 values except for field
+// defaults, which are kept as json.Number values so that long defaults don't lose precision.
+func decodeSchemaJSON(rawSchema string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(rawSchema))
+	dec.UseNumber()
+	var schema interface{}
+	if err := dec.Decode(&schema); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("Unexpected data after schema JSON")
+	}
+	return floatNumbers(schema, true), nil
+}
+
+// floatNumbers converts the json.Number values in v to float64 values, but those of defaults if
+// keepDefaults is set.
+func floatNumbers(v interface{}, keepDefaults bool) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			if key == schemaDefaultField && keepDefaults {
+				if _, ok := value.(json.Number); ok {
+					continue
+				}
+				v[key] = floatNumbers(value, false)
+			} else {
+				v[key] = floatNumbers(value, keepDefaults)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = floatNumbers(value, keepDefaults)
+		}
+	}
+	return v
 }
 
 // MustParseSchema is like ParseSchema, but panics if the given schema cannot be parsed.
@@ -941,7 +1384,7 @@ func MustParseSchema(rawSchema string) Schema {
 	return s
 }
 
-func schemaByType(i interface{}, registry map[string]Schema, namespace string) (Schema, error) {
+func schemaByType(i interface{}, registry Registry, namespace string) (Schema, error) {
 	switch v := i.(type) {
 	case nil:
 		return new(NullSchema), nil
@@ -971,7 +1414,7 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 			if !strings.ContainsRune(fullName, '.') {
 				fullName = getFullName(v, namespace)
 			}
-			schema, ok := registry[fullName]
+			schema, ok := registry.Get(fullName)
 			if !ok {
 				return nil, fmt.Errorf("Unknown type name: %s", v)
 			}
@@ -983,21 +1426,24 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 	case map[string]interface{}:
 		switch v[schemaTypeField] {
 		case typeNull:
-			return new(NullSchema), nil
+			return &NullSchema{Properties: primitiveProperties(v)}, nil
 		case typeBoolean:
-			return new(BooleanSchema), nil
+			return &BooleanSchema{Properties: primitiveProperties(v)}, nil
 		case typeInt:
-			return new(IntSchema), nil
+			logicalType, _ := v[schemaLogicalTypeField].(string)
+			return &IntSchema{LogicalType: logicalType, Properties: primitiveProperties(v, schemaLogicalTypeField)}, nil
 		case typeLong:
-			return new(LongSchema), nil
+			logicalType, _ := v[schemaLogicalTypeField].(string)
+			return &LongSchema{LogicalType: logicalType, Properties: primitiveProperties(v, schemaLogicalTypeField)}, nil
 		case typeFloat:
-			return new(FloatSchema), nil
+			return &FloatSchema{Properties: primitiveProperties(v)}, nil
 		case typeDouble:
-			return new(DoubleSchema), nil
+			return &DoubleSchema{Properties: primitiveProperties(v)}, nil
 		case typeBytes:
-			return new(BytesSchema), nil
+			return parseBytesSchema(v), nil
 		case typeString:
-			return new(StringSchema), nil
+			logicalType, _ := v[schemaLogicalTypeField].(string)
+			return &StringSchema{LogicalType: logicalType, Properties: primitiveProperties(v, schemaLogicalTypeField)}, nil
 		case typeArray:
 			items, err := schemaByType(v[schemaItemsField], registry, namespace)
 			if err != nil {
@@ -1028,32 +1474,56 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 	return nil, ErrInvalidSchema
 }
 
-func parseEnumSchema(v map[string]interface{}, registry map[string]Schema, namespace string) (Schema, error) {
+func parseEnumSchema(v map[string]interface{}, registry Registry, namespace string) (Schema, error) {
 	symbols := make([]string, len(v[schemaSymbolsField].([]interface{})))
 	for i, symbol := range v[schemaSymbolsField].([]interface{}) {
 		symbols[i] = symbol.(string)
 	}
 
 	schema := &EnumSchema{Name: v[schemaNameField].(string), Symbols: symbols}
-	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
+	setNamespace(&schema.Namespace, schema.Name, v, namespace)
 	setOptionalField(&schema.Doc, v, schemaDocField)
+	setAliases(&schema.Aliases, v)
 	schema.Properties = getProperties(v)
 
-	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
+	return addSchema(schema, registry)
+}
+
+func parseBytesSchema(v map[string]interface{}) *BytesSchema {
+	schema := &BytesSchema{}
+	schema.LogicalType, _ = v[schemaLogicalTypeField].(string)
+	if schema.LogicalType != LogicalTypeDecimal {
+		schema.Properties = primitiveProperties(v, schemaLogicalTypeField)
+		return schema
+	}
+	schema.Precision, schema.Scale = decimalAttributes(v)
+	schema.Properties = primitiveProperties(v, schemaLogicalTypeField, schemaPrecisionField, schemaScaleField)
+	return schema
 }
 
-func parseFixedSchema(v map[string]interface{}, registry map[string]Schema, namespace string) (Schema, error) {
+func parseFixedSchema(v map[string]interface{}, registry Registry, namespace string) (Schema, error) {
 	size, ok := v[schemaSizeField].(float64)
 	if !ok {
 		return nil, ErrInvalidFixedSize
 	}
 
 	schema := &FixedSchema{Name: v[schemaNameField].(string), Size: int(size), Properties: getProperties(v)}
-	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
-	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
+	if logicalType, ok := v[schemaLogicalTypeField].(string); ok {
+		schema.LogicalType = logicalType
+		delete(schema.Properties, schemaLogicalTypeField)
+		if logicalType == LogicalTypeDecimal {
+			schema.Precision, schema.Scale = decimalAttributes(v)
+			delete(schema.Properties, schemaPrecisionField)
+			delete(schema.Properties, schemaScaleField)
+		}
+	}
+	setNamespace(&schema.Namespace, schema.Name, v, namespace)
+	setOptionalField(&schema.Doc, v, schemaDocField)
+	setAliases(&schema.Aliases, v)
+	return addSchema(schema, registry)
 }
 
-func parseUnionSchema(v []interface{}, registry map[string]Schema, namespace string) (Schema, error) {
+func parseUnionSchema(v []interface{}, registry Registry, namespace string) (Schema, error) {
 	types := make([]Schema, len(v))
 	var err error
 	for i := range v {
@@ -1065,12 +1535,14 @@ func parseUnionSchema(v []interface{}, registry map[string]Schema, namespace str
 	return &UnionSchema{Types: types}, nil
 }
 
-func parseRecordSchema(v map[string]interface{}, registry map[string]Schema, namespace string) (Schema, error) {
+func parseRecordSchema(v map[string]interface{}, registry Registry, namespace string) (Schema, error) {
 	schema := &RecordSchema{Name: v[schemaNameField].(string)}
-	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
-	setOptionalField(&namespace, v, schemaNamespaceField)
+	namespace = setNamespace(&schema.Namespace, schema.Name, v, namespace)
 	setOptionalField(&schema.Doc, v, schemaDocField)
-	addSchema(getFullName(v[schemaNameField].(string), namespace), newRecursiveSchema(schema), registry)
+	setAliases(&schema.Aliases, v)
+	if _, err := addSchema(newRecursiveSchema(schema), registry); err != nil {
+		return nil, err
+	}
 	fields := make([]*SchemaField, len(v[schemaFieldsField].([]interface{})))
 	for i := range fields {
 		field, err := parseSchemaField(v[schemaFieldsField].([]interface{})[i], registry, namespace)
@@ -1085,7 +1557,7 @@ func parseRecordSchema(v map[string]interface{}, registry map[string]Schema, nam
 	return schema, nil
 }
 
-func parseSchemaField(i interface{}, registry map[string]Schema, namespace string) (*SchemaField, error) {
+func parseSchemaField(i interface{}, registry Registry, namespace string) (*SchemaField, error) {
 	switch v := i.(type) {
 	case map[string]interface{}:
 		name, ok := v[schemaNameField].(string)
@@ -1094,12 +1566,25 @@ func parseSchemaField(i interface{}, registry map[string]Schema, namespace strin
 		}
 		schemaField := &SchemaField{Name: name, Properties: getProperties(v)}
 		setOptionalField(&schemaField.Doc, v, schemaDocField)
+		setOptionalField(&schemaField.Order, v, schemaOrderField)
+		switch schemaField.Order {
+		case "", "ascending", "descending", "ignore":
+		default:
+			return nil, fmt.Errorf("Invalid order %q of field %s", schemaField.Order, name)
+		}
+		setAliases(&schemaField.Aliases, v)
 		fieldType, err := schemaByType(v[schemaTypeField], registry, namespace)
 		if err != nil {
 			return nil, err
 		}
 		schemaField.Type = fieldType
 		if def, exists := v[schemaDefaultField]; exists {
+			if n, ok := def.(json.Number); ok {
+				def, _ = n.Float64()
+				if i, err := n.Int64(); err == nil && schemaField.Type.Type() == Long {
+					def = i
+				}
+			}
 			switch def.(type) {
 			case float64:
 				// JSON treats all numbers as float64 by default
@@ -1133,16 +1618,40 @@ func setOptionalField(where *string, v map[string]interface{}, fieldName string)
 	}
 }
 
-func addSchema(name string, schema Schema, schemas map[string]Schema) Schema {
-	if schemas != nil {
-		if sch, ok := schemas[name]; ok {
-			return sch
+// setAliases sets the aliases of a named schema or field definition, ignoring those which aren't strings.
+func setAliases(where *[]string, v map[string]interface{}) {
+	if aliases, ok := v[schemaAliasesField].([]interface{}); ok {
+		for _, alias := range aliases {
+			if alias, ok := alias.(string); ok {
+				*where = append(*where, alias)
+			}
 		}
+	}
+}
 
-		schemas[name] = schema
+// setNamespace sets the namespace of a named schema definition, inheriting the enclosing namespace if
+// there is no explicit one, and returns the namespace to use for definitions nested in it.
+func setNamespace(where *string, name string, v map[string]interface{}, enclosing string) string {
+	*where = enclosing
+	setOptionalField(where, v, schemaNamespaceField)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		// A full name carries its own namespace, which wins over everything else.
+		return name[:i]
 	}
+	return *where
+}
 
-	return schema
+// addSchema adds a named schema to the registry, returning the schema already registered under the same
+// full name if there is one.
+func addSchema(schema Schema, registry Registry) (Schema, error) {
+	if sch, ok := registry.Get(schema.FullName()); ok {
+		return sch, nil
+	}
+
+	if err := registry.Add(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
 }
 
 func getFullName(name string, namespace string) string {
@@ -1164,6 +1673,40 @@ func getProperties(v map[string]interface{}) map[string]interface{} {
 	return props
 }
 
+// primitiveProperties returns the custom properties of a primitive schema, leaving out the given
+// attributes it keeps in fields of its own, or nil if it has none.
+func primitiveProperties(v map[string]interface{}, attributes ...string) map[string]interface{} {
+	props := getProperties(v)
+	for _, name := range attributes {
+		delete(props, name)
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return props
+}
+
+func setProp(props *map[string]interface{}, key string, value interface{}) error {
+	if isReserved(key) {
+		return ErrReservedProperty
+	}
+	if *props == nil {
+		*props = make(map[string]interface{})
+	}
+	(*props)[key] = value
+	return nil
+}
+
+// isReservedFieldProp reports whether name is an attribute of a record field rather than a custom property.
+func isReservedFieldProp(name string) bool {
+	switch name {
+	case schemaDefaultField, schemaOrderField:
+		return true
+	}
+
+	return isReserved(name)
+}
+
 func isReserved(name string) bool {
 	switch name {
 	case schemaAliasesField, schemaDocField, schemaFieldsField, schemaItemsField, schemaNameField,
@@ -1174,6 +1717,51 @@ func isReserved(name string) bool {
 	return false
 }
 
+// IsRecord tells whether s is a record schema, possibly referenced recursively or prepared.
+func IsRecord(s Schema) bool {
+	return s.Type() == Record || s.Type() == Recursive
+}
+
+// IsEnum tells whether s is an enum schema.
+func IsEnum(s Schema) bool {
+	return s.Type() == Enum
+}
+
+// IsFixed tells whether s is a fixed schema.
+func IsFixed(s Schema) bool {
+	return s.Type() == Fixed
+}
+
+// IsArray tells whether s is an array schema.
+func IsArray(s Schema) bool {
+	return s.Type() == Array
+}
+
+// IsMap tells whether s is a map schema.
+func IsMap(s Schema) bool {
+	return s.Type() == Map
+}
+
+// IsUnion tells whether s is a union schema.
+func IsUnion(s Schema) bool {
+	return s.Type() == Union
+}
+
+// IsNamed tells whether s is a record, enum or fixed schema, which have a full name.
+func IsNamed(s Schema) bool {
+	return IsRecord(s) || IsEnum(s) || IsFixed(s)
+}
+
+// IsPrimitive tells whether s is one of the primitive schemas: null, boolean, int, long, float,
+// double, bytes or string.
+func IsPrimitive(s Schema) bool {
+	switch s.Type() {
+	case Null, Boolean, Int, Long, Float, Double, Bytes, String:
+		return true
+	}
+	return false
+}
+
 func dereference(v reflect.Value) reflect.Value {
 	if v.Kind() == reflect.Ptr {
 		return v.Elem()
@@ -0,0 +1,15 @@
+//go:build go1.18
+// +build go1.18
+
+package avro
+
+// As returns s as the concrete schema type T, e.g. As[*RecordSchema](s), following recursive
+// references and prepared schemas to the schema they stand for. Unlike a type assertion, it
+// reports a mismatch instead of panicking.
+func As[T Schema](s Schema) (T, bool) {
+	if t, ok := s.(T); ok {
+		return t, true
+	}
+	t, ok := resolveSchema(s).(T)
+	return t, ok
+}
@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package avro
+
+import "testing"
+
+func TestAs(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "next", "type": ["null", "Node"]}
+	]}`)
+
+	record, ok := As[*RecordSchema](schema)
+	assert(t, ok, true)
+	assert(t, record, schema)
+	next := record.Fields[0].Type.(*UnionSchema).Types[1]
+	_, ok = As[*RecursiveSchema](next)
+	assert(t, ok, true)
+	record, ok = As[*RecordSchema](next)
+	assert(t, ok, true)
+	assert(t, record, schema)
+	record, ok = As[*RecordSchema](Prepare(schema))
+	assert(t, ok, true)
+	assert(t, record.Name, "Node")
+
+	union, ok := As[*UnionSchema](schema)
+	assert(t, union == nil && !ok, true)
+}
@@ -0,0 +1,132 @@
+package avro
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const schemaCacheRefsDir = "refs"
+
+// SchemaCache is a persistent, content-addressable store of schemas: a directory holding the JSON of
+// every schema in a file named after its SHA-256 fingerprint. The whole JSON is kept rather than the
+// Parsing Canonical Form, so that schemas come back with their logical types, defaults, aliases and
+// docs, and schemas only differing in those replace each other.
+//
+// Schemas can also be stored under a ref, any string identifying them elsewhere such as a schema
+// registry ID, so that consumers looking schemas up remotely can keep working from the cache once
+// warmed up if the remote end becomes unavailable. A SchemaCache is safe for concurrent use, also
+// by multiple processes sharing its directory.
+type SchemaCache struct {
+	dir    string
+	lock   sync.RWMutex
+	loaded map[string]Schema
+}
+
+// NewSchemaCache creates a SchemaCache storing its files in the given directory, creating it if needed.
+func NewSchemaCache(dir string) (*SchemaCache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, schemaCacheRefsDir), 0755); err != nil {
+		return nil, err
+	}
+	return &SchemaCache{dir: dir, loaded: make(map[string]Schema)}, nil
+}
+
+// Put stores the given schema and returns its fingerprint.
+func (c *SchemaCache) Put(schema Schema) ([]byte, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := Fingerprint(schema)
+	if err != nil {
+		return nil, err
+	}
+	key := hex.EncodeToString(fingerprint)
+	if err = writeFileAtomic(c.schemaPath(key), data); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.loaded[key] = schema
+	c.lock.Unlock()
+	return fingerprint, nil
+}
+
+// Get returns the schema with the given fingerprint and a bool representing if it was found.
+func (c *SchemaCache) Get(fingerprint []byte) (Schema, bool) {
+	return c.get(hex.EncodeToString(fingerprint))
+}
+
+// PutRef stores the given schema, making it retrievable with GetRef(ref) too.
+func (c *SchemaCache) PutRef(ref string, schema Schema) error {
+	fingerprint, err := c.Put(schema)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.refPath(ref), []byte(hex.EncodeToString(fingerprint)))
+}
+
+// GetRef returns the schema stored under the given ref and a bool representing if it was found.
+func (c *SchemaCache) GetRef(ref string) (Schema, bool) {
+	key, err := ioutil.ReadFile(c.refPath(ref))
+	if err != nil {
+		return nil, false
+	}
+	return c.get(strings.TrimSpace(string(key)))
+}
+
+func (c *SchemaCache) get(key string) (Schema, bool) {
+	c.lock.RLock()
+	schema, ok := c.loaded[key]
+	c.lock.RUnlock()
+	if ok {
+		return schema, true
+	}
+
+	// Schema JSON defines named types where they're first used, so each file parses on its own.
+	raw, err := ioutil.ReadFile(c.schemaPath(key))
+	if err != nil {
+		return nil, false
+	}
+	schema, err = ParseSchema(string(raw))
+	if err != nil {
+		return nil, false
+	}
+	c.lock.Lock()
+	c.loaded[key] = schema
+	c.lock.Unlock()
+	return schema, true
+}
+
+func (c *SchemaCache) schemaPath(key string) string {
+	return filepath.Join(c.dir, key+schemaExtension)
+}
+
+func (c *SchemaCache) refPath(ref string) string {
+	return filepath.Join(c.dir, schemaCacheRefsDir, url.QueryEscape(ref))
+}
+
+// writeFileAtomic writes a file so that concurrent readers see either nothing or all of it.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package avro
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSchemaCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avro-schema-cache")
+	assert(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	cache, err := NewSchemaCache(dir)
+	assert(t, err, nil)
+	schema := MustParseSchema(`{"type": "record", "name": "Rec", "namespace": "a", "doc": "kept", "fields": [
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "other", "type": "Hash"},
+		{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}, "default": 0, "aliases": ["when"]}
+	]}`)
+	fingerprint, err := cache.Put(schema)
+	assert(t, err, nil)
+	assert(t, cache.PutRef("registry/42", schema), nil)
+
+	// a fresh cache only has the files to go by
+	cache, err = NewSchemaCache(dir)
+	assert(t, err, nil)
+	cached, ok := cache.Get(fingerprint)
+	assert(t, ok, true)
+	assert(t, cached.FullName(), "a.Rec")
+	cachedFingerprint, err := Fingerprint(cached)
+	assert(t, err, nil)
+	assert(t, cachedFingerprint, fingerprint)
+	assert(t, cached.(*RecordSchema).Doc, "kept")
+	at := cached.(*RecordSchema).Fields[2]
+	assert(t, at.Type.(*LongSchema).LogicalType, LogicalTypeTimestampMillis)
+	assert(t, at.Default, int64(0))
+	assert(t, at.Aliases, []string{"when"})
+
+	cached, ok = cache.GetRef("registry/42")
+	assert(t, ok, true)
+	assert(t, cached.FullName(), "a.Rec")
+
+	_, ok = cache.GetRef("registry/43")
+	assert(t, ok, false)
+	_, ok = cache.Get([]byte{1, 2, 3})
+	assert(t, ok, false)
+}
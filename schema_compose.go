@@ -0,0 +1,106 @@
+package avro
+
+import "fmt"
+
+// ExtendRecord returns a new record schema with all fields of base followed by the extra ones,
+// e.g. to add audit columns to a record. The new schema has the name, namespace, doc, aliases and
+// properties of base, which is left unchanged.
+// May return an error if an extra field has no type or an invalid name, or if its name or one of its
+// aliases collides with those of another field.
+func ExtendRecord(base *RecordSchema, extra ...*SchemaField) (*RecordSchema, error) {
+	record := copyRecordHeader(base)
+	fields := make([]*SchemaField, 0, len(base.Fields)+len(extra))
+	fields = append(fields, base.Fields...)
+	fields = append(fields, extra...)
+	if err := checkRecordFields(record.FullName(), fields); err != nil {
+		return nil, err
+	}
+	record.Fields = fields
+	return record, nil
+}
+
+// EmbedRecord returns a new record schema with all fields of target followed by copies of those of
+// source with their names and aliases prefixed, e.g. EmbedRecord(event, header, "header_") to flatten
+// a shared envelope header into an event. The new schema has the name, namespace, doc, aliases and
+// properties of target; neither target nor source is changed.
+// May return an error if a field name or alias collides with another one once prefixed, or if the
+// prefix makes a name invalid.
+func EmbedRecord(target, source *RecordSchema, prefix string) (*RecordSchema, error) {
+	record := copyRecordHeader(target)
+	fields := make([]*SchemaField, 0, len(target.Fields)+len(source.Fields))
+	fields = append(fields, target.Fields...)
+	for _, field := range source.Fields {
+		embedded := *field
+		embedded.Name = prefix + field.Name
+		embedded.Aliases = nil
+		for _, alias := range field.Aliases {
+			embedded.Aliases = append(embedded.Aliases, prefix+alias)
+		}
+		embedded.Properties = copyProperties(field.Properties)
+		fields = append(fields, &embedded)
+	}
+	if err := checkRecordFields(record.FullName(), fields); err != nil {
+		return nil, err
+	}
+	record.Fields = fields
+	return record, nil
+}
+
+func copyRecordHeader(s *RecordSchema) *RecordSchema {
+	return &RecordSchema{
+		Name:       s.Name,
+		Namespace:  s.Namespace,
+		Doc:        s.Doc,
+		Aliases:    append([]string(nil), s.Aliases...),
+		Properties: copyProperties(s.Properties),
+	}
+}
+
+func copyProperties(properties map[string]interface{}) map[string]interface{} {
+	if properties == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		copied[key] = value
+	}
+	return copied
+}
+
+// checkRecordFields checks that the fields of a record have types and valid names, and that no
+// field name or alias is used twice.
+func checkRecordFields(record string, fields []*SchemaField) error {
+	owners := make(map[string]string, len(fields))
+	for _, field := range fields {
+		if field == nil || field.Type == nil {
+			return fmt.Errorf("Record %s has a field without a type", record)
+		}
+		for _, name := range append([]string{field.Name}, field.Aliases...) {
+			if !isValidName(name) {
+				return fmt.Errorf("Record %s has a field with invalid name %q", record, name)
+			}
+			if owner, ok := owners[name]; ok {
+				return fmt.Errorf("Record %s has fields %s and %s both named %s", record, owner, field.Name, name)
+			}
+			owners[name] = field.Name
+		}
+	}
+	return nil
+}
+
+// isValidName reports whether name is a valid Avro name, which starts with a letter or an underscore
+// followed by letters, digits and underscores.
+func isValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
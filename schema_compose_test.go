@@ -0,0 +1,69 @@
+package avro
+
+import "testing"
+
+func TestExtendRecord(t *testing.T) {
+	base := MustParseSchema(`{"type": "record", "name": "User", "namespace": "example", "fields": [
+		{"name": "id", "type": "long", "aliases": ["user_id"]}
+	]}`).(*RecordSchema)
+
+	extended, err := ExtendRecord(base,
+		&SchemaField{Name: "created_at", Type: &LongSchema{}, Default: int64(0)},
+		&SchemaField{Name: "created_by", Type: &StringSchema{}, Default: ""},
+	)
+	assert(t, err, nil)
+	assert(t, extended.FullName(), "example.User")
+	assert(t, len(extended.Fields), 3)
+	assert(t, len(base.Fields), 1)
+	assert(t, extended.Fields[2].Name, "created_by")
+
+	parsed, err := ParseSchema(extended.String())
+	assert(t, err, nil)
+	assert(t, parsed.String(), extended.String())
+
+	_, err = ExtendRecord(base, &SchemaField{Name: "id", Type: &IntSchema{}})
+	assert(t, err.Error(), "Record example.User has fields id and id both named id")
+	_, err = ExtendRecord(base, &SchemaField{Name: "id2", Aliases: []string{"user_id"}, Type: &IntSchema{}})
+	assert(t, err.Error(), "Record example.User has fields id and id2 both named user_id")
+	_, err = ExtendRecord(base, &SchemaField{Name: "no-dashes", Type: &IntSchema{}})
+	assert(t, err.Error(), `Record example.User has a field with invalid name "no-dashes"`)
+	_, err = ExtendRecord(base, &SchemaField{Name: "untyped"})
+	assert(t, err.Error(), "Record example.User has a field without a type")
+}
+
+func TestEmbedRecord(t *testing.T) {
+	event := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "payload", "type": "bytes"},
+		{"name": "header_id", "type": "string"}
+	]}`).(*RecordSchema)
+	header := MustParseSchema(`{"type": "record", "name": "Header", "fields": [
+		{"name": "source", "type": "string", "aliases": ["origin"], "x-pii": true},
+		{"name": "time", "type": "long"}
+	]}`).(*RecordSchema)
+
+	embedded, err := EmbedRecord(event, header, "header_")
+	assert(t, err, nil)
+	assert(t, embedded.FullName(), "Event")
+	assert(t, len(embedded.Fields), 4)
+	assert(t, embedded.Fields[2].Name, "header_source")
+	assert(t, embedded.Fields[2].Aliases, []string{"header_origin"})
+	pii, _ := embedded.Fields[2].Prop("x-pii")
+	assert(t, pii, true)
+	assert(t, embedded.Fields[3].Name, "header_time")
+	assert(t, header.Fields[0].Name, "source")
+	assert(t, header.Fields[0].Aliases, []string{"origin"})
+
+	_, err = ParseSchema(embedded.String())
+	assert(t, err, nil)
+
+	_, err = EmbedRecord(event, header, "")
+	assert(t, err, nil)
+	_, err = EmbedRecord(event, event, "header_")
+	assert(t, err, nil)
+	_, err = EmbedRecord(event, MustParseSchema(`{"type": "record", "name": "Id", "fields": [
+		{"name": "id", "type": "string"}
+	]}`).(*RecordSchema), "header_")
+	assert(t, err.Error(), "Record Event has fields header_id and header_id both named header_id")
+	_, err = EmbedRecord(event, header, "1_")
+	assert(t, err.Error(), `Record Event has a field with invalid name "1_source"`)
+}
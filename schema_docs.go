@@ -0,0 +1,200 @@
+package avro
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SchemaDocs describes named types and how they reference each other, e.g. to build schema catalogs
+// or documentation sites from. It marshals to JSON as is, and WriteHTML renders it as a page.
+type SchemaDocs struct {
+	// Types are the named types described, sorted by full name.
+	Types []*TypeDoc `json:"types"`
+}
+
+// TypeDoc describes a record, enum or fixed type.
+type TypeDoc struct {
+	Name       string                 `json:"name"` // full name
+	Type       string                 `json:"type"` // "record", "enum" or "fixed"
+	Doc        string                 `json:"doc,omitempty"`
+	Aliases    []string               `json:"aliases,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	Fields     []*FieldDoc            `json:"fields,omitempty"`  // of records
+	Symbols    []string               `json:"symbols,omitempty"` // of enums
+	Size       int                    `json:"size,omitempty"`    // of fixed types
+
+	// ReferencedBy are the full names of the records with fields of this type, sorted.
+	ReferencedBy []string `json:"referencedBy,omitempty"`
+}
+
+// FieldDoc describes a field of a record.
+type FieldDoc struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc,omitempty"`
+
+	// Type describes the type of the field, with named types by their full names, arrays and maps as
+	// array<items> and map<values>, and unions as their branches separated by " | ". References
+	// are the full names of the named types it has, in order.
+	Type       string   `json:"type"`
+	References []string `json:"references,omitempty"`
+
+	Default    interface{}            `json:"default,omitempty"`
+	HasDefault bool                   `json:"hasDefault,omitempty"`
+	Order      string                 `json:"order,omitempty"`
+	Aliases    []string               `json:"aliases,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// DescribeRegistry describes all the named types of a Registry, such as one loaded with
+// LoadSchemasUsing, and the named types they reference.
+func DescribeRegistry(registry Registry) *SchemaDocs {
+	var schemas []Schema
+	for _, name := range registry.List() {
+		if schema, ok := registry.Get(name); ok {
+			schemas = append(schemas, schema)
+		}
+	}
+	return DescribeSchemas(schemas...)
+}
+
+// DescribeSchemas describes the named types of the given schemas, and those they reference, e.g.
+// the values of the map returned by LoadSchemas.
+func DescribeSchemas(schemas ...Schema) *SchemaDocs {
+	d := &schemaDescriber{types: make(map[string]*TypeDoc)}
+	for _, schema := range schemas {
+		d.describe(schema)
+	}
+
+	docs := &SchemaDocs{}
+	for _, doc := range d.types {
+		sort.Strings(doc.ReferencedBy)
+		docs.Types = append(docs.Types, doc)
+	}
+	sort.Sort(typeDocsByName(docs.Types))
+	return docs
+}
+
+type typeDocsByName []*TypeDoc
+
+func (d typeDocsByName) Len() int           { return len(d) }
+func (d typeDocsByName) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d typeDocsByName) Less(i, j int) bool { return d[i].Name < d[j].Name }
+
+type schemaDescriber struct {
+	types map[string]*TypeDoc
+}
+
+// describe adds the named types of s to the descriptions, returning the description of its type.
+func (d *schemaDescriber) describe(s Schema) (string, []string) {
+	switch s := resolveSchema(s).(type) {
+	case *RecordSchema:
+		name := s.FullName()
+		if _, ok := d.types[name]; !ok {
+			doc := &TypeDoc{Name: name, Type: "record", Doc: s.Doc, Aliases: s.Aliases, Properties: s.Properties}
+			d.types[name] = doc
+			for _, field := range s.Fields {
+				fieldDoc := &FieldDoc{Name: field.Name, Doc: field.Doc, Order: field.Order, Aliases: field.Aliases, Properties: field.Properties}
+				fieldDoc.Type, fieldDoc.References = d.describe(field.Type)
+				if hasDefault(field) {
+					fieldDoc.Default, fieldDoc.HasDefault = field.Default, true
+				}
+				doc.Fields = append(doc.Fields, fieldDoc)
+				for _, ref := range fieldDoc.References {
+					d.referencedBy(ref, name)
+				}
+			}
+		}
+		return name, []string{name}
+	case *EnumSchema:
+		name := s.FullName()
+		if _, ok := d.types[name]; !ok {
+			d.types[name] = &TypeDoc{Name: name, Type: "enum", Doc: s.Doc, Aliases: s.Aliases, Properties: s.Properties, Symbols: s.Symbols}
+		}
+		return name, []string{name}
+	case *FixedSchema:
+		name := s.FullName()
+		if _, ok := d.types[name]; !ok {
+			d.types[name] = &TypeDoc{Name: name, Type: "fixed", Doc: s.Doc, Aliases: s.Aliases, Properties: s.Properties, Size: s.Size}
+		}
+		return name, []string{name}
+	case *ArraySchema:
+		items, refs := d.describe(s.Items)
+		return "array<" + items + ">", refs
+	case *MapSchema:
+		values, refs := d.describe(s.Values)
+		return "map<" + values + ">", refs
+	case *UnionSchema:
+		var branches, refs []string
+		for _, t := range s.Types {
+			branch, branchRefs := d.describe(t)
+			branches = append(branches, branch)
+			refs = append(refs, branchRefs...)
+		}
+		return strings.Join(branches, " | "), refs
+	default:
+		return s.GetName(), nil
+	}
+}
+
+func (d *schemaDescriber) referencedBy(name, record string) {
+	doc := d.types[name]
+	for _, existing := range doc.ReferencedBy {
+		if existing == record {
+			return
+		}
+	}
+	doc.ReferencedBy = append(doc.ReferencedBy, record)
+}
+
+var schemaDocsTemplate = template.Must(template.New("schemas").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Schemas</title></head>
+<body>
+<h1>Schemas</h1>
+<ul>
+{{- range .Types}}
+<li><a href="#{{.Name}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- range .Types}}
+<section id="{{.Name}}">
+<h2>{{.Type}} {{.Name}}</h2>
+{{- if .Doc}}
+<p>{{.Doc}}</p>
+{{- end}}
+{{- if .Aliases}}
+<p>Aliases: {{range $i, $a := .Aliases}}{{if $i}}, {{end}}{{$a}}{{end}}</p>
+{{- end}}
+{{- if .Fields}}
+<table>
+<tr><th>Field</th><th>Type</th><th>Default</th><th>Description</th></tr>
+{{- range .Fields}}
+<tr><td>{{.Name}}</td><td>{{.Type}}{{range .References}} <a href="#{{.}}">&#8599;</a>{{end}}</td><td>{{if .HasDefault}}{{printf "%v" .Default}}{{end}}</td><td>{{.Doc}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Symbols}}
+<p>Symbols: {{range $i, $s := .Symbols}}{{if $i}}, {{end}}{{$s}}{{end}}</p>
+{{- end}}
+{{- if .Size}}
+<p>Size: {{.Size}} bytes</p>
+{{- end}}
+{{- if .ReferencedBy}}
+<p>Referenced by: {{range $i, $r := .ReferencedBy}}{{if $i}}, {{end}}<a href="#{{$r}}">{{$r}}</a>{{end}}</p>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders the descriptions to w as an HTML page with the given template, or a plain
+// built-in one if nil. Templates are executed with the SchemaDocs.
+func (docs *SchemaDocs) WriteHTML(w io.Writer, tmpl *template.Template) error {
+	if tmpl == nil {
+		tmpl = schemaDocsTemplate
+	}
+	return tmpl.Execute(w, docs)
+}
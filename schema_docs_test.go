@@ -0,0 +1,61 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDescribeSchemas(t *testing.T) {
+	registry := NewRegistry()
+	_, err := ParseSchemaUsing(`{"type": "record", "name": "Order", "namespace": "shop", "doc": "An order.", "fields": [
+		{"name": "id", "type": "long", "doc": "Order ID."},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["OPEN", "PAID"]}, "default": "OPEN"},
+		{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+			{"name": "sku", "type": {"type": "fixed", "name": "SKU", "size": 8}},
+			{"name": "parent", "type": ["null", "Line"], "default": null}
+		]}}},
+		{"name": "tags", "type": {"type": "map", "values": "string"}, "order": "ignore"},
+		{"name": "refund", "type": ["null", "Order"]}
+	]}`, registry)
+	assert(t, err, nil)
+
+	docs := DescribeRegistry(registry)
+	var names []string
+	for _, doc := range docs.Types {
+		names = append(names, doc.Type+" "+doc.Name)
+	}
+	assert(t, names, []string{"record shop.Line", "record shop.Order", "fixed shop.SKU", "enum shop.Status"})
+
+	line, order, sku, status := docs.Types[0], docs.Types[1], docs.Types[2], docs.Types[3]
+	assert(t, order.Doc, "An order.")
+	assert(t, len(order.Fields), 5)
+	assert(t, order.Fields[0].Name, "id")
+	assert(t, order.Fields[0].Doc, "Order ID.")
+	assert(t, order.Fields[0].Type, "long")
+	assert(t, order.Fields[0].HasDefault, false)
+	assert(t, order.Fields[1].Type, "shop.Status")
+	assert(t, order.Fields[1].Default, "OPEN")
+	assert(t, order.Fields[1].HasDefault, true)
+	assert(t, order.Fields[2].Type, "array<shop.Line>")
+	assert(t, order.Fields[2].References, []string{"shop.Line"})
+	assert(t, order.Fields[3].Type, "map<string>")
+	assert(t, order.Fields[3].Order, "ignore")
+	assert(t, order.Fields[4].Type, "null | shop.Order")
+	assert(t, order.Fields[4].HasDefault, true)
+	assert(t, order.ReferencedBy, []string{"shop.Order"})
+	assert(t, line.ReferencedBy, []string{"shop.Line", "shop.Order"})
+	assert(t, sku.Size, 8)
+	assert(t, sku.ReferencedBy, []string{"shop.Line"})
+	assert(t, status.Symbols, []string{"OPEN", "PAID"})
+
+	data, err := json.Marshal(docs.Types[3])
+	assert(t, err, nil)
+	assert(t, string(data), `{"name":"shop.Status","type":"enum","symbols":["OPEN","PAID"],"referencedBy":["shop.Order"]}`)
+
+	var html bytes.Buffer
+	assert(t, docs.WriteHTML(&html, nil), nil)
+	assert(t, strings.Contains(html.String(), `<section id="shop.Order">`), true)
+	assert(t, strings.Contains(html.String(), `<td>null | shop.Order <a href="#shop.Order">`), true)
+}
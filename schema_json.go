@@ -0,0 +1,515 @@
+package avro
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// The String methods of schemas are meant for logging and debugging, and don't fail. Serializing
+// schemas with AppendJSON and MarshalText instead returns errors, e.g. for custom properties that
+// can't be serialized as JSON.
+//
+// Schemas are written with a JSONWriter rather than encoding/json, so that producing their JSON, as
+// registry clients and container file writers do, takes no reflection. Only the values of defaults
+// and custom properties go through encoding/json. MarshalJSON returns the same JSON.
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *StringSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *StringSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *BytesSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *BytesSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *IntSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *IntSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *LongSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *LongSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *FloatSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *FloatSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *DoubleSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *DoubleSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *BooleanSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *BooleanSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *NullSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *NullSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *RecordSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *RecordSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *RecursiveSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *RecursiveSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *EnumSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *EnumSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *ArraySchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *ArraySchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *MapSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *MapSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *UnionSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *UnionSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON serialization of this schema to buf.
+func (s *FixedSchema) AppendJSON(buf []byte) ([]byte, error) {
+	return appendSchemaJSON(buf, s)
+}
+
+// MarshalText serializes this schema as JSON.
+func (s *FixedSchema) MarshalText() ([]byte, error) {
+	return s.AppendJSON(nil)
+}
+
+// schemaJSONWriter is implemented by the schemas of this package, which write their JSON with a
+// JSONWriter.
+type schemaJSONWriter interface {
+	writeJSON(w *JSONWriter) error
+}
+
+// appendSchemaJSON appends the JSON serialization of s to buf, or returns buf as is on errors.
+func appendSchemaJSON(buf []byte, s schemaJSONWriter) ([]byte, error) {
+	w := &JSONWriter{buf: buf}
+	if err := s.writeJSON(w); err != nil {
+		return buf, err
+	}
+	return w.buf, nil
+}
+
+// writeSchema writes the JSON of a nested schema, with encoding/json for schemas implemented
+// outside of this package.
+func writeSchema(w *JSONWriter, s Schema) error {
+	if s, ok := s.(schemaJSONWriter); ok {
+		return s.writeJSON(w)
+	}
+	if s == nil {
+		w.Null()
+		return nil
+	}
+	return writeJSONValue(w, s)
+}
+
+// writeJSONValue writes an arbitrary value, such as a default or a custom property, with
+// encoding/json.
+func writeJSONValue(w *JSONWriter, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Raw(b)
+	return nil
+}
+
+// writeProperties writes custom properties as fields of the current object, sorted by name so that
+// the output is stable, leaving out those already written from fields of the schema.
+func writeProperties(w *JSONWriter, props map[string]interface{}, written ...string) error {
+	if len(props) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		if !containsString(written, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeJSONValue(w.Field(name), props[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// writeStrings writes an array of strings, or null for a nil slice as encoding/json does.
+func writeStrings(w *JSONWriter, values []string) {
+	if values == nil {
+		w.Null()
+		return
+	}
+	w.BeginArray()
+	for _, s := range values {
+		w.String(s)
+	}
+	w.EndArray()
+}
+
+// writeNamed writes the namespace, name, aliases and doc of a named schema, leaving out those that
+// are empty.
+func writeNamed(w *JSONWriter, namespace, name string, aliases []string, doc string, docFirst bool) {
+	if namespace != "" {
+		w.Field("namespace").String(namespace)
+	}
+	if name != "" {
+		w.Field("name").String(name)
+	}
+	if docFirst && doc != "" {
+		w.Field("doc").String(doc)
+	}
+	if len(aliases) > 0 {
+		writeStrings(w.Field("aliases"), aliases)
+	}
+	if !docFirst && doc != "" {
+		w.Field("doc").String(doc)
+	}
+}
+
+// writePrimitive writes a primitive schema as its bare type name, or as an object with its logical
+// type, the attributes written by attributes if any, and custom properties.
+func writePrimitive(w *JSONWriter, name, logicalType string, attributes func() []string, props map[string]interface{}) error {
+	if logicalType == "" && len(props) == 0 {
+		w.String(name)
+		return nil
+	}
+	w.BeginObject().Field("type").String(name)
+	var written []string
+	if logicalType != "" {
+		w.Field("logicalType").String(logicalType)
+		written = append(written, "logicalType")
+		if attributes != nil {
+			written = append(written, attributes()...)
+		}
+	}
+	if err := writeProperties(w, props, written...); err != nil {
+		return err
+	}
+	w.EndObject()
+	return nil
+}
+
+func (s *StringSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeString, s.LogicalType, nil, s.Properties)
+}
+
+func (s *BytesSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeBytes, s.LogicalType, func() []string {
+		return writeDecimalParams(w, s.Precision, s.Scale)
+	}, s.Properties)
+}
+
+func (s *IntSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeInt, s.LogicalType, nil, s.Properties)
+}
+
+func (s *LongSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeLong, s.LogicalType, nil, s.Properties)
+}
+
+func (s *FloatSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeFloat, "", nil, s.Properties)
+}
+
+func (s *DoubleSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeDouble, "", nil, s.Properties)
+}
+
+func (s *BooleanSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeBoolean, "", nil, s.Properties)
+}
+
+func (s *NullSchema) writeJSON(w *JSONWriter) error {
+	return writePrimitive(w, typeNull, "", nil, s.Properties)
+}
+
+// writeDecimalParams writes the precision and scale of a decimal, leaving out those that are zero.
+func writeDecimalParams(w *JSONWriter, precision, scale int) []string {
+	var written []string
+	if precision != 0 {
+		w.Field(schemaPrecisionField).Int(int64(precision))
+		written = append(written, schemaPrecisionField)
+	}
+	if scale != 0 {
+		w.Field(schemaScaleField).Int(int64(scale))
+		written = append(written, schemaScaleField)
+	}
+	return written
+}
+
+func (s *RecordSchema) writeJSON(w *JSONWriter) error {
+	w.BeginObject().Field("type").String("record")
+	writeNamed(w, s.Namespace, s.Name, s.Aliases, s.Doc, true)
+	w.Field("fields")
+	if s.Fields == nil {
+		w.Null()
+	} else {
+		w.BeginArray()
+		for _, field := range s.Fields {
+			if err := field.writeJSON(w); err != nil {
+				return err
+			}
+		}
+		w.EndArray()
+	}
+	if err := writeProperties(w, s.Properties); err != nil {
+		return err
+	}
+	w.EndObject()
+	return nil
+}
+
+func (s *RecursiveSchema) writeJSON(w *JSONWriter) error {
+	w.String(s.Actual.GetName())
+	return nil
+}
+
+func (s *SchemaField) writeJSON(w *JSONWriter) error {
+	if s == nil {
+		w.Null()
+		return nil
+	}
+	w.BeginObject()
+	if s.Name != "" {
+		w.Field("name").String(s.Name)
+	}
+	if s.Doc != "" {
+		w.Field("doc").String(s.Doc)
+	}
+	if len(s.Aliases) > 0 {
+		writeStrings(w.Field("aliases"), s.Aliases)
+	}
+	// Fields of optional types always have a default, null if none was given.
+	nullable := s.Type.Type() == Null || (s.Type.Type() == Union && s.Type.(*UnionSchema).Types[0].Type() == Null)
+	if s.Default != nil || nullable {
+		if err := writeJSONValue(w.Field("default"), s.Default); err != nil {
+			return err
+		}
+	}
+	if s.Type != nil {
+		if err := writeSchema(w.Field("type"), s.Type); err != nil {
+			return err
+		}
+	}
+	if s.Order != "" {
+		w.Field("order").String(s.Order)
+	}
+	// Parsed fields keep their default and order among their properties too.
+	var props map[string]interface{}
+	for name, value := range s.Properties {
+		if !isReservedFieldProp(name) {
+			if props == nil {
+				props = make(map[string]interface{})
+			}
+			props[name] = value
+		}
+	}
+	if err := writeProperties(w, props); err != nil {
+		return err
+	}
+	w.EndObject()
+	return nil
+}
+
+func (s *EnumSchema) writeJSON(w *JSONWriter) error {
+	w.BeginObject().Field("type").String("enum")
+	writeNamed(w, s.Namespace, s.Name, s.Aliases, s.Doc, false)
+	if len(s.Symbols) > 0 {
+		writeStrings(w.Field("symbols"), s.Symbols)
+	}
+	if err := writeProperties(w, s.Properties); err != nil {
+		return err
+	}
+	w.EndObject()
+	return nil
+}
+
+func (s *ArraySchema) writeJSON(w *JSONWriter) error {
+	w.BeginObject().Field("type").String("array")
+	if s.Items != nil {
+		if err := writeSchema(w.Field("items"), s.Items); err != nil {
+			return err
+		}
+	}
+	if err := writeProperties(w, s.Properties); err != nil {
+		return err
+	}
+	w.EndObject()
+	return nil
+}
+
+func (s *MapSchema) writeJSON(w *JSONWriter) error {
+	w.BeginObject().Field("type").String("map")
+	if s.Values != nil {
+		if err := writeSchema(w.Field("values"), s.Values); err != nil {
+			return err
+		}
+	}
+	if err := writeProperties(w, s.Properties); err != nil {
+		return err
+	}
+	w.EndObject()
+	return nil
+}
+
+func (s *UnionSchema) writeJSON(w *JSONWriter) error {
+	if s.Types == nil {
+		w.Null()
+		return nil
+	}
+	w.BeginArray()
+	for _, t := range s.Types {
+		if err := writeSchema(w, t); err != nil {
+			return err
+		}
+	}
+	w.EndArray()
+	return nil
+}
+
+func (s *FixedSchema) writeJSON(w *JSONWriter) error {
+	w.BeginObject().Field("type").String("fixed")
+	if s.Size != 0 {
+		w.Field("size").Int(int64(s.Size))
+	}
+	writeNamed(w, s.Namespace, s.Name, s.Aliases, s.Doc, false)
+	var written []string
+	if s.LogicalType != "" {
+		w.Field("logicalType").String(s.LogicalType)
+		written = append(written, "logicalType")
+	}
+	written = append(written, writeDecimalParams(w, s.Precision, s.Scale)...)
+	if err := writeProperties(w, s.Properties, written...); err != nil {
+		return err
+	}
+	w.EndObject()
+	return nil
+}
+
+// schemaJSON returns the JSON serialization of a schema, or its Parsing Canonical Form if it can't
+// be serialized, e.g. because of its custom properties.
+func schemaJSON(s Schema) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return schemaFallbackString(s)
+	}
+	return string(b)
+}
+
+// indentedSchemaJSON is like schemaJSON, but indents the JSON.
+func indentedSchemaJSON(s Schema) string {
+	b, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return schemaFallbackString(s)
+	}
+	return string(b)
+}
+
+// schemaFallbackString returns the Parsing Canonical Form of a schema that can't be serialized,
+// which leaves out everything but the names and types, or its full name if that fails too.
+func schemaFallbackString(s Schema) string {
+	canonical, err := CanonicalForm(s)
+	if err != nil {
+		return s.FullName()
+	}
+	return canonical
+}
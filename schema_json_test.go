@@ -0,0 +1,74 @@
+package avro
+
+import (
+	"encoding"
+	"testing"
+)
+
+func TestSchemaAppendJSON(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Event", "namespace": "ns", "fields": [
+		{"name": "id", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}}
+	]}`)
+	const expected = `{"type":"record","namespace":"ns","name":"Event","fields":[` +
+		`{"name":"id","type":{"type":"long","logicalType":"timestamp-millis"}},` +
+		`{"name":"tags","type":{"type":"array","items":"string"}}]}`
+	buf, err := s.(*RecordSchema).AppendJSON([]byte("schema="))
+	assert(t, err, nil)
+	assert(t, string(buf), "schema="+expected)
+	text, err := s.(encoding.TextMarshaler).MarshalText()
+	assert(t, err, nil)
+	assert(t, string(text), expected)
+
+	for _, s := range []Schema{new(NullSchema), new(IntSchema), &UnionSchema{Types: []Schema{new(NullSchema), new(StringSchema)}}} {
+		text, err := s.(encoding.TextMarshaler).MarshalText()
+		assert(t, err, nil)
+		json, _ := s.(interface {
+			MarshalJSON() ([]byte, error)
+		}).MarshalJSON()
+		assert(t, string(text), string(json))
+	}
+}
+
+func TestSchemaStringFallback(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Event", "fields": [{"name": "id", "type": "long"}]}`)
+	assert(t, s.SetProp("callback", func() {}), nil)
+	buf, err := s.(*RecordSchema).AppendJSON([]byte("schema="))
+	assert(t, err != nil, true)
+	assert(t, string(buf), "schema=")
+	_, err = s.(*RecordSchema).MarshalText()
+	assert(t, err != nil, true)
+	assert(t, s.String(), `{"name":"Event","type":"record","fields":[{"name":"id","type":"long"}]}`)
+
+	long := &LongSchema{}
+	assert(t, long.SetProp("callback", func() {}), nil)
+	assert(t, long.String(), `"long"`)
+	union := &UnionSchema{Types: []Schema{new(NullSchema), long}}
+	assert(t, union.String(), `{"type": ["null","long"]}`)
+}
+
+func TestSchemaAppendJSONAttributes(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Order", "namespace": "shop", "doc": "An <order>",
+		"aliases": ["Purchase"], "owner": "billing", "fields": [
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "PAID"], "doc": "State", "aliases": ["State"]}, "default": "NEW"},
+		{"name": "amount", "type": {"type": "fixed", "name": "Amount", "size": 8, "logicalType": "decimal", "precision": 18, "scale": 2}},
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2, "currency": "EUR"}},
+		{"name": "note", "type": ["null", "string"], "order": "ignore"},
+		{"name": "lines", "type": {"type": "map", "values": {"type": "array", "items": "Order"}}}
+	]}`)
+	const expected = `{"type":"record","namespace":"shop","name":"Order","doc":"An \u003corder\u003e","aliases":["Purchase"],"fields":[` +
+		`{"name":"status","default":"NEW","type":{"type":"enum","namespace":"shop","name":"Status","aliases":["State"],"doc":"State","symbols":["NEW","PAID"]}},` +
+		`{"name":"amount","type":{"type":"fixed","size":8,"namespace":"shop","name":"Amount","logicalType":"decimal","precision":18,"scale":2}},` +
+		`{"name":"price","type":{"type":"bytes","logicalType":"decimal","precision":9,"scale":2,"currency":"EUR"}},` +
+		`{"name":"note","default":null,"type":["null","string"],"order":"ignore"},` +
+		`{"name":"lines","type":{"type":"map","values":{"type":"array","items":"Order"}}}],"owner":"billing"}`
+	buf, err := s.(*RecordSchema).AppendJSON(nil)
+	assert(t, err, nil)
+	assert(t, string(buf), expected)
+
+	reparsed, err := ParseSchema(string(buf))
+	assert(t, err, nil)
+	again, err := reparsed.(*RecordSchema).AppendJSON(nil)
+	assert(t, err, nil)
+	assert(t, string(again), expected)
+}
@@ -17,27 +17,93 @@ package avro
 
 import (
 	"io/ioutil"
+	"os"
 	"strings"
 )
 
 const schemaExtension = ".avsc"
 
-// LoadSchemas loads and parses a schema file or directory.
+// schemaExtensions are those of the files loaded as schemas, JSON first.
+var schemaExtensions = []string{schemaExtension, ".yaml", ".yml"}
+
+func isSchemaFile(name string) bool {
+	for _, extension := range schemaExtensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// readSchemaFile reads a schema file, converting YAML files to JSON.
+func readSchemaFile(name string) (string, error) {
+	contents, err := readFile(name)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		if contents, err = yamlToJSON(contents); err != nil {
+			return "", err
+		}
+	}
+	return string(contents), nil
+}
+
+// schemaFile returns the file the schema of a full name is loaded from, trying every extension for
+// local files.
+func schemaFile(basePath, fullName string) string {
+	base := basePath + strings.Replace(fullName, ".", "/", -1)
+	if local, ok := localPath(base); ok {
+		for _, extension := range schemaExtensions {
+			if _, err := os.Stat(local + extension); err == nil {
+				return base + extension
+			}
+		}
+	}
+	return base + schemaExtension
+}
+
+// LoadSchemas loads and parses a schema file or directory, of .avsc JSON files or .yaml and .yml
+// YAML files.
 // Directory names MUST end with "/"
+// Directories behind a registered Opener can be loaded if it implements Lister.
 func LoadSchemas(path string) map[string]Schema {
-	files := getFiles(path, make([]string, 0))
-
 	schemas := make(map[string]Schema)
+	if err := LoadSchemasUsing(path, mapRegistry(schemas)); err != nil {
+		return make(map[string]Schema)
+	}
+
+	return schemas
+}
+
+// LoadSchemasUsing loads and parses a schema file or directory into the given Registry.
+// Directory names MUST end with "/"
+// May return an error if any of the schemas fails to load.
+func LoadSchemasUsing(path string, registry Registry) error {
+	var files []string
+	if local, ok := localPath(path); ok {
+		files = getFiles(local, make([]string, 0))
+	} else {
+		remote, err := listFiles(path)
+		if err != nil {
+			return err
+		}
+		for _, file := range remote {
+			if isSchemaFile(file) {
+				files = append(files, file)
+			}
+		}
+	}
 
 	if files != nil {
 		for _, file := range files {
-			if _, err := loadSchema(path, file, schemas); err != nil {
-				return make(map[string]Schema)
+			if _, err := loadSchema(path, file, registry); err != nil {
+				return err
 			}
 		}
 	}
 
-	return schemas
+	return nil
 }
 
 func getFiles(path string, files []string) []string {
@@ -53,7 +119,7 @@ func getFiles(path string, files []string) []string {
 				return nil
 			}
 		} else if file.Mode().IsRegular() {
-			if strings.HasSuffix(file.Name(), schemaExtension) {
+			if isSchemaFile(file.Name()) {
 				files = addFile(path+file.Name(), files)
 			}
 		}
@@ -76,23 +142,21 @@ func addFile(path string, files []string) []string {
 	return files
 }
 
-func loadSchema(basePath, avscPath string, schemas map[string]Schema) (Schema, error) {
-	avscJSON, err := ioutil.ReadFile(avscPath)
+func loadSchema(basePath, avscPath string, registry Registry) (Schema, error) {
+	avscJSON, err := readSchemaFile(avscPath)
 	if err != nil {
 		return nil, err
 	}
 
 	var sch Schema
 	for {
-		sch, err = ParseSchemaWithRegistry(string(avscJSON), schemas)
+		sch, err = ParseSchemaUsing(avscJSON, registry)
 
 		if err != nil {
 			text := err.Error()
 			if strings.HasPrefix(text, "Undefined schema:") {
 				typ := text[18:len(text)]
-				path := basePath + strings.Replace(typ, ".", "/", -1) + schemaExtension
-
-				_, errDep := loadSchema(basePath, path, schemas)
+				_, errDep := loadSchema(basePath, schemaFile(basePath, typ), registry)
 
 				if errDep != nil {
 					return nil, errDep
@@ -1,7 +1,6 @@
 package avro
 
 import (
-	"fmt"
 	"reflect"
 	"sync"
 )
@@ -74,15 +73,21 @@ func (job *prepareJob) prepareMapSchema(input *MapSchema) Schema {
 
 func (job *prepareJob) prepareRecordSchema(input *RecordSchema) *preparedRecordSchema {
 	output := &preparedRecordSchema{
-		RecordSchema: *input,
-		pool:         sync.Pool{New: func() interface{} { return make(map[reflect.Type]*recordPlan) }},
+		RecordSchema: RecordSchema{
+			Name:       input.Name,
+			Namespace:  input.Namespace,
+			Doc:        input.Doc,
+			Aliases:    input.Aliases,
+			Properties: input.Properties,
+		},
+		pool: sync.Pool{New: func() interface{} { return make(map[reflect.Type]*recordPlan) }},
 	}
 	job.seen[input] = output // put the in-progress output here before iterating fields, solves self-recursive and co-recursive.
-	output.Fields = nil
 	for _, field := range input.Fields {
 		output.Fields = append(output.Fields, &SchemaField{
 			Name:    field.Name,
 			Doc:     field.Doc,
+			Aliases: field.Aliases,
 			Default: field.Default,
 			Type:    job.prepare(field.Type),
 		})
@@ -102,14 +107,13 @@ func (rs *preparedRecordSchema) getPlan(t reflect.Type) (plan *recordPlan, err e
 		return
 	}
 
-	// Use the reflectmap to get field info.
-	ri := reflectEnsureRi(t)
-
 	decodePlan := make([]structFieldPlan, len(rs.Fields))
 	for i, schemafield := range rs.Fields {
-		index, ok := ri.names[schemafield.Name]
-		if !ok {
-			err = fmt.Errorf("Type %v does not have field %s required for decoding schema", t, schemafield.Name)
+		index, err := structFieldIndex(t, schemafield)
+		if err != nil {
+			// Don't cache the plan, it would store the whole struct where the field is missing.
+			rs.pool.Put(cache)
+			return nil, err
 		}
 		entry := &decodePlan[i]
 		entry.schema = schemafield.Type
@@ -127,9 +131,6 @@ func (rs *preparedRecordSchema) getPlan(t reflect.Type) (plan *recordPlan, err e
 	return
 }
 
-// This is used
-var sdr sDatumReader
-
 type recordPlan struct {
 	decodePlan []structFieldPlan
 }
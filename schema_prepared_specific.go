@@ -22,17 +22,17 @@ type structFieldPlan struct {
 	dec    preparedDecoder
 }
 
-type preparedDecoder func(reflectField reflect.Value, dec Decoder) (reflect.Value, error)
+type preparedDecoder func(reader sDatumReader, reflectField reflect.Value, dec Decoder) (reflect.Value, error)
 
 func genericDec(schema Schema) preparedDecoder {
-	return func(reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
-		return sdr.readValue(schema, reflectField, dec)
+	return func(reader sDatumReader, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+		return reader.readValue(schema, reflectField, dec)
 	}
 }
 
 func enumDec(schema *EnumSchema) preparedDecoder {
 	symbolsToIndex := NewGenericEnum(schema.Symbols).symbolsToIndex
-	return func(reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	return func(_ sDatumReader, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
 		enumIndex, err := dec.ReadEnum()
 		if err != nil {
 			return reflect.ValueOf(enumIndex), err
@@ -47,7 +47,7 @@ func enumDec(schema *EnumSchema) preparedDecoder {
 }
 
 func recordDec(schema Schema) preparedDecoder {
-	return func(reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
-		return sdr.mapRecord(schema, reflectField, dec)
+	return func(reader sDatumReader, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+		return reader.mapRecord(schema, reflectField, dec)
 	}
 }
@@ -0,0 +1,155 @@
+package avro
+
+import "math"
+
+// Assumptions AnalyzeSchema makes about typical data.
+const (
+	typicalItems     = 4  // items of arrays and entries of maps
+	typicalBytesSize = 16 // bytes of strings, bytes and map keys
+)
+
+// SchemaStats describes the size and complexity of a schema, as reported by AnalyzeSchema.
+type SchemaStats struct {
+	// NamedTypes is the number of distinct records, enums and fixed types, Records that of records.
+	NamedTypes int
+	Records    int
+
+	// Fields is the number of fields of all distinct records, MaxFields that of the widest one.
+	Fields    int
+	MaxFields int
+
+	// MaxDepth is how deep records, arrays and maps nest, a top-level record with primitive fields
+	// having a depth of 1. Unions don't add to it, and recursive references aren't followed.
+	MaxDepth int
+
+	// Unions is the number of unions in all distinct definitions, MaxUnionBranches the most branches
+	// any of them has.
+	Unions           int
+	MaxUnionBranches int
+
+	// Recursive tells whether any record references itself, directly or not.
+	Recursive bool
+
+	// MinSize is the smallest number of bytes a datum can be encoded in. TypicalSize is an estimate
+	// for typical data, assuming varints of a few bytes, strings and bytes of 16 bytes, 4 items in
+	// arrays and maps and the average size of union branches. Recursive references count for nothing
+	// in both.
+	MinSize     int
+	TypicalSize int
+}
+
+// AnalyzeSchema reports statistics about the given schema, e.g. for capacity planning or for linters
+// flagging overly complex schemas.
+func AnalyzeSchema(s Schema) SchemaStats {
+	a := &schemaAnalyzer{seen: make(map[string]bool), visiting: make(map[string]bool), records: make(map[string]schemaSize)}
+	size := a.visit(s)
+	a.stats.MaxDepth = size.depth
+	a.stats.MinSize = size.min
+	a.stats.TypicalSize = int(math.Ceil(size.typical))
+	return a.stats
+}
+
+type schemaSize struct {
+	depth   int
+	min     int
+	typical float64
+}
+
+type schemaAnalyzer struct {
+	stats    SchemaStats
+	seen     map[string]bool       // named types counted already
+	visiting map[string]bool       // records being analyzed
+	records  map[string]schemaSize // of the records analyzed already
+}
+
+// named counts a named type the first time it's seen and tells whether it was.
+func (a *schemaAnalyzer) named(s Schema) bool {
+	name := s.FullName()
+	if a.seen[name] {
+		return false
+	}
+	a.seen[name] = true
+	a.stats.NamedTypes++
+	return true
+}
+
+func (a *schemaAnalyzer) visit(s Schema) schemaSize {
+	switch s := resolveSchema(s).(type) {
+	case *RecordSchema:
+		name := s.FullName()
+		if a.visiting[name] {
+			a.stats.Recursive = true
+			return schemaSize{}
+		} else if size, ok := a.records[name]; ok {
+			return size
+		}
+		a.named(s)
+		a.stats.Records++
+		a.stats.Fields += len(s.Fields)
+		if len(s.Fields) > a.stats.MaxFields {
+			a.stats.MaxFields = len(s.Fields)
+		}
+		a.visiting[name] = true
+		var size schemaSize
+		for _, field := range s.Fields {
+			fieldSize := a.visit(field.Type)
+			if fieldSize.depth > size.depth {
+				size.depth = fieldSize.depth
+			}
+			size.min += fieldSize.min
+			size.typical += fieldSize.typical
+		}
+		size.depth++
+		delete(a.visiting, name)
+		a.records[name] = size
+		return size
+	case *EnumSchema:
+		a.named(s)
+		return schemaSize{min: 1, typical: 1}
+	case *FixedSchema:
+		a.named(s)
+		return schemaSize{min: s.Size, typical: float64(s.Size)}
+	case *ArraySchema:
+		items := a.visit(s.Items)
+		// A block count, the items and the terminating empty block.
+		return schemaSize{depth: items.depth + 1, min: 1, typical: 2 + typicalItems*items.typical}
+	case *MapSchema:
+		values := a.visit(s.Values)
+		return schemaSize{depth: values.depth + 1, min: 1, typical: 2 + typicalItems*(1+typicalBytesSize+values.typical)}
+	case *UnionSchema:
+		a.stats.Unions++
+		if len(s.Types) > a.stats.MaxUnionBranches {
+			a.stats.MaxUnionBranches = len(s.Types)
+		}
+		var size schemaSize
+		for i, t := range s.Types {
+			branch := a.visit(t)
+			if branch.depth > size.depth {
+				size.depth = branch.depth
+			}
+			if i == 0 || branch.min < size.min {
+				size.min = branch.min
+			}
+			size.typical += branch.typical / float64(len(s.Types))
+		}
+		size.min++
+		size.typical++
+		return size
+	}
+
+	switch s.Type() {
+	case Boolean:
+		return schemaSize{min: 1, typical: 1}
+	case Int:
+		return schemaSize{min: 1, typical: 3}
+	case Long:
+		return schemaSize{min: 1, typical: 5}
+	case Float:
+		return schemaSize{min: 4, typical: 4}
+	case Double:
+		return schemaSize{min: 8, typical: 8}
+	case String, Bytes:
+		return schemaSize{min: 1, typical: 1 + typicalBytesSize}
+	}
+	return schemaSize{}
+}
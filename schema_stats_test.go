@@ -0,0 +1,39 @@
+package avro
+
+import "testing"
+
+func TestAnalyzeSchema(t *testing.T) {
+	node := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": "string"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "meta", "type": {"type": "map", "values": "int"}},
+		{"name": "next", "type": ["null", "Node"]},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}}
+	]}`)
+	assert(t, AnalyzeSchema(node), SchemaStats{
+		NamedTypes:       3,
+		Records:          1,
+		Fields:           7,
+		MaxFields:        7,
+		MaxDepth:         2,
+		Unions:           1,
+		MaxUnionBranches: 2,
+		Recursive:        true,
+		MinSize:          10,
+		TypicalSize:      180,
+	})
+
+	// Records referenced several times count once.
+	pair := MustParseSchema(`{"type": "record", "name": "Pair", "fields": [
+		{"name": "a", "type": {"type": "record", "name": "Point", "fields": [
+			{"name": "x", "type": "int"},
+			{"name": "y", "type": "int"}
+		]}},
+		{"name": "b", "type": "Point"}
+	]}`)
+	assert(t, AnalyzeSchema(Prepare(pair)), SchemaStats{NamedTypes: 2, Records: 2, Fields: 4, MaxFields: 2, MaxDepth: 2, MinSize: 4, TypicalSize: 12})
+
+	assert(t, AnalyzeSchema(MustParseSchema(`"double"`)), SchemaStats{MinSize: 8, TypicalSize: 8})
+}
@@ -1,7 +1,11 @@
 package avro
 
 import (
+	"bytes"
+	"math/big"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestPrimitiveSchema(t *testing.T) {
@@ -259,6 +263,30 @@ func TestFixedSchema(t *testing.T) {
 	}
 }
 
+func TestNamedSchemaJSON(t *testing.T) {
+	raw := `{"type": "record", "name": "Hashed", "namespace": "com.example", "fields": [
+		{"name": "hash", "type": {"type": "fixed", "name": "MD5", "aliases": ["Digest"], "doc": "A digest", "size": 16}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "namespace": "com.other", "aliases": ["Sort"], "doc": "The kind", "symbols": ["A"]}}
+	]}`
+	s, err := ParseSchema(raw)
+	assert(t, err, nil)
+	fields := s.(*RecordSchema).Fields
+
+	json, err := fields[0].Type.(*FixedSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"fixed","size":16,"namespace":"com.example","name":"MD5","aliases":["Digest"],"doc":"A digest"}`)
+	json, err = fields[1].Type.(*EnumSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"enum","namespace":"com.other","name":"Kind","aliases":["Sort"],"doc":"The kind","symbols":["A"]}`)
+
+	// Re-serialized named types can still be referenced by their full names.
+	registry := make(map[string]Schema)
+	_, err = ParseSchemaWithRegistry(fields[0].Type.String(), registry)
+	assert(t, err, nil)
+	_, err = ParseSchemaWithRegistry(`{"type": "record", "name": "Ref", "fields": [{"name": "hash", "type": "com.example.MD5"}]}`, registry)
+	assert(t, err, nil)
+}
+
 func TestSchemaRegistryMap(t *testing.T) {
 	rawSchema1 := `{"type": "record", "name": "TestRecord", "namespace": "com.github.elodina", "fields": [
 		{"name": "longRecordField", "type": "long"}
@@ -315,6 +343,78 @@ func TestRecordCustomProps(t *testing.T) {
 	assert(t, value, "world")
 }
 
+func TestSetProp(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "next", "type": ["null", "Rec"]}
+	]}`).(*RecordSchema)
+
+	assert(t, s.SetProp("lineage", "upstream"), nil)
+	value, exists := s.Prop("lineage")
+	assert(t, exists, true)
+	assert(t, value, "upstream")
+	value, exists = s.Fields[1].Type.(*UnionSchema).Types[1].Prop("lineage")
+	assert(t, exists, true)
+	assert(t, value, "upstream")
+	s.DeleteProp("lineage")
+	_, exists = s.Prop("lineage")
+	assert(t, exists, false)
+	assert(t, s.SetProp("name", "Other"), ErrReservedProperty)
+	assert(t, s.Name, "Rec")
+
+	long := s.Fields[0].Type
+	assert(t, long.SetProp("logicalType", "timestamp-millis"), nil)
+	value, _ = long.Prop("logicalType")
+	assert(t, value, "timestamp-millis")
+	assert(t, long.SetProp("type", "int"), ErrReservedProperty)
+
+	assert(t, s.Fields[0].SetProp("pii", true), nil)
+	value, _ = s.Fields[0].Prop("pii")
+	assert(t, value, true)
+	assert(t, s.Fields[0].SetProp("default", 1), ErrReservedProperty)
+
+	assert(t, s.Fields[1].Type.SetProp("pii", true), ErrUnionProperty)
+}
+
+func TestSetLogicalTypeProp(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "at", "type": "long"},
+		{"name": "amount", "type": "bytes"}
+	]}`).(*RecordSchema)
+	at, amount := s.Fields[0].Type.(*LongSchema), s.Fields[1].Type.(*BytesSchema)
+	assert(t, at.SetProp("logicalType", "timestamp-millis"), nil)
+	assert(t, at.LogicalType, LogicalTypeTimestampMillis)
+	assert(t, len(at.Properties), 0)
+	assert(t, at.SetProp("logicalType", 1).Error(), "Logical type must be a string, not int")
+	assert(t, amount.SetProp("precision", 4), nil)
+	assert(t, amount.SetProp("logicalType", "decimal"), nil)
+	assert(t, amount.SetProp("scale", 2), nil)
+	assert(t, amount.Precision, 4)
+	assert(t, amount.Scale, 2)
+	value, _ := amount.Prop("scale")
+	assert(t, value, 2)
+
+	// Properties duplicating the typed attributes aren't written twice.
+	at.Properties = map[string]interface{}{"logicalType": "timestamp-micros"}
+	assert(t, at.String(), `{"type":"long","logicalType":"timestamp-millis"}`)
+	at.Properties = nil
+
+	parsed := MustParseSchema(s.String()).(*RecordSchema)
+	assert(t, parsed.Fields[0].Type.(*LongSchema).LogicalType, LogicalTypeTimestampMillis)
+	assert(t, isDecimal(parsed.Fields[1].Type), true)
+	record := NewGenericRecord(parsed)
+	record.Set("at", time.Unix(1, 0))
+	record.Set("amount", big.NewRat(314, 100))
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(s).Write(record, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), []byte{0xd0, 0x0f, 0x04, 0x01, 0x3a})
+
+	amount.DeleteProp("logicalType")
+	assert(t, amount.LogicalType, "")
+	value, _ = amount.Prop("precision")
+	assert(t, value, 4)
+}
+
 func TestLoadSchemas(t *testing.T) {
 	schemas := LoadSchemas("test/schemas/")
 	assert(t, len(schemas), 4)
@@ -337,3 +437,200 @@ func arrayEqual(arr1 []string, arr2 []string) bool {
 	}
 	return true
 }
+
+func TestSchemaFullName(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Outer", "namespace": "com.example", "fields": [
+		{"name": "inner", "type": {"type": "enum", "name": "Inner", "symbols": ["A"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "org.other.Hash", "size": 4}},
+		{"name": "self", "type": ["null", "Outer"]},
+		{"name": "items", "type": {"type": "array", "items": "long"}}
+	]}`)
+	fields := s.(*RecordSchema).Fields
+	assert(t, s.FullName(), "com.example.Outer")
+	assert(t, Prepare(s).FullName(), "com.example.Outer")
+	assert(t, fields[0].Type.FullName(), "com.example.Inner")
+	assert(t, fields[1].Type.FullName(), "org.other.Hash")
+	assert(t, fields[2].Type.(*UnionSchema).Types[1].FullName(), "com.example.Outer")
+	assert(t, fields[3].Type.FullName(), "array")
+	assert(t, fields[3].Type.(*ArraySchema).Items.FullName(), "long")
+}
+
+func TestSchemaGetDoc(t *testing.T) {
+	s := MustParseSchema(`{"type": "record", "name": "Outer", "doc": "A record", "fields": [
+		{"name": "inner", "doc": "A field", "type": {"type": "enum", "name": "Inner", "doc": "An enum", "symbols": ["A"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "doc": "A fixed", "size": 4}},
+		{"name": "self", "type": ["null", "Outer"]}
+	]}`)
+	fields := s.(*RecordSchema).Fields
+	assert(t, s.(*RecordSchema).GetDoc(), "A record")
+	assert(t, fields[0].GetDoc(), "A field")
+	assert(t, fields[0].Type.(*EnumSchema).GetDoc(), "An enum")
+	assert(t, fields[1].GetDoc(), "")
+	assert(t, fields[1].Type.(*FixedSchema).GetDoc(), "A fixed")
+	assert(t, fields[2].Type.(*UnionSchema).Types[1].(*RecursiveSchema).GetDoc(), "A record")
+
+	reparsed := MustParseSchema(s.String()).(*RecordSchema)
+	assert(t, reparsed.Fields[0].Type.(*EnumSchema).GetDoc(), "An enum")
+	assert(t, reparsed.Fields[1].Type.(*FixedSchema).GetDoc(), "A fixed")
+}
+
+func TestRecordSchemaField(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "email", "aliases": ["mail", "id"], "type": "string"}
+	]}`).(*RecordSchema)
+
+	field, i, ok := schema.Field("email")
+	assert(t, field.Name, "email")
+	assert(t, i, 1)
+	assert(t, ok, true)
+	field, i, _ = schema.Field("mail")
+	assert(t, field.Name, "email")
+	assert(t, i, 1)
+	// Names win over aliases.
+	field, _, _ = schema.Field("id")
+	assert(t, field.Name, "id")
+	field, i, ok = schema.Field("missing")
+	assert(t, field == nil && i == -1 && !ok, true)
+
+	reparsed := MustParseSchema(schema.String()).(*RecordSchema)
+	assert(t, reparsed.Fields[1].Aliases, []string{"mail", "id"})
+}
+
+func TestUnionSchemaHelpers(t *testing.T) {
+	union := MustParseSchema(`["null", "string", {"type": "record", "name": "Event", "namespace": "ns", "fields": []}]`).(*UnionSchema)
+	assert(t, union.IsNullable(), true)
+	assert(t, union.NonNullTypes(), union.Types[1:])
+
+	i, branch, ok := union.BranchByName("ns.Event")
+	assert(t, i, 2)
+	assert(t, branch, union.Types[2])
+	assert(t, ok, true)
+	i, _, _ = union.BranchByName("string")
+	assert(t, i, 1)
+	_, branch, ok = union.BranchByName("Event")
+	assert(t, branch == nil && !ok, true)
+
+	union = MustParseSchema(`["int", "long"]`).(*UnionSchema)
+	assert(t, union.IsNullable(), false)
+	assert(t, len(union.NonNullTypes()), 2)
+}
+
+func TestSchemaKindPredicates(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "next", "type": ["null", "Node"]},
+		{"name": "tags", "type": {"type": "map", "values": {"type": "array", "items": "string"}}}
+	]}`).(*RecordSchema)
+	next := schema.Fields[0].Type.(*UnionSchema)
+
+	assert(t, IsRecord(schema) && IsRecord(next.Types[1]) && IsRecord(Prepare(schema)), true)
+	assert(t, IsNamed(next.Types[1]) && !IsNamed(next), true)
+	assert(t, IsUnion(next) && !IsRecord(next), true)
+	assert(t, IsMap(schema.Fields[1].Type) && IsArray(schema.Fields[1].Type.(*MapSchema).Values), true)
+	assert(t, IsPrimitive(next.Types[0]) && !IsPrimitive(schema), true)
+	assert(t, IsEnum(MustParseSchema(`{"type": "enum", "name": "E", "symbols": ["A"]}`)), true)
+	assert(t, IsFixed(MustParseSchema(`{"type": "fixed", "name": "F", "size": 1}`)), true)
+}
+
+func TestContainerSchemaProperties(t *testing.T) {
+	s, err := ParseSchema(`{"type": "array", "items": "long", "logicalType": "set", "java-class": "java.util.HashSet"}`)
+	assert(t, err, nil)
+	json, err := s.(*ArraySchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"array","items":"long","java-class":"java.util.HashSet","logicalType":"set"}`)
+	s, err = ParseSchema(string(json))
+	assert(t, err, nil)
+	prop, _ := s.Prop("logicalType")
+	assert(t, prop, "set")
+
+	m := &MapSchema{Values: &StringSchema{}}
+	json, err = m.MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"map","values":"string"}`)
+	assert(t, m.SetProp("sorted", true), nil)
+	json, err = m.MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), `{"type":"map","values":"string","sorted":true}`)
+}
+
+func TestPrimitiveSchemaProperties(t *testing.T) {
+	raw := `{"type":"record","name":"Payment","fields":[` +
+		`{"name":"id","type":{"type":"string","logicalType":"uuid","x-origin":"ledger"}},` +
+		`{"name":"amount","type":{"type":"bytes","logicalType":"decimal","precision":9,"scale":2,"java-class":"java.math.BigDecimal"}},` +
+		`{"name":"raw","type":{"type":"bytes","precision":3}},` +
+		`{"name":"at","type":{"type":"long","logicalType":"timestamp-millis","connect.name":"Timestamp"}},` +
+		`{"name":"rate","type":{"type":"double","unit":"percent"}},` +
+		`{"name":"flags","default":null,"type":["null",{"type":"int","logicalType":"bitset","width":8}]},` +
+		`{"name":"fee","type":{"type":"fixed","size":4,"name":"Fee","logicalType":"decimal","precision":9,"scale":2,"x-origin":"ledger"}},` +
+		`{"name":"valid","type":"boolean"}]}`
+	s, err := ParseSchema(raw)
+	assert(t, err, nil)
+	json, err := s.(*RecordSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), raw)
+
+	fields := s.(*RecordSchema).Fields
+	amount := fields[1].Type.(*BytesSchema)
+	assert(t, amount.Precision, 9)
+	assert(t, amount.Properties, map[string]interface{}{"java-class": "java.math.BigDecimal"})
+	assert(t, fields[2].Type.(*BytesSchema).Properties, map[string]interface{}{"precision": float64(3)})
+	prop, _ := fields[4].Type.Prop("unit")
+	assert(t, prop, "percent")
+	assert(t, fields[4].Type.String(), `{"type":"double","unit":"percent"}`)
+	assert(t, fields[7].Type.(*BooleanSchema).Properties == nil, true)
+	assert(t, fields[7].Type.String(), `"boolean"`)
+	assert(t, (&LongSchema{LogicalType: LogicalTypeTimestampMillis}).String(), `{"type":"long","logicalType":"timestamp-millis"}`)
+
+	reparsed, err := ParseSchema(s.String())
+	assert(t, err, nil)
+	json, err = reparsed.(*RecordSchema).MarshalJSON()
+	assert(t, err, nil)
+	assert(t, string(json), raw)
+}
+
+func TestEnumSchemaValidate(t *testing.T) {
+	s := &EnumSchema{Name: "Suit", Symbols: []string{"HEART", "SPADE"}}
+	spade := NewGenericEnum([]string{"CLUB", "SPADE"})
+	spade.Set("SPADE")
+	club := NewGenericEnum([]string{"CLUB", "SPADE"})
+	for _, c := range []struct {
+		value interface{}
+		valid bool
+	}{
+		{spade, true},
+		{*spade, true},
+		{club, false},
+		{&GenericEnum{index: 1}, true},
+		{&GenericEnum{index: 2}, false},
+		{(*GenericEnum)(nil), false},
+		{"HEART", true},
+		{"CLUB", false},
+		{int32(1), true},
+		{int32(2), false},
+		{int32(-1), false},
+		{int64(0), false},
+		{nil, false},
+	} {
+		assert(t, s.Validate(reflect.ValueOf(c.value)), c.valid)
+	}
+
+	// Unions no longer pick enums for any value.
+	union := MustParseSchema(`[{"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE"]}, "long"]`)
+	assert(t, union.(*UnionSchema).GetType(reflect.ValueOf(int64(3))), 1)
+	assert(t, union.(*UnionSchema).GetType(reflect.ValueOf(spade)), 0)
+	w := NewGenericDatumWriter()
+	w.SetSchema(union)
+	var buf bytes.Buffer
+	assert(t, w.Write(int64(3), NewBinaryEncoder(&buf)), nil)
+	assert(t, w.Write(int32(1), NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes(), []byte{2, 6, 0, 2})
+
+	// Writers pick the same branches as GetType for strings and ints enums may or may not take.
+	union = MustParseSchema(`[{"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE"]}, "int", "string"]`)
+	w.SetSchema(union)
+	buf.Reset()
+	for _, v := range []interface{}{"SPADE", "CLUB", int32(1), int32(100)} {
+		assert(t, w.Write(v, NewBinaryEncoder(&buf)), nil)
+	}
+	assert(t, buf.Bytes(), []byte{0, 2, 4, 8, 'C', 'L', 'U', 'B', 0, 2, 2, 0xc8, 0x01})
+}
@@ -0,0 +1,158 @@
+package avro
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// URLOptions configures how ParseSchemaURL fetches schemas.
+type URLOptions struct {
+	// Client to make requests with, one timing out after 30 seconds if nil.
+	Client *http.Client
+
+	// Retries is how many more times a request is tried after a network error or a 5xx or 429 response.
+	Retries int
+
+	// Backoff is the delay before the first retry, doubled on every subsequent one. Defaults to 100ms.
+	Backoff time.Duration
+
+	// Registry to parse schemas using, a new one for every fetched schema if nil.
+	Registry Registry
+}
+
+type cachedSchemaURL struct {
+	etag         string
+	lastModified string
+	schema       Schema
+	registry     Registry // kept so that its key can't be reused by another one
+}
+
+// schemaURLKey identifies cached schemas by the client they were fetched with, which may send
+// credentials the response depends on, and the registry they were parsed using besides their URL.
+type schemaURLKey struct {
+	url      string
+	client   *http.Client
+	registry interface{}
+}
+
+var (
+	schemaURLCache     = make(map[schemaURLKey]*cachedSchemaURL)
+	schemaURLCacheLock sync.Mutex
+)
+
+// registryKey returns a comparable value identifying registry, or false if there is none, as for
+// registries of types like structs holding maps.
+func registryKey(registry Registry) (interface{}, bool) {
+	if registry == nil {
+		return nil, true
+	}
+	v := reflect.ValueOf(registry)
+	switch {
+	case v.Type().Comparable():
+		return registry, true
+	case v.Kind() == reflect.Map:
+		// Maps aren't comparable, but the ones NewRegistry returns are identified by their address
+		// while cached schemas keep them around.
+		return v.Pointer(), true
+	}
+	return nil, false
+}
+
+// ParseSchemaURL fetches and parses the schema JSON at the given http:// or https:// URL. opts may be nil to use the defaults.
+//
+// Fetched schemas are cached in-process: once a URL has been fetched, subsequent calls with the same
+// Client and Registry send a conditional request with the ETag and Last-Modified values of the previous
+// response, and return the cached schema without parsing anything again if the server replies that it
+// is unchanged.
+func ParseSchemaURL(url string, opts *URLOptions) (Schema, error) {
+	if opts == nil {
+		opts = &URLOptions{}
+	}
+	client := opts.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	key := schemaURLKey{url: url, client: client}
+	var cacheable bool
+	key.registry, cacheable = registryKey(opts.Registry)
+	var cached *cachedSchemaURL
+	if cacheable {
+		schemaURLCacheLock.Lock()
+		cached = schemaURLCache[key]
+		schemaURLCacheLock.Unlock()
+	}
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil {
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+
+		resp, err = client.Do(req)
+		retry := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		if !retry {
+			break
+		} else if attempt >= opts.Retries {
+			if err != nil {
+				return nil, err
+			}
+			resp.Body.Close()
+			return nil, fmt.Errorf("Fetching schema %s: %s", url, resp.Status)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		time.Sleep(backoff << uint(attempt))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.schema, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("Fetching schema %s: %s", url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = NewRegistry()
+	}
+	schema, err := ParseSchemaUsing(string(body), registry)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		schemaURLCacheLock.Lock()
+		schemaURLCache[key] = &cachedSchemaURL{
+			etag:         resp.Header.Get("ETag"),
+			lastModified: resp.Header.Get("Last-Modified"),
+			schema:       schema,
+			registry:     opts.Registry,
+		}
+		schemaURLCacheLock.Unlock()
+	}
+	return schema, nil
+}
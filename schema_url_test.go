@@ -0,0 +1,56 @@
+package avro
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseSchemaURL(t *testing.T) {
+	requests, fails := 0, 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if fails > 0 {
+			fails--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"type": "fixed", "name": "Hash", "size": 16}`))
+	}))
+	defer server.Close()
+
+	_, err := ParseSchemaURL(server.URL, nil)
+	assert(t, err.Error(), "Fetching schema "+server.URL+": 503 Service Unavailable")
+
+	fails = 2
+	opts := &URLOptions{Retries: 2, Backoff: time.Millisecond}
+	schema, err := ParseSchemaURL(server.URL, opts)
+	assert(t, err, nil)
+	assert(t, schema.FullName(), "Hash")
+	assert(t, requests, 4)
+
+	again, err := ParseSchemaURL(server.URL, opts)
+	assert(t, err, nil)
+	assert(t, again == schema, true)
+	assert(t, requests, 5)
+
+	// Schemas parsed using another registry aren't reused.
+	registry := NewRegistry()
+	opts.Registry = registry
+	other, err := ParseSchemaURL(server.URL, opts)
+	assert(t, err, nil)
+	assert(t, other == schema, false)
+	registered, ok := registry.Get("Hash")
+	assert(t, ok, true)
+	assert(t, registered == other, true)
+	again, err = ParseSchemaURL(server.URL, opts)
+	assert(t, err, nil)
+	assert(t, again == other, true)
+	assert(t, requests, 7)
+}
@@ -0,0 +1,291 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// sequenceMagic starts sequence streams, followed by a header of the sequenceHeaderSchema.
+var sequenceMagic = []byte{'A', 'v', 's', 1}
+
+const sequenceHeaderSchemaRaw = `{"type": "record", "name": "avro.SequenceHeader", "fields": [
+	{"name": "schema", "type": "string"},
+	{"name": "codec", "type": "string"},
+	{"name": "streamCompressed", "type": "boolean"}
+]}`
+
+var sequenceHeaderSchema = Prepare(MustParseSchema(sequenceHeaderSchemaRaw))
+
+type sequenceHeader struct {
+	Schema           string `avro:"schema"`
+	Codec            string `avro:"codec"`
+	StreamCompressed bool   `avro:"streamCompressed"`
+}
+
+// SequenceOptions configures how a SequenceWriter compresses streams.
+type SequenceOptions struct {
+	// Codec is the name of the codec to compress the datums with, such as "deflate" or one
+	// registered with RegisterCodec. They aren't compressed if empty or "null".
+	Codec string
+
+	// CompressStream compresses everything after the header as a whole rather than every datum on
+	// its own, which compresses small datums much better. Readers only get the datums written so far
+	// once the writer is flushed or closed then.
+	CompressStream bool
+}
+
+// SequenceWriter writes datums as a sequence stream, a lightweight alternative to object container
+// files for log files and IPC: the stream starts with a magic header, the schema and the codec, and
+// every datum follows, prefixed with its length in bytes as an Avro long. There are no blocks or sync
+// markers, so datums can be read as soon as they're written.
+//
+// Every datum is written to the output with a single call to its Write method, so wrap it in a
+// bufio.Writer to write many small datums efficiently.
+type SequenceWriter struct {
+	output      io.Writer      // of the frames, compressing them if the whole stream is
+	stream      io.WriteCloser // compressing the whole stream, if it is
+	codec       Codec          // compressing every datum on its own, if they are
+	datumWriter DatumWriter
+	datum       *bytes.Buffer
+	datumEnc    *binaryEncoder
+	compressed  bytes.Buffer
+	frame       *bytes.Buffer // the length of the next datum followed by its data
+	frameEnc    *binaryEncoder
+}
+
+// NewSequenceWriter creates a new SequenceWriter for given output and schema using the given
+// DatumWriter to write the data to that Writer, and writes the stream header. Datums aren't
+// compressed. May return an error if writing fails.
+func NewSequenceWriter(output io.Writer, schema Schema, datumWriter DatumWriter) (*SequenceWriter, error) {
+	return NewSequenceWriterOptions(output, schema, datumWriter, SequenceOptions{})
+}
+
+// NewSequenceWriterOptions is like NewSequenceWriter, but compresses datums as configured by
+// options, recording the codec in the stream header. May return an error if the codec is unknown.
+func NewSequenceWriterOptions(output io.Writer, schema Schema, datumWriter DatumWriter, options SequenceOptions) (*SequenceWriter, error) {
+	if options.Codec == "" {
+		options.Codec = "null"
+	}
+	codec, ok := codecs[options.Codec]
+	if !ok {
+		return nil, fmt.Errorf("Unknown codec %s", options.Codec)
+	}
+	switch w := datumWriter.(type) {
+	case *SpecificDatumWriter:
+		w.SetSchema(schema)
+	case *GenericDatumWriter:
+		w.SetSchema(schema)
+	}
+
+	header := &bytes.Buffer{}
+	header.Write(sequenceMagic)
+	values := &sequenceHeader{Schema: schema.String(), Codec: options.Codec, StreamCompressed: options.CompressStream}
+	if err := NewSpecificDatumWriter().SetSchema(sequenceHeaderSchema).Write(values, newBinaryEncoder(header)); err != nil {
+		return nil, err
+	}
+	if _, err := output.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+
+	datum, frame := &bytes.Buffer{}, &bytes.Buffer{}
+	w := &SequenceWriter{output: output, datumWriter: datumWriter, datum: datum, datumEnc: newBinaryEncoder(datum),
+		frame: frame, frameEnc: newBinaryEncoder(frame)}
+	switch _, isNull := codec.(nullCodec); {
+	case isNull:
+	case options.CompressStream:
+		w.stream = codec.CodecWriter(output)
+		w.output = w.stream
+	default:
+		w.codec = codec
+	}
+	return w, nil
+}
+
+// Write writes out a single datum. If it fails to encode, nothing is written.
+func (w *SequenceWriter) Write(v interface{}) error {
+	w.datum.Reset()
+	if err := w.datumWriter.Write(v, w.datumEnc); err != nil {
+		return err
+	}
+	data := w.datum.Bytes()
+	if w.codec != nil {
+		w.compressed.Reset()
+		compressor := w.codec.CodecWriter(&w.compressed)
+		if _, err := compressor.Write(data); err != nil {
+			return err
+		} else if err = compressor.Close(); err != nil {
+			return err
+		}
+		data = w.compressed.Bytes()
+	}
+	w.frame.Reset()
+	w.frameEnc.WriteLong(int64(len(data)))
+	w.frame.Write(data)
+	_, err := w.output.Write(w.frame.Bytes())
+	return err
+}
+
+// Flush writes out the datums written to a compressed stream so far, if its codec supports it.
+// Other streams have every datum written out as it's written already.
+func (w *SequenceWriter) Flush() error {
+	if flusher, ok := w.stream.(interface {
+		Flush() error
+	}); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close finishes a compressed stream, which is required for its last datums to be readable. It
+// doesn't close the output. After Close() is called, this SequenceWriter cannot be used anymore.
+func (w *SequenceWriter) Close() error {
+	if w.stream != nil {
+		return w.stream.Close()
+	}
+	return nil
+}
+
+// SequenceReader reads datums from a sequence stream written by a SequenceWriter.
+type SequenceReader struct {
+	dec    *binaryDecoderReader
+	closer func() // of the reader decompressing the stream, if any
+	codec  Codec  // decompressing every datum on its own, if they are
+	schema Schema
+	datum  DatumReader
+	data   bytes.Buffer // of the next datum
+	frame  bytes.Buffer // compressed data of the next datum
+	ready  bool         // whether data holds the next datum
+	err    error
+}
+
+// NewSequenceReader creates a SequenceReader reading a sequence stream from input, and reads the
+// stream header. Datums are read with a DatumReader for the schema in the header, as returned by
+// NewDatumReader, and decompressed with the codec in the header, which must be registered.
+func NewSequenceReader(input io.Reader) (*SequenceReader, error) {
+	dec := newBinaryDecoderReader(input)
+	magic := make([]byte, len(sequenceMagic))
+	if err := dec.ReadFixed(magic); err != nil {
+		return nil, err
+	} else if !bytes.Equal(magic, sequenceMagic) {
+		return nil, fmt.Errorf("Not a sequence stream")
+	}
+	header := &sequenceHeader{}
+	if err := NewSpecificDatumReader().SetSchema(sequenceHeaderSchema).Read(header, dec); err != nil {
+		return nil, err
+	}
+	schema, err := ParseSchema(header.Schema)
+	if err != nil {
+		return nil, err
+	}
+	codec, ok := codecs[header.Codec]
+	if !ok {
+		return nil, fmt.Errorf("Don't know how to decode codec %s", header.Codec)
+	}
+
+	r := &SequenceReader{dec: dec, schema: schema, datum: NewDatumReader(schema)}
+	switch _, isNull := codec.(nullCodec); {
+	case isNull:
+	case header.StreamCompressed:
+		var decompressed io.Reader
+		decompressed, r.closer = codec.CodecReader(input)
+		r.dec = newBinaryDecoderReader(decompressed)
+	default:
+		r.codec = codec
+	}
+	return r, nil
+}
+
+// Schema returns the schema of the datums of the stream.
+func (r *SequenceReader) Schema() Schema {
+	return r.schema
+}
+
+// HasNext is used in a for loop to know you can continue on. It reads the next datum from the
+// stream, and is false at its end or if that fails, see Err.
+func (r *SequenceReader) HasNext() bool {
+	if r.err != nil {
+		return false
+	} else if r.ready {
+		return true
+	}
+	length, err := r.dec.ReadLong()
+	if err != nil {
+		// The end of the stream between datums is expected.
+		if err == ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		r.err = err
+		return false
+	} else if length < 0 {
+		r.err = fmt.Errorf("Invalid datum length %d", length)
+		return false
+	}
+	r.data.Reset()
+	// Copying rather than allocating length bytes upfront keeps corrupt lengths from allocating
+	// more than the stream holds.
+	if r.codec == nil {
+		_, err = io.CopyN(&r.data, r.dec.r, length)
+	} else {
+		r.frame.Reset()
+		if _, err = io.CopyN(&r.frame, r.dec.r, length); err == nil {
+			err = r.decompress()
+		}
+	}
+	if err != nil {
+		r.err = eofUnexpected(err)
+		return false
+	}
+	r.ready = true
+	return true
+}
+
+// decompress decompresses the frame of a datum compressed on its own into data.
+func (r *SequenceReader) decompress() error {
+	decompressed, closer := r.codec.CodecReader(&r.frame)
+	if closer != nil {
+		defer closer()
+	}
+	if _, err := io.Copy(&r.data, decompressed); err != nil {
+		return fmt.Errorf("Invalid compressed datum: %s", err)
+	}
+	return nil
+}
+
+// Next reads the next datum from the stream and fills the given value with data, which can be
+// anything a DatumReader would accept, as for DataFileReader.Next. Fails if the datum doesn't take
+// exactly the bytes its length says.
+//
+// Will error with io.EOF if you're past the end, loop HasNext() to prevent.
+func (r *SequenceReader) Next(v interface{}) error {
+	if !r.HasNext() {
+		return r.err
+	}
+	r.ready = false
+	dec := &binaryDecoder{buf: r.data.Bytes()}
+	if err := r.datum.Read(v, dec); err != nil {
+		return err
+	} else if rest := int64(r.data.Len()) - dec.pos; rest != 0 {
+		return fmt.Errorf("Datum is followed by %d unread bytes", rest)
+	}
+	return nil
+}
+
+// Err returns the last encountered error.
+//
+// Will not return io.EOF if that was the last error.
+func (r *SequenceReader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// Close releases the reader decompressing a compressed stream. It doesn't close the input.
+func (r *SequenceReader) Close() error {
+	if r.closer != nil {
+		r.closer()
+		r.closer = nil
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	w, err := NewSequenceWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	for i := 0; i < 3; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i), StringField: "hello"}), nil)
+	}
+	size := buf.Len()
+	assert(t, w.Write(&struct{ LongField string }{}) != nil, true)
+	assert(t, buf.Len(), size)
+
+	r, err := NewSequenceReader(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	assert(t, r.Schema().String(), schema.String())
+	var records []primitive
+	for r.HasNext() {
+		var p primitive
+		assert(t, r.Next(&p), nil)
+		records = append(records, p)
+	}
+	assert(t, r.Err(), nil)
+	assert(t, len(records), 3)
+	assert(t, records[2].LongField, int64(2))
+	assert(t, records[2].StringField, "hello")
+	assert(t, r.Next(&primitive{}), io.EOF)
+
+	// Generic records, and a stream cut short in the middle of a datum.
+	r, err = NewSequenceReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	assert(t, err, nil)
+	var record *GenericRecord
+	assert(t, r.Next(&record), nil)
+	assert(t, record.Get("longField"), int64(0))
+	assert(t, r.Next(&record), nil)
+	assert(t, r.HasNext(), false)
+	assert(t, r.Err(), io.ErrUnexpectedEOF)
+
+	_, err = NewSequenceReader(bytes.NewReader([]byte("Obj\x01")))
+	assert(t, err.Error(), "Not a sequence stream")
+}
+
+// invertCodec "compresses" data by inverting its bits, to test registered codecs with.
+type invertCodec struct{}
+
+func (invertCodec) CodecReader(r io.Reader) (io.Reader, func()) {
+	return &invertingReader{r}, nil
+}
+
+func (invertCodec) CodecWriter(w io.Writer) io.WriteCloser {
+	return nopWriteCloser{&invertingWriter{w}}
+}
+
+type invertingReader struct{ r io.Reader }
+
+func (r *invertingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	for i := range p[:n] {
+		p[i] = ^p[i]
+	}
+	return n, err
+}
+
+type invertingWriter struct{ w io.Writer }
+
+func (w *invertingWriter) Write(p []byte) (int, error) {
+	inverted := make([]byte, len(p))
+	for i := range p {
+		inverted[i] = ^p[i]
+	}
+	return w.w.Write(inverted)
+}
+
+func TestSequenceCompression(t *testing.T) {
+	RegisterCodec("invert", invertCodec{})
+	schema := MustParseSchema(primitiveSchemaRaw)
+	for _, options := range []SequenceOptions{
+		{Codec: "deflate"},
+		{Codec: "deflate", CompressStream: true},
+		{Codec: "invert"},
+		{Codec: "invert", CompressStream: true},
+	} {
+		buf := &bytes.Buffer{}
+		w, err := NewSequenceWriterOptions(buf, schema, NewSpecificDatumWriter(), options)
+		assert(t, err, nil)
+		for i := 0; i < 100; i++ {
+			assert(t, w.Write(&primitive{LongField: int64(i), StringField: "hello"}), nil)
+		}
+		assert(t, w.Close(), nil)
+
+		r, err := NewSequenceReader(bytes.NewReader(buf.Bytes()))
+		assert(t, err, nil)
+		var records int
+		for r.HasNext() {
+			var p primitive
+			assert(t, r.Next(&p), nil)
+			assert(t, p.LongField, int64(records))
+			records++
+		}
+		assert(t, r.Err(), nil)
+		assert(t, records, 100)
+		assert(t, r.Close(), nil)
+	}
+
+	// Compressed streams can be flushed to be read as they're written.
+	buf := &bytes.Buffer{}
+	w, err := NewSequenceWriterOptions(buf, schema, NewSpecificDatumWriter(), SequenceOptions{Codec: "deflate", CompressStream: true})
+	assert(t, err, nil)
+	assert(t, w.Write(&primitive{LongField: 7}), nil)
+	assert(t, w.Flush(), nil)
+	r, err := NewSequenceReader(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	var p primitive
+	assert(t, r.Next(&p), nil)
+	assert(t, p.LongField, int64(7))
+
+	_, err = NewSequenceWriterOptions(buf, schema, NewSpecificDatumWriter(), SequenceOptions{Codec: "lz77"})
+	assert(t, err.Error(), "Unknown codec lz77")
+}
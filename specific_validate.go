@@ -0,0 +1,189 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	specificTypes = map[int]reflect.Type{
+		Boolean: reflect.TypeOf(false),
+		Int:     reflect.TypeOf(int32(0)),
+		Long:    reflect.TypeOf(int64(0)),
+		Float:   reflect.TypeOf(float32(0)),
+		Double:  reflect.TypeOf(float64(0)),
+		Bytes:   reflect.TypeOf([]byte(nil)),
+		String:  reflect.TypeOf(""),
+	}
+	genericEnumType   = reflect.TypeOf(&GenericEnum{})
+	genericRecordType = reflect.TypeOf(&GenericRecord{})
+)
+
+// SpecificValidationError lists every mismatch ValidateSpecific found between a Go type and a schema.
+type SpecificValidationError struct {
+	// Every problem, prefixed by the path of the value in the schema, e.g. "User.emails[]: ...".
+	Problems []string
+}
+
+// Error returns all problems found.
+func (e *SpecificValidationError) Error() string {
+	return fmt.Sprintf("Struct doesn't match schema: %s", strings.Join(e.Problems, "; "))
+}
+
+// ValidateSpecific checks that the type of v can be written with the given schema by a
+// SpecificDatumWriter: every record field needs a matching struct field of the exact Go type the
+// writer expects, unions need a branch matching the Go type, fixed values must be []byte of the
+// right size, and struct fields tagged with a name the schema doesn't know are reported too.
+//
+// Checks are based on Go types, plus the contents of v where it holds fixed values, so it's meant to
+// run once at startup, e.g. with a zero value, rather than before every write. Values held in
+// interface{} fields are only checked when written. Returns a *SpecificValidationError describing
+// every mismatch found.
+func ValidateSpecific(schema Schema, v interface{}) error {
+	if v == nil {
+		return &SpecificValidationError{Problems: []string{fmt.Sprintf("%s: nil value", schema.GetName())}}
+	}
+	rv := reflect.ValueOf(v)
+	validator := &specificValidator{seen: make(map[specificValidation]bool)}
+	validator.validate(schema.GetName(), schema, rv.Type(), rv)
+	if len(validator.problems) > 0 {
+		return &SpecificValidationError{Problems: validator.problems}
+	}
+	return nil
+}
+
+type specificValidation struct {
+	schema Schema
+	t      reflect.Type
+}
+
+type specificValidator struct {
+	// records being or already validated, recursive types are assumed valid while being validated.
+	seen     map[specificValidation]bool
+	problems []string
+}
+
+func (sv *specificValidator) problem(path string, format string, args ...interface{}) bool {
+	sv.problems = append(sv.problems, path+": "+fmt.Sprintf(format, args...))
+	return false
+}
+
+// validate checks a Go type, along with a value of it if v is valid, and returns whether it matches.
+func (sv *specificValidator) validate(path string, schema Schema, t reflect.Type, v reflect.Value) bool {
+	schema = resolveSchema(schema)
+	if t.Kind() == reflect.Interface {
+		return true
+	}
+
+	switch schema.Type() {
+	case Null:
+		return true
+	case Boolean, Int, Long, Float, Double, Bytes, String:
+		if t == timeType && (timestampUnit(schema) != 0 || isDate(schema)) || isUUID(schema) && isUUIDType(t) || isDecimal(schema) && isDecimalType(t) {
+			return true
+		}
+		if t != specificTypes[schema.Type()] {
+			return sv.problem(path, "Go type %s can't be written as %s, needs %s", t, schema.GetName(), specificTypes[schema.Type()])
+		}
+	case Array:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return sv.problem(path, "Go type %s can't be written as array, needs a slice", t)
+		}
+		return sv.validate(path+"[]", schema.(*ArraySchema).Items, t.Elem(), reflect.Value{})
+	case Map:
+		if t.Kind() != reflect.Map || t.Key() != specificTypes[String] {
+			return sv.problem(path, "Go type %s can't be written as map, needs a map with string keys", t)
+		}
+		return sv.validate(path+"{}", schema.(*MapSchema).Values, t.Elem(), reflect.Value{})
+	case Enum:
+		if t != genericEnumType {
+			return sv.problem(path, "Go type %s can't be written as enum %s, needs %s", t, schema.GetName(), genericEnumType)
+		}
+	case Fixed:
+		if isDecimal(schema) && isDecimalType(t) {
+			return true
+		}
+		size := schema.(*FixedSchema).Size
+		if t != specificTypes[Bytes] {
+			return sv.problem(path, "Go type %s can't be written as fixed %s, needs []byte", t, schema.GetName())
+		}
+		if v.IsValid() && v.Len() > 0 && v.Len() != size {
+			return sv.problem(path, "%d bytes can't be written as fixed %s of size %d", v.Len(), schema.GetName(), size)
+		}
+	case Union:
+		return sv.validateUnion(path, schema.(*UnionSchema), t, v)
+	case Record:
+		return sv.validateRecord(path, schema.(*RecordSchema), t, v)
+	}
+	return true
+}
+
+func (sv *specificValidator) validateUnion(path string, schema *UnionSchema, t reflect.Type, v reflect.Value) bool {
+	branches := schema.NonNullTypes()
+	for _, branch := range branches {
+		// Try every branch apart, as failing ones aren't problems.
+		trial := &specificValidator{seen: make(map[specificValidation]bool, len(sv.seen))}
+		for key := range sv.seen {
+			trial.seen[key] = true
+		}
+		if trial.validate(path, branch, t, v) {
+			return true
+		}
+	}
+	if len(branches) == 0 {
+		return true
+	}
+	return sv.problem(path, "Go type %s matches no branch of union %s", t, schema.String())
+}
+
+func (sv *specificValidator) validateRecord(path string, schema *RecordSchema, t reflect.Type, v reflect.Value) bool {
+	if t == genericRecordType {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsValid() {
+			v = v.Elem()
+		}
+	}
+	if t.Kind() != reflect.Struct {
+		return sv.problem(path, "Go type %s can't be written as record %s, needs a struct", t, schema.GetName())
+	}
+	key := specificValidation{schema: schema, t: t}
+	if sv.seen[key] {
+		return true
+	}
+	sv.seen[key] = true
+
+	ok := true
+	ri := reflectEnsureRi(t)
+	known := make(map[string]bool, len(schema.Fields))
+	for _, field := range schema.Fields {
+		known[field.Name] = true
+		index, found := ri.names[field.Name]
+		if !found {
+			ok = sv.problem(path+"."+field.Name, "Go type %s has no field for it", t)
+			continue
+		}
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = fieldByIndex(v, index, false)
+		}
+		if !sv.validate(path+"."+field.Name, field.Type, t.FieldByIndex(index).Type, fv) {
+			ok = false
+		}
+	}
+	var unknown []string
+	for name, index := range ri.names {
+		if tag, _ := parseTag(t.FieldByIndex(index).Tag.Get("avro")); tag == name && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		ok = sv.problem(path, "Go field %s.%s is tagged %q but the schema has no such field", t, t.FieldByIndex(ri.names[name]).Name, name)
+	}
+	return ok
+}
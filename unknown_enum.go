@@ -0,0 +1,87 @@
+package avro
+
+import "fmt"
+
+// UnknownEnum chooses what decoding does with enum values the schema being read with has no symbol
+// for, as happens when data written with a newer schema that added symbols is read with an older
+// one, and the enum has no default to fall back to.
+type UnknownEnum int
+
+const (
+	// UnknownEnumError fails reading unknown values, which is the default.
+	UnknownEnumError UnknownEnum = iota
+
+	// UnknownEnumNull reads unknown values of enums that are a branch of a union with null as null,
+	// and fails reading them anywhere else.
+	UnknownEnumNull
+
+	// UnknownEnumSymbol reads unknown values as UnknownSymbol.
+	UnknownEnumSymbol
+)
+
+// UnknownSymbol is the symbol UnknownEnumSymbol reads unknown enum values as. The GenericEnums
+// holding it have it as their last symbol unless the enum already defines it, so they may not be
+// writeable with the schema they were read with.
+const UnknownSymbol = "UNKNOWN"
+
+// SetProjectorUnknownEnum makes p, a DatumProjector created by NewDatumProjector, resolve enum
+// symbols the reader schema doesn't have as u says instead of failing.
+// Must be called before calling Project. May return an error if p is another kind of DatumProjector.
+func SetProjectorUnknownEnum(p DatumProjector, u UnknownEnum) error {
+	projector, ok := p.(*datumProjector)
+	if !ok {
+		return fmt.Errorf("DatumProjector %T doesn't support unknown enum handling", p)
+	}
+	projector.unknownEnum = u
+	return nil
+}
+
+// SetUnknownEnum makes Read decode enum indexes the schema has no symbol for as u says.
+// Must be called before calling Read.
+func (reader *GenericDatumReader) SetUnknownEnum(u UnknownEnum) *GenericDatumReader {
+	reader.unknownEnum = u
+	return reader
+}
+
+// SetUnknownEnum makes Read decode enum indexes the schema has no symbol for as u says.
+// Must be called before calling Read.
+func (reader *SpecificDatumReader) SetUnknownEnum(u UnknownEnum) *SpecificDatumReader {
+	reader.unknownEnum = u
+	return reader
+}
+
+// unknownEnumValue returns the GenericEnum UnknownEnumSymbol reads unknown values of schema as.
+func unknownEnumValue(schema *EnumSchema) *GenericEnum {
+	for i, symbol := range schema.Symbols {
+		if symbol == UnknownSymbol {
+			enum := NewGenericEnum(schema.Symbols)
+			enum.SetIndex(int32(i))
+			return enum
+		}
+	}
+	symbols := append(append(make([]string, 0, len(schema.Symbols)+1), schema.Symbols...), UnknownSymbol)
+	enum := NewGenericEnum(symbols)
+	enum.SetIndex(int32(len(schema.Symbols)))
+	return enum
+}
+
+// hasNullBranch tells whether a union has a null branch.
+func hasNullBranch(union *UnionSchema) bool {
+	for _, t := range union.Types {
+		if t.Type() == Null {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownEnumIndexError is returned by readers reading an enum index too high for the symbols of the
+// schema.
+type unknownEnumIndexError struct {
+	index int32
+	name  string
+}
+
+func (e *unknownEnumIndexError) Error() string {
+	return fmt.Sprintf("Enum index %d too high for enum %s", e.index, e.name)
+}
@@ -0,0 +1,132 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+const newerSuitSchema = `{"type": "record", "name": "Card", "fields": [
+	{"name": "suit", "type": {"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE", "CLUB"]}},
+	{"name": "trump", "type": ["null", "Suit"]}
+]}`
+
+const olderSuitSchema = `{"type": "record", "name": "Card", "fields": [
+	{"name": "suit", "type": {"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE"]}},
+	{"name": "trump", "type": ["null", "Suit"]}
+]}`
+
+// clubs returns a card of the newer schema with a suit the older one doesn't have.
+func clubs(t *testing.T) []byte {
+	schema := MustParseSchema(newerSuitSchema)
+	record := NewGenericRecord(schema)
+	club := NewGenericEnum([]string{"HEART", "SPADE", "CLUB"})
+	club.Set("CLUB")
+	record.Set("suit", "CLUB")
+	record.Set("trump", club)
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(record, NewBinaryEncoder(&buf)), nil)
+	return buf.Bytes()
+}
+
+func TestUnknownEnumGenericReader(t *testing.T) {
+	data, older := clubs(t), MustParseSchema(olderSuitSchema)
+	var suit interface{}
+	err := NewGenericDatumReader().SetSchema(older.(*RecordSchema).Fields[0].Type).Read(&suit, NewBinaryDecoder(data))
+	assert(t, err.Error(), "Enum index 2 too high for enum Suit")
+
+	// Lazy records fail and succeed reading as others do.
+	for _, lazy := range []bool{false, true} {
+		record := NewGenericRecord(older)
+		err = NewGenericDatumReader().SetLazy(lazy).SetSchema(older).Read(record, NewBinaryDecoder(data))
+		assert(t, err.Error(), "Enum index 2 too high for enum Suit")
+
+		reader := NewGenericDatumReader().SetUnknownEnum(UnknownEnumSymbol).SetLazy(lazy)
+		reader.SetSchema(older)
+		assert(t, reader.Read(record, NewBinaryDecoder(data)), nil)
+		assert(t, record.Get("suit"), UnknownSymbol)
+		assert(t, record.Get("trump"), UnknownSymbol)
+
+		// Null only applies to unions, the suit field still fails.
+		reader = NewGenericDatumReader().SetUnknownEnum(UnknownEnumNull).SetLazy(lazy)
+		reader.SetSchema(older.(*RecordSchema).Fields[1].Type)
+		var trump interface{}
+		assert(t, reader.Read(&trump, NewBinaryDecoder(data[1:])), nil)
+		assert(t, trump, nil)
+		reader.SetSchema(older.(*RecordSchema).Fields[0].Type)
+		assert(t, reader.Read(&trump, NewBinaryDecoder(data)).Error(), "Enum index 2 too high for enum Suit")
+		reader.SetSchema(older)
+		assert(t, reader.Read(record, NewBinaryDecoder(data)).Error(), "Enum index 2 too high for enum Suit")
+
+		trumpOnly := MustParseSchema(`{"type": "record", "name": "Card", "fields": [
+			{"name": "trump", "type": ["null", {"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE"]}]}
+		]}`)
+		reader.SetSchema(trumpOnly)
+		record = NewGenericRecord(trumpOnly)
+		assert(t, reader.Read(record, NewBinaryDecoder(data[1:])), nil)
+		assert(t, record.Get("trump"), nil)
+	}
+}
+
+func TestUnknownEnumSpecificReader(t *testing.T) {
+	type card struct {
+		Suit  *GenericEnum `avro:"suit"`
+		Trump *GenericEnum `avro:"trump"`
+	}
+	data, older := clubs(t), MustParseSchema(olderSuitSchema)
+	err := NewSpecificDatumReader().SetSchema(older).Read(&card{}, NewBinaryDecoder(data))
+	assert(t, err.Error(), "Enum index 2 too high for enum Suit")
+
+	reader := NewSpecificDatumReader().SetUnknownEnum(UnknownEnumSymbol)
+	reader.SetSchema(older)
+	c := &card{}
+	assert(t, reader.Read(c, NewBinaryDecoder(data)), nil)
+	assert(t, c.Suit.Get(), UnknownSymbol)
+	assert(t, c.Trump.Get(), UnknownSymbol)
+	assert(t, c.Suit.Symbols, []string{"HEART", "SPADE", UnknownSymbol})
+
+	type trumpOnly struct {
+		Trump *GenericEnum `avro:"trump"`
+	}
+	reader = NewSpecificDatumReader().SetUnknownEnum(UnknownEnumNull)
+	reader.SetSchema(MustParseSchema(`{"type": "record", "name": "Card", "fields": [
+		{"name": "trump", "type": ["null", {"type": "enum", "name": "Suit", "symbols": ["HEART", "SPADE"]}]}
+	]}`))
+	tc := &trumpOnly{}
+	assert(t, reader.Read(tc, NewBinaryDecoder(data[1:])), nil)
+	assert(t, tc.Trump == nil, true)
+}
+
+func TestUnknownEnumProjector(t *testing.T) {
+	newer, older := MustParseSchema(newerSuitSchema), MustParseSchema(olderSuitSchema)
+	record := NewGenericRecord(newer)
+	assert(t, NewGenericDatumReader().SetSchema(newer).Read(record, NewBinaryDecoder(clubs(t))), nil)
+
+	projector, err := NewDatumProjector(newer, older)
+	assert(t, err, nil)
+	_, err = projector.Project(record)
+	assert(t, err.Error(), "Field Card.suit: Symbol CLUB is not defined in enum Suit")
+
+	assert(t, SetProjectorUnknownEnum(projector, UnknownEnumSymbol), nil)
+	projected, err := projector.Project(record)
+	assert(t, err, nil)
+	assert(t, projected.(*GenericRecord).Get("suit"), UnknownSymbol)
+	assert(t, projected.(*GenericRecord).Get("trump"), UnknownSymbol)
+
+	assert(t, SetProjectorUnknownEnum(projector, UnknownEnumNull), nil)
+	record.Set("suit", "SPADE")
+	projected, err = projector.Project(record)
+	assert(t, err, nil)
+	assert(t, projected.(*GenericRecord).Get("suit"), "SPADE")
+	assert(t, projected.(*GenericRecord).Get("trump"), nil)
+
+	// GenericEnums outside records take the symbol as their last one.
+	enums, err := NewDatumProjector(newer.(*RecordSchema).Fields[0].Type, older.(*RecordSchema).Fields[0].Type)
+	assert(t, err, nil)
+	assert(t, SetProjectorUnknownEnum(enums, UnknownEnumSymbol), nil)
+	club := NewGenericEnum([]string{"HEART", "SPADE", "CLUB"})
+	club.Set("CLUB")
+	enum, err := enums.Project(club)
+	assert(t, err, nil)
+	assert(t, enum.(*GenericEnum).Get(), UnknownSymbol)
+	assert(t, enum.(*GenericEnum).GetIndex(), int32(2))
+}
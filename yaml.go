@@ -0,0 +1,589 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// yamlToJSON converts a YAML document to the equivalent JSON. It supports the subset of YAML schemas
+// are written in: block mappings and sequences, flow collections (so JSON is valid input too),
+// plain, quoted, literal and folded scalars, comments, anchors, aliases and merge keys. Plain
+// scalars are resolved as in the YAML 1.2 core schema: null, ~ and nothing are null, true and false
+// are booleans and numbers are numbers, anything else is a string.
+func yamlToJSON(data []byte) ([]byte, error) {
+	p := &yamlParser{anchors: make(map[string]yamlAnchor)}
+	if err := p.split(string(data)); err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if p.skipBlank() {
+		var err error
+		if value, err = p.block(p.lines[p.pos].indent); err != nil {
+			return nil, err
+		}
+	}
+	if p.skipBlank() {
+		return nil, p.errorf("unexpected content")
+	}
+	return json.Marshal(value)
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	raw    string // without the line break, for block scalars
+	text   string // without indentation and comments, empty for blank lines
+}
+
+// maxYAMLAliasNodes is the most nodes the aliases of a document may expand to all together, so that
+// a few nested aliases in a small document can't make it expand to huge values.
+const maxYAMLAliasNodes = 1 << 16
+
+type yamlParser struct {
+	lines   []yamlLine
+	pos     int
+	anchors map[string]yamlAnchor
+	aliased int // nodes aliases expanded to so far
+}
+
+type yamlAnchor struct {
+	value interface{}
+	nodes int
+}
+
+// alias returns the value of the anchor the alias text refers to.
+func (p *yamlParser) alias(text string) (interface{}, error) {
+	anchor, ok := p.anchors[text[1:]]
+	if !ok {
+		return nil, p.errorf("unknown alias %s", text)
+	}
+	if p.aliased += anchor.nodes; p.aliased > maxYAMLAliasNodes {
+		return nil, p.errorf("aliases expand to more than %d nodes", maxYAMLAliasNodes)
+	}
+	return anchor.value, nil
+}
+
+// yamlNodes returns the number of nodes of a parsed value, itself included.
+func yamlNodes(value interface{}) int {
+	nodes := 1
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			nodes += yamlNodes(item)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			nodes += yamlNodes(item)
+		}
+	}
+	return nodes
+}
+
+func (p *yamlParser) split(data string) error {
+	for i, raw := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		line := yamlLine{number: i + 1, raw: raw}
+		for line.indent < len(raw) && raw[line.indent] == ' ' {
+			line.indent++
+		}
+		line.text = strings.TrimRight(stripYAMLComment(raw[line.indent:]), " \t")
+		if strings.HasPrefix(line.text, "\t") {
+			return fmt.Errorf("YAML line %d: tabs can't indent", line.number)
+		}
+		switch {
+		case line.indent == 0 && (line.text == "---" || strings.HasPrefix(line.text, "%")):
+			if len(p.lines) > 0 && line.text == "---" {
+				for _, previous := range p.lines {
+					if previous.text != "" {
+						return fmt.Errorf("YAML line %d: only one document is supported", line.number)
+					}
+				}
+			}
+			line.text = ""
+		case line.indent == 0 && line.text == "...":
+			return nil
+		}
+		p.lines = append(p.lines, line)
+	}
+	return nil
+}
+
+// stripYAMLComment removes a comment, which starts with # at the beginning or after a space, outside
+// of quoted scalars.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:-", text[i-1]) >= 0):
+			quote = c
+		}
+	}
+	return text
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	number := len(p.lines)
+	if p.pos < len(p.lines) {
+		number = p.lines[p.pos].number
+	}
+	return fmt.Errorf("YAML line %d: %s", number, fmt.Sprintf(format, args...))
+}
+
+// skipBlank moves to the next line with content and returns whether there is one.
+func (p *yamlParser) skipBlank() bool {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+	return p.pos < len(p.lines)
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block parses the block node starting at the current line, indented by indent.
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	line := p.lines[p.pos]
+	if isYAMLSequenceItem(line.text) {
+		return p.sequence(indent)
+	}
+	if _, _, ok := splitYAMLKey(line.text); ok {
+		return p.mapping(indent)
+	}
+	p.pos++
+	return p.node(line.text, indent-1, false)
+}
+
+func (p *yamlParser) sequence(indent int) ([]interface{}, error) {
+	sequence := []interface{}{}
+	for p.skipBlank() && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(line.text[1:], " ")
+		var item interface{}
+		var err error
+		if _, _, ok := splitYAMLKey(rest); ok || isYAMLSequenceItem(rest) {
+			// A compact nested collection, which goes on as if "- " were indentation.
+			p.lines[p.pos].indent += len(line.text) - len(rest)
+			p.lines[p.pos].text = rest
+			item, err = p.block(p.lines[p.pos].indent)
+		} else {
+			p.pos++
+			item, err = p.node(rest, indent, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, item)
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, p.errorf("bad indentation")
+	}
+	return sequence, nil
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]interface{}, error) {
+	mapping := make(map[string]interface{})
+	var merges []interface{}
+	for p.skipBlank() && p.lines[p.pos].indent == indent {
+		key, rest, ok := splitYAMLKey(p.lines[p.pos].text)
+		if !ok {
+			return nil, p.errorf("expected a mapping key")
+		}
+		if _, exists := mapping[key]; exists {
+			return nil, p.errorf("duplicate key %s", key)
+		}
+		p.pos++
+		value, err := p.node(rest, indent, true)
+		if err != nil {
+			return nil, err
+		}
+		if key == "<<" {
+			merges = append(merges, value)
+			continue
+		}
+		mapping[key] = value
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, p.errorf("bad indentation")
+	}
+
+	// Merged keys never override those set explicitly.
+	for _, merge := range merges {
+		sources, ok := merge.([]interface{})
+		if !ok {
+			sources = []interface{}{merge}
+		}
+		for _, source := range sources {
+			m, ok := source.(map[string]interface{})
+			if !ok {
+				return nil, p.errorf("can only merge mappings")
+			}
+			for k, v := range m {
+				if _, exists := mapping[k]; !exists {
+					mapping[k] = v
+				}
+			}
+		}
+	}
+	return mapping, nil
+}
+
+// splitYAMLKey splits a mapping entry into its key and the rest of the line.
+func splitYAMLKey(text string) (key string, rest string, ok bool) {
+	if text == "" || strings.IndexByte("[{&*|>", text[0]) >= 0 || isYAMLSequenceItem(text) {
+		return "", "", false
+	}
+	end := 0
+	if text[0] == '"' || text[0] == '\'' {
+		unquoted, next, err := yamlQuoted(text, 0)
+		if err != nil || next >= len(text) || text[next] != ':' {
+			return "", "", false
+		}
+		key, end = unquoted, next
+	} else {
+		end = strings.Index(text, ": ")
+		if end < 0 {
+			if !strings.HasSuffix(text, ":") {
+				return "", "", false
+			}
+			end = len(text) - 1
+		}
+		key = strings.TrimRight(text[:end], " ")
+	}
+	if end+1 < len(text) && text[end+1] != ' ' {
+		return "", "", false
+	}
+	return key, strings.TrimLeft(text[end+1:], " "), true
+}
+
+// node parses the value rest on the line of an entry indented by indent, which may also be on
+// the following lines. Values of mapping entries may be sequences indented as much as their key.
+func (p *yamlParser) node(rest string, indent int, mappingValue bool) (interface{}, error) {
+	var anchor string
+	if strings.HasPrefix(rest, "&") {
+		anchor = rest[1:]
+		rest = ""
+		if i := strings.IndexByte(anchor, ' '); i >= 0 {
+			anchor, rest = anchor[:i], strings.TrimLeft(anchor[i:], " ")
+		}
+	}
+
+	var value interface{}
+	var err error
+	if rest != "" {
+		value, err = p.inline(rest, indent)
+	} else if p.skipBlank() {
+		if line := p.lines[p.pos]; line.indent > indent || mappingValue && line.indent == indent && isYAMLSequenceItem(line.text) {
+			value, err = p.block(line.indent)
+		}
+	}
+	if err == nil && anchor != "" {
+		p.anchors[anchor] = yamlAnchor{value, yamlNodes(value)}
+	}
+	return value, err
+}
+
+func (p *yamlParser) inline(text string, indent int) (interface{}, error) {
+	switch text[0] {
+	case '*':
+		return p.alias(text)
+	case '|', '>':
+		return p.blockScalar(text, indent)
+	case '[', '{':
+		// Flow collections may span lines.
+		for yamlFlowDepth(text) > 0 && p.skipBlank() {
+			text += " " + p.lines[p.pos].text
+			p.pos++
+		}
+		flow := &yamlFlow{text: text, parser: p}
+		value, err := flow.value()
+		if err != nil {
+			return nil, err
+		}
+		if flow.space(); flow.pos < len(text) {
+			return nil, p.errorf("unexpected %q after flow collection", text[flow.pos:])
+		}
+		return value, nil
+	case '"', '\'':
+		value, next, err := yamlQuoted(text, 0)
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		if strings.TrimSpace(text[next:]) != "" {
+			return nil, p.errorf("unexpected %q after quoted scalar", text[next:])
+		}
+		return value, nil
+	}
+
+	// Plain scalars may go on over more indented lines.
+	for p.skipBlank() && p.lines[p.pos].indent > indent {
+		if _, _, ok := splitYAMLKey(p.lines[p.pos].text); ok {
+			return nil, p.errorf("bad indentation")
+		}
+		text += " " + p.lines[p.pos].text
+		p.pos++
+	}
+	return resolveYAMLScalar(text), nil
+}
+
+// blockScalar parses a literal (|) or folded (>) scalar with the given header, made of the
+// following lines indented more than indent.
+func (p *yamlParser) blockScalar(header string, indent int) (interface{}, error) {
+	chomp := byte(0)
+	for _, c := range header[1:] {
+		switch {
+		case c == '-' || c == '+':
+			chomp = byte(c)
+		case c < '1' || c > '9':
+			return nil, p.errorf("invalid block scalar header %s", header)
+		}
+	}
+
+	var lines []string
+	contentIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if line.indent <= indent || contentIndent >= 0 && line.indent < contentIndent {
+			break
+		}
+		if contentIndent < 0 {
+			contentIndent = line.indent
+		}
+		lines = append(lines, line.raw[contentIndent:])
+	}
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if header[0] == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		for i, line := range lines {
+			switch {
+			case line == "":
+				text += "\n"
+			case i > 0 && lines[i-1] != "":
+				text += " " + line
+			default:
+				text += line
+			}
+		}
+	}
+	switch {
+	case chomp == '-' || text == "":
+	case chomp == '+':
+		text += "\n" + strings.Repeat("\n", trailing)
+	default:
+		text += "\n"
+	}
+	return text, nil
+}
+
+// yamlFlowDepth returns how many flow collections are left open in text.
+func yamlFlowDepth(text string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// yamlQuoted parses the quoted scalar starting at text[start], returning its value and the
+// index following it.
+func yamlQuoted(text string, start int) (string, int, error) {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			if quote == '\'' {
+				return strings.Replace(text[start+1:i], "''", "'", -1), i + 1, nil
+			}
+			value, err := strconv.Unquote(text[start : i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid double-quoted scalar %s", text[start:i+1])
+			}
+			return value, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted scalar")
+}
+
+// resolveYAMLScalar returns the value of a plain scalar.
+func resolveYAMLScalar(text string) interface{} {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if strings.IndexFunc(text, isYAMLNumberRune) >= 0 {
+		return text
+	}
+	sign, digits := "", text
+	if digits[0] == '-' || digits[0] == '+' {
+		sign, digits = digits[:1], digits[1:]
+	}
+	base := 10
+	if strings.HasPrefix(digits, "0x") {
+		base, digits = 16, digits[2:]
+	} else if strings.HasPrefix(digits, "0o") {
+		base, digits = 8, digits[2:]
+	}
+	// Integers are kept as they are, rather than as float64 values which can't hold all of them.
+	if n, ok := new(big.Int).SetString(sign+digits, base); ok {
+		return json.Number(n.String())
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && base == 10 {
+		return f
+	}
+	return text
+}
+
+// isYAMLNumberRune returns whether r can't be part of a number.
+func isYAMLNumberRune(r rune) bool {
+	return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || strings.ContainsRune("+-.xo", r))
+}
+
+// yamlFlow parses flow collections.
+type yamlFlow struct {
+	text   string
+	pos    int
+	parser *yamlParser
+}
+
+func (f *yamlFlow) space() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+	f.space()
+	if f.pos >= len(f.text) {
+		return nil, f.parser.errorf("unterminated flow collection")
+	}
+	switch f.text[f.pos] {
+	case '[':
+		f.pos++
+		sequence := []interface{}{}
+		for {
+			if f.space(); f.pos < len(f.text) && f.text[f.pos] == ']' {
+				f.pos++
+				return sequence, nil
+			}
+			item, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, item)
+			if err = f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		mapping := make(map[string]interface{})
+		for {
+			if f.space(); f.pos < len(f.text) && f.text[f.pos] == '}' {
+				f.pos++
+				return mapping, nil
+			}
+			key, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			var value interface{}
+			if f.space(); f.pos < len(f.text) && f.text[f.pos] == ':' {
+				f.pos++
+				if value, err = f.value(); err != nil {
+					return nil, err
+				}
+			}
+			mapping[fmt.Sprint(key)] = value
+			if err = f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		value, next, err := yamlQuoted(f.text, f.pos)
+		if err != nil {
+			return nil, f.parser.errorf("%s", err)
+		}
+		f.pos = next
+		return value, nil
+	case '*':
+		start := f.pos
+		for f.pos < len(f.text) && strings.IndexByte(" ,]}", f.text[f.pos]) < 0 {
+			f.pos++
+		}
+		return f.parser.alias(f.text[start:f.pos])
+	}
+
+	start := f.pos
+	for f.pos < len(f.text) {
+		c := f.text[f.pos]
+		if c == ',' || c == ']' || c == '}' || c == ':' && (f.pos+1 == len(f.text) || strings.IndexByte(" ,]}", f.text[f.pos+1]) >= 0) {
+			break
+		}
+		f.pos++
+	}
+	return resolveYAMLScalar(strings.TrimSpace(f.text[start:f.pos])), nil
+}
+
+// separator moves past the comma between flow entries, unless at the end of the collection.
+func (f *yamlFlow) separator(end byte) error {
+	f.space()
+	if f.pos < len(f.text) {
+		switch f.text[f.pos] {
+		case ',':
+			f.pos++
+			return nil
+		case end:
+			return nil
+		}
+	}
+	return f.parser.errorf("expected , or %c in flow collection", end)
+}
@@ -0,0 +1,137 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func yamlValue(t *testing.T, yaml string) interface{} {
+	data, err := yamlToJSON([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Converting %q: %s", yaml, err)
+	}
+	var value interface{}
+	assert(t, json.Unmarshal(data, &value), nil)
+	return value
+}
+
+func TestYAMLToJSON(t *testing.T) {
+	assert(t, yamlValue(t, "plain text"), "plain text")
+	assert(t, yamlValue(t, `{"type": "array", "items": ["null", "int"]}`), map[string]interface{}{
+		"type": "array", "items": []interface{}{"null", "int"},
+	})
+	assert(t, yamlValue(t, "[1, -2.5, 0x10, 010, true, ~, null, '', 'it''s', \"a\\tb\", 1_000, .inf]"), []interface{}{
+		1.0, -2.5, 16.0, 10.0, true, nil, nil, "", "it's", "a\tb", "1_000", ".inf",
+	})
+
+	value := yamlValue(t, `
+# A user
+---
+type: record
+name: User   # comments go anywhere
+doc: >
+  Folded lines
+  make one.
+
+  Paragraphs stay.
+fields:
+- name: id
+  type: long
+  default: 0
+- &name
+  name: name
+  type: {type: string, "x-pii": true}
+  doc: |-
+    Kept # as is
+      indented
+-   name: tags
+    type:
+      type: array
+      items:
+        - "null"
+        - string
+- <<: *name
+  name: alias
+`)
+	assert(t, value, map[string]interface{}{
+		"type": "record",
+		"name": "User",
+		"doc":  "Folded lines make one.\nParagraphs stay.\n",
+		"fields": []interface{}{
+			map[string]interface{}{"name": "id", "type": "long", "default": 0.0},
+			map[string]interface{}{"name": "name", "type": map[string]interface{}{"type": "string", "x-pii": true}, "doc": "Kept # as is\n  indented"},
+			map[string]interface{}{"name": "tags", "type": map[string]interface{}{"type": "array", "items": []interface{}{"null", "string"}}},
+			map[string]interface{}{"name": "alias", "type": map[string]interface{}{"type": "string", "x-pii": true}, "doc": "Kept # as is\n  indented"},
+		},
+	})
+
+	for _, invalid := range []string{
+		"a: 1\na: 2",
+		"a: 1\n  b: 2",
+		"a: *missing",
+		"[1, 2",
+		"a:\n\tb: 1",
+		"a: 1\n---\nb: 2",
+		"a: 'open",
+	} {
+		if _, err := yamlToJSON([]byte(invalid)); err == nil {
+			t.Errorf("Converting %q should fail", invalid)
+		}
+	}
+}
+
+func TestYAMLAliasExpansion(t *testing.T) {
+	// Each level aliases the previous one ten times, which would expand to 10^9 strings.
+	laughs := "a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n"
+	for c := 'b'; c <= 'i'; c++ {
+		prev := string(c - 1)
+		laughs += fmt.Sprintf("%c: &%c [*%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s]\n", c, c, prev, prev, prev, prev, prev, prev, prev, prev, prev, prev)
+	}
+	_, err := yamlToJSON([]byte(laughs))
+	assert(t, err != nil && strings.HasSuffix(err.Error(), "aliases expand to more than 65536 nodes"), true)
+
+	// Aliasing small nodes many times is fine.
+	value := yamlValue(t, "a: &a {type: string}\nb: [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]")
+	assert(t, len(value.(map[string]interface{})["b"].([]interface{})), 10)
+}
+
+func TestLoadYAMLSchemas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avro-yaml")
+	assert(t, err, nil)
+	defer os.RemoveAll(dir)
+	assert(t, os.MkdirAll(filepath.Join(dir, "example"), 0755), nil)
+	assert(t, ioutil.WriteFile(filepath.Join(dir, "example", "Wallet.yaml"), []byte(`
+type: record
+name: Wallet
+namespace: example
+fields:
+  - {name: owner, type: example.User}
+`), 0644), nil)
+	assert(t, ioutil.WriteFile(filepath.Join(dir, "example", "User.yml"), []byte(`
+type: record
+name: User
+namespace: example
+fields:
+  - name: name
+    type: string
+  - name: id
+    type: long
+    default: 9007199254740993
+`), 0644), nil)
+
+	schemas := make(map[string]Schema)
+	assert(t, LoadSchemasUsing(dir+"/", mapRegistry(schemas)), nil)
+	assert(t, len(schemas), 2)
+	assert(t, resolveSchema(schemas["example.Wallet"]).(*RecordSchema).Fields[0].Type.GetName(), "User")
+
+	schema, err := ParseSchemaFile(filepath.Join(dir, "example", "User.yml"))
+	assert(t, err, nil)
+	assert(t, schema.FullName(), "example.User")
+	// Integers don't lose precision on the way.
+	assert(t, schema.(*RecordSchema).Fields[1].Default, int64(9007199254740993))
+}